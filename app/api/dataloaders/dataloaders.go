@@ -27,6 +27,9 @@ const RoleLoaderKey ContextKey = "role_loader"
 // UserLoaderKey declares a statically typed key for context reference in other packages
 const UserLoaderKey ContextKey = "user_loader"
 
+// UserRolesLoaderKey declares a statically typed key for context reference in other packages
+const UserRolesLoaderKey ContextKey = "user_roles_loader"
+
 // OrganizationLoaderFromContext runs the dataloader inside the context
 func OrganizationLoaderFromContext(ctx context.Context, uid string) (*dbmodels.Organization, error) {
 	return ctx.Value(OrganizationLoaderKey).(*OrganizationLoader).Load(uid)
@@ -47,6 +50,11 @@ func UserLoaderFromContext(ctx context.Context, id int64) (*dbmodels.User, error
 	return ctx.Value(UserLoaderKey).(*UserLoader).Load(id)
 }
 
+// UserRolesLoaderFromContext runs the dataloader inside the context
+func UserRolesLoaderFromContext(ctx context.Context, userID int64) ([]*dbmodels.Role, error) {
+	return ctx.Value(UserRolesLoaderKey).(*UserRolesLoader).Load(userID)
+}
+
 // WithDataloaders returns a new context that contains dataloaders
 func WithDataloaders(
 	ctx context.Context,
@@ -156,10 +164,58 @@ func WithDataloaders(
 		},
 	)
 
+	userRolesLoader := NewUserRolesLoader(
+		UserRolesLoaderConfig{
+			Fetch: func(userIDs []int64) ([][]*dbmodels.Role, []error) {
+				assignments, err := dbstore.UserRoleStore.ListActiveByUserIDs(ctx, userIDs)
+				if err != nil {
+					return nil, []error{err.Error}
+				}
+
+				data := []*dbmodels.Role{}
+				if len(assignments) > 0 {
+					roleIDs := make([]int64, 0, len(assignments))
+					for _, e := range assignments {
+						roleIDs = append(roleIDs, e.RoleID)
+					}
+					data, err = dbstore.RoleStore.GetManyByIDs(ctx, roleIDs)
+					if err != nil {
+						return nil, []error{err.Error}
+					}
+				}
+
+				// group the roles by user in the order of the user ids
+				roles := make(map[int64]*dbmodels.Role, len(data))
+				for _, e := range data {
+					roles[e.ID] = e
+				}
+				slice := make(map[int64][]*dbmodels.Role, len(userIDs))
+				for _, e := range assignments {
+					if role, ok := roles[e.RoleID]; ok {
+						slice[e.UserID] = append(slice[e.UserID], role)
+					}
+				}
+
+				result := make([][]*dbmodels.Role, len(userIDs))
+				for i, key := range userIDs {
+					result[i] = slice[key]
+					if result[i] == nil {
+						result[i] = []*dbmodels.Role{}
+					}
+				}
+
+				return result, nil
+			},
+			Wait:     1 * time.Millisecond,
+			MaxBatch: 100,
+		},
+	)
+
 	ctx = context.WithValue(ctx, OrganizationLoaderKey, organizationLoader)
 	ctx = context.WithValue(ctx, DepartmentLoaderKey, departmentLoader)
 	ctx = context.WithValue(ctx, RoleLoaderKey, roleLoader)
 	ctx = context.WithValue(ctx, UserLoaderKey, userLoader)
+	ctx = context.WithValue(ctx, UserRolesLoaderKey, userRolesLoader)
 	return ctx
 }
 
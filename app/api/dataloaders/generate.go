@@ -2,5 +2,6 @@
 //go:generate go run github.com/vektah/dataloaden DepartmentLoader int64 *gogql/app/models/dbmodels.Department
 //go:generate go run github.com/vektah/dataloaden RoleLoader int64 *gogql/app/models/dbmodels.Role
 //go:generate go run github.com/vektah/dataloaden UserLoader int64 *gogql/app/models/dbmodels.User
+//go:generate go run github.com/vektah/dataloaden UserRolesLoader int64 []*gogql/app/models/dbmodels.Role
 
 package dataloaders
@@ -4,14 +4,29 @@ package graph
 
 import (
 	"fmt"
-	"io"
 	"gogql/app/models/dbmodels"
+	"io"
 	"strconv"
+	"time"
 
 	"github.com/gofrs/uuid"
 	"github.com/volatiletech/null"
 )
 
+type AccessReviewCampaignRequest struct {
+	OrgUID       *uuid.NullUUID `json:"orgUID,omitempty"`
+	DepartmentID *null.Int64    `json:"departmentID,omitempty"`
+	Name         string         `json:"name"`
+	ReviewerIDs  []int64        `json:"reviewerIDs,omitempty"`
+}
+
+type AccessReviewDecisionRequest struct {
+	Decision       string      `json:"decision"`
+	RevokeAction   *string     `json:"revokeAction,omitempty"`
+	ReassignRoleID *null.Int64 `json:"reassignRoleID,omitempty"`
+	Note           *string     `json:"note,omitempty"`
+}
+
 type BatchActionInput struct {
 	ID       *null.Int64  `json:"id,omitempty"`
 	Str      *null.String `json:"str,omitempty"`
@@ -20,6 +35,14 @@ type BatchActionInput struct {
 	Bool     *null.Bool   `json:"bool,omitempty"`
 }
 
+type CreateAPIKey struct {
+	Name        string     `json:"name"`
+	OrgUID      *uuid.UUID `json:"orgUID,omitempty"`
+	Permissions []string   `json:"permissions"`
+	AllowedIPs  []string   `json:"allowedIPs,omitempty"`
+	ExpiresAt   *null.Time `json:"expiresAt,omitempty"`
+}
+
 type DepartmentsResult struct {
 	Departments []dbmodels.Department `json:"departments"`
 	Total       int                   `json:"total"`
@@ -30,10 +53,16 @@ type FileInput struct {
 	URL  string `json:"url"`
 }
 
+type InvitationsResult struct {
+	Invitations []dbmodels.Invitation `json:"invitations"`
+	Total       int                   `json:"total"`
+}
+
 type LoginRequest struct {
-	Email *null.String `json:"email,omitempty"`
-	Phone *null.String `json:"phone,omitempty"`
-	Otp   *null.String `json:"otp,omitempty"`
+	Email       *null.String `json:"email,omitempty"`
+	Phone       *null.String `json:"phone,omitempty"`
+	Otp         *null.String `json:"otp,omitempty"`
+	DeviceLabel *null.String `json:"deviceLabel,omitempty"`
 }
 
 type OTPRequest struct {
@@ -51,15 +80,55 @@ type PageInfo struct {
 	EndCursor   int64 `json:"endCursor"`
 }
 
+type PasskeyAssertion struct {
+	CredentialID      string       `json:"credentialID"`
+	ClientDataJSON    string       `json:"clientDataJSON"`
+	AuthenticatorData string       `json:"authenticatorData"`
+	Signature         string       `json:"signature"`
+	UserHandle        *null.String `json:"userHandle,omitempty"`
+	DeviceLabel       *null.String `json:"deviceLabel,omitempty"`
+}
+
+type PasskeyLoginRequest struct {
+	Email *null.String `json:"email,omitempty"`
+	Phone *null.String `json:"phone,omitempty"`
+}
+
+type PasskeyRegistration struct {
+	ClientDataJSON    string       `json:"clientDataJSON"`
+	AttestationObject string       `json:"attestationObject"`
+	Name              *null.String `json:"name,omitempty"`
+}
+
+type PermissionElevationRequest struct {
+	UserID          *null.Int64 `json:"userID,omitempty"`
+	Permission      string      `json:"permission"`
+	Justification   string      `json:"justification"`
+	StartsAt        *null.Time  `json:"startsAt,omitempty"`
+	EndsAt          time.Time   `json:"endsAt"`
+	RequireApproval *bool       `json:"requireApproval,omitempty"`
+}
+
+type PermissionGrant struct {
+	Permission string          `json:"permission"`
+	Scope      PermissionScope `json:"scope"`
+}
+
+type PermissionGrantInput struct {
+	Permission string          `json:"permission"`
+	Scope      PermissionScope `json:"scope"`
+}
+
 type RegisterOrganization struct {
-	OrgName   *null.String `json:"orgName,omitempty"`
-	Website   *null.String `json:"website,omitempty"`
-	Logo      *FileInput   `json:"logo,omitempty"`
-	Sector    *null.String `json:"sector,omitempty"`
-	FirstName *null.String `json:"firstName,omitempty"`
-	LastName  *null.String `json:"lastName,omitempty"`
-	Email     *null.String `json:"email,omitempty"`
-	Phone     *null.String `json:"phone,omitempty"`
+	OrgName     *null.String `json:"orgName,omitempty"`
+	Website     *null.String `json:"website,omitempty"`
+	Logo        *FileInput   `json:"logo,omitempty"`
+	Sector      *null.String `json:"sector,omitempty"`
+	FirstName   *null.String `json:"firstName,omitempty"`
+	LastName    *null.String `json:"lastName,omitempty"`
+	Email       *null.String `json:"email,omitempty"`
+	Phone       *null.String `json:"phone,omitempty"`
+	PhoneRegion *null.String `json:"phoneRegion,omitempty"`
 }
 
 type RequestToken struct {
@@ -82,25 +151,45 @@ type SearchFilter struct {
 	OrgUID  *uuid.UUID    `json:"orgUID,omitempty"`
 }
 
+type SeparationRuleRequest struct {
+	OrgUID      *uuid.NullUUID `json:"orgUID,omitempty"`
+	Name        string         `json:"name"`
+	Permissions []string       `json:"permissions"`
+	IsActive    *bool          `json:"isActive,omitempty"`
+}
+
 type UpdateDepartment struct {
 	Name   *null.String   `json:"name,omitempty"`
 	OrgUID *uuid.NullUUID `json:"orgUID,omitempty"`
 }
 
 type UpdateOrganization struct {
-	Name    *null.String `json:"name,omitempty"`
-	Website *null.String `json:"website,omitempty"`
-	Sector  *null.String `json:"sector,omitempty"`
-	Logo    *FileInput   `json:"logo,omitempty"`
+	Name                 *null.String `json:"name,omitempty"`
+	Website              *null.String `json:"website,omitempty"`
+	Sector               *null.String `json:"sector,omitempty"`
+	Logo                 *FileInput   `json:"logo,omitempty"`
+	RequireManagement2fa *null.Bool   `json:"requireManagement2FA,omitempty"`
+	PhoneRegion          *null.String `json:"phoneRegion,omitempty"`
+}
+
+type UpdateOrganizationOidc struct {
+	Issuer          string       `json:"issuer"`
+	ClientID        string       `json:"clientID"`
+	ClientSecret    *null.String `json:"clientSecret,omitempty"`
+	AllowedDomains  []string     `json:"allowedDomains"`
+	IsEnabled       bool         `json:"isEnabled"`
+	JitProvisioning bool         `json:"jitProvisioning"`
+	DefaultRoleID   *null.Int64  `json:"defaultRoleID,omitempty"`
 }
 
 type UpdateRole struct {
-	Name         *null.String   `json:"name,omitempty"`
-	IsManagement *null.Bool     `json:"isManagement,omitempty"`
-	OrgUID       *uuid.NullUUID `json:"orgUID,omitempty"`
-	DepartmentID *null.Int64    `json:"departmentID,omitempty"`
-	Permissions  []string       `json:"permissions,omitempty"`
-	IsArchived   *null.Bool     `json:"isArchived,omitempty"`
+	Name             *null.String           `json:"name,omitempty"`
+	IsManagement     *null.Bool             `json:"isManagement,omitempty"`
+	OrgUID           *uuid.NullUUID         `json:"orgUID,omitempty"`
+	DepartmentID     *null.Int64            `json:"departmentID,omitempty"`
+	Permissions      []string               `json:"permissions,omitempty"`
+	PermissionScopes []PermissionGrantInput `json:"permissionScopes,omitempty"`
+	IsArchived       *null.Bool             `json:"isArchived,omitempty"`
 }
 
 type UpdateUser struct {
@@ -210,6 +299,133 @@ func (e FilterOption) MarshalGQL(w io.Writer) {
 	fmt.Fprint(w, strconv.Quote(e.String()))
 }
 
+type PermissionName string
+
+const (
+	PermissionNameUploadFile         PermissionName = "UPLOAD_FILE"
+	PermissionNameCreateOrganization PermissionName = "CREATE_ORGANIZATION"
+	PermissionNameReadOrganization   PermissionName = "READ_ORGANIZATION"
+	PermissionNameUpdateOrganization PermissionName = "UPDATE_ORGANIZATION"
+	PermissionNameDeleteOrganization PermissionName = "DELETE_ORGANIZATION"
+	PermissionNameCreateDepartment   PermissionName = "CREATE_DEPARTMENT"
+	PermissionNameReadDepartment     PermissionName = "READ_DEPARTMENT"
+	PermissionNameUpdateDepartment   PermissionName = "UPDATE_DEPARTMENT"
+	PermissionNameDeleteDepartment   PermissionName = "DELETE_DEPARTMENT"
+	PermissionNameCreateRole         PermissionName = "CREATE_ROLE"
+	PermissionNameReadRole           PermissionName = "READ_ROLE"
+	PermissionNameUpdateRole         PermissionName = "UPDATE_ROLE"
+	PermissionNameDeleteRole         PermissionName = "DELETE_ROLE"
+	PermissionNameCreateUser         PermissionName = "CREATE_USER"
+	PermissionNameReadUser           PermissionName = "READ_USER"
+	PermissionNameUpdateUser         PermissionName = "UPDATE_USER"
+	PermissionNameDeleteUser         PermissionName = "DELETE_USER"
+	PermissionNameCreateContract     PermissionName = "CREATE_CONTRACT"
+	PermissionNameReadContract       PermissionName = "READ_CONTRACT"
+	PermissionNameUpdateContract     PermissionName = "UPDATE_CONTRACT"
+	PermissionNameDeleteContract     PermissionName = "DELETE_CONTRACT"
+	PermissionNameReadUserActivity   PermissionName = "READ_USER_ACTIVITY"
+	PermissionNameManageAPIKey       PermissionName = "MANAGE_API_KEY"
+)
+
+var AllPermissionName = []PermissionName{
+	PermissionNameUploadFile,
+	PermissionNameCreateOrganization,
+	PermissionNameReadOrganization,
+	PermissionNameUpdateOrganization,
+	PermissionNameDeleteOrganization,
+	PermissionNameCreateDepartment,
+	PermissionNameReadDepartment,
+	PermissionNameUpdateDepartment,
+	PermissionNameDeleteDepartment,
+	PermissionNameCreateRole,
+	PermissionNameReadRole,
+	PermissionNameUpdateRole,
+	PermissionNameDeleteRole,
+	PermissionNameCreateUser,
+	PermissionNameReadUser,
+	PermissionNameUpdateUser,
+	PermissionNameDeleteUser,
+	PermissionNameCreateContract,
+	PermissionNameReadContract,
+	PermissionNameUpdateContract,
+	PermissionNameDeleteContract,
+	PermissionNameReadUserActivity,
+	PermissionNameManageAPIKey,
+}
+
+func (e PermissionName) IsValid() bool {
+	switch e {
+	case PermissionNameUploadFile, PermissionNameCreateOrganization, PermissionNameReadOrganization, PermissionNameUpdateOrganization, PermissionNameDeleteOrganization, PermissionNameCreateDepartment, PermissionNameReadDepartment, PermissionNameUpdateDepartment, PermissionNameDeleteDepartment, PermissionNameCreateRole, PermissionNameReadRole, PermissionNameUpdateRole, PermissionNameDeleteRole, PermissionNameCreateUser, PermissionNameReadUser, PermissionNameUpdateUser, PermissionNameDeleteUser, PermissionNameCreateContract, PermissionNameReadContract, PermissionNameUpdateContract, PermissionNameDeleteContract, PermissionNameReadUserActivity, PermissionNameManageAPIKey:
+		return true
+	}
+	return false
+}
+
+func (e PermissionName) String() string {
+	return string(e)
+}
+
+func (e *PermissionName) UnmarshalGQL(v interface{}) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = PermissionName(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid PermissionName", str)
+	}
+	return nil
+}
+
+func (e PermissionName) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+// the part of the organization a role grants a permission for
+type PermissionScope string
+
+const (
+	PermissionScopeOrganization PermissionScope = "ORGANIZATION"
+	PermissionScopeDepartment   PermissionScope = "DEPARTMENT"
+	PermissionScopeOwn          PermissionScope = "OWN"
+)
+
+var AllPermissionScope = []PermissionScope{
+	PermissionScopeOrganization,
+	PermissionScopeDepartment,
+	PermissionScopeOwn,
+}
+
+func (e PermissionScope) IsValid() bool {
+	switch e {
+	case PermissionScopeOrganization, PermissionScopeDepartment, PermissionScopeOwn:
+		return true
+	}
+	return false
+}
+
+func (e PermissionScope) String() string {
+	return string(e)
+}
+
+func (e *PermissionScope) UnmarshalGQL(v interface{}) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = PermissionScope(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid PermissionScope", str)
+	}
+	return nil
+}
+
+func (e PermissionScope) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
 type SortByOption string
 
 const (
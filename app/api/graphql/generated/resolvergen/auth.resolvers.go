@@ -9,10 +9,18 @@ import (
 	"fmt"
 	"gogql/app/api/graphql/generated/graph"
 	"gogql/app/models"
+	"gogql/app/models/dbmodels"
+
+	"github.com/gofrs/uuid"
 )
 
+// EffectivePermissions is the resolver for the effectivePermissions field.
+func (r *autherResolver) EffectivePermissions(ctx context.Context, obj *models.Auther) ([]string, error) {
+	panic(fmt.Errorf("not implemented: EffectivePermissions - effectivePermissions"))
+}
+
 // GenerateOtp is the resolver for the generateOTP field.
-func (r *mutationResolver) GenerateOtp(ctx context.Context, input *graph.OTPRequest) (*string, error) {
+func (r *mutationResolver) GenerateOtp(ctx context.Context, input *graph.OTPRequest) (*models.OTPAcknowledgement, error) {
 	panic(fmt.Errorf("not implemented: GenerateOtp - generateOTP"))
 }
 
@@ -21,16 +29,125 @@ func (r *mutationResolver) Login(ctx context.Context, input graph.LoginRequest)
 	panic(fmt.Errorf("not implemented: Login - login"))
 }
 
+// RefreshSession is the resolver for the refreshSession field.
+func (r *mutationResolver) RefreshSession(ctx context.Context, refreshToken string) (*models.Auther, error) {
+	panic(fmt.Errorf("not implemented: RefreshSession - refreshSession"))
+}
+
+// SessionRevoke is the resolver for the sessionRevoke field.
+func (r *mutationResolver) SessionRevoke(ctx context.Context, id int64) (*models.Session, error) {
+	panic(fmt.Errorf("not implemented: SessionRevoke - sessionRevoke"))
+}
+
+// LogoutAllSessions is the resolver for the logoutAllSessions field.
+func (r *mutationResolver) LogoutAllSessions(ctx context.Context) (int, error) {
+	panic(fmt.Errorf("not implemented: LogoutAllSessions - logoutAllSessions"))
+}
+
+// TotpEnroll is the resolver for the totpEnroll field.
+func (r *mutationResolver) TotpEnroll(ctx context.Context) (*models.TOTPEnrollment, error) {
+	panic(fmt.Errorf("not implemented: TotpEnroll - totpEnroll"))
+}
+
+// TotpConfirm is the resolver for the totpConfirm field.
+func (r *mutationResolver) TotpConfirm(ctx context.Context, code string) (*models.TOTPConfirmation, error) {
+	panic(fmt.Errorf("not implemented: TotpConfirm - totpConfirm"))
+}
+
+// VerifySecondFactor is the resolver for the verifySecondFactor field.
+func (r *mutationResolver) VerifySecondFactor(ctx context.Context, code string) (*models.Auther, error) {
+	panic(fmt.Errorf("not implemented: VerifySecondFactor - verifySecondFactor"))
+}
+
+// TotpDisable is the resolver for the totpDisable field.
+func (r *mutationResolver) TotpDisable(ctx context.Context, code string) (bool, error) {
+	panic(fmt.Errorf("not implemented: TotpDisable - totpDisable"))
+}
+
+// RecoveryCodesRegenerate is the resolver for the recoveryCodesRegenerate field.
+func (r *mutationResolver) RecoveryCodesRegenerate(ctx context.Context, code string) ([]string, error) {
+	panic(fmt.Errorf("not implemented: RecoveryCodesRegenerate - recoveryCodesRegenerate"))
+}
+
+// PasskeyRegisterBegin is the resolver for the passkeyRegisterBegin field.
+func (r *mutationResolver) PasskeyRegisterBegin(ctx context.Context) (*models.PasskeyOptions, error) {
+	panic(fmt.Errorf("not implemented: PasskeyRegisterBegin - passkeyRegisterBegin"))
+}
+
+// PasskeyRegisterFinish is the resolver for the passkeyRegisterFinish field.
+func (r *mutationResolver) PasskeyRegisterFinish(ctx context.Context, input graph.PasskeyRegistration) (*dbmodels.WebAuthnCredential, error) {
+	panic(fmt.Errorf("not implemented: PasskeyRegisterFinish - passkeyRegisterFinish"))
+}
+
+// PasskeyLoginBegin is the resolver for the passkeyLoginBegin field.
+func (r *mutationResolver) PasskeyLoginBegin(ctx context.Context, input *graph.PasskeyLoginRequest) (*models.PasskeyOptions, error) {
+	panic(fmt.Errorf("not implemented: PasskeyLoginBegin - passkeyLoginBegin"))
+}
+
+// PasskeyLogin is the resolver for the passkeyLogin field.
+func (r *mutationResolver) PasskeyLogin(ctx context.Context, input graph.PasskeyAssertion) (*models.Auther, error) {
+	panic(fmt.Errorf("not implemented: PasskeyLogin - passkeyLogin"))
+}
+
+// PasskeyDelete is the resolver for the passkeyDelete field.
+func (r *mutationResolver) PasskeyDelete(ctx context.Context, id int64) (*dbmodels.WebAuthnCredential, error) {
+	panic(fmt.Errorf("not implemented: PasskeyDelete - passkeyDelete"))
+}
+
+// APIKeyCreate is the resolver for the apiKeyCreate field.
+func (r *mutationResolver) APIKeyCreate(ctx context.Context, input graph.CreateAPIKey) (*models.APIKeyCreated, error) {
+	panic(fmt.Errorf("not implemented: APIKeyCreate - apiKeyCreate"))
+}
+
+// APIKeyRevoke is the resolver for the apiKeyRevoke field.
+func (r *mutationResolver) APIKeyRevoke(ctx context.Context, id int64) (*dbmodels.APIKey, error) {
+	panic(fmt.Errorf("not implemented: APIKeyRevoke - apiKeyRevoke"))
+}
+
+// ImpersonateUser is the resolver for the impersonateUser field.
+func (r *mutationResolver) ImpersonateUser(ctx context.Context, id int64) (*models.Auther, error) {
+	panic(fmt.Errorf("not implemented: ImpersonateUser - impersonateUser"))
+}
+
 // Auther is the resolver for the auther field.
 func (r *queryResolver) Auther(ctx context.Context) (*models.Auther, error) {
 	panic(fmt.Errorf("not implemented: Auther - auther"))
 }
 
+// MySessions is the resolver for the mySessions field.
+func (r *queryResolver) MySessions(ctx context.Context) ([]models.Session, error) {
+	panic(fmt.Errorf("not implemented: MySessions - mySessions"))
+}
+
+// UserSessions is the resolver for the userSessions field.
+func (r *queryResolver) UserSessions(ctx context.Context, userID int64) ([]models.Session, error) {
+	panic(fmt.Errorf("not implemented: UserSessions - userSessions"))
+}
+
+// Passkeys is the resolver for the passkeys field.
+func (r *queryResolver) Passkeys(ctx context.Context) ([]dbmodels.WebAuthnCredential, error) {
+	panic(fmt.Errorf("not implemented: Passkeys - passkeys"))
+}
+
+// APIKeys is the resolver for the apiKeys field.
+func (r *queryResolver) APIKeys(ctx context.Context, orgUID *uuid.UUID) ([]dbmodels.APIKey, error) {
+	panic(fmt.Errorf("not implemented: APIKeys - apiKeys"))
+}
+
+// AuthCacheStats is the resolver for the authCacheStats field.
+func (r *queryResolver) AuthCacheStats(ctx context.Context) ([]models.CacheStats, error) {
+	panic(fmt.Errorf("not implemented: AuthCacheStats - authCacheStats"))
+}
+
+// Auther returns graph.AutherResolver implementation.
+func (r *Resolver) Auther() graph.AutherResolver { return &autherResolver{r} }
+
 // Mutation returns graph.MutationResolver implementation.
 func (r *Resolver) Mutation() graph.MutationResolver { return &mutationResolver{r} }
 
 // Query returns graph.QueryResolver implementation.
 func (r *Resolver) Query() graph.QueryResolver { return &queryResolver{r} }
 
+type autherResolver struct{ *Resolver }
 type mutationResolver struct{ *Resolver }
 type queryResolver struct{ *Resolver }
@@ -8,6 +8,7 @@ import (
 	"context"
 	"fmt"
 	"gogql/app/api/graphql/generated/graph"
+	"gogql/app/models"
 	"gogql/app/models/dbmodels"
 
 	"github.com/gofrs/uuid"
@@ -33,6 +34,11 @@ func (r *mutationResolver) OrganizationUnarchive(ctx context.Context, uid uuid.U
 	panic(fmt.Errorf("not implemented: OrganizationUnarchive - organizationUnarchive"))
 }
 
+// OrganizationOIDCUpdate is the resolver for the organizationOIDCUpdate field.
+func (r *mutationResolver) OrganizationOIDCUpdate(ctx context.Context, uid uuid.UUID, input graph.UpdateOrganizationOidc) (*models.OIDCConfig, error) {
+	panic(fmt.Errorf("not implemented: OrganizationOIDCUpdate - organizationOIDCUpdate"))
+}
+
 // Organizations is the resolver for the organizations field.
 func (r *queryResolver) Organizations(ctx context.Context, search graph.SearchFilter, sector *string) (*graph.OrganizationsResult, error) {
 	panic(fmt.Errorf("not implemented: Organizations - organizations"))
@@ -42,3 +48,8 @@ func (r *queryResolver) Organizations(ctx context.Context, search graph.SearchFi
 func (r *queryResolver) Organization(ctx context.Context, uid *uuid.UUID, code *string) (*dbmodels.Organization, error) {
 	panic(fmt.Errorf("not implemented: Organization - organization"))
 }
+
+// OrganizationOIDCConfig is the resolver for the organizationOIDCConfig field.
+func (r *queryResolver) OrganizationOIDCConfig(ctx context.Context, uid uuid.UUID) (*models.OIDCConfig, error) {
+	panic(fmt.Errorf("not implemented: OrganizationOIDCConfig - organizationOIDCConfig"))
+}
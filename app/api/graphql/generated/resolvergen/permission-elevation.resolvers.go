@@ -0,0 +1,59 @@
+package resolvergen
+
+// This file will be automatically regenerated based on the schema, any resolver implementations
+// will be copied through when generating and any unknown code will be moved to the end.
+// Code generated by github.com/99designs/gqlgen version v0.17.27
+
+import (
+	"context"
+	"fmt"
+	"gogql/app/api/graphql/generated/graph"
+	"gogql/app/models/dbmodels"
+)
+
+// PermissionElevationRequest is the resolver for the permissionElevationRequest field.
+func (r *mutationResolver) PermissionElevationRequest(ctx context.Context, input graph.PermissionElevationRequest) (*dbmodels.PermissionElevation, error) {
+	panic(fmt.Errorf("not implemented: PermissionElevationRequest - permissionElevationRequest"))
+}
+
+// PermissionElevationApprove is the resolver for the permissionElevationApprove field.
+func (r *mutationResolver) PermissionElevationApprove(ctx context.Context, id int64) (*dbmodels.PermissionElevation, error) {
+	panic(fmt.Errorf("not implemented: PermissionElevationApprove - permissionElevationApprove"))
+}
+
+// PermissionElevationDeny is the resolver for the permissionElevationDeny field.
+func (r *mutationResolver) PermissionElevationDeny(ctx context.Context, id int64) (*dbmodels.PermissionElevation, error) {
+	panic(fmt.Errorf("not implemented: PermissionElevationDeny - permissionElevationDeny"))
+}
+
+// PermissionElevationRevoke is the resolver for the permissionElevationRevoke field.
+func (r *mutationResolver) PermissionElevationRevoke(ctx context.Context, id int64) (*dbmodels.PermissionElevation, error) {
+	panic(fmt.Errorf("not implemented: PermissionElevationRevoke - permissionElevationRevoke"))
+}
+
+// User is the resolver for the user field.
+func (r *permissionElevationResolver) User(ctx context.Context, obj *dbmodels.PermissionElevation) (*dbmodels.User, error) {
+	panic(fmt.Errorf("not implemented: User - user"))
+}
+
+// RequestedBy is the resolver for the requestedBy field.
+func (r *permissionElevationResolver) RequestedBy(ctx context.Context, obj *dbmodels.PermissionElevation) (*dbmodels.User, error) {
+	panic(fmt.Errorf("not implemented: RequestedBy - requestedBy"))
+}
+
+// DecidedBy is the resolver for the decidedBy field.
+func (r *permissionElevationResolver) DecidedBy(ctx context.Context, obj *dbmodels.PermissionElevation) (*dbmodels.User, error) {
+	panic(fmt.Errorf("not implemented: DecidedBy - decidedBy"))
+}
+
+// PermissionElevations is the resolver for the permissionElevations field.
+func (r *queryResolver) PermissionElevations(ctx context.Context, userID *int64, status *string) ([]dbmodels.PermissionElevation, error) {
+	panic(fmt.Errorf("not implemented: PermissionElevations - permissionElevations"))
+}
+
+// PermissionElevation returns graph.PermissionElevationResolver implementation.
+func (r *Resolver) PermissionElevation() graph.PermissionElevationResolver {
+	return &permissionElevationResolver{r}
+}
+
+type permissionElevationResolver struct{ *Resolver }
@@ -8,7 +8,11 @@ import (
 	"context"
 	"fmt"
 	"gogql/app/api/graphql/generated/graph"
+	"gogql/app/models"
 	"gogql/app/models/dbmodels"
+	"time"
+
+	"github.com/gofrs/uuid"
 )
 
 // RoleCreate is the resolver for the roleCreate field.
@@ -36,6 +40,26 @@ func (r *mutationResolver) RoleUnarchive(ctx context.Context, id int64) (*dbmode
 	panic(fmt.Errorf("not implemented: RoleUnarchive - roleUnarchive"))
 }
 
+// PermissionRename is the resolver for the permissionRename field.
+func (r *mutationResolver) PermissionRename(ctx context.Context, from string, to string) (int, error) {
+	panic(fmt.Errorf("not implemented: PermissionRename - permissionRename"))
+}
+
+// Scope is the resolver for the scope field.
+func (r *permissionDecisionResolver) Scope(ctx context.Context, obj *models.PermissionDecision) (*graph.PermissionScope, error) {
+	panic(fmt.Errorf("not implemented: Scope - scope"))
+}
+
+// DepartmentIDs is the resolver for the departmentIDs field.
+func (r *permissionDecisionResolver) DepartmentIDs(ctx context.Context, obj *models.PermissionDecision) ([]int64, error) {
+	panic(fmt.Errorf("not implemented: DepartmentIDs - departmentIDs"))
+}
+
+// User is the resolver for the user field.
+func (r *permissionHolderResolver) User(ctx context.Context, obj *models.PermissionHolder) (*dbmodels.User, error) {
+	panic(fmt.Errorf("not implemented: User - user"))
+}
+
 // Roles is the resolver for the roles field.
 func (r *queryResolver) Roles(ctx context.Context, search graph.SearchFilter, deptID *int64) (*graph.RolesResult, error) {
 	panic(fmt.Errorf("not implemented: Roles - roles"))
@@ -46,6 +70,26 @@ func (r *queryResolver) Role(ctx context.Context, id *int64, code *string) (*dbm
 	panic(fmt.Errorf("not implemented: Role - role"))
 }
 
+// Permissions is the resolver for the permissions field.
+func (r *queryResolver) Permissions(ctx context.Context, includeRemoved *bool) ([]dbmodels.Permission, error) {
+	panic(fmt.Errorf("not implemented: Permissions - permissions"))
+}
+
+// RolesWithUnknownPermissions is the resolver for the rolesWithUnknownPermissions field.
+func (r *queryResolver) RolesWithUnknownPermissions(ctx context.Context) ([]models.RolePermissionIssue, error) {
+	panic(fmt.Errorf("not implemented: RolesWithUnknownPermissions - rolesWithUnknownPermissions"))
+}
+
+// PermissionCheck is the resolver for the permissionCheck field.
+func (r *queryResolver) PermissionCheck(ctx context.Context, perm string, userID *int64, at *time.Time) (*models.PermissionDecision, error) {
+	panic(fmt.Errorf("not implemented: PermissionCheck - permissionCheck"))
+}
+
+// PermissionHolders is the resolver for the permissionHolders field.
+func (r *queryResolver) PermissionHolders(ctx context.Context, perm string, at time.Time, orgUID *uuid.NullUUID) ([]models.PermissionHolder, error) {
+	panic(fmt.Errorf("not implemented: PermissionHolders - permissionHolders"))
+}
+
 // Organization is the resolver for the organization field.
 func (r *roleResolver) Organization(ctx context.Context, obj *dbmodels.Role) (*dbmodels.Organization, error) {
 	panic(fmt.Errorf("not implemented: Organization - organization"))
@@ -56,7 +100,24 @@ func (r *roleResolver) Department(ctx context.Context, obj *dbmodels.Role) (*dbm
 	panic(fmt.Errorf("not implemented: Department - department"))
 }
 
+// PermissionGrants is the resolver for the permissionGrants field.
+func (r *roleResolver) PermissionGrants(ctx context.Context, obj *dbmodels.Role) ([]graph.PermissionGrant, error) {
+	panic(fmt.Errorf("not implemented: PermissionGrants - permissionGrants"))
+}
+
+// PermissionDecision returns graph.PermissionDecisionResolver implementation.
+func (r *Resolver) PermissionDecision() graph.PermissionDecisionResolver {
+	return &permissionDecisionResolver{r}
+}
+
+// PermissionHolder returns graph.PermissionHolderResolver implementation.
+func (r *Resolver) PermissionHolder() graph.PermissionHolderResolver {
+	return &permissionHolderResolver{r}
+}
+
 // Role returns graph.RoleResolver implementation.
 func (r *Resolver) Role() graph.RoleResolver { return &roleResolver{r} }
 
+type permissionDecisionResolver struct{ *Resolver }
+type permissionHolderResolver struct{ *Resolver }
 type roleResolver struct{ *Resolver }
@@ -0,0 +1,50 @@
+package resolvergen
+
+// This file will be automatically regenerated based on the schema, any resolver implementations
+// will be copied through when generating and any unknown code will be moved to the end.
+// Code generated by github.com/99designs/gqlgen version v0.17.27
+
+import (
+	"context"
+	"fmt"
+	"gogql/app/api/graphql/generated/graph"
+	"gogql/app/models"
+	"gogql/app/models/dbmodels"
+
+	"github.com/gofrs/uuid"
+)
+
+// SeparationRuleCreate is the resolver for the separationRuleCreate field.
+func (r *mutationResolver) SeparationRuleCreate(ctx context.Context, input graph.SeparationRuleRequest) (*dbmodels.SeparationRule, error) {
+	panic(fmt.Errorf("not implemented: SeparationRuleCreate - separationRuleCreate"))
+}
+
+// SeparationRuleUpdate is the resolver for the separationRuleUpdate field.
+func (r *mutationResolver) SeparationRuleUpdate(ctx context.Context, id int64, input graph.SeparationRuleRequest) (*dbmodels.SeparationRule, error) {
+	panic(fmt.Errorf("not implemented: SeparationRuleUpdate - separationRuleUpdate"))
+}
+
+// SeparationRuleDelete is the resolver for the separationRuleDelete field.
+func (r *mutationResolver) SeparationRuleDelete(ctx context.Context, id int64) (*dbmodels.SeparationRule, error) {
+	panic(fmt.Errorf("not implemented: SeparationRuleDelete - separationRuleDelete"))
+}
+
+// SeparationRules is the resolver for the separationRules field.
+func (r *queryResolver) SeparationRules(ctx context.Context, orgUID *uuid.NullUUID, isActive *bool) ([]dbmodels.SeparationRule, error) {
+	panic(fmt.Errorf("not implemented: SeparationRules - separationRules"))
+}
+
+// SeparationViolations is the resolver for the separationViolations field.
+func (r *queryResolver) SeparationViolations(ctx context.Context, orgUID *uuid.NullUUID) ([]models.SeparationViolation, error) {
+	panic(fmt.Errorf("not implemented: SeparationViolations - separationViolations"))
+}
+
+// CreatedBy is the resolver for the createdBy field.
+func (r *separationRuleResolver) CreatedBy(ctx context.Context, obj *dbmodels.SeparationRule) (*dbmodels.User, error) {
+	panic(fmt.Errorf("not implemented: CreatedBy - createdBy"))
+}
+
+// SeparationRule returns graph.SeparationRuleResolver implementation.
+func (r *Resolver) SeparationRule() graph.SeparationRuleResolver { return &separationRuleResolver{r} }
+
+type separationRuleResolver struct{ *Resolver }
@@ -26,6 +26,11 @@ func (r *userActivityResolver) User(ctx context.Context, obj *dbmodels.UserActiv
 	panic(fmt.Errorf("not implemented: User - user"))
 }
 
+// Actor is the resolver for the actor field.
+func (r *userActivityResolver) Actor(ctx context.Context, obj *dbmodels.UserActivity) (*dbmodels.User, error) {
+	panic(fmt.Errorf("not implemented: Actor - actor"))
+}
+
 // Organization is the resolver for the organization field.
 func (r *userActivityResolver) Organization(ctx context.Context, obj *dbmodels.UserActivity) (*dbmodels.Organization, error) {
 	panic(fmt.Errorf("not implemented: Organization - organization"))
@@ -9,6 +9,8 @@ import (
 	"fmt"
 	"gogql/app/api/graphql/generated/graph"
 	"gogql/app/models/dbmodels"
+
+	"github.com/volatiletech/null"
 )
 
 // SuperAdminCreate is the resolver for the superAdminCreate field.
@@ -16,11 +18,6 @@ func (r *mutationResolver) SuperAdminCreate(ctx context.Context, input graph.Upd
 	panic(fmt.Errorf("not implemented: SuperAdminCreate - superAdminCreate"))
 }
 
-// UserCreate is the resolver for the userCreate field.
-func (r *mutationResolver) UserCreate(ctx context.Context, input graph.UpdateUser) (*dbmodels.User, error) {
-	panic(fmt.Errorf("not implemented: UserCreate - userCreate"))
-}
-
 // ChangeDetails is the resolver for the changeDetails field.
 func (r *mutationResolver) ChangeDetails(ctx context.Context, id int64, input graph.UpdateUser) (*dbmodels.User, error) {
 	panic(fmt.Errorf("not implemented: ChangeDetails - changeDetails"))
@@ -31,9 +28,14 @@ func (r *mutationResolver) UserUpdate(ctx context.Context, id int64, input graph
 	panic(fmt.Errorf("not implemented: UserUpdate - userUpdate"))
 }
 
-// ResendEmailVerification is the resolver for the resendEmailVerification field.
-func (r *mutationResolver) ResendEmailVerification(ctx context.Context, email string) (bool, error) {
-	panic(fmt.Errorf("not implemented: ResendEmailVerification - resendEmailVerification"))
+// ContactChangeConfirm is the resolver for the contactChangeConfirm field.
+func (r *mutationResolver) ContactChangeConfirm(ctx context.Context, id int64, code string) (*dbmodels.User, error) {
+	panic(fmt.Errorf("not implemented: ContactChangeConfirm - contactChangeConfirm"))
+}
+
+// ContactChangeCancel is the resolver for the contactChangeCancel field.
+func (r *mutationResolver) ContactChangeCancel(ctx context.Context, token string) (bool, error) {
+	panic(fmt.Errorf("not implemented: ContactChangeCancel - contactChangeCancel"))
 }
 
 // UserArchive is the resolver for the userArchive field.
@@ -46,6 +48,16 @@ func (r *mutationResolver) UserUnarchive(ctx context.Context, id int64) (*dbmode
 	panic(fmt.Errorf("not implemented: UserUnarchive - userUnarchive"))
 }
 
+// UserRoleAssign is the resolver for the userRoleAssign field.
+func (r *mutationResolver) UserRoleAssign(ctx context.Context, userID int64, roleID int64, expiresAt *null.Time) (*dbmodels.User, error) {
+	panic(fmt.Errorf("not implemented: UserRoleAssign - userRoleAssign"))
+}
+
+// UserRoleRevoke is the resolver for the userRoleRevoke field.
+func (r *mutationResolver) UserRoleRevoke(ctx context.Context, userID int64, roleID int64) (*dbmodels.User, error) {
+	panic(fmt.Errorf("not implemented: UserRoleRevoke - userRoleRevoke"))
+}
+
 // Users is the resolver for the users field.
 func (r *queryResolver) Users(ctx context.Context, search graph.SearchFilter, roleID *int64) (*graph.UserResult, error) {
 	panic(fmt.Errorf("not implemented: Users - users"))
@@ -61,17 +73,45 @@ func (r *queryResolver) Me(ctx context.Context) (*dbmodels.User, error) {
 	panic(fmt.Errorf("not implemented: Me - me"))
 }
 
+// ContactChanges is the resolver for the contactChanges field.
+func (r *queryResolver) ContactChanges(ctx context.Context, userID *int64) ([]dbmodels.ContactChange, error) {
+	panic(fmt.Errorf("not implemented: ContactChanges - contactChanges"))
+}
+
 // Role is the resolver for the role field.
 func (r *userResolver) Role(ctx context.Context, obj *dbmodels.User) (*dbmodels.Role, error) {
 	panic(fmt.Errorf("not implemented: Role - role"))
 }
 
+// Roles is the resolver for the roles field.
+func (r *userResolver) Roles(ctx context.Context, obj *dbmodels.User) ([]dbmodels.Role, error) {
+	panic(fmt.Errorf("not implemented: Roles - roles"))
+}
+
 // Organization is the resolver for the organization field.
 func (r *userResolver) Organization(ctx context.Context, obj *dbmodels.User) (*dbmodels.Organization, error) {
 	panic(fmt.Errorf("not implemented: Organization - organization"))
 }
 
+// EffectivePermissions is the resolver for the effectivePermissions field.
+func (r *userResolver) EffectivePermissions(ctx context.Context, obj *dbmodels.User) ([]string, error) {
+	panic(fmt.Errorf("not implemented: EffectivePermissions - effectivePermissions"))
+}
+
 // User returns graph.UserResolver implementation.
 func (r *Resolver) User() graph.UserResolver { return &userResolver{r} }
 
 type userResolver struct{ *Resolver }
+
+// !!! WARNING !!!
+// The code below was going to be deleted when updating resolvers. It has been copied here so you have
+// one last chance to move it out of harms way if you want. There are two reasons this happens:
+//   - When renaming or deleting a resolver the old code will be put in here. You can safely delete
+//     it when you're done.
+//   - You have helper methods in this file. Move them out to keep these resolver files clean.
+func (r *mutationResolver) UserCreate(ctx context.Context, input graph.UpdateUser) (*dbmodels.User, error) {
+	panic(fmt.Errorf("not implemented: UserCreate - userCreate"))
+}
+func (r *mutationResolver) ResendEmailVerification(ctx context.Context, email string) (bool, error) {
+	panic(fmt.Errorf("not implemented: ResendEmailVerification - resendEmailVerification"))
+}
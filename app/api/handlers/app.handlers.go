@@ -3,6 +3,7 @@ package handlers
 import (
 	"gogql/app/services"
 	"gogql/app/store/filestore"
+	"gogql/utils/authtoken"
 )
 
 type Handlers struct {
@@ -10,9 +11,9 @@ type Handlers struct {
 	GraphQLHandler *GraphQLHandler
 }
 
-func NewHandlers(s *services.Services, fs *filestore.FileStore) *Handlers {
+func NewHandlers(s *services.Services, fs *filestore.FileStore, keys *authtoken.KeySet) *Handlers {
 	return &Handlers{
-		NewAuthHandler(s),
+		NewAuthHandler(s, keys),
 		NewGraphQLHandler(s, fs),
 	}
 }
@@ -2,28 +2,74 @@ package handlers
 
 import (
 	"context"
+	"fmt"
+	"gogql/app/middlewares"
 	"gogql/app/models"
+	"gogql/app/models/constants"
+	"gogql/app/models/dbmodels"
 	"gogql/app/services"
 	"gogql/utils/authtoken"
 	"gogql/utils/faulterr"
 	"net/http"
 	"time"
+
+	"github.com/go-chi/chi"
+	"github.com/volatiletech/null"
 )
 
 type AuthHandler struct {
 	services *services.Services
-	// jwt      *authentication.JWT
+	keys     *authtoken.KeySet
+}
+
+func NewAuthHandler(s *services.Services, keys *authtoken.KeySet) *AuthHandler {
+	return &AuthHandler{s, keys}
+}
+
+// IssueToken exchanges the request's session token for a stateless jwt, jwts can not be
+// exchanged for new ones so they never outlive the session that issued them by more than their ttl.
+// Impersonation sessions get no jwt, their activities are attributed to the super admin through the session.
+func (h *AuthHandler) IssueToken(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	token := middlewares.GetSessionToken(ctx)
+	if token == nil {
+		h.errorResponse(w, r, faulterr.NewBadRequestError("no session token provided"))
+		return
+	}
+	auther, err := h.services.AuthService.GetAutherByToken(ctx, *token)
+	if err != nil {
+		h.errorResponse(w, r, err)
+		return
+	}
+	if auther.ImpersonatorID.Valid {
+		h.errorResponse(w, r, faulterr.NewFrobiddenError("not allowed while impersonating a user").WithCode(constants.ErrCodeImpersonationForbidden))
+		return
+	}
+
+	cookie, err := h.GenerateToken(w, ctx, auther)
+	if err != nil {
+		h.errorResponse(w, r, err)
+		return
+	}
+
+	response := ResponseBody{
+		Data:       AuthData{CookieToken: cookie, Auther: auther, Token: cookie.Value},
+		Message:    "jwt issued",
+		StatusCode: http.StatusCreated,
+	}
+
+	RestResponse(w, r, response.StatusCode, response)
 }
 
-func NewAuthHandler(s *services.Services) *AuthHandler {
-	// jwt := authentication.NewJWT(jwt.SecretKey, 30)
-	// return &AuthHandler{s, jwt}
-	return &AuthHandler{s}
+// JWKS serves the public keys that verify jwts
+func (h *AuthHandler) JWKS(w http.ResponseWriter, r *http.Request) {
+	RestResponse(w, r, http.StatusOK, h.keys.JWKS())
 }
 
 // GenerateToken generates a jwt token and sets cookie
 func (h *AuthHandler) GenerateToken(w http.ResponseWriter, ctx context.Context, auther *models.Auther) (*http.Cookie, *faulterr.FaultErr) {
-	tokenPayload, err := authtoken.Generate(auther)
+	tokenPayload, err := h.keys.Generate(auther)
 	if err != nil {
 		return nil, err
 	}
@@ -40,6 +86,94 @@ func (h *AuthHandler) GenerateToken(w http.ResponseWriter, ctx context.Context,
 	return cookie, nil
 }
 
+// OIDCLogin redirects to the identity provider of the organization
+func (h *AuthHandler) OIDCLogin(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	// start db transaction
+	tx, err := h.services.DBTX.BeginTx(ctx)
+	if err != nil {
+		h.errorResponse(w, r, err)
+		return
+	}
+	defer h.services.DBTX.RollbackTx(ctx, tx)
+
+	authURL, err := h.services.AuthService.BeginOIDCLogin(ctx, tx, chi.URLParam(r, "orgCode"), middlewares.GetClientIP(ctx))
+	if err != nil {
+		h.errorResponse(w, r, err)
+		return
+	}
+
+	// commit db transaction
+	if err := h.services.DBTX.CommitTx(ctx, tx); err != nil {
+		h.errorResponse(w, r, err)
+		return
+	}
+
+	http.Redirect(w, r, authURL, http.StatusFound)
+}
+
+// OIDCCallback completes the sign in at the identity provider and returns the new session
+func (h *AuthHandler) OIDCCallback(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	query := r.URL.Query()
+
+	req := &models.OIDCCallbackRequest{
+		OrgCode:   chi.URLParam(r, "orgCode"),
+		State:     query.Get("state"),
+		Code:      query.Get("code"),
+		Error:     query.Get("error"),
+		IPAddress: middlewares.GetClientIP(ctx),
+		UserAgent: middlewares.GetUserAgent(ctx),
+	}
+	if req.Error == "" && (req.State == "" || req.Code == "") {
+		h.errorResponse(w, r, faulterr.NewBadRequestError("state and code are required"))
+		return
+	}
+
+	// start db transaction
+	tx, err := h.services.DBTX.BeginTx(ctx)
+	if err != nil {
+		h.errorResponse(w, r, err)
+		return
+	}
+	defer h.services.DBTX.RollbackTx(ctx, tx)
+
+	auther, err := h.services.AuthService.OIDCLogin(ctx, tx, req)
+	if err != nil {
+		h.errorResponse(w, r, err)
+		return
+	}
+
+	// record user activity
+	actReq := dbmodels.UserActivityRequest{
+		UserID:       auther.ID,
+		OrgUID:       auther.OrgUID,
+		Action:       fmt.Sprintf("%s_%s", constants.SSOObject, constants.LoginAction),
+		ObjectID:     null.Int64From(auther.ID),
+		ObjectType:   null.StringFrom(string(constants.AutherObject)),
+		SessionToken: auther.SessionToken,
+	}
+	if _, err := h.services.UserActivityService.Create(ctx, tx, actReq); err != nil {
+		h.errorResponse(w, r, err)
+		return
+	}
+
+	// commit db transaction
+	if err := h.services.DBTX.CommitTx(ctx, tx); err != nil {
+		h.errorResponse(w, r, err)
+		return
+	}
+
+	response := ResponseBody{
+		Data:       AuthData{Auther: auther, Token: auther.SessionToken.String(), RefreshToken: auther.RefreshToken},
+		Message:    "user login",
+		StatusCode: http.StatusCreated,
+	}
+
+	RestResponse(w, r, response.StatusCode, response)
+}
+
 // ListPermissions Handler
 func (h *AuthHandler) ListPermissions(w http.ResponseWriter, r *http.Request) {
 	result := models.ListPermissions()
@@ -53,7 +187,13 @@ func (h *AuthHandler) ListPermissions(w http.ResponseWriter, r *http.Request) {
 	RestResponse(w, r, response.StatusCode, response)
 }
 
+// Logout invalidates the session used by the request and clears the cookie
 func (h *AuthHandler) Logout(w http.ResponseWriter, r *http.Request) {
+	if err := h.invalidateSession(r.Context()); err != nil {
+		h.errorResponse(w, r, err)
+		return
+	}
+
 	http.SetCookie(w,
 		&http.Cookie{
 			Name:     "jwt",
@@ -70,3 +210,56 @@ func (h *AuthHandler) Logout(w http.ResponseWriter, r *http.Request) {
 
 	RestResponse(w, r, response.StatusCode, response)
 }
+
+// invalidateSession sets the request's auth session invalid and records the logout,
+// requests without a usable session have nothing to invalidate
+func (h *AuthHandler) invalidateSession(ctx context.Context) *faulterr.FaultErr {
+	// a jwt names the session that issued it
+	auther, err := middlewares.GetJWTAuther(ctx)
+	if auther == nil {
+		token := middlewares.GetSessionToken(ctx)
+		if token == nil {
+			return nil
+		}
+		auther, err = h.services.AuthService.GetPartialAutherByToken(ctx, *token)
+	}
+	if err != nil {
+		return nil
+	}
+
+	// start db transaction
+	tx, err := h.services.DBTX.BeginTx(ctx)
+	if err != nil {
+		return err
+	}
+	defer h.services.DBTX.RollbackTx(ctx, tx)
+
+	if err := h.services.AuthService.Logout(ctx, tx, auther); err != nil {
+		return err
+	}
+
+	// record user activity
+	actReq := dbmodels.UserActivityRequest{
+		UserID:       auther.ID,
+		OrgUID:       auther.OrgUID,
+		Action:       constants.LogoutAction,
+		ObjectID:     null.Int64From(auther.ID),
+		ObjectType:   null.StringFrom(string(constants.AutherObject)),
+		SessionToken: auther.SessionToken,
+	}
+	if _, err := h.services.UserActivityService.Create(ctx, tx, actReq); err != nil {
+		return err
+	}
+
+	// commit db transaction
+	return h.services.DBTX.CommitTx(ctx, tx)
+}
+
+func (h *AuthHandler) errorResponse(w http.ResponseWriter, r *http.Request, err *faulterr.FaultErr) {
+	response := ResponseBody{
+		Data:       nil,
+		Message:    err.Message,
+		StatusCode: err.Status,
+	}
+	RestResponse(w, r, response.StatusCode, response)
+}
@@ -1,14 +1,20 @@
 package handlers
 
 import (
+	"context"
+	"errors"
 	"gogql/app/api/graphql/generated/graph"
 	"gogql/app/api/resolvers"
 	"gogql/app/services"
 	"gogql/app/store/filestore"
+	"gogql/utils/faulterr"
+	"log"
 	"net/http"
 
+	"github.com/99designs/gqlgen/graphql"
 	"github.com/99designs/gqlgen/graphql/handler"
 	"github.com/99designs/gqlgen/graphql/playground"
+	"github.com/vektah/gqlparser/v2/gqlerror"
 )
 
 type GraphQLHandler struct {
@@ -34,6 +40,36 @@ func (h *GraphQLHandler) Playground() http.HandlerFunc {
 
 // Query Handler
 func (h *GraphQLHandler) Query() *handler.Server {
-	resolvers := resolvers.NewResolver(h.services, h.filestore)
-	return handler.NewDefaultServer(graph.NewExecutableSchema(graph.Config{Resolvers: resolvers}))
+	resolver := resolvers.NewResolver(h.services, h.filestore)
+	schema := graph.NewExecutableSchema(graph.Config{
+		Resolvers:  resolver,
+		Directives: resolver.Directives(),
+	})
+	// refuse to serve a schema with a query or mutation nobody declared access for
+	if err := resolvers.CheckAuthDirectives(schema.Schema()); err != nil {
+		log.Fatal(err)
+	}
+
+	srv := handler.NewDefaultServer(schema)
+	srv.SetErrorPresenter(errorPresenter)
+	return srv
+}
+
+// errorPresenter exposes fault error codes and their details in the graphql error extensions
+func errorPresenter(ctx context.Context, err error) *gqlerror.Error {
+	gqlErr := graphql.DefaultErrorPresenter(ctx, err)
+
+	var codedErr *faulterr.CodedError
+	if errors.As(err, &codedErr) {
+		if gqlErr.Extensions == nil {
+			gqlErr.Extensions = map[string]interface{}{}
+		}
+		if codedErr.Code != "" {
+			gqlErr.Extensions["code"] = codedErr.Code
+		}
+		for key, value := range codedErr.Details {
+			gqlErr.Extensions[key] = value
+		}
+	}
+	return gqlErr
 }
@@ -19,6 +19,8 @@ type AuthData struct {
 	CookieToken *http.Cookie   `json:"cookieToken"`
 	Auther      *models.Auther `json:"auther"`
 	Token       string         `json:"token"`
+
+	RefreshToken string `json:"refreshToken,omitempty"`
 }
 
 // RestResponse handles the http status and renders body in JSON
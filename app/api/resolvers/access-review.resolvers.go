@@ -0,0 +1,340 @@
+package resolvers
+
+import (
+	"context"
+	"fmt"
+	"gogql/app/api/dataloaders"
+	"gogql/app/api/graphql/generated/graph"
+	"gogql/app/middlewares"
+	"gogql/app/models"
+	"gogql/app/models/constants"
+	"gogql/app/models/dbmodels"
+	"gogql/utils/faulterr"
+
+	"github.com/volatiletech/null"
+)
+
+// AccessReviewCampaign returns graph.AccessReviewCampaignResolver implementation.
+func (r *Resolver) AccessReviewCampaign() graph.AccessReviewCampaignResolver {
+	return &accessReviewCampaignResolver{r}
+}
+
+// AccessReviewItem returns graph.AccessReviewItemResolver implementation.
+func (r *Resolver) AccessReviewItem() graph.AccessReviewItemResolver {
+	return &accessReviewItemResolver{r}
+}
+
+type accessReviewCampaignResolver struct{ *Resolver }
+type accessReviewItemResolver struct{ *Resolver }
+
+// Department is the resolver for the department field.
+func (r *accessReviewCampaignResolver) Department(ctx context.Context, obj *dbmodels.AccessReviewCampaign) (*dbmodels.Department, error) {
+	if obj.DepartmentID.Valid {
+		return dataloaders.DepartmentLoaderFromContext(ctx, obj.DepartmentID.Int64)
+	}
+	return nil, nil
+}
+
+// Reviewers is the resolver for the reviewers field.
+func (r *accessReviewCampaignResolver) Reviewers(ctx context.Context, obj *dbmodels.AccessReviewCampaign) ([]dbmodels.User, error) {
+	result := []dbmodels.User{}
+	for _, id := range obj.ReviewerIDs {
+		user, err := dataloaders.UserLoaderFromContext(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		if user != nil {
+			result = append(result, *user)
+		}
+	}
+	return result, nil
+}
+
+// StartedBy is the resolver for the startedBy field.
+func (r *accessReviewCampaignResolver) StartedBy(ctx context.Context, obj *dbmodels.AccessReviewCampaign) (*dbmodels.User, error) {
+	return dataloaders.UserLoaderFromContext(ctx, obj.StartedBy)
+}
+
+// ClosedBy is the resolver for the closedBy field.
+func (r *accessReviewCampaignResolver) ClosedBy(ctx context.Context, obj *dbmodels.AccessReviewCampaign) (*dbmodels.User, error) {
+	if obj.ClosedBy.Valid {
+		return dataloaders.UserLoaderFromContext(ctx, obj.ClosedBy.Int64)
+	}
+	return nil, nil
+}
+
+// Items is the resolver for the items field.
+func (r *accessReviewCampaignResolver) Items(ctx context.Context, obj *dbmodels.AccessReviewCampaign) ([]dbmodels.AccessReviewItem, error) {
+	reviewerID, err := r.accessReviewer(ctx)
+	if err != nil {
+		return nil, err.Error
+	}
+
+	result, err := r.services.AccessReviewService.ListItems(ctx, obj.ID, middlewares.GetOrgScope(ctx), reviewerID)
+	if err != nil {
+		return nil, err.Error
+	}
+	return result, nil
+}
+
+// User is the resolver for the user field.
+func (r *accessReviewItemResolver) User(ctx context.Context, obj *dbmodels.AccessReviewItem) (*dbmodels.User, error) {
+	return dataloaders.UserLoaderFromContext(ctx, obj.UserID)
+}
+
+// Role is the resolver for the role field.
+func (r *accessReviewItemResolver) Role(ctx context.Context, obj *dbmodels.AccessReviewItem) (*dbmodels.Role, error) {
+	return dataloaders.RoleLoaderFromContext(ctx, obj.RoleID)
+}
+
+// Reviewer is the resolver for the reviewer field.
+func (r *accessReviewItemResolver) Reviewer(ctx context.Context, obj *dbmodels.AccessReviewItem) (*dbmodels.User, error) {
+	return dataloaders.UserLoaderFromContext(ctx, obj.ReviewerID)
+}
+
+// ReassignRole is the resolver for the reassignRole field.
+func (r *accessReviewItemResolver) ReassignRole(ctx context.Context, obj *dbmodels.AccessReviewItem) (*dbmodels.Role, error) {
+	if obj.ReassignRoleID.Valid {
+		return dataloaders.RoleLoaderFromContext(ctx, obj.ReassignRoleID.Int64)
+	}
+	return nil, nil
+}
+
+///////////////
+//   Query   //
+///////////////
+
+// AccessReviewCampaigns is the resolver for the accessReviewCampaigns field.
+func (r *queryResolver) AccessReviewCampaigns(ctx context.Context, status *string) ([]dbmodels.AccessReviewCampaign, error) {
+	reviewerID, err := r.accessReviewer(ctx)
+	if err != nil {
+		return nil, err.Error
+	}
+
+	filter := ""
+	if status != nil {
+		filter = *status
+	}
+
+	result, err := r.services.AccessReviewService.ListCampaigns(ctx, middlewares.GetOrgScope(ctx), reviewerID, filter)
+	if err != nil {
+		return nil, err.Error
+	}
+	return result, nil
+}
+
+// AccessReviewCampaign is the resolver for the accessReviewCampaign field.
+func (r *queryResolver) AccessReviewCampaign(ctx context.Context, id int64) (*dbmodels.AccessReviewCampaign, error) {
+	reviewerID, err := r.accessReviewer(ctx)
+	if err != nil {
+		return nil, err.Error
+	}
+
+	obj, err := r.services.AccessReviewService.GetCampaign(ctx, id, middlewares.GetOrgScope(ctx), reviewerID)
+	if err != nil {
+		return nil, err.Error
+	}
+	return obj, nil
+}
+
+// AccessReviewExport is the resolver for the accessReviewExport field.
+func (r *queryResolver) AccessReviewExport(ctx context.Context, id int64) (string, error) {
+	if _, err := r.accessReviewManager(ctx); err != nil {
+		return "", err.Error
+	}
+
+	output, err := r.services.AccessReviewService.ExportCampaign(ctx, id, middlewares.GetOrgScope(ctx))
+	if err != nil {
+		return "", err.Error
+	}
+	return output, nil
+}
+
+///////////////
+// Mutations //
+///////////////
+
+// AccessReviewStart is the resolver for the accessReviewStart field.
+func (r *mutationResolver) AccessReviewStart(ctx context.Context, input graph.AccessReviewCampaignRequest) (*dbmodels.AccessReviewCampaign, error) {
+	auther, err := r.accessReviewManager(ctx)
+	if err != nil {
+		return nil, err.Error
+	}
+
+	orgUID, err := r.TargetOrgUID(ctx, input.OrgUID)
+	if err != nil {
+		return nil, err.Error
+	}
+	req := dbmodels.AccessReviewCampaignRequest{
+		OrgUID:      *orgUID,
+		Name:        input.Name,
+		ReviewerIDs: input.ReviewerIDs,
+	}
+	if input.DepartmentID != nil {
+		req.DepartmentID = *input.DepartmentID
+	}
+
+	// start db transaction
+	tx, err := r.services.DBTX.BeginTx(ctx)
+	if err != nil {
+		return nil, err.Error
+	}
+	defer r.services.DBTX.RollbackTx(ctx, tx)
+
+	obj, err := r.services.AccessReviewService.StartCampaign(ctx, tx, req, auther.ID)
+	if err != nil {
+		return nil, err.Error
+	}
+
+	// record user activity
+	actReq := dbmodels.UserActivityRequest{
+		UserID:       auther.ID,
+		OrgUID:       auther.OrgUID,
+		Action:       fmt.Sprintf("%s_%s", constants.AccessReviewObject, constants.CreateAction),
+		ObjectID:     null.Int64From(obj.ID),
+		ObjectType:   null.StringFrom(string(constants.AccessReviewObject)),
+		SessionToken: auther.SessionToken,
+	}
+	_, err = r.services.UserActivityService.Create(ctx, tx, actReq)
+	if err != nil {
+		return nil, err.Error
+	}
+
+	// commit db transaction
+	if err := r.services.DBTX.CommitTx(ctx, tx); err != nil {
+		return nil, err.Error
+	}
+	return obj, nil
+}
+
+// AccessReviewDecide is the resolver for the accessReviewDecide field.
+func (r *mutationResolver) AccessReviewDecide(ctx context.Context, itemID int64, input graph.AccessReviewDecisionRequest) (*dbmodels.AccessReviewItem, error) {
+	auther, err := r.GetAuther(ctx)
+	if err != nil {
+		return nil, err.Error
+	}
+	orgUID := middlewares.GetOrgScope(ctx)
+
+	req := dbmodels.AccessReviewDecisionRequest{
+		Decision: input.Decision,
+	}
+	if input.RevokeAction != nil {
+		req.RevokeAction = *input.RevokeAction
+	}
+	if input.ReassignRoleID != nil {
+		req.ReassignRoleID = *input.ReassignRoleID
+	}
+	if input.Note != nil {
+		req.Note = *input.Note
+	}
+
+	// start db transaction
+	tx, err := r.services.DBTX.BeginTx(ctx)
+	if err != nil {
+		return nil, err.Error
+	}
+	defer r.services.DBTX.RollbackTx(ctx, tx)
+
+	obj, err := r.services.AccessReviewService.DecideItem(ctx, tx, itemID, req, auther.ID, orgUID)
+	if err != nil {
+		return nil, err.Error
+	}
+
+	// record user activity
+	action := constants.CertifyAction
+	if obj.Decision == constants.StatusRevoked {
+		action = constants.RevokeAction
+	}
+	actReq := dbmodels.UserActivityRequest{
+		UserID:       auther.ID,
+		OrgUID:       auther.OrgUID,
+		Action:       fmt.Sprintf("%s_%s", constants.AccessReviewItemObject, action),
+		ObjectID:     null.Int64From(obj.ID),
+		ObjectType:   null.StringFrom(string(constants.AccessReviewItemObject)),
+		SessionToken: auther.SessionToken,
+	}
+	_, err = r.services.UserActivityService.Create(ctx, tx, actReq)
+	if err != nil {
+		return nil, err.Error
+	}
+
+	// commit db transaction
+	if err := r.services.DBTX.CommitTx(ctx, tx); err != nil {
+		return nil, err.Error
+	}
+	return obj, nil
+}
+
+// AccessReviewClose is the resolver for the accessReviewClose field.
+func (r *mutationResolver) AccessReviewClose(ctx context.Context, id int64) (*dbmodels.AccessReviewCampaign, error) {
+	auther, err := r.accessReviewManager(ctx)
+	if err != nil {
+		return nil, err.Error
+	}
+	orgUID := middlewares.GetOrgScope(ctx)
+
+	// start db transaction
+	tx, err := r.services.DBTX.BeginTx(ctx)
+	if err != nil {
+		return nil, err.Error
+	}
+	defer r.services.DBTX.RollbackTx(ctx, tx)
+
+	obj, err := r.services.AccessReviewService.CloseCampaign(ctx, tx, id, orgUID, auther.ID)
+	if err != nil {
+		return nil, err.Error
+	}
+
+	// record user activity
+	actReq := dbmodels.UserActivityRequest{
+		UserID:       auther.ID,
+		OrgUID:       auther.OrgUID,
+		Action:       fmt.Sprintf("%s_%s", constants.AccessReviewObject, constants.CloseAction),
+		ObjectID:     null.Int64From(obj.ID),
+		ObjectType:   null.StringFrom(string(constants.AccessReviewObject)),
+		SessionToken: auther.SessionToken,
+	}
+	_, err = r.services.UserActivityService.Create(ctx, tx, actReq)
+	if err != nil {
+		return nil, err.Error
+	}
+
+	// commit db transaction
+	if err := r.services.DBTX.CommitTx(ctx, tx); err != nil {
+		return nil, err.Error
+	}
+	return obj, nil
+}
+
+// accessReviewManager gets the auther when it is a super admin or holds a management role,
+// only they start, close and export campaigns
+func (r *Resolver) accessReviewManager(ctx context.Context) (*models.Auther, *faulterr.FaultErr) {
+	auther, err := r.GetAuther(ctx)
+	if err != nil {
+		return nil, err
+	}
+	isManager, err := r.services.AuthService.IsManager(ctx, auther)
+	if err != nil {
+		return nil, err
+	}
+	if !isManager {
+		return nil, faulterr.NewUnauthorizedError("only management roles run access reviews")
+	}
+	return auther, nil
+}
+
+// accessReviewer is the reviewer campaigns and items are narrowed to, managers see every reviewer
+func (r *Resolver) accessReviewer(ctx context.Context) (*int64, *faulterr.FaultErr) {
+	auther, err := r.GetAuther(ctx)
+	if err != nil {
+		return nil, err
+	}
+	isManager, err := r.services.AuthService.IsManager(ctx, auther)
+	if err != nil {
+		return nil, err
+	}
+	if isManager {
+		return nil, nil
+	}
+	return &auther.ID, nil
+}
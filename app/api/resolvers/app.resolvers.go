@@ -5,9 +5,13 @@ import (
 	"gogql/app/api/graphql/generated/graph"
 	"gogql/app/middlewares"
 	"gogql/app/models"
+	"gogql/app/models/constants"
+	"gogql/app/models/dbmodels"
 	"gogql/app/services"
 	"gogql/app/store/filestore"
 	"gogql/utils/faulterr"
+
+	"github.com/gofrs/uuid"
 )
 
 const (
@@ -34,11 +38,88 @@ type mutationResolver struct{ *Resolver }
 type queryResolver struct{ *Resolver }
 
 func (r *Resolver) GetAuther(ctx context.Context) (*models.Auther, *faulterr.FaultErr) {
+	// jwts are stateless and already verified by the middleware
+	if auther, err := middlewares.GetJWTAuther(ctx); auther != nil || err != nil {
+		return auther, err
+	}
+	// so are api keys
+	if auther, err := middlewares.GetAPIKeyAuther(ctx); auther != nil || err != nil {
+		return auther, err
+	}
+
+	token := middlewares.GetSessionToken(ctx)
+	if token == nil {
+		return nil, faulterr.NewBadRequestError("no auth credentials provided")
+	}
+	// sessions are looked up once per request
+	return middlewares.MemoizeAuther(ctx, func() (*models.Auther, *faulterr.FaultErr) {
+		return r.services.AuthService.GetAutherByToken(ctx, *token)
+	})
+}
+
+// GetSessionAuther only accepts users signed in with a session, it guards account
+// management like sessions, second factors and api keys against api keys
+func (r *Resolver) GetSessionAuther(ctx context.Context) (*models.Auther, *faulterr.FaultErr) {
+	auther, err := r.GetAuther(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if auther.APIKeyID.Valid {
+		return nil, faulterr.NewFrobiddenError("api keys cannot manage accounts")
+	}
+	return auther, nil
+}
+
+// GetSensitiveAuther is GetSessionAuther for mutations a super admin must not perform
+// while impersonating a user, like changing its sessions, second factors and api keys
+func (r *Resolver) GetSensitiveAuther(ctx context.Context) (*models.Auther, *faulterr.FaultErr) {
+	auther, err := r.GetSessionAuther(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := r.DenyImpersonation(auther); err != nil {
+		return nil, err
+	}
+	return auther, nil
+}
+
+// DenyImpersonation returns an error if the auther is a super admin impersonating a user
+func (r *Resolver) DenyImpersonation(auther *models.Auther) *faulterr.FaultErr {
+	if auther.ImpersonatorID.Valid {
+		return faulterr.NewFrobiddenError("not allowed while impersonating a user").WithCode(constants.ErrCodeImpersonationForbidden)
+	}
+	return nil
+}
+
+// GetPartialAuther also accepts sessions still waiting for their second factor,
+// it is only used by the mutations that complete the login
+func (r *Resolver) GetPartialAuther(ctx context.Context) (*models.Auther, *faulterr.FaultErr) {
+	if auther, err := middlewares.GetJWTAuther(ctx); auther != nil || err != nil {
+		return auther, err
+	}
+
 	token := middlewares.GetSessionToken(ctx)
 	if token == nil {
 		return nil, faulterr.NewBadRequestError("no auth credentials provided")
 	}
-	return r.services.AuthService.GetAutherByToken(ctx, *token)
+	return r.services.AuthService.GetPartialAutherByToken(ctx, *token)
+}
+
+// TargetOrgUID returns the organization a new object belongs to, super admins pick it with the
+// requested uid or their Organization header, members always get their own
+func (r *Resolver) TargetOrgUID(ctx context.Context, requested *uuid.NullUUID) (*uuid.UUID, *faulterr.FaultErr) {
+	var orgUID *uuid.UUID
+	if requested != nil && requested.Valid {
+		orgUID = &requested.UUID
+	}
+	orgUID, err := middlewares.NarrowOrgScope(ctx, orgUID)
+	if err != nil {
+		return nil, err
+	}
+	if orgUID == nil {
+		return nil, faulterr.NewBadRequestError("org uid is required")
+	}
+	return orgUID, nil
 }
 
 func (r *Resolver) GetAutherWithPermission(ctx context.Context, perm string) (*models.Auther, *faulterr.FaultErr) {
@@ -52,6 +133,26 @@ func (r *Resolver) GetAutherWithPermission(ctx context.Context, perm string) (*m
 	return auther, nil
 }
 
+// GetPermissionScope returns the scope the auther was granted a permission with, the list
+// queries only return the records inside it
+func (r *Resolver) GetPermissionScope(ctx context.Context, perm string) (models.PermissionScope, *faulterr.FaultErr) {
+	auther, err := r.GetAuther(ctx)
+	if err != nil {
+		return models.PermissionScope{}, err
+	}
+	return r.services.AuthService.GetPermissionScope(ctx, auther, perm)
+}
+
+// GetUserInScope gets a user inside the scope the auther was granted a permission with, users
+// outside of it are not found
+func (r *Resolver) GetUserInScope(ctx context.Context, perm string, id int64) (*dbmodels.User, *faulterr.FaultErr) {
+	scope, err := r.GetPermissionScope(ctx, perm)
+	if err != nil {
+		return nil, err
+	}
+	return r.services.UserService.GetByIDInScope(ctx, id, middlewares.GetOrgScope(ctx), scope)
+}
+
 func (r *Resolver) SearchFilter(search graph.SearchFilter) models.SearchFilter {
 	filter := models.SearchFilter{}
 
@@ -0,0 +1,46 @@
+package resolvers
+
+import (
+	"gogql/app/models"
+	"gogql/config"
+	"gogql/utils/authtoken"
+	"testing"
+	"time"
+
+	"github.com/volatiletech/null"
+)
+
+func TestImpersonationTokenIsDenied(t *testing.T) {
+	keys, err := authtoken.NewKeySet(&config.JWT{
+		SigningKeyID: "test",
+		Keys:         []config.JWTKey{{ID: "test", Algorithm: "HS256", Key: "secret"}},
+		TTL:          time.Minute,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	impersonated := &models.Auther{ID: 7, SessionID: 3, ImpersonatorID: null.Int64From(1)}
+	payload, ferr := keys.Generate(impersonated)
+	if ferr != nil {
+		t.Fatal(ferr.Error)
+	}
+	auther, ferr := keys.Decode(payload.TokenString)
+	if ferr != nil {
+		t.Fatal(ferr.Error)
+	}
+	if auther.ImpersonatorID != impersonated.ImpersonatorID {
+		t.Fatalf("got impersonator %v, want %v", auther.ImpersonatorID, impersonated.ImpersonatorID)
+	}
+
+	r := &Resolver{}
+	if err := r.DenyImpersonation(auther); err == nil {
+		t.Error("token of an impersonation session passed DenyImpersonation")
+	}
+
+	payload, _ = keys.Generate(&models.Auther{ID: 7, SessionID: 4})
+	auther, _ = keys.Decode(payload.TokenString)
+	if err := r.DenyImpersonation(auther); err != nil {
+		t.Errorf("token of a regular session was denied: %s", err.Message)
+	}
+}
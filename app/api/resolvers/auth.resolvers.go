@@ -5,15 +5,34 @@ package resolvers
 
 import (
 	"context"
+	"fmt"
 	"gogql/app/api/graphql/generated/graph"
+	"gogql/app/helpers"
+	"gogql/app/middlewares"
 	"gogql/app/models"
 	"gogql/app/models/constants"
 	"gogql/app/models/dbmodels"
 	"gogql/utils/faulterr"
 
+	"github.com/gofrs/uuid"
+	"github.com/jackc/pgx/v5"
 	"github.com/volatiletech/null"
 )
 
+// Auther returns graph.AutherResolver implementation.
+func (r *Resolver) Auther() graph.AutherResolver { return &autherResolver{r} }
+
+type autherResolver struct{ *Resolver }
+
+// EffectivePermissions is the resolver for the effectivePermissions field.
+func (r *autherResolver) EffectivePermissions(ctx context.Context, obj *models.Auther) ([]string, error) {
+	result, err := r.services.AuthService.EffectivePermissions(ctx, obj)
+	if err != nil {
+		return nil, err.Error
+	}
+	return result, nil
+}
+
 // Auther is the resolver for the auther field.
 func (r *queryResolver) Auther(ctx context.Context) (*models.Auther, error) {
 	auther, err := r.GetAuther(ctx)
@@ -23,8 +42,76 @@ func (r *queryResolver) Auther(ctx context.Context) (*models.Auther, error) {
 	return auther, nil
 }
 
+// MySessions is the resolver for the mySessions field.
+func (r *queryResolver) MySessions(ctx context.Context) ([]models.Session, error) {
+	auther, err := r.GetSessionAuther(ctx)
+	if err != nil {
+		return nil, err.Error
+	}
+
+	result, err := r.services.AuthService.ListSessions(ctx, auther, auther.ID)
+	if err != nil {
+		return nil, err.Error
+	}
+	return result, nil
+}
+
+// UserSessions is the resolver for the userSessions field.
+func (r *queryResolver) UserSessions(ctx context.Context, userID int64) ([]models.Session, error) {
+	auther, err := r.GetAuther(ctx)
+	if err != nil {
+		return nil, err.Error
+	}
+	orgUID := middlewares.GetOrgScope(ctx)
+
+	// make sure the user is visible to the auther
+	user, err := r.services.UserService.GetByID(ctx, userID, orgUID)
+	if err != nil {
+		return nil, err.Error
+	}
+
+	result, err := r.services.AuthService.ListSessions(ctx, auther, user.ID)
+	if err != nil {
+		return nil, err.Error
+	}
+	return result, nil
+}
+
+// Passkeys is the resolver for the passkeys field.
+func (r *queryResolver) Passkeys(ctx context.Context) ([]dbmodels.WebAuthnCredential, error) {
+	auther, err := r.GetSessionAuther(ctx)
+	if err != nil {
+		return nil, err.Error
+	}
+
+	result, err := r.services.AuthService.ListPasskeys(ctx, auther)
+	if err != nil {
+		return nil, err.Error
+	}
+	return result, nil
+}
+
+// APIKeys is the resolver for the apiKeys field.
+func (r *queryResolver) APIKeys(ctx context.Context, orgUID *uuid.UUID) ([]dbmodels.APIKey, error) {
+	auther, err := r.GetSessionAuther(ctx)
+	if err != nil {
+		return nil, err.Error
+	}
+
+	result, err := r.services.AuthService.ListAPIKeys(ctx, auther, orgUID)
+	if err != nil {
+		return nil, err.Error
+	}
+	return result, nil
+}
+
+// AuthCacheStats is the resolver for the authCacheStats field.
+func (r *queryResolver) AuthCacheStats(ctx context.Context) ([]models.CacheStats, error) {
+	return r.services.AuthService.CacheStats(), nil
+}
+
 // GenerateOtp is the resolver for the generateOtp field.
-func (r *mutationResolver) GenerateOtp(ctx context.Context, input *graph.OTPRequest) (*string, error) {
+func (r *mutationResolver) GenerateOtp(ctx context.Context, input *graph.OTPRequest) (*models.OTPAcknowledgement, error) {
 	req := &models.OTPRequest{}
 
 	if input.Email != nil && input.Email.String != "" {
@@ -36,6 +123,10 @@ func (r *mutationResolver) GenerateOtp(ctx context.Context, input *graph.OTPRequ
 	if !req.Email.Valid && !req.Phone.Valid {
 		return nil, faulterr.NewFrobiddenError("email or phone is required").Error
 	}
+	req.IPAddress = middlewares.GetClientIP(ctx)
+	if orgUID := middlewares.GetOrgHint(ctx); orgUID != nil {
+		req.OrgUID = helpers.NullUUIDFromUUID(*orgUID)
+	}
 
 	// start db transaction
 	tx, err := r.services.DBTX.BeginTx(ctx)
@@ -44,7 +135,7 @@ func (r *mutationResolver) GenerateOtp(ctx context.Context, input *graph.OTPRequ
 	}
 	defer r.services.DBTX.RollbackTx(ctx, tx)
 
-	otp, err := r.services.AuthService.GetOTP(ctx, tx, req)
+	ack, err := r.services.AuthService.GetOTP(ctx, tx, req)
 	if err != nil {
 		return nil, err.Error
 	}
@@ -54,7 +145,7 @@ func (r *mutationResolver) GenerateOtp(ctx context.Context, input *graph.OTPRequ
 		return nil, err.Error
 	}
 
-	return otp, nil
+	return ack, nil
 }
 
 // Login is the resolver for the login field.
@@ -76,6 +167,14 @@ func (r *mutationResolver) Login(ctx context.Context, input graph.LoginRequest)
 	} else {
 		return nil, faulterr.NewFrobiddenError("otp is required").Error
 	}
+	if input.DeviceLabel != nil && input.DeviceLabel.String != "" {
+		req.DeviceLabel = input.DeviceLabel.String
+	}
+	req.IPAddress = middlewares.GetClientIP(ctx)
+	if orgUID := middlewares.GetOrgHint(ctx); orgUID != nil {
+		req.OrgUID = helpers.NullUUIDFromUUID(*orgUID)
+	}
+	req.UserAgent = middlewares.GetUserAgent(ctx)
 
 	// start db transaction
 	tx, err := r.services.DBTX.BeginTx(ctx)
@@ -110,3 +209,657 @@ func (r *mutationResolver) Login(ctx context.Context, input graph.LoginRequest)
 
 	return auther, nil
 }
+
+// RefreshSession is the resolver for the refreshSession field.
+func (r *mutationResolver) RefreshSession(ctx context.Context, refreshToken string) (*models.Auther, error) {
+	if refreshToken == "" {
+		return nil, faulterr.NewFrobiddenError("refresh token is required").Error
+	}
+	req := &models.RefreshRequest{
+		RefreshToken: refreshToken,
+	}
+
+	// start db transaction
+	tx, err := r.services.DBTX.BeginTx(ctx)
+	if err != nil {
+		return nil, err.Error
+	}
+	defer r.services.DBTX.RollbackTx(ctx, tx)
+
+	auther, err := r.services.AuthService.RefreshSession(ctx, tx, req)
+	if err != nil {
+		return nil, err.Error
+	}
+
+	// commit db transaction
+	if err := r.services.DBTX.CommitTx(ctx, tx); err != nil {
+		return nil, err.Error
+	}
+
+	return auther, nil
+}
+
+// SessionRevoke is the resolver for the sessionRevoke field.
+func (r *mutationResolver) SessionRevoke(ctx context.Context, id int64) (*models.Session, error) {
+	auther, err := r.GetSensitiveAuther(ctx)
+	if err != nil {
+		return nil, err.Error
+	}
+
+	// start db transaction
+	tx, err := r.services.DBTX.BeginTx(ctx)
+	if err != nil {
+		return nil, err.Error
+	}
+	defer r.services.DBTX.RollbackTx(ctx, tx)
+
+	obj, err := r.services.AuthService.RevokeSession(ctx, tx, auther, id)
+	if err != nil {
+		return nil, err.Error
+	}
+
+	// record user activity
+	actReq := dbmodels.UserActivityRequest{
+		UserID:       auther.ID,
+		OrgUID:       auther.OrgUID,
+		Action:       fmt.Sprintf("%s_%s", constants.SessionObject, constants.RevokeAction),
+		ObjectID:     null.Int64From(obj.ID),
+		ObjectType:   null.StringFrom(string(constants.SessionObject)),
+		SessionToken: auther.SessionToken,
+	}
+	_, err = r.services.UserActivityService.Create(ctx, tx, actReq)
+	if err != nil {
+		return nil, err.Error
+	}
+
+	// commit db transaction
+	if err := r.services.DBTX.CommitTx(ctx, tx); err != nil {
+		return nil, err.Error
+	}
+
+	return obj, nil
+}
+
+// LogoutAllSessions is the resolver for the logoutAllSessions field.
+func (r *mutationResolver) LogoutAllSessions(ctx context.Context) (int, error) {
+	auther, err := r.GetSensitiveAuther(ctx)
+	if err != nil {
+		return 0, err.Error
+	}
+
+	// start db transaction
+	tx, err := r.services.DBTX.BeginTx(ctx)
+	if err != nil {
+		return 0, err.Error
+	}
+	defer r.services.DBTX.RollbackTx(ctx, tx)
+
+	count, err := r.services.AuthService.LogoutAllSessions(ctx, tx, auther)
+	if err != nil {
+		return 0, err.Error
+	}
+
+	// record user activity
+	actReq := dbmodels.UserActivityRequest{
+		UserID:       auther.ID,
+		OrgUID:       auther.OrgUID,
+		Action:       fmt.Sprintf("%s_%s", constants.AutherObject, constants.LogoutAction),
+		ObjectID:     null.Int64From(auther.ID),
+		ObjectType:   null.StringFrom(string(constants.AutherObject)),
+		SessionToken: auther.SessionToken,
+	}
+	_, err = r.services.UserActivityService.Create(ctx, tx, actReq)
+	if err != nil {
+		return 0, err.Error
+	}
+
+	// commit db transaction
+	if err := r.services.DBTX.CommitTx(ctx, tx); err != nil {
+		return 0, err.Error
+	}
+
+	return int(count), nil
+}
+
+// TotpEnroll is the resolver for the totpEnroll field.
+func (r *mutationResolver) TotpEnroll(ctx context.Context) (*models.TOTPEnrollment, error) {
+	auther, err := r.GetPartialAuther(ctx)
+	if err != nil {
+		return nil, err.Error
+	}
+	if err := r.DenyImpersonation(auther); err != nil {
+		return nil, err.Error
+	}
+
+	// start db transaction
+	tx, err := r.services.DBTX.BeginTx(ctx)
+	if err != nil {
+		return nil, err.Error
+	}
+	defer r.services.DBTX.RollbackTx(ctx, tx)
+
+	obj, err := r.services.AuthService.EnrollTOTP(ctx, tx, auther)
+	if err != nil {
+		return nil, err.Error
+	}
+
+	// commit db transaction
+	if err := r.services.DBTX.CommitTx(ctx, tx); err != nil {
+		return nil, err.Error
+	}
+
+	return obj, nil
+}
+
+// TotpConfirm is the resolver for the totpConfirm field.
+func (r *mutationResolver) TotpConfirm(ctx context.Context, code string) (*models.TOTPConfirmation, error) {
+	auther, err := r.GetPartialAuther(ctx)
+	if err != nil {
+		return nil, err.Error
+	}
+	if err := r.DenyImpersonation(auther); err != nil {
+		return nil, err.Error
+	}
+	req, err := r.secondFactorRequest(ctx, code)
+	if err != nil {
+		return nil, err.Error
+	}
+
+	// start db transaction
+	tx, err := r.services.DBTX.BeginTx(ctx)
+	if err != nil {
+		return nil, err.Error
+	}
+	defer r.services.DBTX.RollbackTx(ctx, tx)
+
+	obj, err := r.services.AuthService.ConfirmTOTP(ctx, tx, auther, req)
+	if err != nil {
+		return nil, err.Error
+	}
+
+	// record user activity
+	if err := r.recordTwoFactorActivity(ctx, tx, auther, constants.EnableAction); err != nil {
+		return nil, err.Error
+	}
+
+	// commit db transaction
+	if err := r.services.DBTX.CommitTx(ctx, tx); err != nil {
+		return nil, err.Error
+	}
+
+	return obj, nil
+}
+
+// VerifySecondFactor is the resolver for the verifySecondFactor field.
+func (r *mutationResolver) VerifySecondFactor(ctx context.Context, code string) (*models.Auther, error) {
+	auther, err := r.GetPartialAuther(ctx)
+	if err != nil {
+		return nil, err.Error
+	}
+	req, err := r.secondFactorRequest(ctx, code)
+	if err != nil {
+		return nil, err.Error
+	}
+
+	// start db transaction
+	tx, err := r.services.DBTX.BeginTx(ctx)
+	if err != nil {
+		return nil, err.Error
+	}
+	defer r.services.DBTX.RollbackTx(ctx, tx)
+
+	obj, err := r.services.AuthService.VerifySecondFactor(ctx, tx, auther, req)
+	if err != nil {
+		return nil, err.Error
+	}
+
+	// record user activity
+	if err := r.recordTwoFactorActivity(ctx, tx, obj, constants.VerifyAction); err != nil {
+		return nil, err.Error
+	}
+
+	// commit db transaction
+	if err := r.services.DBTX.CommitTx(ctx, tx); err != nil {
+		return nil, err.Error
+	}
+
+	return obj, nil
+}
+
+// TotpDisable is the resolver for the totpDisable field.
+func (r *mutationResolver) TotpDisable(ctx context.Context, code string) (bool, error) {
+	auther, err := r.GetSensitiveAuther(ctx)
+	if err != nil {
+		return false, err.Error
+	}
+	req, err := r.secondFactorRequest(ctx, code)
+	if err != nil {
+		return false, err.Error
+	}
+
+	// start db transaction
+	tx, err := r.services.DBTX.BeginTx(ctx)
+	if err != nil {
+		return false, err.Error
+	}
+	defer r.services.DBTX.RollbackTx(ctx, tx)
+
+	if err := r.services.AuthService.DisableTOTP(ctx, tx, auther, req); err != nil {
+		return false, err.Error
+	}
+
+	// record user activity
+	if err := r.recordTwoFactorActivity(ctx, tx, auther, constants.DisableAction); err != nil {
+		return false, err.Error
+	}
+
+	// commit db transaction
+	if err := r.services.DBTX.CommitTx(ctx, tx); err != nil {
+		return false, err.Error
+	}
+
+	return true, nil
+}
+
+// RecoveryCodesRegenerate is the resolver for the recoveryCodesRegenerate field.
+func (r *mutationResolver) RecoveryCodesRegenerate(ctx context.Context, code string) ([]string, error) {
+	auther, err := r.GetSensitiveAuther(ctx)
+	if err != nil {
+		return nil, err.Error
+	}
+	req, err := r.secondFactorRequest(ctx, code)
+	if err != nil {
+		return nil, err.Error
+	}
+
+	// start db transaction
+	tx, err := r.services.DBTX.BeginTx(ctx)
+	if err != nil {
+		return nil, err.Error
+	}
+	defer r.services.DBTX.RollbackTx(ctx, tx)
+
+	codes, err := r.services.AuthService.RegenerateRecoveryCodes(ctx, tx, auther, req)
+	if err != nil {
+		return nil, err.Error
+	}
+
+	// record user activity
+	if err := r.recordTwoFactorActivity(ctx, tx, auther, constants.UpdateAction); err != nil {
+		return nil, err.Error
+	}
+
+	// commit db transaction
+	if err := r.services.DBTX.CommitTx(ctx, tx); err != nil {
+		return nil, err.Error
+	}
+
+	return codes, nil
+}
+
+// PasskeyRegisterBegin is the resolver for the passkeyRegisterBegin field.
+func (r *mutationResolver) PasskeyRegisterBegin(ctx context.Context) (*models.PasskeyOptions, error) {
+	auther, err := r.GetSensitiveAuther(ctx)
+	if err != nil {
+		return nil, err.Error
+	}
+
+	// start db transaction
+	tx, err := r.services.DBTX.BeginTx(ctx)
+	if err != nil {
+		return nil, err.Error
+	}
+	defer r.services.DBTX.RollbackTx(ctx, tx)
+
+	obj, err := r.services.AuthService.BeginPasskeyRegistration(ctx, tx, auther, middlewares.GetClientIP(ctx))
+	if err != nil {
+		return nil, err.Error
+	}
+
+	// commit db transaction
+	if err := r.services.DBTX.CommitTx(ctx, tx); err != nil {
+		return nil, err.Error
+	}
+
+	return obj, nil
+}
+
+// PasskeyRegisterFinish is the resolver for the passkeyRegisterFinish field.
+func (r *mutationResolver) PasskeyRegisterFinish(ctx context.Context, input graph.PasskeyRegistration) (*dbmodels.WebAuthnCredential, error) {
+	auther, err := r.GetSensitiveAuther(ctx)
+	if err != nil {
+		return nil, err.Error
+	}
+
+	req := &models.PasskeyRegistration{
+		ClientDataJSON:    input.ClientDataJSON,
+		AttestationObject: input.AttestationObject,
+	}
+	if input.Name != nil && input.Name.Valid {
+		req.Name = input.Name.String
+	}
+
+	// start db transaction
+	tx, err := r.services.DBTX.BeginTx(ctx)
+	if err != nil {
+		return nil, err.Error
+	}
+	defer r.services.DBTX.RollbackTx(ctx, tx)
+
+	obj, err := r.services.AuthService.FinishPasskeyRegistration(ctx, tx, auther, req)
+	if err != nil {
+		return nil, err.Error
+	}
+
+	// record user activity
+	actReq := dbmodels.UserActivityRequest{
+		UserID:       auther.ID,
+		OrgUID:       auther.OrgUID,
+		Action:       fmt.Sprintf("%s_%s", constants.PasskeyObject, constants.CreateAction),
+		ObjectID:     null.Int64From(obj.ID),
+		ObjectType:   null.StringFrom(string(constants.PasskeyObject)),
+		SessionToken: auther.SessionToken,
+	}
+	_, err = r.services.UserActivityService.Create(ctx, tx, actReq)
+	if err != nil {
+		return nil, err.Error
+	}
+
+	// commit db transaction
+	if err := r.services.DBTX.CommitTx(ctx, tx); err != nil {
+		return nil, err.Error
+	}
+
+	return obj, nil
+}
+
+// PasskeyLoginBegin is the resolver for the passkeyLoginBegin field.
+func (r *mutationResolver) PasskeyLoginBegin(ctx context.Context, input *graph.PasskeyLoginRequest) (*models.PasskeyOptions, error) {
+	req := &models.PasskeyLoginRequest{}
+
+	if input != nil && input.Email != nil && input.Email.String != "" {
+		req.Email = *input.Email
+	}
+	if input != nil && input.Phone != nil && input.Phone.String != "" {
+		req.Phone = *input.Phone
+	}
+	req.IPAddress = middlewares.GetClientIP(ctx)
+	if orgUID := middlewares.GetOrgHint(ctx); orgUID != nil {
+		req.OrgUID = helpers.NullUUIDFromUUID(*orgUID)
+	}
+
+	// start db transaction
+	tx, err := r.services.DBTX.BeginTx(ctx)
+	if err != nil {
+		return nil, err.Error
+	}
+	defer r.services.DBTX.RollbackTx(ctx, tx)
+
+	obj, err := r.services.AuthService.BeginPasskeyLogin(ctx, tx, req)
+	if err != nil {
+		return nil, err.Error
+	}
+
+	// commit db transaction
+	if err := r.services.DBTX.CommitTx(ctx, tx); err != nil {
+		return nil, err.Error
+	}
+
+	return obj, nil
+}
+
+// PasskeyLogin is the resolver for the passkeyLogin field.
+func (r *mutationResolver) PasskeyLogin(ctx context.Context, input graph.PasskeyAssertion) (*models.Auther, error) {
+	req := &models.PasskeyAssertion{
+		CredentialID:      input.CredentialID,
+		ClientDataJSON:    input.ClientDataJSON,
+		AuthenticatorData: input.AuthenticatorData,
+		Signature:         input.Signature,
+	}
+
+	if input.UserHandle != nil && input.UserHandle.Valid {
+		req.UserHandle = input.UserHandle.String
+	}
+	if input.DeviceLabel != nil && input.DeviceLabel.String != "" {
+		req.DeviceLabel = input.DeviceLabel.String
+	}
+	req.IPAddress = middlewares.GetClientIP(ctx)
+	req.UserAgent = middlewares.GetUserAgent(ctx)
+
+	// start db transaction
+	tx, err := r.services.DBTX.BeginTx(ctx)
+	if err != nil {
+		return nil, err.Error
+	}
+	defer r.services.DBTX.RollbackTx(ctx, tx)
+
+	auther, err := r.services.AuthService.PasskeyLogin(ctx, tx, req)
+	if err != nil {
+		return nil, err.Error
+	}
+
+	// record user activity
+	actReq := dbmodels.UserActivityRequest{
+		UserID:       auther.ID,
+		OrgUID:       auther.OrgUID,
+		Action:       constants.LoginAction,
+		ObjectID:     null.Int64From(auther.ID),
+		ObjectType:   null.StringFrom(string(constants.AutherObject)),
+		SessionToken: auther.SessionToken,
+	}
+	_, err = r.services.UserActivityService.Create(ctx, tx, actReq)
+	if err != nil {
+		return nil, err.Error
+	}
+
+	// commit db transaction
+	if err := r.services.DBTX.CommitTx(ctx, tx); err != nil {
+		return nil, err.Error
+	}
+
+	return auther, nil
+}
+
+// PasskeyDelete is the resolver for the passkeyDelete field.
+func (r *mutationResolver) PasskeyDelete(ctx context.Context, id int64) (*dbmodels.WebAuthnCredential, error) {
+	auther, err := r.GetSensitiveAuther(ctx)
+	if err != nil {
+		return nil, err.Error
+	}
+
+	// start db transaction
+	tx, err := r.services.DBTX.BeginTx(ctx)
+	if err != nil {
+		return nil, err.Error
+	}
+	defer r.services.DBTX.RollbackTx(ctx, tx)
+
+	obj, err := r.services.AuthService.DeletePasskey(ctx, tx, auther, id)
+	if err != nil {
+		return nil, err.Error
+	}
+
+	// record user activity
+	actReq := dbmodels.UserActivityRequest{
+		UserID:       auther.ID,
+		OrgUID:       auther.OrgUID,
+		Action:       fmt.Sprintf("%s_%s", constants.PasskeyObject, constants.DeleteAction),
+		ObjectID:     null.Int64From(obj.ID),
+		ObjectType:   null.StringFrom(string(constants.PasskeyObject)),
+		SessionToken: auther.SessionToken,
+	}
+	_, err = r.services.UserActivityService.Create(ctx, tx, actReq)
+	if err != nil {
+		return nil, err.Error
+	}
+
+	// commit db transaction
+	if err := r.services.DBTX.CommitTx(ctx, tx); err != nil {
+		return nil, err.Error
+	}
+
+	return obj, nil
+}
+
+// APIKeyCreate is the resolver for the apiKeyCreate field.
+func (r *mutationResolver) APIKeyCreate(ctx context.Context, input graph.CreateAPIKey) (*models.APIKeyCreated, error) {
+	auther, err := r.GetSensitiveAuther(ctx)
+	if err != nil {
+		return nil, err.Error
+	}
+
+	req := dbmodels.APIKeyRequest{
+		Name:        input.Name,
+		Permissions: input.Permissions,
+		AllowedIPs:  input.AllowedIPs,
+	}
+	if input.OrgUID != nil {
+		req.OrgUID = helpers.NullUUIDFromUUID(*input.OrgUID)
+	}
+	if input.ExpiresAt != nil && input.ExpiresAt.Valid {
+		req.ExpiresAt = *input.ExpiresAt
+	}
+
+	// start db transaction
+	tx, err := r.services.DBTX.BeginTx(ctx)
+	if err != nil {
+		return nil, err.Error
+	}
+	defer r.services.DBTX.RollbackTx(ctx, tx)
+
+	result, err := r.services.AuthService.CreateAPIKey(ctx, tx, auther, req)
+	if err != nil {
+		return nil, err.Error
+	}
+
+	// record user activity
+	actReq := dbmodels.UserActivityRequest{
+		UserID:       auther.ID,
+		OrgUID:       auther.OrgUID,
+		Action:       fmt.Sprintf("%s_%s", constants.APIKeyObject, constants.CreateAction),
+		ObjectID:     null.Int64From(result.APIKey.ID),
+		ObjectType:   null.StringFrom(string(constants.APIKeyObject)),
+		SessionToken: auther.SessionToken,
+	}
+	_, err = r.services.UserActivityService.Create(ctx, tx, actReq)
+	if err != nil {
+		return nil, err.Error
+	}
+
+	// commit db transaction
+	if err := r.services.DBTX.CommitTx(ctx, tx); err != nil {
+		return nil, err.Error
+	}
+
+	return result, nil
+}
+
+// APIKeyRevoke is the resolver for the apiKeyRevoke field.
+func (r *mutationResolver) APIKeyRevoke(ctx context.Context, id int64) (*dbmodels.APIKey, error) {
+	auther, err := r.GetSensitiveAuther(ctx)
+	if err != nil {
+		return nil, err.Error
+	}
+
+	// start db transaction
+	tx, err := r.services.DBTX.BeginTx(ctx)
+	if err != nil {
+		return nil, err.Error
+	}
+	defer r.services.DBTX.RollbackTx(ctx, tx)
+
+	obj, err := r.services.AuthService.RevokeAPIKey(ctx, tx, auther, id)
+	if err != nil {
+		return nil, err.Error
+	}
+
+	// record user activity
+	actReq := dbmodels.UserActivityRequest{
+		UserID:       auther.ID,
+		OrgUID:       auther.OrgUID,
+		Action:       fmt.Sprintf("%s_%s", constants.APIKeyObject, constants.RevokeAction),
+		ObjectID:     null.Int64From(obj.ID),
+		ObjectType:   null.StringFrom(string(constants.APIKeyObject)),
+		SessionToken: auther.SessionToken,
+	}
+	_, err = r.services.UserActivityService.Create(ctx, tx, actReq)
+	if err != nil {
+		return nil, err.Error
+	}
+
+	// commit db transaction
+	if err := r.services.DBTX.CommitTx(ctx, tx); err != nil {
+		return nil, err.Error
+	}
+
+	return obj, nil
+}
+
+// ImpersonateUser is the resolver for the impersonateUser field.
+func (r *mutationResolver) ImpersonateUser(ctx context.Context, id int64) (*models.Auther, error) {
+	auther, err := r.GetSensitiveAuther(ctx)
+	if err != nil {
+		return nil, err.Error
+	}
+	req := &models.ImpersonationRequest{
+		UserID:    id,
+		IPAddress: middlewares.GetClientIP(ctx),
+		UserAgent: middlewares.GetUserAgent(ctx),
+	}
+
+	// start db transaction
+	tx, err := r.services.DBTX.BeginTx(ctx)
+	if err != nil {
+		return nil, err.Error
+	}
+	defer r.services.DBTX.RollbackTx(ctx, tx)
+
+	result, err := r.services.AuthService.ImpersonateUser(ctx, tx, auther, req)
+	if err != nil {
+		return nil, err.Error
+	}
+
+	// record user activity, in the organization of the impersonated user
+	actReq := dbmodels.UserActivityRequest{
+		UserID:       auther.ID,
+		OrgUID:       result.OrgUID,
+		Action:       fmt.Sprintf("%s_%s", constants.UserObject, constants.ImpersonateAction),
+		ObjectID:     null.Int64From(result.ID),
+		ObjectType:   null.StringFrom(string(constants.UserObject)),
+		SessionToken: auther.SessionToken,
+	}
+	_, err = r.services.UserActivityService.Create(ctx, tx, actReq)
+	if err != nil {
+		return nil, err.Error
+	}
+
+	// commit db transaction
+	if err := r.services.DBTX.CommitTx(ctx, tx); err != nil {
+		return nil, err.Error
+	}
+
+	return result, nil
+}
+
+func (r *mutationResolver) secondFactorRequest(ctx context.Context, code string) (*models.SecondFactorRequest, *faulterr.FaultErr) {
+	if code == "" {
+		return nil, faulterr.NewFrobiddenError("code is required")
+	}
+	return &models.SecondFactorRequest{
+		Code:      code,
+		IPAddress: middlewares.GetClientIP(ctx),
+	}, nil
+}
+
+func (r *mutationResolver) recordTwoFactorActivity(ctx context.Context, tx pgx.Tx, auther *models.Auther, action string) *faulterr.FaultErr {
+	actReq := dbmodels.UserActivityRequest{
+		UserID:       auther.ID,
+		OrgUID:       auther.OrgUID,
+		Action:       fmt.Sprintf("%s_%s", constants.TwoFactorObject, action),
+		ObjectID:     null.Int64From(auther.ID),
+		ObjectType:   null.StringFrom(string(constants.TwoFactorObject)),
+		SessionToken: auther.SessionToken,
+	}
+	_, err := r.services.UserActivityService.Create(ctx, tx, actReq)
+	return err
+}
@@ -30,21 +30,17 @@ func (r *departmentResolver) Organization(ctx context.Context, obj *dbmodels.Dep
 
 // Departments is the resolver for the departments field.
 func (r *queryResolver) Departments(ctx context.Context, search graph.SearchFilter) (*graph.DepartmentsResult, error) {
-	orgUID := middlewares.GetOrgUID(ctx)
-	if search.OrgUID != nil {
-		orgUID = search.OrgUID
-	}
-
-	auther, err := r.GetAutherWithPermission(ctx, models.ReadDepartment)
+	orgUID, err := middlewares.NarrowOrgScope(ctx, search.OrgUID)
 	if err != nil {
 		return nil, err.Error
 	}
-	if !auther.IsAdmin {
-		orgUID = &auther.OrgUID.UUID
+	scope, err := r.GetPermissionScope(ctx, models.ReadDepartment)
+	if err != nil {
+		return nil, err.Error
 	}
 
 	filter := r.SearchFilter(search)
-	output, total, err := r.services.DepartmentService.List(ctx, filter, orgUID)
+	output, total, err := r.services.DepartmentService.List(ctx, filter, orgUID, scope)
 	if err != nil {
 		return nil, err.Error
 	}
@@ -53,14 +49,7 @@ func (r *queryResolver) Departments(ctx context.Context, search graph.SearchFilt
 
 // Department is the resolver for the department field.
 func (r *queryResolver) Department(ctx context.Context, id *int64, code *string) (*dbmodels.Department, error) {
-	orgUID := middlewares.GetOrgUID(ctx)
-	auther, err := r.GetAutherWithPermission(ctx, models.ReadDepartment)
-	if err != nil {
-		return nil, err.Error
-	}
-	if !auther.IsAdmin {
-		orgUID = &auther.OrgUID.UUID
-	}
+	orgUID := middlewares.GetOrgScope(ctx)
 
 	if id != nil {
 		obj, err := r.services.DepartmentService.GetByID(ctx, *id, orgUID)
@@ -87,8 +76,7 @@ func (r *queryResolver) Department(ctx context.Context, id *int64, code *string)
 
 // DepartmentCreate is the resolver for the departmentCreate field.
 func (r *mutationResolver) DepartmentCreate(ctx context.Context, input graph.UpdateDepartment) (*dbmodels.Department, error) {
-	orgUID := middlewares.GetOrgUID(ctx)
-	auther, err := r.GetAutherWithPermission(ctx, models.CreateDepartment)
+	auther, err := r.GetAuther(ctx)
 	if err != nil {
 		return nil, err.Error
 	}
@@ -98,17 +86,11 @@ func (r *mutationResolver) DepartmentCreate(ctx context.Context, input graph.Upd
 		return nil, reqErr
 	}
 
-	if auther.IsAdmin {
-		if input.OrgUID != nil && input.OrgUID.Valid {
-			req.OrgUID = input.OrgUID.UUID
-		} else if orgUID != nil {
-			req.OrgUID = *orgUID
-		} else {
-			return nil, faulterr.NewBadRequestError("org uid is required").Error
-		}
-	} else {
-		req.OrgUID = auther.OrgUID.UUID
+	orgUID, err := r.TargetOrgUID(ctx, input.OrgUID)
+	if err != nil {
+		return nil, err.Error
 	}
+	req.OrgUID = *orgUID
 
 	// start db transaction
 	tx, err := r.services.DBTX.BeginTx(ctx)
@@ -146,14 +128,11 @@ func (r *mutationResolver) DepartmentCreate(ctx context.Context, input graph.Upd
 
 // DepartmentUpdate is the resolver for the departmentUpdate field.
 func (r *mutationResolver) DepartmentUpdate(ctx context.Context, id int64, input graph.UpdateDepartment) (*dbmodels.Department, error) {
-	orgUID := middlewares.GetOrgUID(ctx)
-	auther, err := r.GetAutherWithPermission(ctx, models.UpdateDepartment)
+	auther, err := r.GetAuther(ctx)
 	if err != nil {
 		return nil, err.Error
 	}
-	if !auther.IsAdmin {
-		orgUID = &auther.OrgUID.UUID
-	}
+	orgUID := middlewares.GetOrgScope(ctx)
 
 	req, reqErr := r.generateDepartmentRequest(input)
 	if reqErr != nil {
@@ -196,14 +175,11 @@ func (r *mutationResolver) DepartmentUpdate(ctx context.Context, id int64, input
 
 // DepartmentFinalize is the resolver for the departmentFinalize field.
 func (r *mutationResolver) DepartmentFinalize(ctx context.Context, id int64) (*dbmodels.Department, error) {
-	orgUID := middlewares.GetOrgUID(ctx)
-	auther, err := r.GetAutherWithPermission(ctx, models.UpdateDepartment)
+	auther, err := r.GetAuther(ctx)
 	if err != nil {
 		return nil, err.Error
 	}
-	if !auther.IsAdmin {
-		orgUID = &auther.OrgUID.UUID
-	}
+	orgUID := middlewares.GetOrgScope(ctx)
 
 	// start db transaction
 	tx, err := r.services.DBTX.BeginTx(ctx)
@@ -241,14 +217,11 @@ func (r *mutationResolver) DepartmentFinalize(ctx context.Context, id int64) (*d
 
 // DepartmentArchive is the resolver for the departmentArchive field.
 func (r *mutationResolver) DepartmentArchive(ctx context.Context, id int64) (*dbmodels.Department, error) {
-	orgUID := middlewares.GetOrgUID(ctx)
-	auther, err := r.GetAutherWithPermission(ctx, models.UpdateDepartment)
+	auther, err := r.GetAuther(ctx)
 	if err != nil {
 		return nil, err.Error
 	}
-	if !auther.IsAdmin {
-		orgUID = &auther.OrgUID.UUID
-	}
+	orgUID := middlewares.GetOrgScope(ctx)
 
 	// start db transaction
 	tx, err := r.services.DBTX.BeginTx(ctx)
@@ -286,14 +259,11 @@ func (r *mutationResolver) DepartmentArchive(ctx context.Context, id int64) (*db
 
 // DepartmentUnarchive is the resolver for the departmentUnarchive field.
 func (r *mutationResolver) DepartmentUnarchive(ctx context.Context, id int64) (*dbmodels.Department, error) {
-	orgUID := middlewares.GetOrgUID(ctx)
-	auther, err := r.GetAutherWithPermission(ctx, models.UpdateDepartment)
+	auther, err := r.GetAuther(ctx)
 	if err != nil {
 		return nil, err.Error
 	}
-	if !auther.IsAdmin {
-		orgUID = &auther.OrgUID.UUID
-	}
+	orgUID := middlewares.GetOrgScope(ctx)
 
 	// start db transaction
 	tx, err := r.services.DBTX.BeginTx(ctx)
@@ -0,0 +1,104 @@
+package resolvers
+
+import (
+	"context"
+	"fmt"
+	"gogql/app/api/graphql/generated/graph"
+	"gogql/app/models"
+	"gogql/utils/faulterr"
+	"strings"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+// authDirectives are the directives declaring who may call a query or mutation
+var authDirectives = []string{"public", "authenticated", "hasPermission", "superAdmin"}
+
+// Directives returns the graph.DirectiveRoot implementation of the auth directives.
+func (r *Resolver) Directives() graph.DirectiveRoot {
+	return graph.DirectiveRoot{
+		Public:        r.public,
+		Authenticated: r.authenticated,
+		HasPermission: r.hasPermission,
+		SuperAdmin:    r.superAdmin,
+	}
+}
+
+// public fields check their own credentials, like a password or a token sent by email
+func (r *Resolver) public(ctx context.Context, obj interface{}, next graphql.Resolver) (interface{}, error) {
+	return next(ctx)
+}
+
+// authenticated fields need a signed in user, partial also accepts sessions still
+// waiting for their second factor
+func (r *Resolver) authenticated(ctx context.Context, obj interface{}, next graphql.Resolver, partial *bool) (interface{}, error) {
+	if partial != nil && *partial {
+		if _, err := r.GetPartialAuther(ctx); err != nil {
+			return nil, err.Error
+		}
+		return next(ctx)
+	}
+
+	if _, err := r.GetAuther(ctx); err != nil {
+		return nil, err.Error
+	}
+	return next(ctx)
+}
+
+// hasPermission fields need a user whose role grants the permission
+func (r *Resolver) hasPermission(ctx context.Context, obj interface{}, next graphql.Resolver, perm graph.PermissionName) (interface{}, error) {
+	if _, err := r.GetAutherWithPermission(ctx, string(perm)); err != nil {
+		return nil, err.Error
+	}
+	return next(ctx)
+}
+
+// superAdmin fields are only for super admins
+func (r *Resolver) superAdmin(ctx context.Context, obj interface{}, next graphql.Resolver) (interface{}, error) {
+	auther, err := r.GetAuther(ctx)
+	if err != nil {
+		return nil, err.Error
+	}
+	if !auther.IsAdmin {
+		return nil, faulterr.NewUnauthorizedError(unauthorizedErr).Error
+	}
+	return next(ctx)
+}
+
+// CheckAuthDirectives returns an error if a query or mutation does not declare exactly one
+// auth directive or the PermissionName enum holds a permission missing from the registry
+func CheckAuthDirectives(schema *ast.Schema) error {
+	missing := []string{}
+	for _, def := range []*ast.Definition{schema.Query, schema.Mutation} {
+		if def == nil {
+			continue
+		}
+		for _, field := range def.Fields {
+			if strings.HasPrefix(field.Name, "__") {
+				continue
+			}
+			count := 0
+			for _, name := range authDirectives {
+				if field.Directives.ForName(name) != nil {
+					count++
+				}
+			}
+			if count != 1 {
+				missing = append(missing, fmt.Sprintf("%s.%s", def.Name, field.Name))
+			}
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("fields without exactly one auth directive: %s", strings.Join(missing, ", "))
+	}
+
+	if enum := schema.Types["PermissionName"]; enum != nil {
+		for _, value := range enum.EnumValues {
+			if !models.IsPermission(value.Name) {
+				return fmt.Errorf("unknown permission %s in PermissionName", value.Name)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,41 @@
+package resolvers
+
+import (
+	"gogql/app/api/graphql/generated/graph"
+	"strings"
+	"testing"
+
+	"github.com/vektah/gqlparser/v2"
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+func TestSchemaDeclaresAuthDirectives(t *testing.T) {
+	schema := graph.NewExecutableSchema(graph.Config{}).Schema()
+	if err := CheckAuthDirectives(schema); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCheckAuthDirectivesRejectsUndeclaredFields(t *testing.T) {
+	schema := gqlparser.MustLoadSchema(&ast.Source{Input: `
+		directive @public on FIELD_DEFINITION
+		directive @superAdmin on FIELD_DEFINITION
+
+		type Query {
+			open: Int @public
+			bare: Int
+			both: Int @public @superAdmin
+		}
+	`})
+
+	err := CheckAuthDirectives(schema)
+	if err == nil {
+		t.Fatal("expected an error for fields without exactly one auth directive")
+	}
+	if !strings.Contains(err.Error(), "Query.bare") || !strings.Contains(err.Error(), "Query.both") {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if strings.Contains(err.Error(), "Query.open") {
+		t.Fatalf("declared field reported: %v", err)
+	}
+}
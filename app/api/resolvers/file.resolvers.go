@@ -16,9 +16,6 @@ func (r *mutationResolver) FileUpload(ctx context.Context, file graphql.Upload)
 	if err != nil {
 		return nil, err.Error
 	}
-	if err := r.services.AuthService.GrantPermission(ctx, auther, models.UploadFile); err != nil {
-		return nil, err.Error
-	}
 
 	obj, uploadErr := r.Upload(ctx, file, auther)
 	if uploadErr != nil {
@@ -32,9 +29,6 @@ func (r *mutationResolver) FileUploadMultiple(ctx context.Context, files []graph
 	if err != nil {
 		return nil, err.Error
 	}
-	if err := r.services.AuthService.GrantPermission(ctx, auther, models.UploadFile); err != nil {
-		return nil, err.Error
-	}
 
 	objects := []dbmodels.File{}
 	for _, file := range files {
@@ -0,0 +1,242 @@
+package resolvers
+
+import (
+	"context"
+	"fmt"
+	"gogql/app/api/dataloaders"
+	"gogql/app/api/graphql/generated/graph"
+	"gogql/app/helpers"
+	"gogql/app/middlewares"
+	"gogql/app/models"
+	"gogql/app/models/constants"
+	"gogql/app/models/dbmodels"
+	"gogql/utils/faulterr"
+
+	"github.com/gofrs/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/volatiletech/null"
+)
+
+type invitationResolver struct{ *Resolver }
+
+// Invitation returns graph.InvitationResolver implementation.
+func (r *Resolver) Invitation() graph.InvitationResolver { return &invitationResolver{r} }
+
+// User is the resolver for the user field.
+func (r *invitationResolver) User(ctx context.Context, obj *dbmodels.Invitation) (*dbmodels.User, error) {
+	return dataloaders.UserLoaderFromContext(ctx, obj.UserID)
+}
+
+// Organization is the resolver for the organization field.
+func (r *invitationResolver) Organization(ctx context.Context, obj *dbmodels.Invitation) (*dbmodels.Organization, error) {
+	return dataloaders.OrganizationLoaderFromContext(ctx, obj.OrgUID.String())
+}
+
+// Inviter is the resolver for the inviter field.
+func (r *invitationResolver) Inviter(ctx context.Context, obj *dbmodels.Invitation) (*dbmodels.User, error) {
+	return dataloaders.UserLoaderFromContext(ctx, obj.InvitedBy)
+}
+
+///////////////
+//   Query   //
+///////////////
+
+// Invitations is the resolver for the invitations field.
+func (r *queryResolver) Invitations(ctx context.Context, search graph.SearchFilter, status *string) (*graph.InvitationsResult, error) {
+	orgUID, err := middlewares.NarrowOrgScope(ctx, search.OrgUID)
+	if err != nil {
+		return nil, err.Error
+	}
+	scope, err := r.GetPermissionScope(ctx, models.ReadUser)
+	if err != nil {
+		return nil, err.Error
+	}
+
+	filter := r.SearchFilter(search)
+	output, total, err := r.services.InvitationService.List(ctx, filter, orgUID, status, scope)
+	if err != nil {
+		return nil, err.Error
+	}
+	return &graph.InvitationsResult{Invitations: output, Total: total}, nil
+}
+
+// Invitation is the resolver for the invitation field.
+func (r *queryResolver) Invitation(ctx context.Context, id int64) (*dbmodels.Invitation, error) {
+	orgUID := middlewares.GetOrgScope(ctx)
+
+	obj, err := r.services.InvitationService.GetByID(ctx, id, orgUID)
+	if err != nil {
+		return nil, err.Error
+	}
+	return obj, nil
+}
+
+//////////////////
+//   Mutation   //
+//////////////////
+
+// UserInvite is the resolver for the userInvite field.
+func (r *mutationResolver) UserInvite(ctx context.Context, input graph.UpdateUser) (*dbmodels.Invitation, error) {
+	auther, err := r.GetAuther(ctx)
+	if err != nil {
+		return nil, err.Error
+	}
+
+	req, err := r.generateUserRequest(input)
+	if err != nil {
+		return nil, err.Error
+	}
+	orgUID, err := r.TargetOrgUID(ctx, input.OrgUID)
+	if err != nil {
+		return nil, err.Error
+	}
+	req.OrgUID = helpers.NullUUIDFromUUID(*orgUID)
+	if input.RoleID != nil && input.RoleID.Int64 > 0 {
+		req.RoleID = *input.RoleID
+	} else {
+		return nil, faulterr.NewFrobiddenError("role id is required").Error
+	}
+
+	// start db transaction
+	tx, err := r.services.DBTX.BeginTx(ctx)
+	if err != nil {
+		return nil, err.Error
+	}
+	defer r.services.DBTX.RollbackTx(ctx, tx)
+
+	obj, err := r.services.InvitationService.Invite(ctx, tx, auther, *req)
+	if err != nil {
+		return nil, err.Error
+	}
+
+	// record user activity
+	if err := r.recordInvitationActivity(ctx, tx, auther, obj, constants.CreateAction); err != nil {
+		return nil, err.Error
+	}
+
+	// commit db transaction
+	if err := r.services.DBTX.CommitTx(ctx, tx); err != nil {
+		return nil, err.Error
+	}
+
+	return obj, nil
+}
+
+// InvitationResend is the resolver for the invitationResend field.
+func (r *mutationResolver) InvitationResend(ctx context.Context, id int64) (*dbmodels.Invitation, error) {
+	auther, err := r.GetAuther(ctx)
+	if err != nil {
+		return nil, err.Error
+	}
+	orgUID := middlewares.GetOrgScope(ctx)
+
+	// start db transaction
+	tx, err := r.services.DBTX.BeginTx(ctx)
+	if err != nil {
+		return nil, err.Error
+	}
+	defer r.services.DBTX.RollbackTx(ctx, tx)
+
+	obj, err := r.services.InvitationService.Resend(ctx, tx, id, orgUID)
+	if err != nil {
+		return nil, err.Error
+	}
+
+	// record user activity
+	if err := r.recordInvitationActivity(ctx, tx, auther, obj, constants.UpdateAction); err != nil {
+		return nil, err.Error
+	}
+
+	// commit db transaction
+	if err := r.services.DBTX.CommitTx(ctx, tx); err != nil {
+		return nil, err.Error
+	}
+
+	return obj, nil
+}
+
+// InvitationRevoke is the resolver for the invitationRevoke field.
+func (r *mutationResolver) InvitationRevoke(ctx context.Context, id int64) (*dbmodels.Invitation, error) {
+	auther, err := r.GetAuther(ctx)
+	if err != nil {
+		return nil, err.Error
+	}
+	orgUID := middlewares.GetOrgScope(ctx)
+
+	// start db transaction
+	tx, err := r.services.DBTX.BeginTx(ctx)
+	if err != nil {
+		return nil, err.Error
+	}
+	defer r.services.DBTX.RollbackTx(ctx, tx)
+
+	obj, err := r.services.InvitationService.Revoke(ctx, tx, id, orgUID)
+	if err != nil {
+		return nil, err.Error
+	}
+
+	// record user activity
+	if err := r.recordInvitationActivity(ctx, tx, auther, obj, constants.RevokeAction); err != nil {
+		return nil, err.Error
+	}
+
+	// commit db transaction
+	if err := r.services.DBTX.CommitTx(ctx, tx); err != nil {
+		return nil, err.Error
+	}
+
+	return obj, nil
+}
+
+// InvitationAccept is the resolver for the invitationAccept field.
+func (r *mutationResolver) InvitationAccept(ctx context.Context, token string) (*dbmodels.User, error) {
+	if token == "" {
+		return nil, faulterr.NewFrobiddenError("token is required").Error
+	}
+
+	// start db transaction
+	tx, err := r.services.DBTX.BeginTx(ctx)
+	if err != nil {
+		return nil, err.Error
+	}
+	defer r.services.DBTX.RollbackTx(ctx, tx)
+
+	obj, err := r.services.InvitationService.Accept(ctx, tx, token)
+	if err != nil {
+		return nil, err.Error
+	}
+
+	// record user activity, the user has no session yet
+	actReq := dbmodels.UserActivityRequest{
+		UserID:       obj.ID,
+		OrgUID:       obj.OrgUID,
+		Action:       fmt.Sprintf("%s_%s", constants.InvitationObject, constants.AcceptAction),
+		ObjectID:     null.Int64From(obj.ID),
+		ObjectType:   null.StringFrom(string(constants.UserObject)),
+		SessionToken: uuid.Nil,
+	}
+	_, err = r.services.UserActivityService.Create(ctx, tx, actReq)
+	if err != nil {
+		return nil, err.Error
+	}
+
+	// commit db transaction
+	if err := r.services.DBTX.CommitTx(ctx, tx); err != nil {
+		return nil, err.Error
+	}
+
+	return obj, nil
+}
+
+func (r *mutationResolver) recordInvitationActivity(ctx context.Context, tx pgx.Tx, auther *models.Auther, obj *dbmodels.Invitation, action string) *faulterr.FaultErr {
+	actReq := dbmodels.UserActivityRequest{
+		UserID:       auther.ID,
+		OrgUID:       auther.OrgUID,
+		Action:       fmt.Sprintf("%s_%s", constants.InvitationObject, action),
+		ObjectID:     null.Int64From(obj.ID),
+		ObjectType:   null.StringFrom(string(constants.InvitationObject)),
+		SessionToken: auther.SessionToken,
+	}
+	_, err := r.services.UserActivityService.Create(ctx, tx, actReq)
+	return err
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"gogql/app/api/graphql/generated/graph"
+	"gogql/app/middlewares"
 	"gogql/app/models"
 	"gogql/app/models/constants"
 	"gogql/app/models/dbmodels"
@@ -18,14 +19,6 @@ import (
 ///////////////
 
 func (r *queryResolver) Organizations(ctx context.Context, search graph.SearchFilter, sector *string) (*graph.OrganizationsResult, error) {
-	auther, err := r.GetAuther(ctx)
-	if err != nil {
-		return nil, err.Error
-	}
-	if !auther.IsAdmin {
-		return nil, faulterr.NewUnauthorizedError(unauthorizedErr).Error
-	}
-
 	filter := r.SearchFilter(search)
 	output, total, err := r.services.OrganizationService.List(ctx, filter, sector)
 	if err != nil {
@@ -35,15 +28,7 @@ func (r *queryResolver) Organizations(ctx context.Context, search graph.SearchFi
 }
 
 func (r *queryResolver) Organization(ctx context.Context, uid *uuid.UUID, code *string) (*dbmodels.Organization, error) {
-	auther, err := r.GetAutherWithPermission(ctx, models.ReadOrganization)
-	if err != nil {
-		return nil, err.Error
-	}
-
-	var orgUID *uuid.UUID
-	if !auther.IsAdmin {
-		orgUID = &auther.OrgUID.UUID
-	}
+	orgUID := middlewares.GetOrgScope(ctx)
 
 	if uid != nil {
 		result, err := r.services.OrganizationService.GetByUID(ctx, *uid, orgUID)
@@ -64,6 +49,17 @@ func (r *queryResolver) Organization(ctx context.Context, uid *uuid.UUID, code *
 	return nil, faulterr.NewFrobiddenError(noQueryParamsErr).Error
 }
 
+// OrganizationOIDCConfig is the resolver for the organizationOIDCConfig field.
+func (r *queryResolver) OrganizationOIDCConfig(ctx context.Context, uid uuid.UUID) (*models.OIDCConfig, error) {
+	orgUID := middlewares.GetOrgScope(ctx)
+
+	result, err := r.services.OrganizationService.GetOIDCConfig(ctx, uid, orgUID)
+	if err != nil {
+		return nil, err.Error
+	}
+	return result, nil
+}
+
 ///////////////
 // Mutations //
 ///////////////
@@ -110,6 +106,9 @@ func (r *mutationResolver) OrganizationRegister(ctx context.Context, input graph
 	} else {
 		return nil, faulterr.NewFrobiddenError("phone is required").Error
 	}
+	if input.PhoneRegion != nil && input.PhoneRegion.Valid && input.PhoneRegion.String != "" {
+		req.PhoneRegion = *input.PhoneRegion
+	}
 
 	// start db transaction
 	tx, err := r.services.DBTX.BeginTx(ctx)
@@ -132,15 +131,12 @@ func (r *mutationResolver) OrganizationRegister(ctx context.Context, input graph
 }
 
 func (r *mutationResolver) OrganizationUpdate(ctx context.Context, uid uuid.UUID, input graph.UpdateOrganization) (*dbmodels.Organization, error) {
-	auther, err := r.GetAutherWithPermission(ctx, models.UpdateOrganization)
+	auther, err := r.GetAuther(ctx)
 	if err != nil {
 		return nil, err.Error
 	}
 
-	var orgUID *uuid.UUID
-	if !auther.IsAdmin {
-		orgUID = &auther.OrgUID.UUID
-	}
+	orgUID := middlewares.GetOrgScope(ctx)
 
 	req := dbmodels.OrganizationRequest{}
 
@@ -162,6 +158,12 @@ func (r *mutationResolver) OrganizationUpdate(ctx context.Context, uid uuid.UUID
 		req.Logo.Name = input.Logo.Name
 		req.Logo.URL = input.Logo.URL
 	}
+	if input.RequireManagement2fa != nil && input.RequireManagement2fa.Valid {
+		req.RequireManagement2FA = *input.RequireManagement2fa
+	}
+	if input.PhoneRegion != nil && input.PhoneRegion.Valid && input.PhoneRegion.String != "" {
+		req.PhoneRegion = *input.PhoneRegion
+	}
 
 	// start db transaction
 	tx, err := r.services.DBTX.BeginTx(ctx)
@@ -203,9 +205,6 @@ func (r *mutationResolver) OrganizationArchive(ctx context.Context, uid uuid.UUI
 	if err != nil {
 		return nil, err.Error
 	}
-	if !auther.IsAdmin {
-		return nil, faulterr.NewUnauthorizedError(unauthorizedErr).Error
-	}
 
 	// start db transaction
 	tx, err := r.services.DBTX.BeginTx(ctx)
@@ -247,9 +246,6 @@ func (r *mutationResolver) OrganizationUnarchive(ctx context.Context, uid uuid.U
 	if err != nil {
 		return nil, err.Error
 	}
-	if !auther.IsAdmin {
-		return nil, faulterr.NewFrobiddenError(noQueryParamsErr).Error
-	}
 
 	// start db transaction
 	tx, err := r.services.DBTX.BeginTx(ctx)
@@ -284,3 +280,60 @@ func (r *mutationResolver) OrganizationUnarchive(ctx context.Context, uid uuid.U
 
 	return obj, nil
 }
+
+// OrganizationOIDCUpdate is the resolver for the organizationOIDCUpdate field.
+func (r *mutationResolver) OrganizationOIDCUpdate(ctx context.Context, uid uuid.UUID, input graph.UpdateOrganizationOidc) (*models.OIDCConfig, error) {
+	auther, err := r.GetAuther(ctx)
+	if err != nil {
+		return nil, err.Error
+	}
+
+	orgUID := middlewares.GetOrgScope(ctx)
+
+	req := dbmodels.OIDCConfigRequest{
+		Issuer:          input.Issuer,
+		ClientID:        input.ClientID,
+		AllowedDomains:  input.AllowedDomains,
+		IsEnabled:       input.IsEnabled,
+		JITProvisioning: input.JitProvisioning,
+	}
+	if input.ClientSecret != nil && input.ClientSecret.Valid {
+		req.ClientSecret = *input.ClientSecret
+	}
+	if input.DefaultRoleID != nil && input.DefaultRoleID.Valid {
+		req.DefaultRoleID = *input.DefaultRoleID
+	}
+
+	// start db transaction
+	tx, err := r.services.DBTX.BeginTx(ctx)
+	if err != nil {
+		return nil, err.Error
+	}
+	defer r.services.DBTX.RollbackTx(ctx, tx)
+
+	obj, err := r.services.OrganizationService.UpdateOIDCConfig(ctx, tx, uid, req, orgUID)
+	if err != nil {
+		return nil, err.Error
+	}
+
+	// record user activity
+	actReq := dbmodels.UserActivityRequest{
+		UserID:       auther.ID,
+		OrgUID:       auther.OrgUID,
+		Action:       fmt.Sprintf("%s_%s", constants.SSOObject, constants.UpdateAction),
+		ObjectID:     null.Int64From(obj.ID),
+		ObjectType:   null.StringFrom(string(constants.SSOObject)),
+		SessionToken: auther.SessionToken,
+	}
+	_, err = r.services.UserActivityService.Create(ctx, tx, actReq)
+	if err != nil {
+		return nil, err.Error
+	}
+
+	// commit db transaction
+	if err := r.services.DBTX.CommitTx(ctx, tx); err != nil {
+		return nil, err.Error
+	}
+
+	return obj, nil
+}
@@ -0,0 +1,244 @@
+package resolvers
+
+import (
+	"context"
+	"fmt"
+	"gogql/app/api/dataloaders"
+	"gogql/app/api/graphql/generated/graph"
+	"gogql/app/middlewares"
+	"gogql/app/models/constants"
+	"gogql/app/models/dbmodels"
+
+	"github.com/volatiletech/null"
+)
+
+// PermissionElevation returns graph.PermissionElevationResolver implementation.
+func (r *Resolver) PermissionElevation() graph.PermissionElevationResolver {
+	return &permissionElevationResolver{r}
+}
+
+type permissionElevationResolver struct{ *Resolver }
+
+// User is the resolver for the user field.
+func (r *permissionElevationResolver) User(ctx context.Context, obj *dbmodels.PermissionElevation) (*dbmodels.User, error) {
+	return dataloaders.UserLoaderFromContext(ctx, obj.UserID)
+}
+
+// RequestedBy is the resolver for the requestedBy field.
+func (r *permissionElevationResolver) RequestedBy(ctx context.Context, obj *dbmodels.PermissionElevation) (*dbmodels.User, error) {
+	return dataloaders.UserLoaderFromContext(ctx, obj.RequestedBy)
+}
+
+// DecidedBy is the resolver for the decidedBy field.
+func (r *permissionElevationResolver) DecidedBy(ctx context.Context, obj *dbmodels.PermissionElevation) (*dbmodels.User, error) {
+	if obj.DecidedBy.Valid {
+		return dataloaders.UserLoaderFromContext(ctx, obj.DecidedBy.Int64)
+	}
+	return nil, nil
+}
+
+///////////////
+//   Query   //
+///////////////
+
+// PermissionElevations is the resolver for the permissionElevations field.
+func (r *queryResolver) PermissionElevations(ctx context.Context, userID *int64, status *string) ([]dbmodels.PermissionElevation, error) {
+	auther, err := r.GetAuther(ctx)
+	if err != nil {
+		return nil, err.Error
+	}
+
+	filter := ""
+	if status != nil {
+		filter = *status
+	}
+
+	result, err := r.services.AuthService.ListPermissionElevations(ctx, auther, userID, filter, middlewares.GetOrgScope(ctx))
+	if err != nil {
+		return nil, err.Error
+	}
+	return result, nil
+}
+
+///////////////
+// Mutations //
+///////////////
+
+// PermissionElevationRequest is the resolver for the permissionElevationRequest field.
+func (r *mutationResolver) PermissionElevationRequest(ctx context.Context, input graph.PermissionElevationRequest) (*dbmodels.PermissionElevation, error) {
+	auther, err := r.GetAuther(ctx)
+	if err != nil {
+		return nil, err.Error
+	}
+	orgUID := middlewares.GetOrgScope(ctx)
+
+	req := dbmodels.PermissionElevationRequest{
+		Permission:    input.Permission,
+		Justification: input.Justification,
+		EndsAt:        input.EndsAt,
+	}
+	if input.UserID != nil {
+		req.UserID = *input.UserID
+	}
+	if input.StartsAt != nil {
+		req.StartsAt = *input.StartsAt
+	}
+	if input.RequireApproval != nil {
+		req.RequireApproval = *input.RequireApproval
+	}
+
+	// start db transaction
+	tx, err := r.services.DBTX.BeginTx(ctx)
+	if err != nil {
+		return nil, err.Error
+	}
+	defer r.services.DBTX.RollbackTx(ctx, tx)
+
+	obj, err := r.services.AuthService.RequestPermissionElevation(ctx, tx, auther, req, orgUID)
+	if err != nil {
+		return nil, err.Error
+	}
+
+	// record user activity
+	actReq := dbmodels.UserActivityRequest{
+		UserID:       auther.ID,
+		OrgUID:       auther.OrgUID,
+		Action:       fmt.Sprintf("%s_%s", constants.PermissionElevationObject, constants.CreateAction),
+		ObjectID:     null.Int64From(obj.ID),
+		ObjectType:   null.StringFrom(string(constants.PermissionElevationObject)),
+		SessionToken: auther.SessionToken,
+	}
+	_, err = r.services.UserActivityService.Create(ctx, tx, actReq)
+	if err != nil {
+		return nil, err.Error
+	}
+
+	// commit db transaction
+	if err := r.services.DBTX.CommitTx(ctx, tx); err != nil {
+		return nil, err.Error
+	}
+	return obj, nil
+}
+
+// PermissionElevationApprove is the resolver for the permissionElevationApprove field.
+func (r *mutationResolver) PermissionElevationApprove(ctx context.Context, id int64) (*dbmodels.PermissionElevation, error) {
+	auther, err := r.GetAuther(ctx)
+	if err != nil {
+		return nil, err.Error
+	}
+	orgUID := middlewares.GetOrgScope(ctx)
+
+	// start db transaction
+	tx, err := r.services.DBTX.BeginTx(ctx)
+	if err != nil {
+		return nil, err.Error
+	}
+	defer r.services.DBTX.RollbackTx(ctx, tx)
+
+	obj, err := r.services.AuthService.ApprovePermissionElevation(ctx, tx, auther, id, orgUID)
+	if err != nil {
+		return nil, err.Error
+	}
+
+	// record user activity
+	actReq := dbmodels.UserActivityRequest{
+		UserID:       auther.ID,
+		OrgUID:       auther.OrgUID,
+		Action:       fmt.Sprintf("%s_%s", constants.PermissionElevationObject, constants.ApproveAction),
+		ObjectID:     null.Int64From(obj.ID),
+		ObjectType:   null.StringFrom(string(constants.PermissionElevationObject)),
+		SessionToken: auther.SessionToken,
+	}
+	_, err = r.services.UserActivityService.Create(ctx, tx, actReq)
+	if err != nil {
+		return nil, err.Error
+	}
+
+	// commit db transaction
+	if err := r.services.DBTX.CommitTx(ctx, tx); err != nil {
+		return nil, err.Error
+	}
+	return obj, nil
+}
+
+// PermissionElevationDeny is the resolver for the permissionElevationDeny field.
+func (r *mutationResolver) PermissionElevationDeny(ctx context.Context, id int64) (*dbmodels.PermissionElevation, error) {
+	auther, err := r.GetAuther(ctx)
+	if err != nil {
+		return nil, err.Error
+	}
+	orgUID := middlewares.GetOrgScope(ctx)
+
+	// start db transaction
+	tx, err := r.services.DBTX.BeginTx(ctx)
+	if err != nil {
+		return nil, err.Error
+	}
+	defer r.services.DBTX.RollbackTx(ctx, tx)
+
+	obj, err := r.services.AuthService.DenyPermissionElevation(ctx, tx, auther, id, orgUID)
+	if err != nil {
+		return nil, err.Error
+	}
+
+	// record user activity
+	actReq := dbmodels.UserActivityRequest{
+		UserID:       auther.ID,
+		OrgUID:       auther.OrgUID,
+		Action:       fmt.Sprintf("%s_%s", constants.PermissionElevationObject, constants.DeclineAction),
+		ObjectID:     null.Int64From(obj.ID),
+		ObjectType:   null.StringFrom(string(constants.PermissionElevationObject)),
+		SessionToken: auther.SessionToken,
+	}
+	_, err = r.services.UserActivityService.Create(ctx, tx, actReq)
+	if err != nil {
+		return nil, err.Error
+	}
+
+	// commit db transaction
+	if err := r.services.DBTX.CommitTx(ctx, tx); err != nil {
+		return nil, err.Error
+	}
+	return obj, nil
+}
+
+// PermissionElevationRevoke is the resolver for the permissionElevationRevoke field.
+func (r *mutationResolver) PermissionElevationRevoke(ctx context.Context, id int64) (*dbmodels.PermissionElevation, error) {
+	auther, err := r.GetAuther(ctx)
+	if err != nil {
+		return nil, err.Error
+	}
+	orgUID := middlewares.GetOrgScope(ctx)
+
+	// start db transaction
+	tx, err := r.services.DBTX.BeginTx(ctx)
+	if err != nil {
+		return nil, err.Error
+	}
+	defer r.services.DBTX.RollbackTx(ctx, tx)
+
+	obj, err := r.services.AuthService.RevokePermissionElevation(ctx, tx, auther, id, orgUID)
+	if err != nil {
+		return nil, err.Error
+	}
+
+	// record user activity
+	actReq := dbmodels.UserActivityRequest{
+		UserID:       auther.ID,
+		OrgUID:       auther.OrgUID,
+		Action:       fmt.Sprintf("%s_%s", constants.PermissionElevationObject, constants.RevokeAction),
+		ObjectID:     null.Int64From(obj.ID),
+		ObjectType:   null.StringFrom(string(constants.PermissionElevationObject)),
+		SessionToken: auther.SessionToken,
+	}
+	_, err = r.services.UserActivityService.Create(ctx, tx, actReq)
+	if err != nil {
+		return nil, err.Error
+	}
+
+	// commit db transaction
+	if err := r.services.DBTX.CommitTx(ctx, tx); err != nil {
+		return nil, err.Error
+	}
+	return obj, nil
+}
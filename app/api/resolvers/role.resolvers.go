@@ -10,7 +10,9 @@ import (
 	"gogql/app/models/constants"
 	"gogql/app/models/dbmodels"
 	"gogql/utils/faulterr"
+	"time"
 
+	"github.com/gofrs/uuid"
 	"github.com/volatiletech/null"
 )
 
@@ -29,27 +31,72 @@ func (r *roleResolver) Department(ctx context.Context, obj *dbmodels.Role) (*dbm
 	return dataloaders.DepartmentLoaderFromContext(ctx, obj.DepartmentID)
 }
 
+// PermissionGrants is the resolver for the permissionGrants field.
+func (r *roleResolver) PermissionGrants(ctx context.Context, obj *dbmodels.Role) ([]graph.PermissionGrant, error) {
+	result := []graph.PermissionGrant{}
+	for _, perm := range obj.Permissions {
+		scope := graph.PermissionScopeOrganization
+		if s, ok := obj.PermissionScopes[perm]; ok {
+			scope = graph.PermissionScope(s)
+		}
+		result = append(result, graph.PermissionGrant{Permission: perm, Scope: scope})
+	}
+	return result, nil
+}
+
+// PermissionDecision returns graph.PermissionDecisionResolver implementation.
+func (r *Resolver) PermissionDecision() graph.PermissionDecisionResolver {
+	return &permissionDecisionResolver{r}
+}
+
+type permissionDecisionResolver struct{ *Resolver }
+
+// Scope is the resolver for the scope field.
+func (r *permissionDecisionResolver) Scope(ctx context.Context, obj *models.PermissionDecision) (*graph.PermissionScope, error) {
+	if !obj.Granted {
+		return nil, nil
+	}
+	scope := graph.PermissionScope(obj.Scope.Scope)
+	return &scope, nil
+}
+
+// DepartmentIDs is the resolver for the departmentIDs field.
+func (r *permissionDecisionResolver) DepartmentIDs(ctx context.Context, obj *models.PermissionDecision) ([]int64, error) {
+	if obj.Scope.DepartmentIDs == nil {
+		return []int64{}, nil
+	}
+	return obj.Scope.DepartmentIDs, nil
+}
+
+// PermissionHolder returns graph.PermissionHolderResolver implementation.
+func (r *Resolver) PermissionHolder() graph.PermissionHolderResolver {
+	return &permissionHolderResolver{r}
+}
+
+type permissionHolderResolver struct{ *Resolver }
+
+// User is the resolver for the user field.
+func (r *permissionHolderResolver) User(ctx context.Context, obj *models.PermissionHolder) (*dbmodels.User, error) {
+	return dataloaders.UserLoaderFromContext(ctx, obj.UserID)
+}
+
 ///////////////
 //   Query   //
 ///////////////
 
 // Roles is the resolver for the roles field.
 func (r *queryResolver) Roles(ctx context.Context, search graph.SearchFilter, deptID *int64) (*graph.RolesResult, error) {
-	orgUID := middlewares.GetOrgUID(ctx)
-	if search.OrgUID != nil {
-		orgUID = search.OrgUID
-	}
-
-	auther, err := r.GetAutherWithPermission(ctx, models.ReadRole)
+	orgUID, err := middlewares.NarrowOrgScope(ctx, search.OrgUID)
 	if err != nil {
 		return nil, err.Error
 	}
-	if !auther.IsAdmin {
-		orgUID = &auther.OrgUID.UUID
+	scope, err := r.GetPermissionScope(ctx, models.ReadRole)
+	if err != nil {
+		return nil, err.Error
 	}
 
 	filter := r.SearchFilter(search)
-	output, total, err := r.services.RoleService.List(ctx, filter, orgUID, deptID)
+	output, total, err := r.services.RoleService.List(ctx, filter, orgUID, deptID, scope)
 	if err != nil {
 		return nil, err.Error
 	}
@@ -58,14 +105,7 @@ func (r *queryResolver) Roles(ctx context.Context, search graph.SearchFilter, de
 
 // Role is the resolver for the role field.
 func (r *queryResolver) Role(ctx context.Context, id *int64, code *string) (*dbmodels.Role, error) {
-	orgUID := middlewares.GetOrgUID(ctx)
-	auther, err := r.GetAutherWithPermission(ctx, models.ReadRole)
-	if err != nil {
-		return nil, err.Error
-	}
-	if !auther.IsAdmin {
-		orgUID = &auther.OrgUID.UUID
-	}
+	orgUID := middlewares.GetOrgScope(ctx)
 
 	if id != nil {
 		obj, err := r.services.RoleService.GetByID(ctx, *id, orgUID)
@@ -86,14 +126,98 @@ func (r *queryResolver) Role(ctx context.Context, id *int64, code *string) (*dbm
 	return nil, faulterr.NewFrobiddenError(noQueryParamsErr).Error
 }
 
+// Permissions is the resolver for the permissions field.
+func (r *queryResolver) Permissions(ctx context.Context, includeRemoved *bool) ([]dbmodels.Permission, error) {
+	_, err := r.GetAuther(ctx)
+	if err != nil {
+		return nil, err.Error
+	}
+
+	output, err := r.services.RoleService.ListPermissions(ctx, includeRemoved != nil && *includeRemoved)
+	if err != nil {
+		return nil, err.Error
+	}
+	return output, nil
+}
+
+// RolesWithUnknownPermissions is the resolver for the rolesWithUnknownPermissions field.
+func (r *queryResolver) RolesWithUnknownPermissions(ctx context.Context) ([]models.RolePermissionIssue, error) {
+	orgUID := middlewares.GetOrgScope(ctx)
+
+	output, err := r.services.RoleService.UnknownPermissionRoles(ctx, orgUID)
+	if err != nil {
+		return nil, err.Error
+	}
+	return output, nil
+}
+
+// PermissionCheck is the resolver for the permissionCheck field.
+func (r *queryResolver) PermissionCheck(ctx context.Context, perm string, userID *int64, at *time.Time) (*models.PermissionDecision, error) {
+	auther, err := r.GetAuther(ctx)
+	if err != nil {
+		return nil, err.Error
+	}
+
+	// a check at a point in time is decided on the history of the user, who may be gone by now
+	if at != nil {
+		targetID := auther.ID
+		if userID != nil && *userID != auther.ID {
+			scope, err := r.services.AuthService.GetPermissionScope(ctx, auther, models.ReadUser)
+			if err != nil {
+				return nil, err.Error
+			}
+			// only organization wide grants reach users that may be gone, narrower ones need them in scope
+			if scope.Scope != models.ScopeOrganization {
+				if _, err := r.services.UserService.GetByIDInScope(ctx, *userID, middlewares.GetOrgScope(ctx), scope); err != nil {
+					return nil, err.Error
+				}
+			}
+			targetID = *userID
+		}
+		decision, err := r.services.AuthService.CheckPermissionAt(ctx, targetID, middlewares.GetOrgScope(ctx), perm, *at)
+		if err != nil {
+			return nil, err.Error
+		}
+		return decision, nil
+	}
+
+	// checking the permissions of another user needs to read users
+	if userID != nil && *userID != auther.ID {
+		user, err := r.GetUserInScope(ctx, models.ReadUser, *userID)
+		if err != nil {
+			return nil, err.Error
+		}
+		auther = r.services.AuthService.UserAuther(user)
+	}
+
+	decision, err := r.services.AuthService.CheckPermission(ctx, auther, perm)
+	if err != nil {
+		return nil, err.Error
+	}
+	return decision, nil
+}
+
+// PermissionHolders is the resolver for the permissionHolders field.
+func (r *queryResolver) PermissionHolders(ctx context.Context, perm string, at time.Time, orgUID *uuid.NullUUID) ([]models.PermissionHolder, error) {
+	targetOrgUID, err := r.TargetOrgUID(ctx, orgUID)
+	if err != nil {
+		return nil, err.Error
+	}
+
+	result, err := r.services.AuthService.PermissionHoldersAt(ctx, *targetOrgUID, perm, at)
+	if err != nil {
+		return nil, err.Error
+	}
+	return result, nil
+}
+
 ///////////////
 // Mutations //
 ///////////////
 
 // RoleCreate is the resolver for the roleCreate field.
 func (r *mutationResolver) RoleCreate(ctx context.Context, input graph.UpdateRole) (*dbmodels.Role, error) {
-	orgUID := middlewares.GetOrgUID(ctx)
-	auther, err := r.GetAutherWithPermission(ctx, models.CreateRole)
+	auther, err := r.GetAuther(ctx)
 	if err != nil {
 		return nil, err.Error
 	}
@@ -103,17 +227,11 @@ func (r *mutationResolver) RoleCreate(ctx context.Context, input graph.UpdateRol
 		return nil, reqErr
 	}
 
-	if auther.IsAdmin {
-		if input.OrgUID != nil && input.OrgUID.Valid {
-			req.OrgUID = input.OrgUID.UUID
-		} else if orgUID != nil {
-			req.OrgUID = *orgUID
-		} else {
-			return nil, faulterr.NewBadRequestError("org uid is required").Error
-		}
-	} else {
-		req.OrgUID = auther.OrgUID.UUID
+	orgUID, err := r.TargetOrgUID(ctx, input.OrgUID)
+	if err != nil {
+		return nil, err.Error
 	}
+	req.OrgUID = *orgUID
 
 	// start db transaction
 	tx, err := r.services.DBTX.BeginTx(ctx)
@@ -151,14 +269,11 @@ func (r *mutationResolver) RoleCreate(ctx context.Context, input graph.UpdateRol
 
 // RoleUpdate is the resolver for the roleUpdate field.
 func (r *mutationResolver) RoleUpdate(ctx context.Context, id int64, input graph.UpdateRole) (*dbmodels.Role, error) {
-	orgUID := middlewares.GetOrgUID(ctx)
-	auther, err := r.GetAutherWithPermission(ctx, models.UpdateRole)
+	auther, err := r.GetAuther(ctx)
 	if err != nil {
 		return nil, err.Error
 	}
-	if !auther.IsAdmin {
-		orgUID = &auther.OrgUID.UUID
-	}
+	orgUID := middlewares.GetOrgScope(ctx)
 
 	req, reqErr := r.generateRoleRequest(input)
 	if reqErr != nil {
@@ -201,14 +316,11 @@ func (r *mutationResolver) RoleUpdate(ctx context.Context, id int64, input graph
 
 // RoleFinalize is the resolver for the roleFinalize field.
 func (r *mutationResolver) RoleFinalize(ctx context.Context, id int64) (*dbmodels.Role, error) {
-	orgUID := middlewares.GetOrgUID(ctx)
-	auther, err := r.GetAutherWithPermission(ctx, models.UpdateRole)
+	auther, err := r.GetAuther(ctx)
 	if err != nil {
 		return nil, err.Error
 	}
-	if !auther.IsAdmin {
-		orgUID = &auther.OrgUID.UUID
-	}
+	orgUID := middlewares.GetOrgScope(ctx)
 
 	// start db transaction
 	tx, err := r.services.DBTX.BeginTx(ctx)
@@ -245,14 +357,11 @@ func (r *mutationResolver) RoleFinalize(ctx context.Context, id int64) (*dbmodel
 
 // RoleArchive is the resolver for the roleArchive field.
 func (r *mutationResolver) RoleArchive(ctx context.Context, id int64) (*dbmodels.Role, error) {
-	orgUID := middlewares.GetOrgUID(ctx)
-	auther, err := r.GetAutherWithPermission(ctx, models.UpdateRole)
+	auther, err := r.GetAuther(ctx)
 	if err != nil {
 		return nil, err.Error
 	}
-	if !auther.IsAdmin {
-		orgUID = &auther.OrgUID.UUID
-	}
+	orgUID := middlewares.GetOrgScope(ctx)
 
 	// start db transaction
 	tx, err := r.services.DBTX.BeginTx(ctx)
@@ -289,14 +398,11 @@ func (r *mutationResolver) RoleArchive(ctx context.Context, id int64) (*dbmodels
 
 // RoleUnarchive is the resolver for the roleUnarchive field.
 func (r *mutationResolver) RoleUnarchive(ctx context.Context, id int64) (*dbmodels.Role, error) {
-	orgUID := middlewares.GetOrgUID(ctx)
-	auther, err := r.GetAutherWithPermission(ctx, models.UpdateRole)
+	auther, err := r.GetAuther(ctx)
 	if err != nil {
 		return nil, err.Error
 	}
-	if !auther.IsAdmin {
-		orgUID = &auther.OrgUID.UUID
-	}
+	orgUID := middlewares.GetOrgScope(ctx)
 
 	// start db transaction
 	tx, err := r.services.DBTX.BeginTx(ctx)
@@ -331,6 +437,45 @@ func (r *mutationResolver) RoleUnarchive(ctx context.Context, id int64) (*dbmode
 	return obj, nil
 }
 
+// PermissionRename is the resolver for the permissionRename field.
+func (r *mutationResolver) PermissionRename(ctx context.Context, from string, to string) (int, error) {
+	auther, err := r.GetAuther(ctx)
+	if err != nil {
+		return 0, err.Error
+	}
+
+	// start db transaction
+	tx, err := r.services.DBTX.BeginTx(ctx)
+	if err != nil {
+		return 0, err.Error
+	}
+	defer r.services.DBTX.RollbackTx(ctx, tx)
+
+	count, err := r.services.RoleService.RenamePermission(ctx, tx, from, to)
+	if err != nil {
+		return 0, err.Error
+	}
+
+	// record user activity
+	actReq := dbmodels.UserActivityRequest{
+		UserID:       auther.ID,
+		OrgUID:       auther.OrgUID,
+		Action:       fmt.Sprintf("%s_%s", constants.RoleObject, constants.UpdateAction),
+		ObjectType:   null.StringFrom(string(constants.RoleObject)),
+		SessionToken: auther.SessionToken,
+	}
+	_, err = r.services.UserActivityService.Create(ctx, tx, actReq)
+	if err != nil {
+		return 0, err.Error
+	}
+
+	// commit db transaction
+	if err := r.services.DBTX.CommitTx(ctx, tx); err != nil {
+		return 0, err.Error
+	}
+	return int(count), nil
+}
+
 func (r *mutationResolver) generateRoleRequest(input graph.UpdateRole) (*dbmodels.RoleRequest, error) {
 	req := &dbmodels.RoleRequest{}
 	rolePermissions := []string{}
@@ -354,16 +499,28 @@ func (r *mutationResolver) generateRoleRequest(input graph.UpdateRole) (*dbmodel
 	}
 
 	if !req.IsManagement && len(input.Permissions) > 0 {
-		permissions := models.ListPermissions()
 		reqPermissions := r.services.RoleService.UniquePermissions(input.Permissions)
 		for _, perm := range reqPermissions {
-			ok := r.services.RoleService.ContainsPermission(permissions, perm)
-			if ok {
+			if models.IsPermission(perm) {
 				rolePermissions = append(rolePermissions, perm)
 			}
 		}
 		req.Permissions = rolePermissions
 	}
 
+	// organization wide grants are the default and not stored
+	req.PermissionScopes = map[string]string{}
+	for _, grant := range input.PermissionScopes {
+		if !grant.Scope.IsValid() {
+			return nil, fmt.Errorf("invalid scope %s", grant.Scope)
+		}
+		if !r.services.RoleService.ContainsPermission(req.Permissions, grant.Permission) {
+			return nil, fmt.Errorf("role does not grant %s", grant.Permission)
+		}
+		if grant.Scope != graph.PermissionScopeOrganization {
+			req.PermissionScopes[grant.Permission] = string(grant.Scope)
+		}
+	}
+
 	return req, nil
 }
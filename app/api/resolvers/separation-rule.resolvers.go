@@ -0,0 +1,205 @@
+package resolvers
+
+import (
+	"context"
+	"fmt"
+	"gogql/app/api/dataloaders"
+	"gogql/app/api/graphql/generated/graph"
+	"gogql/app/middlewares"
+	"gogql/app/models"
+	"gogql/app/models/constants"
+	"gogql/app/models/dbmodels"
+
+	"github.com/gofrs/uuid"
+	"github.com/volatiletech/null"
+)
+
+// SeparationRule returns graph.SeparationRuleResolver implementation.
+func (r *Resolver) SeparationRule() graph.SeparationRuleResolver { return &separationRuleResolver{r} }
+
+type separationRuleResolver struct{ *Resolver }
+
+// CreatedBy is the resolver for the createdBy field.
+func (r *separationRuleResolver) CreatedBy(ctx context.Context, obj *dbmodels.SeparationRule) (*dbmodels.User, error) {
+	return dataloaders.UserLoaderFromContext(ctx, obj.CreatedBy)
+}
+
+///////////////
+//   Query   //
+///////////////
+
+// SeparationRules is the resolver for the separationRules field.
+func (r *queryResolver) SeparationRules(ctx context.Context, orgUID *uuid.NullUUID, isActive *bool) ([]dbmodels.SeparationRule, error) {
+	targetOrgUID, err := r.TargetOrgUID(ctx, orgUID)
+	if err != nil {
+		return nil, err.Error
+	}
+
+	result, err := r.services.RoleService.ListSeparationRules(ctx, *targetOrgUID, isActive)
+	if err != nil {
+		return nil, err.Error
+	}
+	return result, nil
+}
+
+// SeparationViolations is the resolver for the separationViolations field.
+func (r *queryResolver) SeparationViolations(ctx context.Context, orgUID *uuid.NullUUID) ([]models.SeparationViolation, error) {
+	targetOrgUID, err := r.TargetOrgUID(ctx, orgUID)
+	if err != nil {
+		return nil, err.Error
+	}
+
+	result, err := r.services.RoleService.SeparationViolations(ctx, *targetOrgUID)
+	if err != nil {
+		return nil, err.Error
+	}
+	return result, nil
+}
+
+///////////////
+// Mutations //
+///////////////
+
+// SeparationRuleCreate is the resolver for the separationRuleCreate field.
+func (r *mutationResolver) SeparationRuleCreate(ctx context.Context, input graph.SeparationRuleRequest) (*dbmodels.SeparationRule, error) {
+	auther, err := r.GetAuther(ctx)
+	if err != nil {
+		return nil, err.Error
+	}
+
+	orgUID, err := r.TargetOrgUID(ctx, input.OrgUID)
+	if err != nil {
+		return nil, err.Error
+	}
+	req := r.generateSeparationRuleRequest(input)
+	req.OrgUID = *orgUID
+
+	// start db transaction
+	tx, err := r.services.DBTX.BeginTx(ctx)
+	if err != nil {
+		return nil, err.Error
+	}
+	defer r.services.DBTX.RollbackTx(ctx, tx)
+
+	obj, err := r.services.RoleService.CreateSeparationRule(ctx, tx, req, auther.ID)
+	if err != nil {
+		return nil, err.Error
+	}
+
+	// record user activity
+	actReq := dbmodels.UserActivityRequest{
+		UserID:       auther.ID,
+		OrgUID:       auther.OrgUID,
+		Action:       fmt.Sprintf("%s_%s", constants.SeparationRuleObject, constants.CreateAction),
+		ObjectID:     null.Int64From(obj.ID),
+		ObjectType:   null.StringFrom(string(constants.SeparationRuleObject)),
+		SessionToken: auther.SessionToken,
+	}
+	_, err = r.services.UserActivityService.Create(ctx, tx, actReq)
+	if err != nil {
+		return nil, err.Error
+	}
+
+	// commit db transaction
+	if err := r.services.DBTX.CommitTx(ctx, tx); err != nil {
+		return nil, err.Error
+	}
+	return obj, nil
+}
+
+// SeparationRuleUpdate is the resolver for the separationRuleUpdate field.
+func (r *mutationResolver) SeparationRuleUpdate(ctx context.Context, id int64, input graph.SeparationRuleRequest) (*dbmodels.SeparationRule, error) {
+	auther, err := r.GetAuther(ctx)
+	if err != nil {
+		return nil, err.Error
+	}
+	orgUID := middlewares.GetOrgScope(ctx)
+
+	req := r.generateSeparationRuleRequest(input)
+
+	// start db transaction
+	tx, err := r.services.DBTX.BeginTx(ctx)
+	if err != nil {
+		return nil, err.Error
+	}
+	defer r.services.DBTX.RollbackTx(ctx, tx)
+
+	obj, err := r.services.RoleService.UpdateSeparationRule(ctx, tx, id, req, orgUID)
+	if err != nil {
+		return nil, err.Error
+	}
+
+	// record user activity
+	actReq := dbmodels.UserActivityRequest{
+		UserID:       auther.ID,
+		OrgUID:       auther.OrgUID,
+		Action:       fmt.Sprintf("%s_%s", constants.SeparationRuleObject, constants.UpdateAction),
+		ObjectID:     null.Int64From(obj.ID),
+		ObjectType:   null.StringFrom(string(constants.SeparationRuleObject)),
+		SessionToken: auther.SessionToken,
+	}
+	_, err = r.services.UserActivityService.Create(ctx, tx, actReq)
+	if err != nil {
+		return nil, err.Error
+	}
+
+	// commit db transaction
+	if err := r.services.DBTX.CommitTx(ctx, tx); err != nil {
+		return nil, err.Error
+	}
+	return obj, nil
+}
+
+// SeparationRuleDelete is the resolver for the separationRuleDelete field.
+func (r *mutationResolver) SeparationRuleDelete(ctx context.Context, id int64) (*dbmodels.SeparationRule, error) {
+	auther, err := r.GetAuther(ctx)
+	if err != nil {
+		return nil, err.Error
+	}
+	orgUID := middlewares.GetOrgScope(ctx)
+
+	// start db transaction
+	tx, err := r.services.DBTX.BeginTx(ctx)
+	if err != nil {
+		return nil, err.Error
+	}
+	defer r.services.DBTX.RollbackTx(ctx, tx)
+
+	obj, err := r.services.RoleService.DeleteSeparationRule(ctx, tx, id, orgUID)
+	if err != nil {
+		return nil, err.Error
+	}
+
+	// record user activity
+	actReq := dbmodels.UserActivityRequest{
+		UserID:       auther.ID,
+		OrgUID:       auther.OrgUID,
+		Action:       fmt.Sprintf("%s_%s", constants.SeparationRuleObject, constants.DeleteAction),
+		ObjectID:     null.Int64From(obj.ID),
+		ObjectType:   null.StringFrom(string(constants.SeparationRuleObject)),
+		SessionToken: auther.SessionToken,
+	}
+	_, err = r.services.UserActivityService.Create(ctx, tx, actReq)
+	if err != nil {
+		return nil, err.Error
+	}
+
+	// commit db transaction
+	if err := r.services.DBTX.CommitTx(ctx, tx); err != nil {
+		return nil, err.Error
+	}
+	return obj, nil
+}
+
+// rules are active unless created or updated inactive
+func (r *mutationResolver) generateSeparationRuleRequest(input graph.SeparationRuleRequest) dbmodels.SeparationRuleRequest {
+	req := dbmodels.SeparationRuleRequest{
+		Name:        input.Name,
+		Permissions: input.Permissions,
+		IsActive:    true,
+	}
+	if input.IsActive != nil {
+		req.IsActive = *input.IsActive
+	}
+	return req
+}
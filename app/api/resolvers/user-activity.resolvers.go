@@ -19,6 +19,11 @@ func (r *userActivityResolver) User(ctx context.Context, obj *dbmodels.UserActiv
 	return dataloaders.UserLoaderFromContext(ctx, obj.UserID)
 }
 
+// Actor is the resolver for the actor field.
+func (r *userActivityResolver) Actor(ctx context.Context, obj *dbmodels.UserActivity) (*dbmodels.User, error) {
+	return dataloaders.UserLoaderFromContext(ctx, obj.ActorID)
+}
+
 // Organization is the resolver for the organization field.
 func (r *userActivityResolver) Organization(ctx context.Context, obj *dbmodels.UserActivity) (*dbmodels.Organization, error) {
 	if obj.OrgUID.Valid {
@@ -33,21 +38,17 @@ func (r *userActivityResolver) Organization(ctx context.Context, obj *dbmodels.U
 
 // UserActivities is the resolver for the userActivities field.
 func (r *queryResolver) UserActivities(ctx context.Context, search graph.SearchFilter, userID *int64) (*graph.UserActivitiesResult, error) {
-	orgUID := middlewares.GetOrgUID(ctx)
-	if search.OrgUID != nil {
-		orgUID = search.OrgUID
-	}
-
-	auther, err := r.GetAutherWithPermission(ctx, models.ReadUserActivity)
+	orgUID, err := middlewares.NarrowOrgScope(ctx, search.OrgUID)
 	if err != nil {
 		return nil, err.Error
 	}
-	if !auther.IsAdmin {
-		orgUID = &auther.OrgUID.UUID
+	scope, err := r.GetPermissionScope(ctx, models.ReadUserActivity)
+	if err != nil {
+		return nil, err.Error
 	}
 
 	filter := r.SearchFilter(search)
-	output, total, err := r.services.UserActivityService.List(ctx, filter, userID, orgUID)
+	output, total, err := r.services.UserActivityService.List(ctx, filter, userID, orgUID, scope)
 	if err != nil {
 		return nil, err.Error
 	}
@@ -56,14 +57,7 @@ func (r *queryResolver) UserActivities(ctx context.Context, search graph.SearchF
 
 // UserActivity is the resolver for the userActivity field.
 func (r *queryResolver) UserActivity(ctx context.Context, id int64) (*dbmodels.UserActivity, error) {
-	orgUID := middlewares.GetOrgUID(ctx)
-	auther, err := r.GetAutherWithPermission(ctx, models.ReadUserActivity)
-	if err != nil {
-		return nil, err.Error
-	}
-	if !auther.IsAdmin {
-		orgUID = &auther.OrgUID.UUID
-	}
+	orgUID := middlewares.GetOrgScope(ctx)
 
 	obj, err := r.services.UserActivityService.GetByID(ctx, id, orgUID)
 	if err != nil {
@@ -5,12 +5,14 @@ import (
 	"fmt"
 	"gogql/app/api/dataloaders"
 	"gogql/app/api/graphql/generated/graph"
+	"gogql/app/helpers"
 	"gogql/app/middlewares"
 	"gogql/app/models"
 	"gogql/app/models/constants"
 	"gogql/app/models/dbmodels"
 	"gogql/utils/faulterr"
 
+	"github.com/gofrs/uuid"
 	"github.com/volatiletech/null"
 )
 
@@ -35,6 +37,34 @@ func (r *userResolver) Role(ctx context.Context, obj *dbmodels.User) (*dbmodels.
 	return nil, nil
 }
 
+// Roles is the resolver for the roles field.
+func (r *userResolver) Roles(ctx context.Context, obj *dbmodels.User) ([]dbmodels.Role, error) {
+	roles, err := dataloaders.UserRolesLoaderFromContext(ctx, obj.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]dbmodels.Role, 0, len(roles))
+	for _, role := range roles {
+		result = append(result, *role)
+	}
+	return result, nil
+}
+
+// EffectivePermissions is the resolver for the effectivePermissions field.
+func (r *userResolver) EffectivePermissions(ctx context.Context, obj *dbmodels.User) ([]string, error) {
+	auther, err := r.GetAuther(ctx)
+	if err != nil || auther.ID != obj.ID {
+		return nil, nil
+	}
+
+	result, err := r.services.AuthService.EffectivePermissions(ctx, auther)
+	if err != nil {
+		return nil, err.Error
+	}
+	return result, nil
+}
+
 ///////////////
 //   Query   //
 ///////////////
@@ -56,24 +86,17 @@ func (r *queryResolver) Me(ctx context.Context) (*dbmodels.User, error) {
 
 // Users is the resolver for the users field.
 func (r *queryResolver) Users(ctx context.Context, search graph.SearchFilter, roleID *int64) (*graph.UserResult, error) {
-	orgUID := middlewares.GetOrgUID(ctx)
-	if search.OrgUID != nil {
-		orgUID = search.OrgUID
-	}
-
-	auther, err := r.GetAuther(ctx)
+	orgUID, err := middlewares.NarrowOrgScope(ctx, search.OrgUID)
 	if err != nil {
 		return nil, err.Error
 	}
-	if err := r.services.AuthService.GrantPermission(ctx, auther, models.ReadUser); err != nil {
+	scope, err := r.GetPermissionScope(ctx, models.ReadUser)
+	if err != nil {
 		return nil, err.Error
 	}
-	if !auther.IsAdmin {
-		orgUID = &auther.OrgUID.UUID
-	}
 
 	filter := r.SearchFilter(search)
-	output, total, err := r.services.UserService.List(ctx, filter, orgUID, roleID)
+	output, total, err := r.services.UserService.List(ctx, filter, orgUID, roleID, scope)
 	if err != nil {
 		return nil, err.Error
 	}
@@ -82,43 +105,57 @@ func (r *queryResolver) Users(ctx context.Context, search graph.SearchFilter, ro
 
 // User is the resolver for the user field.
 func (r *queryResolver) User(ctx context.Context, id *int64, email, phone *string) (*dbmodels.User, error) {
-	orgUID := middlewares.GetOrgUID(ctx)
-	auther, err := r.GetAuther(ctx)
+	scope, err := r.GetPermissionScope(ctx, models.ReadUser)
 	if err != nil {
 		return nil, err.Error
 	}
-	if err := r.services.AuthService.GrantPermission(ctx, auther, models.ReadUser); err != nil {
-		return nil, err.Error
+	orgUID := middlewares.GetOrgScope(ctx)
+
+	var result *dbmodels.User
+	switch {
+	case id != nil:
+		result, err = r.services.UserService.GetByID(ctx, *id, orgUID)
+	case email != nil:
+		result, err = r.services.UserService.GetByEmail(ctx, *email, orgUID)
+	case phone != nil:
+		result, err = r.services.UserService.GetByPhone(ctx, *phone, orgUID)
+	default:
+		return nil, faulterr.NewFrobiddenError(noQueryParamsErr).Error
 	}
-	if !auther.IsAdmin {
-		orgUID = &auther.OrgUID.UUID
+	if err != nil {
+		return nil, err.Error
 	}
 
-	if id != nil {
-		result, err := r.services.UserService.GetByID(ctx, *id, orgUID)
-		if err != nil {
-			return nil, err.Error
-		}
-		return result, nil
+	// users outside the scope of the grant are not found
+	if err := r.services.UserService.CheckScope(ctx, result, scope); err != nil {
+		return nil, err.Error
 	}
+	return result, nil
+}
 
-	if email != nil {
-		result, err := r.services.UserService.GetByEmail(ctx, *email, orgUID)
-		if err != nil {
-			return nil, err.Error
-		}
-		return result, nil
+// ContactChanges is the resolver for the contactChanges field.
+func (r *queryResolver) ContactChanges(ctx context.Context, userID *int64) ([]dbmodels.ContactChange, error) {
+	auther, err := r.GetAuther(ctx)
+	if err != nil {
+		return nil, err.Error
 	}
 
-	if phone != nil {
-		result, err := r.services.UserService.GetByPhone(ctx, *phone, orgUID)
-		if err != nil {
+	// users see their own changes, the changes of others need the read user permission
+	id := auther.ID
+	var orgUID *uuid.UUID
+	if userID != nil && *userID != auther.ID {
+		if _, err := r.GetUserInScope(ctx, models.ReadUser, *userID); err != nil {
 			return nil, err.Error
 		}
-		return result, nil
+		orgUID = middlewares.GetOrgScope(ctx)
+		id = *userID
 	}
 
-	return nil, faulterr.NewFrobiddenError(noQueryParamsErr).Error
+	output, err := r.services.UserService.ListContactChanges(ctx, id, orgUID)
+	if err != nil {
+		return nil, err.Error
+	}
+	return output, nil
 }
 
 ///////////////
@@ -131,9 +168,6 @@ func (r *mutationResolver) SuperAdminCreate(ctx context.Context, input graph.Upd
 	if err != nil {
 		return nil, err.Error
 	}
-	if !auther.IsAdmin {
-		return nil, faulterr.NewUnauthorizedError(unauthorizedErr).Error
-	}
 
 	req, err := r.generateUserRequest(input)
 	if err != nil {
@@ -175,27 +209,33 @@ func (r *mutationResolver) SuperAdminCreate(ctx context.Context, input graph.Upd
 	return obj, nil
 }
 
-// UserCreate is the resolver for the userCreate field.
-func (r *mutationResolver) UserCreate(ctx context.Context, input graph.UpdateUser) (*dbmodels.User, error) {
-	auther, err := r.GetAutherWithPermission(ctx, models.CreateUser)
+// ChangeDetails is the resolver for the changeDetails field.
+func (r *mutationResolver) ChangeDetails(ctx context.Context, id int64, input graph.UpdateUser) (*dbmodels.User, error) {
+	auther, err := r.GetAuther(ctx)
 	if err != nil {
 		return nil, err.Error
 	}
+	// users change their own details, the details of others and roles need the update user permission
+	if id != auther.ID || (input.RoleID != nil && input.RoleID.Valid) {
+		if _, err := r.GetUserInScope(ctx, models.UpdateUser, id); err != nil {
+			return nil, err.Error
+		}
+	}
+	orgUID := middlewares.GetOrgScope(ctx)
 
 	req, err := r.generateUserRequest(input)
 	if err != nil {
 		return nil, err.Error
 	}
-	if input.OrgUID != nil && input.OrgUID.Valid {
-		req.OrgUID = *input.OrgUID
-	} else {
-		return nil, faulterr.NewFrobiddenError("organization uid is required").Error
-	}
+
 	if input.RoleID != nil && input.RoleID.Int64 > 0 {
 		req.RoleID = *input.RoleID
-	} else {
-		return nil, faulterr.NewFrobiddenError("role id is required").Error
 	}
+	targetOrgUID, err := r.TargetOrgUID(ctx, input.OrgUID)
+	if err != nil {
+		return nil, err.Error
+	}
+	req.OrgUID = helpers.NullUUIDFromUUID(*targetOrgUID)
 
 	// start db transaction
 	tx, err := r.services.DBTX.BeginTx(ctx)
@@ -204,23 +244,25 @@ func (r *mutationResolver) UserCreate(ctx context.Context, input graph.UpdateUse
 	}
 	defer r.services.DBTX.RollbackTx(ctx, tx)
 
-	obj, err := r.services.UserService.Create(ctx, tx, *req)
+	obj, updated, err := r.services.UserService.Update(ctx, tx, id, *req, orgUID, auther)
 	if err != nil {
 		return nil, err.Error
 	}
 
-	// record user activity
-	actReq := dbmodels.UserActivityRequest{
-		UserID:       auther.ID,
-		OrgUID:       auther.OrgUID,
-		Action:       fmt.Sprintf("%s_%s", constants.UserObject, constants.CreateAction),
-		ObjectID:     null.Int64From(obj.ID),
-		ObjectType:   null.StringFrom(string(constants.UserObject)),
-		SessionToken: auther.SessionToken,
-	}
-	_, err = r.services.UserActivityService.Create(ctx, tx, actReq)
-	if err != nil {
-		return nil, err.Error
+	// record user activity, email and phone changes are recorded once confirmed
+	if updated {
+		actReq := dbmodels.UserActivityRequest{
+			UserID:       auther.ID,
+			OrgUID:       auther.OrgUID,
+			Action:       fmt.Sprintf("%s_%s", constants.UserObject, constants.UpdateAction),
+			ObjectID:     null.Int64From(obj.ID),
+			ObjectType:   null.StringFrom(string(constants.UserObject)),
+			SessionToken: auther.SessionToken,
+		}
+		_, err = r.services.UserActivityService.Create(ctx, tx, actReq)
+		if err != nil {
+			return nil, err.Error
+		}
 	}
 
 	// commit db transaction
@@ -231,15 +273,16 @@ func (r *mutationResolver) UserCreate(ctx context.Context, input graph.UpdateUse
 	return obj, nil
 }
 
-// ChangeDetails is the resolver for the changeDetails field.
-func (r *mutationResolver) ChangeDetails(ctx context.Context, id int64, input graph.UpdateUser) (*dbmodels.User, error) {
-	orgUID := middlewares.GetOrgUID(ctx)
+// UserUpdate is the resolver for the userUpdate field.
+func (r *mutationResolver) UserUpdate(ctx context.Context, id int64, input graph.UpdateUser) (*dbmodels.User, error) {
 	auther, err := r.GetAuther(ctx)
 	if err != nil {
 		return nil, err.Error
 	}
-	if !auther.IsAdmin {
-		orgUID = &auther.OrgUID.UUID
+	orgUID := middlewares.GetOrgScope(ctx)
+	// users outside the scope of the grant are not found
+	if _, err := r.GetUserInScope(ctx, models.UpdateUser, id); err != nil {
+		return nil, err.Error
 	}
 
 	req, err := r.generateUserRequest(input)
@@ -250,15 +293,11 @@ func (r *mutationResolver) ChangeDetails(ctx context.Context, id int64, input gr
 	if input.RoleID != nil && input.RoleID.Int64 > 0 {
 		req.RoleID = *input.RoleID
 	}
-	if auther.IsAdmin {
-		if input.OrgUID != nil && input.OrgUID.Valid {
-			req.OrgUID = *input.OrgUID
-		} else {
-			return nil, faulterr.NewBadRequestError("org uid is required").Error
-		}
-	} else {
-		req.OrgUID = auther.OrgUID
+	targetOrgUID, err := r.TargetOrgUID(ctx, input.OrgUID)
+	if err != nil {
+		return nil, err.Error
 	}
+	req.OrgUID = helpers.NullUUIDFromUUID(*targetOrgUID)
 
 	// start db transaction
 	tx, err := r.services.DBTX.BeginTx(ctx)
@@ -267,23 +306,25 @@ func (r *mutationResolver) ChangeDetails(ctx context.Context, id int64, input gr
 	}
 	defer r.services.DBTX.RollbackTx(ctx, tx)
 
-	obj, err := r.services.UserService.Update(ctx, tx, id, *req, orgUID)
+	obj, updated, err := r.services.UserService.Update(ctx, tx, id, *req, orgUID, auther)
 	if err != nil {
 		return nil, err.Error
 	}
 
-	// record user activity
-	actReq := dbmodels.UserActivityRequest{
-		UserID:       auther.ID,
-		OrgUID:       auther.OrgUID,
-		Action:       fmt.Sprintf("%s_%s", constants.UserObject, constants.UpdateAction),
-		ObjectID:     null.Int64From(obj.ID),
-		ObjectType:   null.StringFrom(string(constants.UserObject)),
-		SessionToken: auther.SessionToken,
-	}
-	_, err = r.services.UserActivityService.Create(ctx, tx, actReq)
-	if err != nil {
-		return nil, err.Error
+	// record user activity, email and phone changes are recorded once confirmed
+	if updated {
+		actReq := dbmodels.UserActivityRequest{
+			UserID:       auther.ID,
+			OrgUID:       auther.OrgUID,
+			Action:       fmt.Sprintf("%s_%s", constants.UserObject, constants.UpdateAction),
+			ObjectID:     null.Int64From(obj.ID),
+			ObjectType:   null.StringFrom(string(constants.UserObject)),
+			SessionToken: auther.SessionToken,
+		}
+		_, err = r.services.UserActivityService.Create(ctx, tx, actReq)
+		if err != nil {
+			return nil, err.Error
+		}
 	}
 
 	// commit db transaction
@@ -294,36 +335,16 @@ func (r *mutationResolver) ChangeDetails(ctx context.Context, id int64, input gr
 	return obj, nil
 }
 
-// UserUpdate is the resolver for the userUpdate field.
-func (r *mutationResolver) UserUpdate(ctx context.Context, id int64, input graph.UpdateUser) (*dbmodels.User, error) {
-	orgUID := middlewares.GetOrgUID(ctx)
+// ContactChangeConfirm is the resolver for the contactChangeConfirm field.
+func (r *mutationResolver) ContactChangeConfirm(ctx context.Context, id int64, code string) (*dbmodels.User, error) {
 	auther, err := r.GetAuther(ctx)
 	if err != nil {
 		return nil, err.Error
 	}
-	if !auther.IsAdmin {
-		orgUID = &auther.OrgUID.UUID
-	}
-
-	req, err := r.generateUserRequest(input)
-	if err != nil {
+	if err := r.DenyImpersonation(auther); err != nil {
 		return nil, err.Error
 	}
 
-	if input.RoleID != nil && input.RoleID.Int64 > 0 {
-		req.RoleID = *input.RoleID
-	}
-	if auther.IsAdmin {
-		if input.OrgUID == nil || !input.OrgUID.Valid {
-			return nil, faulterr.NewFrobiddenError("organization id is required").Error
-		} else {
-			req.OrgUID = *input.OrgUID
-		}
-	}
-	if !auther.IsAdmin {
-		req.OrgUID = auther.OrgUID
-	}
-
 	// start db transaction
 	tx, err := r.services.DBTX.BeginTx(ctx)
 	if err != nil {
@@ -331,7 +352,7 @@ func (r *mutationResolver) UserUpdate(ctx context.Context, id int64, input graph
 	}
 	defer r.services.DBTX.RollbackTx(ctx, tx)
 
-	obj, err := r.services.UserService.Update(ctx, tx, id, *req, orgUID)
+	obj, err := r.services.UserService.ConfirmContactChange(ctx, tx, auther, id, code)
 	if err != nil {
 		return nil, err.Error
 	}
@@ -358,20 +379,60 @@ func (r *mutationResolver) UserUpdate(ctx context.Context, id int64, input graph
 	return obj, nil
 }
 
-// ResendEmailVerification is the resolver for the resendEmailVerification field.
-func (r *mutationResolver) ResendEmailVerification(ctx context.Context, email string) (bool, error) {
-	return false, faulterr.NewBadRequestError("not implemented").Error
+// ContactChangeCancel is the resolver for the contactChangeCancel field.
+func (r *mutationResolver) ContactChangeCancel(ctx context.Context, token string) (bool, error) {
+	if token == "" {
+		return false, faulterr.NewFrobiddenError("token is required").Error
+	}
+
+	// start db transaction
+	tx, err := r.services.DBTX.BeginTx(ctx)
+	if err != nil {
+		return false, err.Error
+	}
+	defer r.services.DBTX.RollbackTx(ctx, tx)
+
+	obj, err := r.services.UserService.CancelContactChange(ctx, tx, token)
+	if err != nil {
+		return false, err.Error
+	}
+	user, err := r.services.UserService.Me(ctx, obj.UserID)
+	if err != nil {
+		return false, err.Error
+	}
+
+	// record user activity, the cancel link is used without a session
+	actReq := dbmodels.UserActivityRequest{
+		UserID:       user.ID,
+		OrgUID:       user.OrgUID,
+		Action:       fmt.Sprintf("%s_%s", constants.ContactObject, constants.RevokeAction),
+		ObjectID:     null.Int64From(user.ID),
+		ObjectType:   null.StringFrom(string(constants.UserObject)),
+		SessionToken: uuid.Nil,
+	}
+	_, err = r.services.UserActivityService.Create(ctx, tx, actReq)
+	if err != nil {
+		return false, err.Error
+	}
+
+	// commit db transaction
+	if err := r.services.DBTX.CommitTx(ctx, tx); err != nil {
+		return false, err.Error
+	}
+
+	return true, nil
 }
 
 // UserArchive is the resolver for the userArchive field.
 func (r *mutationResolver) UserArchive(ctx context.Context, id int64) (*dbmodels.User, error) {
-	orgUID := middlewares.GetOrgUID(ctx)
-	auther, err := r.GetAutherWithPermission(ctx, models.UpdateUser)
+	auther, err := r.GetAuther(ctx)
 	if err != nil {
 		return nil, err.Error
 	}
-	if !auther.IsAdmin {
-		orgUID = &auther.OrgUID.UUID
+	orgUID := middlewares.GetOrgScope(ctx)
+	// users outside the scope of the grant are not found
+	if _, err := r.GetUserInScope(ctx, models.UpdateUser, id); err != nil {
+		return nil, err.Error
 	}
 
 	// start db transaction
@@ -409,13 +470,14 @@ func (r *mutationResolver) UserArchive(ctx context.Context, id int64) (*dbmodels
 
 // UserUnarchive is the resolver for the userUnarchive field.
 func (r *mutationResolver) UserUnarchive(ctx context.Context, id int64) (*dbmodels.User, error) {
-	orgUID := middlewares.GetOrgUID(ctx)
-	auther, err := r.GetAutherWithPermission(ctx, models.UpdateUser)
+	auther, err := r.GetAuther(ctx)
 	if err != nil {
 		return nil, err.Error
 	}
-	if !auther.IsAdmin {
-		orgUID = &auther.OrgUID.UUID
+	orgUID := middlewares.GetOrgScope(ctx)
+	// users outside the scope of the grant are not found
+	if _, err := r.GetUserInScope(ctx, models.UpdateUser, id); err != nil {
+		return nil, err.Error
 	}
 
 	// start db transaction
@@ -477,3 +539,98 @@ func (r *mutationResolver) generateUserRequest(input graph.UpdateUser) (*dbmodel
 
 	return req, nil
 }
+
+// UserRoleAssign is the resolver for the userRoleAssign field.
+func (r *mutationResolver) UserRoleAssign(ctx context.Context, userID int64, roleID int64, expiresAt *null.Time) (*dbmodels.User, error) {
+	auther, err := r.GetAuther(ctx)
+	if err != nil {
+		return nil, err.Error
+	}
+	orgUID := middlewares.GetOrgScope(ctx)
+	// users outside the scope of the grant are not found
+	if _, err := r.GetUserInScope(ctx, models.UpdateUser, userID); err != nil {
+		return nil, err.Error
+	}
+
+	expiry := null.Time{}
+	if expiresAt != nil {
+		expiry = *expiresAt
+	}
+
+	// start db transaction
+	tx, err := r.services.DBTX.BeginTx(ctx)
+	if err != nil {
+		return nil, err.Error
+	}
+	defer r.services.DBTX.RollbackTx(ctx, tx)
+
+	obj, err := r.services.UserService.AssignRole(ctx, tx, userID, roleID, expiry, orgUID)
+	if err != nil {
+		return nil, err.Error
+	}
+
+	// record user activity
+	actReq := dbmodels.UserActivityRequest{
+		UserID:       auther.ID,
+		OrgUID:       auther.OrgUID,
+		Action:       fmt.Sprintf("%s_%s", constants.RoleObject, constants.CreateAction),
+		ObjectID:     null.Int64From(obj.ID),
+		ObjectType:   null.StringFrom(string(constants.UserObject)),
+		SessionToken: auther.SessionToken,
+	}
+	_, err = r.services.UserActivityService.Create(ctx, tx, actReq)
+	if err != nil {
+		return nil, err.Error
+	}
+
+	// commit db transaction
+	if err := r.services.DBTX.CommitTx(ctx, tx); err != nil {
+		return nil, err.Error
+	}
+	return obj, nil
+}
+
+// UserRoleRevoke is the resolver for the userRoleRevoke field.
+func (r *mutationResolver) UserRoleRevoke(ctx context.Context, userID int64, roleID int64) (*dbmodels.User, error) {
+	auther, err := r.GetAuther(ctx)
+	if err != nil {
+		return nil, err.Error
+	}
+	orgUID := middlewares.GetOrgScope(ctx)
+	// users outside the scope of the grant are not found
+	if _, err := r.GetUserInScope(ctx, models.UpdateUser, userID); err != nil {
+		return nil, err.Error
+	}
+
+	// start db transaction
+	tx, err := r.services.DBTX.BeginTx(ctx)
+	if err != nil {
+		return nil, err.Error
+	}
+	defer r.services.DBTX.RollbackTx(ctx, tx)
+
+	obj, err := r.services.UserService.RevokeRole(ctx, tx, userID, roleID, orgUID)
+	if err != nil {
+		return nil, err.Error
+	}
+
+	// record user activity
+	actReq := dbmodels.UserActivityRequest{
+		UserID:       auther.ID,
+		OrgUID:       auther.OrgUID,
+		Action:       fmt.Sprintf("%s_%s", constants.RoleObject, constants.RevokeAction),
+		ObjectID:     null.Int64From(obj.ID),
+		ObjectType:   null.StringFrom(string(constants.UserObject)),
+		SessionToken: auther.SessionToken,
+	}
+	_, err = r.services.UserActivityService.Create(ctx, tx, actReq)
+	if err != nil {
+		return nil, err.Error
+	}
+
+	// commit db transaction
+	if err := r.services.DBTX.CommitTx(ctx, tx); err != nil {
+		return nil, err.Error
+	}
+	return obj, nil
+}
@@ -11,5 +11,16 @@ func (rt *Routes) AuthRoutes(r chi.Router) {
 	r.Route("/auth", func(r chi.Router) {
 		r.Get("/permissions", h.ListPermissions)
 		r.Post("/logout", h.Logout)
+		r.Post("/token", h.IssueToken)
+
+		r.Get("/oidc/{orgCode}", h.OIDCLogin)
+		r.Get("/oidc/{orgCode}/callback", h.OIDCCallback)
 	})
 }
+
+// WellKnownRoutes serves discovery documents at the root
+func (rt *Routes) WellKnownRoutes(r chi.Router) {
+	h := rt.Handlers.AuthHandler
+
+	r.Get("/.well-known/jwks.json", h.JWKS)
+}
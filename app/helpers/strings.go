@@ -86,6 +86,23 @@ func StripEmailAddress(str string) string {
 	return str
 }
 
+// MaskEmail hides the local part of an email address except its first character
+func MaskEmail(email string) string {
+	at := strings.LastIndex(email, "@")
+	if at < 1 {
+		return LimitName(email)
+	}
+	return fmt.Sprintf("%s%s%s", email[0:1], strings.Repeat("*", at-1), email[at:])
+}
+
+// MaskPhone hides every digit of a phone number except the last four
+func MaskPhone(phone string) string {
+	if len(phone) <= 4 {
+		return strings.Repeat("*", len(phone))
+	}
+	return fmt.Sprintf("%s%s", strings.Repeat("*", len(phone)-4), phone[len(phone)-4:])
+}
+
 // FindStringSubmatchMap construct hash of regular expression named capture group
 // http://blog.kamilkisiel.net/blog/2012/07/05/using-the-go-regexp-package/
 func FindStringSubmatchMap(r *regexp.Regexp, s string) map[string]*string {
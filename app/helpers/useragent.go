@@ -0,0 +1,51 @@
+package helpers
+
+import "strings"
+
+// known browsers and platforms in the order they have to be matched,
+// e.g. edge and opera user agents also contain chrome and safari
+var (
+	userAgentBrowsers = [][2]string{
+		{"Edg/", "Edge"},
+		{"OPR/", "Opera"},
+		{"Firefox/", "Firefox"},
+		{"Chrome/", "Chrome"},
+		{"Safari/", "Safari"},
+		{"curl/", "curl"},
+		{"PostmanRuntime/", "Postman"},
+	}
+	userAgentPlatforms = [][2]string{
+		{"Android", "Android"},
+		{"iPhone", "iPhone"},
+		{"iPad", "iPad"},
+		{"Windows", "Windows"},
+		{"Mac OS X", "macOS"},
+		{"Linux", "Linux"},
+	}
+)
+
+// DeviceLabel builds a short human readable label like "Chrome on macOS" from a user agent
+func DeviceLabel(userAgent string) string {
+	browser := matchUserAgent(userAgent, userAgentBrowsers)
+	platform := matchUserAgent(userAgent, userAgentPlatforms)
+
+	switch {
+	case browser != "" && platform != "":
+		return browser + " on " + platform
+	case browser != "":
+		return browser
+	case platform != "":
+		return platform
+	default:
+		return "Unknown device"
+	}
+}
+
+func matchUserAgent(userAgent string, known [][2]string) string {
+	for _, k := range known {
+		if strings.Contains(userAgent, k[0]) {
+			return k[1]
+		}
+	}
+	return ""
+}
@@ -3,28 +3,60 @@ package master
 import (
 	"gogql/app/master/orgmaster"
 	"gogql/app/store/dbstore"
+	"gogql/config"
+	"gogql/utils/encrypt"
+	"gogql/utils/oidc"
+	"gogql/utils/webauthn"
+	"time"
 )
 
 type Master struct {
 	// companies
-	OrganizationMaster *orgmaster.OrganizationMaster
-	DepartmentMaster   *orgmaster.DepartmentMaster
-	RoleMaster         *orgmaster.RoleMaster
-	UserMaster         *orgmaster.UserMaster
-	OTPSessionMaster   *orgmaster.OTPSessionMaster
-	AuthSessionMaster  *orgmaster.AuthSessionMaster
-	UserActivityMaster *orgmaster.UserActivityMaster
+	OrganizationMaster  *orgmaster.OrganizationMaster
+	DepartmentMaster    *orgmaster.DepartmentMaster
+	RoleMaster          *orgmaster.RoleMaster
+	UserMaster          *orgmaster.UserMaster
+	OTPSessionMaster    *orgmaster.OTPSessionMaster
+	AuthSessionMaster   *orgmaster.AuthSessionMaster
+	UserActivityMaster  *orgmaster.UserActivityMaster
+	TwoFactorMaster     *orgmaster.TwoFactorMaster
+	PasskeyMaster       *orgmaster.PasskeyMaster
+	OIDCMaster          *orgmaster.OIDCMaster
+	APIKeyMaster        *orgmaster.APIKeyMaster
+	InvitationMaster    *orgmaster.InvitationMaster
+	ContactChangeMaster *orgmaster.ContactChangeMaster
+	PermissionMaster    *orgmaster.PermissionMaster
+
+	PermissionElevationMaster *orgmaster.PermissionElevationMaster
+	SeparationRuleMaster      *orgmaster.SeparationRuleMaster
+	AccessReviewMaster        *orgmaster.AccessReviewMaster
 }
 
-func NewMaster(dbStore *dbstore.DBStore) *Master {
+func NewMaster(dbStore *dbstore.DBStore, security *config.Security) *Master {
+	hasher := encrypt.NewTokenHasher(security.TokenHashKey)
+	box := encrypt.NewSecretBox(security.SecretEncryptionKey)
+	rp := webauthn.NewRelyingParty(security.WebAuthnRPID, security.WebAuthnRPName, security.WebAuthnOrigins)
+	oidcClient := oidc.NewClient(10 * time.Second)
+
 	return &Master{
 		// companies
-		orgmaster.NewOrganizationMaster(dbStore),
+		orgmaster.NewOrganizationMaster(dbStore, security.DefaultPhoneRegion),
 		orgmaster.NewDepartmentMaster(dbStore),
 		orgmaster.NewRoleMaster(dbStore),
-		orgmaster.NewUserMaster(dbStore),
-		orgmaster.NewOTPSessionMaster(dbStore),
-		orgmaster.NewAuthSessionMaster(dbStore),
-		orgmaster.NewUserActivityMaster(dbStore),
+		orgmaster.NewUserMaster(dbStore, security.DefaultPhoneRegion),
+		orgmaster.NewOTPSessionMaster(dbStore, hasher),
+		orgmaster.NewAuthSessionMaster(dbStore, hasher, security),
+		orgmaster.NewUserActivityMaster(dbStore, hasher),
+		orgmaster.NewTwoFactorMaster(dbStore, hasher, box, security.TOTPIssuer),
+		orgmaster.NewPasskeyMaster(dbStore, hasher, rp),
+		orgmaster.NewOIDCMaster(dbStore, hasher, box, oidcClient, security.OIDCCallbackBaseURL),
+		orgmaster.NewAPIKeyMaster(dbStore, hasher),
+		orgmaster.NewInvitationMaster(dbStore, hasher, security),
+		orgmaster.NewContactChangeMaster(dbStore, hasher, security),
+		orgmaster.NewPermissionMaster(dbStore),
+
+		orgmaster.NewPermissionElevationMaster(dbStore),
+		orgmaster.NewSeparationRuleMaster(dbStore),
+		orgmaster.NewAccessReviewMaster(dbStore),
 	}
 }
@@ -0,0 +1,351 @@
+package orgmaster
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"gogql/app/models"
+	"gogql/app/models/constants"
+	"gogql/app/models/dbmodels"
+	"gogql/app/store/dbstore"
+	"gogql/utils/faulterr"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/volatiletech/null"
+)
+
+// users in scope of a campaign are listed by pages of this size
+const accessReviewPageSize = 100
+
+type AccessReviewMaster struct {
+	dbstore *dbstore.DBStore
+}
+
+func NewAccessReviewMaster(s *dbstore.DBStore) *AccessReviewMaster {
+	return &AccessReviewMaster{s}
+}
+
+// Start opens a campaign with an item for every active role assignment of the unarchived users in
+// scope, a department campaign only reviews the roles of the department. The items are shared
+// between the reviewers and nobody reviews their own access.
+func (m *AccessReviewMaster) Start(ctx context.Context, tx pgx.Tx, req dbmodels.AccessReviewCampaignRequest, startedBy int64) (*dbmodels.AccessReviewCampaign, *faulterr.FaultErr) {
+	if strings.TrimSpace(req.Name) == "" {
+		return nil, faulterr.NewBadRequestError("campaign name is required")
+	}
+	reviewerIDs, err := m.verifyReviewers(ctx, req, startedBy)
+	if err != nil {
+		return nil, err
+	}
+
+	scope := models.PermissionScope{}
+	if req.DepartmentID.Valid {
+		dept, err := m.dbstore.DepartmentStore.GetByID(ctx, req.DepartmentID.Int64)
+		if err != nil {
+			return nil, err
+		}
+		if dept.OrgUID != req.OrgUID {
+			return nil, faulterr.NewNotFoundError("department not found")
+		}
+		scope = models.PermissionScope{Scope: models.ScopeDepartment, DepartmentIDs: []int64{dept.ID}}
+	}
+
+	// users in scope
+	users := []dbmodels.User{}
+	archived := false
+	filter := models.SearchFilter{SortBy: "Alphabetical", Limit: accessReviewPageSize, IsArchived: &archived}
+	for {
+		page, _, err := m.dbstore.UserStore.List(ctx, filter, &req.OrgUID, nil, scope)
+		if err != nil {
+			return nil, err
+		}
+		users = append(users, page...)
+		if len(page) < filter.Limit {
+			break
+		}
+		filter.Offset += filter.Limit
+	}
+
+	// their role assignments
+	userIDs := []int64{}
+	for _, user := range users {
+		userIDs = append(userIDs, user.ID)
+	}
+	assignments := []dbmodels.UserRole{}
+	if len(userIDs) > 0 {
+		assignments, err = m.dbstore.UserRoleStore.ListActiveByUserIDs(ctx, userIDs)
+		if err != nil {
+			return nil, err
+		}
+	}
+	roleIDs := []int64{}
+	for _, assignment := range assignments {
+		roleIDs = append(roleIDs, assignment.RoleID)
+	}
+	rolesByID := map[int64]*dbmodels.Role{}
+	if len(roleIDs) > 0 {
+		roles, err := m.dbstore.RoleStore.GetManyByIDs(ctx, roleIDs)
+		if err != nil {
+			return nil, err
+		}
+		for _, role := range roles {
+			rolesByID[role.ID] = role
+		}
+	}
+
+	campaign, err := m.dbstore.AccessReviewCampaignStore.Insert(ctx, tx, &dbmodels.AccessReviewCampaign{
+		OrgUID:       req.OrgUID,
+		DepartmentID: req.DepartmentID,
+		Name:         strings.TrimSpace(req.Name),
+		Status:       constants.StatusOpen,
+		ReviewerIDs:  reviewerIDs,
+		StartedBy:    startedBy,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	primaryRoles := map[int64]null.Int64{}
+	for _, user := range users {
+		primaryRoles[user.ID] = user.RoleID
+	}
+	count := 0
+	for _, assignment := range assignments {
+		role, ok := rolesByID[assignment.RoleID]
+		if !ok || (req.DepartmentID.Valid && role.DepartmentID != req.DepartmentID.Int64) {
+			continue
+		}
+		reviewerID, ok := accessReviewer(reviewerIDs, assignment.UserID, count)
+		if !ok {
+			return nil, faulterr.NewBadRequestError(fmt.Sprintf("user %d can only be reviewed by themselves, add another reviewer", assignment.UserID))
+		}
+
+		primary := primaryRoles[assignment.UserID]
+		item := &dbmodels.AccessReviewItem{
+			CampaignID: campaign.ID,
+			UserID:     assignment.UserID,
+			RoleID:     assignment.RoleID,
+			IsPrimary:  primary.Valid && primary.Int64 == assignment.RoleID,
+			ReviewerID: reviewerID,
+			Decision:   constants.StatusPending,
+		}
+		if _, err := m.dbstore.AccessReviewItemStore.Insert(ctx, tx, item); err != nil {
+			return nil, err
+		}
+		count++
+	}
+
+	return campaign, nil
+}
+
+// Decide certifies or revokes an item of an open campaign, it can be decided again until the campaign
+// closes. Revoking a primary role archives the user or reassigns it to another role.
+func (m *AccessReviewMaster) Decide(ctx context.Context, tx pgx.Tx, campaign *dbmodels.AccessReviewCampaign, item *dbmodels.AccessReviewItem, req dbmodels.AccessReviewDecisionRequest) *faulterr.FaultErr {
+	if campaign.Status != constants.StatusOpen {
+		return faulterr.NewBadRequestError("campaign is closed")
+	}
+
+	item.RevokeAction = ""
+	item.ReassignRoleID = null.Int64{}
+	switch req.Decision {
+	case constants.StatusCertified:
+	case constants.StatusRevoked:
+		switch req.RevokeAction {
+		case constants.RevokeArchiveUser:
+		case constants.RevokeReassignRole:
+			if item.IsPrimary && !req.ReassignRoleID.Valid {
+				return faulterr.NewBadRequestError("a primary role is reassigned to another role")
+			}
+			if req.ReassignRoleID.Valid {
+				if err := m.verifyReassignRole(ctx, campaign, item, req.ReassignRoleID.Int64); err != nil {
+					return err
+				}
+				item.ReassignRoleID = req.ReassignRoleID
+			}
+		default:
+			return faulterr.NewBadRequestError(fmt.Sprintf("unknown revoke action %s", req.RevokeAction))
+		}
+		item.RevokeAction = req.RevokeAction
+	default:
+		return faulterr.NewBadRequestError(fmt.Sprintf("unknown decision %s", req.Decision))
+	}
+
+	item.Decision = req.Decision
+	item.Note = strings.TrimSpace(req.Note)
+	item.DecidedAt = null.TimeFrom(time.Now())
+	return m.dbstore.AccessReviewItemStore.Update(ctx, tx, item)
+}
+
+// Close closes an open campaign, its revocations are applied by the caller
+func (m *AccessReviewMaster) Close(ctx context.Context, tx pgx.Tx, campaign *dbmodels.AccessReviewCampaign, closedBy int64) *faulterr.FaultErr {
+	if campaign.Status != constants.StatusOpen {
+		return faulterr.NewBadRequestError("campaign is already closed")
+	}
+
+	campaign.Status = constants.StatusClosed
+	campaign.ClosedBy = null.Int64From(closedBy)
+	campaign.ClosedAt = null.TimeFrom(time.Now())
+	return m.dbstore.AccessReviewCampaignStore.Update(ctx, tx, campaign)
+}
+
+// Export writes the items of a campaign as csv, one row per reviewed role assignment
+func (m *AccessReviewMaster) Export(ctx context.Context, campaign *dbmodels.AccessReviewCampaign) (string, *faulterr.FaultErr) {
+	items, err := m.dbstore.AccessReviewItemStore.ListByCampaignID(ctx, campaign.ID, nil)
+	if err != nil {
+		return "", err
+	}
+
+	userIDs := []int64{}
+	roleIDs := []int64{}
+	for _, item := range items {
+		userIDs = append(userIDs, item.UserID, item.ReviewerID)
+		roleIDs = append(roleIDs, item.RoleID)
+	}
+	users := map[int64]*dbmodels.User{}
+	roles := map[int64]*dbmodels.Role{}
+	if len(items) > 0 {
+		userList, err := m.dbstore.UserStore.GetManyByIDs(ctx, userIDs)
+		if err != nil {
+			return "", err
+		}
+		for _, user := range userList {
+			users[user.ID] = user
+		}
+		roleList, err := m.dbstore.RoleStore.GetManyByIDs(ctx, roleIDs)
+		if err != nil {
+			return "", err
+		}
+		for _, role := range roleList {
+			roles[role.ID] = role
+		}
+	}
+
+	return accessReviewCSV(campaign, items, users, roles)
+}
+
+func (m *AccessReviewMaster) verifyReviewers(ctx context.Context, req dbmodels.AccessReviewCampaignRequest, startedBy int64) ([]int64, *faulterr.FaultErr) {
+	result := []int64{}
+	seen := map[int64]bool{}
+	for _, id := range req.ReviewerIDs {
+		if !seen[id] {
+			seen[id] = true
+			result = append(result, id)
+		}
+	}
+	if len(result) == 0 {
+		result = append(result, startedBy)
+	}
+
+	reviewers, err := m.dbstore.UserStore.GetManyByIDs(ctx, result)
+	if err != nil {
+		return nil, err
+	}
+	if len(reviewers) != len(result) {
+		return nil, faulterr.NewNotFoundError("reviewer not found")
+	}
+	for _, reviewer := range reviewers {
+		if reviewer.IsArchived {
+			return nil, faulterr.NewBadRequestError(fmt.Sprintf("reviewer %d is archived", reviewer.ID))
+		}
+		if !reviewer.IsAdmin && (!reviewer.OrgUID.Valid || reviewer.OrgUID.UUID != req.OrgUID) {
+			return nil, faulterr.NewNotFoundError("reviewer not found")
+		}
+	}
+	return result, nil
+}
+
+func (m *AccessReviewMaster) verifyReassignRole(ctx context.Context, campaign *dbmodels.AccessReviewCampaign, item *dbmodels.AccessReviewItem, roleID int64) *faulterr.FaultErr {
+	if roleID == item.RoleID {
+		return faulterr.NewBadRequestError("a role is reassigned to another role")
+	}
+	role, err := m.dbstore.RoleStore.GetByID(ctx, roleID)
+	if err != nil {
+		return err
+	}
+	if role.OrgUID != campaign.OrgUID {
+		return faulterr.NewNotFoundError("role not found")
+	}
+	if role.IsArchived {
+		return faulterr.NewBadRequestError("role is archived")
+	}
+	return nil
+}
+
+// accessReviewer picks the reviewer of the nth item of a campaign, going round the reviewers and
+// skipping the reviewed user. It fails when the user is the only reviewer.
+func accessReviewer(reviewerIDs []int64, userID int64, n int) (int64, bool) {
+	for i := range reviewerIDs {
+		reviewerID := reviewerIDs[(n+i)%len(reviewerIDs)]
+		if reviewerID != userID {
+			return reviewerID, true
+		}
+	}
+	return 0, false
+}
+
+func accessReviewCSV(campaign *dbmodels.AccessReviewCampaign, items []dbmodels.AccessReviewItem, users map[int64]*dbmodels.User, roles map[int64]*dbmodels.Role) (string, *faulterr.FaultErr) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+
+	userName := func(id int64) string {
+		if user, ok := users[id]; ok {
+			return strings.TrimSpace(fmt.Sprintf("%s %s", user.FirstName, user.LastName))
+		}
+		return ""
+	}
+	userEmail := func(id int64) string {
+		if user, ok := users[id]; ok {
+			return user.Email
+		}
+		return ""
+	}
+	roleName := func(id int64) string {
+		if role, ok := roles[id]; ok {
+			return role.Name
+		}
+		return ""
+	}
+	timestamp := func(t null.Time) string {
+		if !t.Valid {
+			return ""
+		}
+		return t.Time.UTC().Format(time.RFC3339)
+	}
+
+	rows := [][]string{{
+		"campaign", "campaign_status", "user_id", "user", "email", "role_id", "role", "primary",
+		"reviewer_id", "reviewer", "decision", "revoke_action", "reassign_role_id", "note", "decided_at", "applied_at",
+	}}
+	for _, item := range items {
+		reassignRoleID := ""
+		if item.ReassignRoleID.Valid {
+			reassignRoleID = strconv.FormatInt(item.ReassignRoleID.Int64, 10)
+		}
+		rows = append(rows, []string{
+			campaign.Name,
+			campaign.Status,
+			strconv.FormatInt(item.UserID, 10),
+			userName(item.UserID),
+			userEmail(item.UserID),
+			strconv.FormatInt(item.RoleID, 10),
+			roleName(item.RoleID),
+			strconv.FormatBool(item.IsPrimary),
+			strconv.FormatInt(item.ReviewerID, 10),
+			userName(item.ReviewerID),
+			item.Decision,
+			item.RevokeAction,
+			reassignRoleID,
+			item.Note,
+			timestamp(item.DecidedAt),
+			timestamp(item.AppliedAt),
+		})
+	}
+
+	if err := w.WriteAll(rows); err != nil {
+		return "", faulterr.NewInternalServerError("error when trying to export access review")
+	}
+	return buf.String(), nil
+}
@@ -0,0 +1,59 @@
+package orgmaster
+
+import (
+	"gogql/app/models/constants"
+	"gogql/app/models/dbmodels"
+	"strings"
+	"testing"
+
+	"github.com/volatiletech/null"
+)
+
+func TestAccessReviewer(t *testing.T) {
+	tests := []struct {
+		name      string
+		reviewers []int64
+		userID    int64
+		n         int
+		want      int64
+		ok        bool
+	}{
+		{"round robin", []int64{1, 2, 3}, 9, 4, 2, true},
+		{"skips the user", []int64{1, 2, 3}, 2, 1, 3, true},
+		{"wraps around", []int64{1, 2}, 2, 1, 1, true},
+		{"only reviewer", []int64{5}, 5, 0, 0, false},
+	}
+	for _, tt := range tests {
+		got, ok := accessReviewer(tt.reviewers, tt.userID, tt.n)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("%s: got %d %v, want %d %v", tt.name, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestAccessReviewCSV(t *testing.T) {
+	campaign := &dbmodels.AccessReviewCampaign{Name: "Q3, 2026", Status: constants.StatusClosed}
+	items := []dbmodels.AccessReviewItem{
+		{UserID: 7, RoleID: 1, IsPrimary: true, ReviewerID: 8, Decision: constants.StatusCertified},
+		{UserID: 7, RoleID: 2, ReviewerID: 8, Decision: constants.StatusRevoked, RevokeAction: constants.RevokeReassignRole,
+			ReassignRoleID: null.Int64From(3), Note: "moved teams"},
+	}
+	users := map[int64]*dbmodels.User{
+		7: {ID: 7, FirstName: "Ada", LastName: "Lovelace", Email: "ada@example.com"},
+		8: {ID: 8, FirstName: "Alan", LastName: "Turing"},
+	}
+	roles := map[int64]*dbmodels.Role{1: {ID: 1, Name: "Sales"}, 2: {ID: 2, Name: "Support"}}
+
+	output, err := accessReviewCSV(campaign, items, users, roles)
+	if err != nil {
+		t.Fatalf("export failed: %v", err.Error)
+	}
+	lines := strings.Split(strings.TrimSpace(output), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("got %d lines, want a header and 2 rows", len(lines))
+	}
+	want := `"Q3, 2026",CLOSED,7,Ada Lovelace,ada@example.com,2,Support,false,8,Alan Turing,REVOKED,REASSIGN_ROLE,3,moved teams,,`
+	if lines[2] != want {
+		t.Errorf("got row %s, want %s", lines[2], want)
+	}
+}
@@ -0,0 +1,169 @@
+package orgmaster
+
+import (
+	"context"
+	"fmt"
+	"gogql/app/models"
+	"gogql/app/models/dbmodels"
+	"gogql/app/store/dbstore"
+	"gogql/utils/authtoken"
+	"gogql/utils/encrypt"
+	"gogql/utils/faulterr"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// last used is written at most once per interval for a busy key
+const apiKeyLastUsedInterval = time.Minute
+
+type APIKeyMaster struct {
+	dbstore *dbstore.DBStore
+	hasher  *encrypt.TokenHasher
+}
+
+func NewAPIKeyMaster(s *dbstore.DBStore, hasher *encrypt.TokenHasher) *APIKeyMaster {
+	return &APIKeyMaster{s, hasher}
+}
+
+// Create validates and stores a new api key, the plain key is returned once and only its hash is stored
+func (m *APIKeyMaster) Create(ctx context.Context, tx pgx.Tx, obj dbmodels.APIKey) (*dbmodels.APIKey, string, *faulterr.FaultErr) {
+	obj.Name = strings.TrimSpace(obj.Name)
+	if obj.Name == "" {
+		return nil, "", faulterr.NewBadRequestError("name is required")
+	}
+
+	permissions, err := m.validatePermissions(obj.Permissions)
+	if err != nil {
+		return nil, "", err
+	}
+	allowedIPs, err := m.validateAllowedIPs(obj.AllowedIPs)
+	if err != nil {
+		return nil, "", err
+	}
+	if obj.ExpiresAt.Valid && !obj.ExpiresAt.Time.After(time.Now()) {
+		return nil, "", faulterr.NewBadRequestError("expiry must be in the future")
+	}
+
+	prefix := encrypt.GenerateRandomKey(4)
+	key := fmt.Sprintf("%s%s_%s", authtoken.APIKeyPrefix, prefix, encrypt.GenerateRandomKey(24))
+
+	obj.Prefix = authtoken.APIKeyPrefix + prefix
+	obj.KeyHash = m.hasher.Hash(key)
+	obj.Permissions = permissions
+	obj.AllowedIPs = allowedIPs
+
+	result, err := m.dbstore.APIKeyStore.Insert(ctx, tx, &obj)
+	if err != nil {
+		return nil, "", err
+	}
+	return result, key, nil
+}
+
+// Verify returns the api key of a request, revoked and expired keys and calls from
+// addresses outside of the allowlist are rejected with the same error
+func (m *APIKeyMaster) Verify(ctx context.Context, key string, ipAddress string) (*dbmodels.APIKey, *faulterr.FaultErr) {
+	errMsg := "api key is not valid"
+
+	obj, err := m.dbstore.APIKeyStore.GetByKeyHash(ctx, m.hasher.Hash(key))
+	if err != nil {
+		if err.Status == http.StatusNotFound {
+			return nil, faulterr.NewUnauthorizedError(errMsg)
+		}
+		return nil, err
+	}
+	if obj.IsRevoked {
+		return nil, faulterr.NewUnauthorizedError(errMsg)
+	}
+	if obj.ExpiresAt.Valid && time.Now().After(obj.ExpiresAt.Time) {
+		return nil, faulterr.NewUnauthorizedError(errMsg)
+	}
+	if !m.AllowsIP(obj, ipAddress) {
+		return nil, faulterr.NewUnauthorizedError(errMsg)
+	}
+	return obj, nil
+}
+
+// AllowsIP tells whether a key may be used from the address, keys without an allowlist may be used from anywhere
+func (m *APIKeyMaster) AllowsIP(obj *dbmodels.APIKey, ipAddress string) bool {
+	if len(obj.AllowedIPs) == 0 {
+		return true
+	}
+
+	ip := net.ParseIP(ipAddress)
+	if ip == nil {
+		return false
+	}
+	for _, allowed := range obj.AllowedIPs {
+		if _, network, err := net.ParseCIDR(allowed); err == nil {
+			if network.Contains(ip) {
+				return true
+			}
+		} else if allowedIP := net.ParseIP(allowed); allowedIP != nil && allowedIP.Equal(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// Touch records the use of a key, outside of the request transaction
+func (m *APIKeyMaster) Touch(ctx context.Context, obj *dbmodels.APIKey, ipAddress string) {
+	if obj.LastUsedAt.Valid && time.Since(obj.LastUsedAt.Time) < apiKeyLastUsedInterval && obj.LastUsedIP == ipAddress {
+		return
+	}
+	m.dbstore.APIKeyStore.UpdateLastUsed(ctx, obj.ID, ipAddress)
+}
+
+// Revoke revokes a key, revoked keys are kept so their use stays attributable
+func (m *APIKeyMaster) Revoke(ctx context.Context, tx pgx.Tx, obj *dbmodels.APIKey) (*dbmodels.APIKey, *faulterr.FaultErr) {
+	if obj.IsRevoked {
+		return nil, faulterr.NewBadRequestError("api key is already revoked")
+	}
+	if err := m.dbstore.APIKeyStore.Revoke(ctx, tx, obj.ID); err != nil {
+		return nil, err
+	}
+	obj.IsRevoked = true
+	return obj, nil
+}
+
+// Validators
+
+// validatePermissions verifies the permissions are known and removes duplicates
+func (m *APIKeyMaster) validatePermissions(permissions []string) ([]string, *faulterr.FaultErr) {
+	known := map[string]bool{}
+	for _, perm := range models.ListPermissions() {
+		known[perm] = true
+	}
+
+	result := []string{}
+	seen := map[string]bool{}
+	for _, perm := range permissions {
+		if !known[perm] {
+			return nil, faulterr.NewBadRequestError(fmt.Sprintf("unknown permission %s", perm))
+		}
+		if !seen[perm] {
+			seen[perm] = true
+			result = append(result, perm)
+		}
+	}
+	if len(result) == 0 {
+		return nil, faulterr.NewBadRequestError("at least one permission is required")
+	}
+	return result, nil
+}
+
+// validateAllowedIPs verifies the allowlist holds ip addresses or cidr ranges
+func (m *APIKeyMaster) validateAllowedIPs(allowedIPs []string) ([]string, *faulterr.FaultErr) {
+	result := []string{}
+	for _, allowed := range allowedIPs {
+		allowed = strings.TrimSpace(allowed)
+		if _, _, err := net.ParseCIDR(allowed); err != nil && net.ParseIP(allowed) == nil {
+			return nil, faulterr.NewBadRequestError(fmt.Sprintf("%q is not an ip address or cidr range", allowed))
+		}
+		result = append(result, allowed)
+	}
+	return result, nil
+}
@@ -3,42 +3,193 @@ package orgmaster
 import (
 	"context"
 	"gogql/app/helpers"
+	"gogql/app/models"
+	"gogql/app/models/constants"
 	"gogql/app/models/dbmodels"
 	"gogql/app/store/dbstore"
+	"gogql/config"
+	"gogql/utils/encrypt"
 	"gogql/utils/faulterr"
 	"time"
 
+	"github.com/gofrs/uuid"
 	"github.com/jackc/pgx/v5"
 )
 
+// partial sessions only live long enough to enter the second factor
+const partialSessionTTL = 5 * time.Minute
+
 type AuthSessionMaster struct {
-	dbstore *dbstore.DBStore
+	dbstore  *dbstore.DBStore
+	hasher   *encrypt.TokenHasher
+	security *config.Security
 }
 
-func NewAuthSessionMaster(s *dbstore.DBStore) *AuthSessionMaster {
-	return &AuthSessionMaster{s}
+func NewAuthSessionMaster(s *dbstore.DBStore, hasher *encrypt.TokenHasher, security *config.Security) *AuthSessionMaster {
+	return &AuthSessionMaster{s, hasher, security}
 }
 
-func (m *AuthSessionMaster) Create(ctx context.Context, tx pgx.Tx, userID int64) (*dbmodels.AuthSession, *faulterr.FaultErr) {
-	obj, err := m.construct(userID)
+// Create stores a new auth session with its first refresh token and returns the plain tokens,
+// only the hashes of the tokens are stored, partial sessions get no refresh token
+func (m *AuthSessionMaster) Create(ctx context.Context, tx pgx.Tx, req dbmodels.AuthSessionRequest) (*dbmodels.AuthSession, *models.AuthTokens, *faulterr.FaultErr) {
+	token, err := helpers.GenerateUID()
 	if err != nil {
-		return nil, err
+		return nil, nil, err
+	}
+
+	obj, err := m.dbstore.AuthSessionStore.Insert(ctx, tx, m.construct(req, *token))
+	if err != nil {
+		return nil, nil, err
+	}
+	if obj.AssuranceLevel == models.AssurancePartial {
+		return obj, &models.AuthTokens{AccessToken: *token, ExpiresAt: obj.ExpiresAt}, nil
 	}
-	return m.dbstore.AuthSessionStore.Insert(ctx, tx, obj)
+
+	refreshToken, err := m.issueRefreshToken(ctx, tx, obj)
+	if err != nil {
+		return nil, nil, err
+	}
+	return obj, &models.AuthTokens{AccessToken: *token, RefreshToken: refreshToken, ExpiresAt: obj.ExpiresAt}, nil
 }
 
-func (m *AuthSessionMaster) construct(userID int64) (*dbmodels.AuthSession, *faulterr.FaultErr) {
+// Rotate replaces the session token of a session and issues the next refresh token of its family
+func (m *AuthSessionMaster) Rotate(ctx context.Context, tx pgx.Tx, obj *dbmodels.AuthSession) (*models.AuthTokens, *faulterr.FaultErr) {
 	token, err := helpers.GenerateUID()
 	if err != nil {
 		return nil, err
 	}
 
+	obj.TokenHash = m.hasher.Hash(token.String())
+	obj.ExpiresAt = m.accessExpiry(obj.AbsoluteExpiresAt)
+	obj.LastSeenAt = time.Now()
+	if err := m.dbstore.AuthSessionStore.Update(ctx, tx, obj); err != nil {
+		return nil, err
+	}
+
+	refreshToken, err := m.issueRefreshToken(ctx, tx, obj)
+	if err != nil {
+		return nil, err
+	}
+	return &models.AuthTokens{AccessToken: *token, RefreshToken: refreshToken, ExpiresAt: obj.ExpiresAt}, nil
+}
+
+// Upgrade raises the assurance level of a session and rotates its tokens,
+// so a token seen before the second factor can not be used afterwards
+func (m *AuthSessionMaster) Upgrade(ctx context.Context, tx pgx.Tx, obj *dbmodels.AuthSession, assuranceLevel string) (*models.AuthTokens, *faulterr.FaultErr) {
+	obj.AssuranceLevel = assuranceLevel
+	return m.Rotate(ctx, tx, obj)
+}
+
+// GetByToken gets auth session by its plain token
+func (m *AuthSessionMaster) GetByToken(ctx context.Context, token uuid.UUID) (*dbmodels.AuthSession, *faulterr.FaultErr) {
+	return m.dbstore.AuthSessionStore.GetByTokenHash(ctx, m.TokenHash(token))
+}
+
+// TokenHash returns the hash a session token is stored and cached by
+func (m *AuthSessionMaster) TokenHash(token uuid.UUID) string {
+	return m.hasher.Hash(token.String())
+}
+
+// GetRefreshToken gets refresh token by its plain token
+func (m *AuthSessionMaster) GetRefreshToken(ctx context.Context, token string) (*dbmodels.RefreshToken, *faulterr.FaultErr) {
+	return m.dbstore.RefreshTokenStore.GetByTokenHash(ctx, m.hasher.Hash(token))
+}
+
+// CheckExpiry returns an error if the session token is expired, or the session is idle for too long
+// or past its absolute lifetime
+func (m *AuthSessionMaster) CheckExpiry(obj *dbmodels.AuthSession) *faulterr.FaultErr {
+	if err := m.checkSessionLifetime(obj); err != nil {
+		return err
+	}
+	if time.Now().After(obj.ExpiresAt) {
+		return faulterr.NewUnauthorizedError("session token is expired").WithCode(constants.ErrCodeSessionExpired)
+	}
+	return nil
+}
+
+// CheckRefreshable returns an error if the refresh token can not be used to extend the session
+func (m *AuthSessionMaster) CheckRefreshable(obj *dbmodels.AuthSession, refreshToken *dbmodels.RefreshToken) *faulterr.FaultErr {
+	if !obj.IsValid {
+		return faulterr.NewUnauthorizedError("session is not valid")
+	}
+	if err := m.checkSessionLifetime(obj); err != nil {
+		return err
+	}
+	if time.Now().After(refreshToken.ExpiresAt) {
+		return faulterr.NewUnauthorizedError("refresh token is expired").WithCode(constants.ErrCodeSessionExpired)
+	}
+	return nil
+}
+
+// Helpers
+
+func (m *AuthSessionMaster) construct(req dbmodels.AuthSessionRequest, token uuid.UUID) *dbmodels.AuthSession {
+	absoluteExpiresAt := time.Now().Add(m.security.SessionAbsoluteLifetime)
+	if req.ImpersonatorID.Valid {
+		absoluteExpiresAt = time.Now().Add(m.security.ImpersonationLifetime)
+	}
+
 	obj := &dbmodels.AuthSession{
-		UserID:    userID,
-		Token:     *token,
-		IsValid:   true,
-		ExpiresAt: time.Now().Add(time.Hour * time.Duration(720)), // token will expire after 5 minutes
+		UserID:            req.UserID,
+		TokenHash:         m.hasher.Hash(token.String()),
+		IsValid:           true,
+		ExpiresAt:         m.accessExpiry(absoluteExpiresAt),
+		IPAddress:         req.IPAddress,
+		UserAgent:         req.UserAgent,
+		DeviceLabel:       helpers.StandardizeSpaces(req.DeviceLabel),
+		LastSeenAt:        time.Now(),
+		AbsoluteExpiresAt: absoluteExpiresAt,
+		AssuranceLevel:    req.AssuranceLevel,
+		ImpersonatorID:    req.ImpersonatorID,
 	}
 
-	return obj, nil
+	if obj.AssuranceLevel == "" {
+		obj.AssuranceLevel = models.AssuranceOTP
+	}
+	if obj.AssuranceLevel == models.AssurancePartial {
+		obj.ExpiresAt = time.Now().Add(partialSessionTTL)
+	}
+	if obj.DeviceLabel == "" {
+		obj.DeviceLabel = helpers.DeviceLabel(req.UserAgent)
+	}
+	return obj
+}
+
+func (m *AuthSessionMaster) issueRefreshToken(ctx context.Context, tx pgx.Tx, obj *dbmodels.AuthSession) (string, *faulterr.FaultErr) {
+	token := encrypt.GenerateRandomKey(32)
+
+	// refresh tokens have to be used within the idle timeout
+	expiresAt := time.Now().Add(m.security.SessionIdleTimeout)
+	if expiresAt.After(obj.AbsoluteExpiresAt) {
+		expiresAt = obj.AbsoluteExpiresAt
+	}
+
+	_, err := m.dbstore.RefreshTokenStore.Insert(ctx, tx, &dbmodels.RefreshToken{
+		SessionID: obj.ID,
+		TokenHash: m.hasher.Hash(token),
+		ExpiresAt: expiresAt,
+	})
+	if err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+func (m *AuthSessionMaster) checkSessionLifetime(obj *dbmodels.AuthSession) *faulterr.FaultErr {
+	if time.Now().After(obj.AbsoluteExpiresAt) {
+		return faulterr.NewUnauthorizedError("session is expired").WithCode(constants.ErrCodeSessionExpired)
+	}
+	if time.Since(obj.LastSeenAt) > m.security.SessionIdleTimeout {
+		return faulterr.NewUnauthorizedError("session is expired after inactivity").WithCode(constants.ErrCodeSessionExpired)
+	}
+	return nil
+}
+
+// accessExpiry is the expiry of a new session token, never past the end of the session
+func (m *AuthSessionMaster) accessExpiry(absoluteExpiresAt time.Time) time.Time {
+	expiresAt := time.Now().Add(m.security.AccessTokenTTL)
+	if expiresAt.After(absoluteExpiresAt) {
+		return absoluteExpiresAt
+	}
+	return expiresAt
 }
@@ -0,0 +1,114 @@
+package orgmaster
+
+import (
+	"context"
+	"crypto/subtle"
+	"gogql/app/models"
+	"gogql/app/models/constants"
+	"gogql/app/models/dbmodels"
+	"gogql/app/store/dbstore"
+	"gogql/config"
+	"gogql/utils/encrypt"
+	"gogql/utils/faulterr"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/volatiletech/null"
+)
+
+const (
+	// a contact change is confirmed with the otp within this window
+	contactChangeTTL = 15 * time.Minute
+	// a contact change is closed after this many wrong codes
+	contactChangeMaxAttempts = 3
+)
+
+type ContactChangeMaster struct {
+	dbstore  *dbstore.DBStore
+	hasher   *encrypt.TokenHasher
+	security *config.Security
+}
+
+func NewContactChangeMaster(s *dbstore.DBStore, hasher *encrypt.TokenHasher, security *config.Security) *ContactChangeMaster {
+	return &ContactChangeMaster{s, hasher, security}
+}
+
+// Create stores an open change of the email or phone of a user and returns the plain otp
+// and cancel token, only their hashes are stored
+func (m *ContactChangeMaster) Create(ctx context.Context, tx pgx.Tx, user *dbmodels.User, channel string, newValue string, requestedBy int64) (*dbmodels.ContactChange, string, string, *faulterr.FaultErr) {
+	oldValue := user.Email
+	if channel == models.OTPChannelSMS {
+		oldValue = user.Phone
+	}
+
+	code := encrypt.GenerateRandomString(5)
+	cancelToken := encrypt.GenerateRandomKey(32)
+	obj := &dbmodels.ContactChange{
+		UserID:          user.ID,
+		Channel:         channel,
+		OldValue:        oldValue,
+		NewValue:        newValue,
+		CodeHash:        m.hasher.Hash(code),
+		CancelTokenHash: m.hasher.Hash(cancelToken),
+		Status:          constants.StatusOpen,
+		RequestedBy:     requestedBy,
+		ExpiresAt:       time.Now().Add(contactChangeTTL),
+	}
+
+	result, err := m.dbstore.ContactChangeStore.Insert(ctx, tx, obj)
+	if err != nil {
+		return nil, "", "", err
+	}
+	return result, code, cancelToken, nil
+}
+
+// Confirm checks the otp of an open change and marks it confirmed, wrong codes are counted
+// and the change is closed once too many were tried
+func (m *ContactChangeMaster) Confirm(ctx context.Context, tx pgx.Tx, obj *dbmodels.ContactChange, code string) *faulterr.FaultErr {
+	if obj.Status != constants.StatusOpen || time.Now().After(obj.ExpiresAt) {
+		return faulterr.NewUnauthorizedError("change is invalid or expired")
+	}
+	if obj.FailedAttempts >= contactChangeMaxAttempts {
+		return faulterr.NewTooManyRequestsError("too many failed attempts, request the change again")
+	}
+
+	if subtle.ConstantTimeCompare([]byte(obj.CodeHash), []byte(m.hasher.Hash(code))) != 1 {
+		if err := m.dbstore.ContactChangeStore.IncrementFailedAttempts(ctx, obj.ID); err != nil {
+			return err
+		}
+		return faulterr.NewUnauthorizedError("invalid code")
+	}
+
+	obj.Status = constants.StatusConfirmed
+	obj.ConfirmedAt = null.TimeFrom(time.Now())
+	return m.dbstore.ContactChangeStore.Update(ctx, tx, obj)
+}
+
+// Cancel closes the open change of a cancel token, unknown and closed changes are rejected with the same error
+func (m *ContactChangeMaster) Cancel(ctx context.Context, tx pgx.Tx, cancelToken string) (*dbmodels.ContactChange, *faulterr.FaultErr) {
+	errMsg := "change is invalid or no longer open"
+
+	obj, err := m.dbstore.ContactChangeStore.GetByCancelTokenHash(ctx, m.hasher.Hash(cancelToken))
+	if err != nil {
+		if err.Status == http.StatusNotFound {
+			return nil, faulterr.NewUnauthorizedError(errMsg)
+		}
+		return nil, err
+	}
+	if obj.Status != constants.StatusOpen {
+		return nil, faulterr.NewUnauthorizedError(errMsg)
+	}
+
+	obj.Status = constants.StatusCancelled
+	if err := m.dbstore.ContactChangeStore.Update(ctx, tx, obj); err != nil {
+		return nil, err
+	}
+	return obj, nil
+}
+
+// CancelURL is the link to the cancel page of the frontend for a token
+func (m *ContactChangeMaster) CancelURL(token string) string {
+	return m.security.ContactChangeCancelURL + "?token=" + url.QueryEscape(token)
+}
@@ -0,0 +1,119 @@
+package orgmaster
+
+import (
+	"context"
+	"gogql/app/models/constants"
+	"gogql/app/models/dbmodels"
+	"gogql/app/store/dbstore"
+	"gogql/config"
+	"gogql/utils/encrypt"
+	"gogql/utils/faulterr"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/volatiletech/null"
+)
+
+// an invitation is sent again at most once per interval
+const invitationResendInterval = time.Minute
+
+type InvitationMaster struct {
+	dbstore  *dbstore.DBStore
+	hasher   *encrypt.TokenHasher
+	security *config.Security
+}
+
+func NewInvitationMaster(s *dbstore.DBStore, hasher *encrypt.TokenHasher, security *config.Security) *InvitationMaster {
+	return &InvitationMaster{s, hasher, security}
+}
+
+// Create stores an open invitation of a pending user and returns the plain token,
+// only the hash of the token is stored
+func (m *InvitationMaster) Create(ctx context.Context, tx pgx.Tx, user *dbmodels.User, invitedBy int64) (*dbmodels.Invitation, string, *faulterr.FaultErr) {
+	if !user.OrgUID.Valid {
+		return nil, "", faulterr.NewBadRequestError("organization uid is required")
+	}
+
+	token := encrypt.GenerateRandomKey(32)
+	obj := &dbmodels.Invitation{
+		OrgUID:    user.OrgUID.UUID,
+		UserID:    user.ID,
+		Email:     user.Email,
+		TokenHash: m.hasher.Hash(token),
+		Status:    constants.StatusOpen,
+		InvitedBy: invitedBy,
+		ExpiresAt: time.Now().Add(m.security.InvitationTTL),
+	}
+
+	result, err := m.dbstore.InvitationStore.Insert(ctx, tx, obj)
+	if err != nil {
+		return nil, "", err
+	}
+	return result, token, nil
+}
+
+// Renew replaces the token of an open invitation and restarts its expiry, the previous token stops working
+func (m *InvitationMaster) Renew(ctx context.Context, tx pgx.Tx, obj *dbmodels.Invitation) (string, *faulterr.FaultErr) {
+	if obj.Status != constants.StatusOpen {
+		return "", faulterr.NewBadRequestError("invitation is no longer open")
+	}
+	if time.Since(obj.LastSentAt) < invitationResendInterval {
+		return "", faulterr.NewTooManyRequestsError("invitation was sent recently, try again later")
+	}
+
+	token := encrypt.GenerateRandomKey(32)
+	obj.TokenHash = m.hasher.Hash(token)
+	obj.ExpiresAt = time.Now().Add(m.security.InvitationTTL)
+	obj.SendCount++
+	obj.LastSentAt = time.Now()
+	if err := m.dbstore.InvitationStore.Update(ctx, tx, obj); err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// Revoke closes an open invitation so its token can not be accepted
+func (m *InvitationMaster) Revoke(ctx context.Context, tx pgx.Tx, obj *dbmodels.Invitation) *faulterr.FaultErr {
+	if obj.Status != constants.StatusOpen {
+		return faulterr.NewBadRequestError("invitation is no longer open")
+	}
+
+	obj.Status = constants.StatusRevoked
+	return m.dbstore.InvitationStore.Update(ctx, tx, obj)
+}
+
+// Accept uses the token of an open invitation, revoked, expired and used invitations are rejected with the same error
+func (m *InvitationMaster) Accept(ctx context.Context, tx pgx.Tx, token string) (*dbmodels.Invitation, *faulterr.FaultErr) {
+	errMsg := "invitation is invalid or expired"
+
+	obj, err := m.dbstore.InvitationStore.GetByTokenHash(ctx, m.hasher.Hash(token))
+	if err != nil {
+		if err.Status == http.StatusNotFound {
+			return nil, faulterr.NewUnauthorizedError(errMsg)
+		}
+		return nil, err
+	}
+	if obj.Status != constants.StatusOpen || time.Now().After(obj.ExpiresAt) {
+		return nil, faulterr.NewUnauthorizedError(errMsg)
+	}
+
+	// a concurrent accept may have used the token since it was read
+	accepted, err := m.dbstore.InvitationStore.MarkAccepted(ctx, tx, obj.ID)
+	if err != nil {
+		return nil, err
+	}
+	if !accepted {
+		return nil, faulterr.NewUnauthorizedError(errMsg)
+	}
+
+	obj.Status = constants.StatusAccepted
+	obj.AcceptedAt = null.TimeFrom(time.Now())
+	return obj, nil
+}
+
+// AcceptURL is the link to the accept page of the frontend for a token
+func (m *InvitationMaster) AcceptURL(token string) string {
+	return m.security.InvitationAcceptURL + "?token=" + url.QueryEscape(token)
+}
@@ -0,0 +1,263 @@
+package orgmaster
+
+import (
+	"context"
+	"fmt"
+	"gogql/app/models"
+	"gogql/app/models/constants"
+	"gogql/app/models/dbmodels"
+	"gogql/app/store/dbstore"
+	"gogql/utils/encrypt"
+	"gogql/utils/faulterr"
+	"gogql/utils/oidc"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// how long a user has to complete the sign in at the identity provider
+const oidcLoginTimeout = 10 * time.Minute
+
+type OIDCMaster struct {
+	dbstore      *dbstore.DBStore
+	hasher       *encrypt.TokenHasher
+	box          *encrypt.SecretBox
+	client       *oidc.Client
+	callbackBase string
+}
+
+func NewOIDCMaster(s *dbstore.DBStore, hasher *encrypt.TokenHasher, box *encrypt.SecretBox, client *oidc.Client, callbackBase string) *OIDCMaster {
+	return &OIDCMaster{s, hasher, box, client, callbackBase}
+}
+
+// GetConfig returns the oidc config of an organization, nil if it has none
+func (m *OIDCMaster) GetConfig(ctx context.Context, org *dbmodels.Organization) (*dbmodels.OrganizationOIDCConfig, *faulterr.FaultErr) {
+	obj, err := m.dbstore.OrganizationOIDCConfigStore.GetByOrgUID(ctx, org.UID)
+	if err != nil {
+		if err.Status == http.StatusNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return obj, nil
+}
+
+// UpdateConfig validates and stores the oidc config of an organization, the issuer has to serve a discovery document
+func (m *OIDCMaster) UpdateConfig(ctx context.Context, tx pgx.Tx, org *dbmodels.Organization, req dbmodels.OIDCConfigRequest) (*dbmodels.OrganizationOIDCConfig, *faulterr.FaultErr) {
+	obj, err := m.GetConfig(ctx, org)
+	if err != nil {
+		return nil, err
+	}
+	if obj == nil {
+		obj = &dbmodels.OrganizationOIDCConfig{OrgUID: org.UID}
+	}
+
+	issuer := strings.TrimSuffix(strings.TrimSpace(req.Issuer), "/")
+	if u, perr := url.Parse(issuer); perr != nil || u.Host == "" || (u.Scheme != "https" && u.Scheme != "http") {
+		return nil, faulterr.NewBadRequestError("issuer must be an absolute url")
+	}
+	if strings.TrimSpace(req.ClientID) == "" {
+		return nil, faulterr.NewBadRequestError("client id is required")
+	}
+
+	domains := []string{}
+	for _, domain := range req.AllowedDomains {
+		domain = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(domain), "@"))
+		if domain == "" || strings.ContainsAny(domain, "@/ ") {
+			return nil, faulterr.NewBadRequestError(fmt.Sprintf("%q is not a valid email domain", domain))
+		}
+		domains = append(domains, domain)
+	}
+	if req.IsEnabled && len(domains) == 0 {
+		return nil, faulterr.NewBadRequestError("at least one allowed email domain is required")
+	}
+
+	if req.JITProvisioning {
+		if !req.DefaultRoleID.Valid {
+			return nil, faulterr.NewBadRequestError("default role is required for just-in-time provisioning")
+		}
+	}
+	if req.DefaultRoleID.Valid {
+		role, err := m.dbstore.RoleStore.GetByID(ctx, req.DefaultRoleID.Int64)
+		if err != nil {
+			if err.Status == http.StatusNotFound {
+				return nil, faulterr.NewBadRequestError("default role not found")
+			}
+			return nil, err
+		}
+		if role.OrgUID != org.UID {
+			return nil, faulterr.NewBadRequestError("default role not found")
+		}
+		if role.IsManagement {
+			return nil, faulterr.NewBadRequestError("default role cannot be a management role")
+		}
+	}
+
+	if req.IsEnabled {
+		if _, derr := m.client.Discover(ctx, issuer); derr != nil {
+			return nil, faulterr.NewBadRequestError("issuer does not serve an openid configuration")
+		}
+	}
+
+	obj.Issuer = issuer
+	obj.ClientID = strings.TrimSpace(req.ClientID)
+	if req.ClientSecret.Valid {
+		obj.ClientSecret = ""
+		if req.ClientSecret.String != "" {
+			obj.ClientSecret = m.box.Seal(req.ClientSecret.String)
+		}
+	}
+	obj.AllowedDomains = domains
+	obj.IsEnabled = req.IsEnabled
+	obj.JITProvisioning = req.JITProvisioning
+	obj.DefaultRoleID = req.DefaultRoleID
+
+	return m.dbstore.OrganizationOIDCConfigStore.Upsert(ctx, tx, obj)
+}
+
+// View returns the config as shown to admins, without the client secret
+func (m *OIDCMaster) View(org *dbmodels.Organization, obj *dbmodels.OrganizationOIDCConfig) *models.OIDCConfig {
+	result := &models.OIDCConfig{
+		OrgUID:         org.UID,
+		AllowedDomains: []string{},
+		RedirectURI:    m.RedirectURI(org),
+	}
+	if obj != nil {
+		result.ID = obj.ID
+		result.Issuer = obj.Issuer
+		result.ClientID = obj.ClientID
+		result.HasClientSecret = obj.ClientSecret != ""
+		result.AllowedDomains = obj.AllowedDomains
+		result.IsEnabled = obj.IsEnabled
+		result.JITProvisioning = obj.JITProvisioning
+		result.DefaultRoleID = obj.DefaultRoleID
+		result.UpdatedAt = obj.UpdatedAt
+	}
+	return result
+}
+
+// BeginLogin stores a login state with a pkce verifier and returns the authorization url of the provider
+func (m *OIDCMaster) BeginLogin(ctx context.Context, tx pgx.Tx, org *dbmodels.Organization, obj *dbmodels.OrganizationOIDCConfig, ipAddress string) (string, *faulterr.FaultErr) {
+	provider, derr := m.client.Discover(ctx, obj.Issuer)
+	if derr != nil {
+		return "", faulterr.NewInternalServerError("identity provider is not reachable")
+	}
+
+	state, serr := oidc.NewState()
+	nonce, nerr := oidc.NewState()
+	verifier, challenge, perr := oidc.NewPKCE()
+	if serr != nil || nerr != nil || perr != nil {
+		return "", faulterr.NewInternalServerError("error when trying to generate oidc state")
+	}
+
+	_, err := m.dbstore.OIDCLoginStateStore.Insert(ctx, tx, &dbmodels.OIDCLoginState{
+		OrgUID:       org.UID,
+		StateHash:    m.hasher.Hash(state),
+		Nonce:        m.box.Seal(nonce),
+		CodeVerifier: m.box.Seal(verifier),
+		IPAddress:    ipAddress,
+		ExpiresAt:    time.Now().Add(oidcLoginTimeout),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return oidc.AuthCodeURL(provider, obj.ClientID, m.RedirectURI(org), state, nonce, challenge), nil
+}
+
+// CompleteLogin uses the login state, redeems the authorization code and returns the verified id token claims
+func (m *OIDCMaster) CompleteLogin(ctx context.Context, tx pgx.Tx, org *dbmodels.Organization, obj *dbmodels.OrganizationOIDCConfig, state string, code string) (*oidc.Claims, *faulterr.FaultErr) {
+	loginState, err := m.useState(ctx, tx, org, state)
+	if err != nil {
+		return nil, err
+	}
+	nonce, oerr := m.box.Open(loginState.Nonce)
+	verifier, verr := m.box.Open(loginState.CodeVerifier)
+	if oerr != nil || verr != nil {
+		return nil, invalidSSOError()
+	}
+
+	clientSecret := ""
+	if obj.ClientSecret != "" {
+		secret, serr := m.box.Open(obj.ClientSecret)
+		if serr != nil {
+			return nil, faulterr.NewInternalServerError("error when trying to open oidc client secret")
+		}
+		clientSecret = secret
+	}
+
+	provider, derr := m.client.Discover(ctx, obj.Issuer)
+	if derr != nil {
+		return nil, faulterr.NewInternalServerError("identity provider is not reachable")
+	}
+
+	tokens, xerr := m.client.Exchange(ctx, provider, obj.ClientID, clientSecret, m.RedirectURI(org), code, verifier)
+	if xerr != nil {
+		return nil, invalidSSOError()
+	}
+
+	claims, cerr := m.client.VerifyIDToken(ctx, provider, obj.ClientID, tokens.IDToken, nonce)
+	if cerr != nil {
+		return nil, invalidSSOError()
+	}
+	return claims, nil
+}
+
+// EmailAllowed tells whether the email belongs to one of the allowed domains of the config
+func (m *OIDCMaster) EmailAllowed(obj *dbmodels.OrganizationOIDCConfig, email string) bool {
+	at := strings.LastIndex(email, "@")
+	if at < 1 {
+		return false
+	}
+	domain := strings.ToLower(email[at+1:])
+	for _, allowed := range obj.AllowedDomains {
+		if domain == allowed {
+			return true
+		}
+	}
+	return false
+}
+
+// RedirectURI is the callback url registered at the identity provider of the organization
+func (m *OIDCMaster) RedirectURI(org *dbmodels.Organization) string {
+	return fmt.Sprintf("%s/api/auth/oidc/%s/callback", m.callbackBase, url.PathEscape(org.Code))
+}
+
+// Helpers
+
+// useState finds the login state of the organization and marks it used, a state is good for one callback
+func (m *OIDCMaster) useState(ctx context.Context, tx pgx.Tx, org *dbmodels.Organization, state string) (*dbmodels.OIDCLoginState, *faulterr.FaultErr) {
+	if state == "" {
+		return nil, invalidSSOError()
+	}
+
+	obj, err := m.dbstore.OIDCLoginStateStore.GetByStateHash(ctx, m.hasher.Hash(state))
+	if err != nil {
+		if err.Status == http.StatusNotFound {
+			return nil, invalidSSOError()
+		}
+		return nil, err
+	}
+	if obj.OrgUID != org.UID {
+		return nil, invalidSSOError()
+	}
+	if obj.IsUsed || time.Now().After(obj.ExpiresAt) {
+		return nil, faulterr.NewUnauthorizedError("sign in request is expired").WithCode(constants.ErrCodeInvalidSSO)
+	}
+
+	marked, err := m.dbstore.OIDCLoginStateStore.MarkUsed(ctx, tx, obj.ID)
+	if err != nil {
+		return nil, err
+	}
+	if !marked {
+		return nil, faulterr.NewUnauthorizedError("sign in request is expired").WithCode(constants.ErrCodeInvalidSSO)
+	}
+	return obj, nil
+}
+
+func invalidSSOError() *faulterr.FaultErr {
+	return faulterr.NewUnauthorizedError("single sign-on failed").WithCode(constants.ErrCodeInvalidSSO)
+}
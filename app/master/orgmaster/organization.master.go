@@ -7,17 +7,20 @@ import (
 	"gogql/app/models/dbmodels"
 	"gogql/app/store/dbstore"
 	"gogql/utils/faulterr"
+	"gogql/utils/phone"
+	"strings"
 
 	"github.com/gofrs/uuid"
 	"github.com/jackc/pgx/v5"
 )
 
 type OrganizationMaster struct {
-	dbstore *dbstore.DBStore
+	dbstore            *dbstore.DBStore
+	defaultPhoneRegion string
 }
 
-func NewOrganizationMaster(dbstore *dbstore.DBStore) *OrganizationMaster {
-	return &OrganizationMaster{dbstore}
+func NewOrganizationMaster(dbstore *dbstore.DBStore, defaultPhoneRegion string) *OrganizationMaster {
+	return &OrganizationMaster{dbstore, defaultPhoneRegion}
 }
 
 func (m *OrganizationMaster) VerifyOrganizationExists(ctx context.Context, uid uuid.UUID) (*dbmodels.Organization, *faulterr.FaultErr) {
@@ -85,19 +88,35 @@ func (m *OrganizationMaster) BulkCreate(ctx context.Context, tx pgx.Tx, requests
 }
 
 func (m *OrganizationMaster) construct(r dbmodels.OrganizationRequest) *dbmodels.Organization {
-	return &dbmodels.Organization{
-		Name:       r.Name,
-		Website:    r.Website,
-		Logo:       r.Logo,
-		Sector:     r.Sector,
-		IsFinal:    true,
-		IsArchived: false,
+	obj := &dbmodels.Organization{
+		Name:        r.Name,
+		Website:     r.Website,
+		Logo:        r.Logo,
+		Sector:      r.Sector,
+		IsFinal:     true,
+		IsArchived:  false,
+		PhoneRegion: m.defaultPhoneRegion,
 	}
+	if r.PhoneRegion.Valid && r.PhoneRegion.String != "" {
+		obj.PhoneRegion = strings.ToUpper(r.PhoneRegion.String)
+	}
+	return obj
 }
 
 func (m *OrganizationMaster) validate(r dbmodels.OrganizationRequest) *faulterr.FaultErr {
 	if r.Name == "" {
 		return faulterr.NewBadRequestError("Organization Name is required")
 	}
+	if r.PhoneRegion.Valid && r.PhoneRegion.String != "" {
+		return m.ValidatePhoneRegion(r.PhoneRegion.String)
+	}
+	return nil
+}
+
+// ValidatePhoneRegion verifies that phone numbers of a region can be normalized
+func (m *OrganizationMaster) ValidatePhoneRegion(region string) *faulterr.FaultErr {
+	if !phone.ValidRegion(region) {
+		return faulterr.NewBadRequestError("phone region is not supported")
+	}
 	return nil
 }
@@ -13,20 +13,34 @@ import (
 
 type OTPSessionMaster struct {
 	dbstore *dbstore.DBStore
+	hasher  *encrypt.TokenHasher
 }
 
-func NewOTPSessionMaster(s *dbstore.DBStore) *OTPSessionMaster {
-	return &OTPSessionMaster{s}
+func NewOTPSessionMaster(s *dbstore.DBStore, hasher *encrypt.TokenHasher) *OTPSessionMaster {
+	return &OTPSessionMaster{s, hasher}
 }
 
-func (m *OTPSessionMaster) Create(ctx context.Context, tx pgx.Tx, userID int64) (*dbmodels.OTPSession, *faulterr.FaultErr) {
-	return m.dbstore.OTPSessionStore.Insert(ctx, tx, m.construct(userID))
+// Create stores a new otp session and returns it with the plain code, only the hash of the code is stored
+func (m *OTPSessionMaster) Create(ctx context.Context, tx pgx.Tx, userID int64, ipAddress string) (*dbmodels.OTPSession, string, *faulterr.FaultErr) {
+	code := encrypt.GenerateRandomString(5)
+
+	obj, err := m.dbstore.OTPSessionStore.Insert(ctx, tx, m.construct(userID, ipAddress, code))
+	if err != nil {
+		return nil, "", err
+	}
+	return obj, code, nil
+}
+
+// GetByUserIDAndCode gets the otp session issued to the user with the given plain code
+func (m *OTPSessionMaster) GetByUserIDAndCode(ctx context.Context, userID int64, code string) (*dbmodels.OTPSession, *faulterr.FaultErr) {
+	return m.dbstore.OTPSessionStore.GetByUserIDAndTokenHash(ctx, userID, m.hasher.Hash(code))
 }
 
-func (m *OTPSessionMaster) construct(userID int64) *dbmodels.OTPSession {
+func (m *OTPSessionMaster) construct(userID int64, ipAddress string, code string) *dbmodels.OTPSession {
 	return &dbmodels.OTPSession{
 		UserID:    userID,
-		Token:     encrypt.GenerateRandomString(5),
+		IPAddress: ipAddress,
+		TokenHash: m.hasher.Hash(code),
 		IsValid:   true,
 		ExpiresAt: time.Now().Add(time.Minute * time.Duration(5)), // token will expire after 5 minutes
 	}
@@ -0,0 +1,275 @@
+package orgmaster
+
+import (
+	"context"
+	"fmt"
+	"gogql/app/models"
+	"gogql/app/models/constants"
+	"gogql/app/models/dbmodels"
+	"gogql/app/store/dbstore"
+	"gogql/utils/encrypt"
+	"gogql/utils/faulterr"
+	"gogql/utils/webauthn"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/volatiletech/null"
+)
+
+// ceremonies a webauthn challenge is issued for
+const (
+	ceremonyRegister string = "REGISTER"
+	ceremonyLogin    string = "LOGIN"
+)
+
+type PasskeyMaster struct {
+	dbstore *dbstore.DBStore
+	hasher  *encrypt.TokenHasher
+	rp      *webauthn.RelyingParty
+}
+
+func NewPasskeyMaster(s *dbstore.DBStore, hasher *encrypt.TokenHasher, rp *webauthn.RelyingParty) *PasskeyMaster {
+	return &PasskeyMaster{s, hasher, rp}
+}
+
+// BeginRegistration issues a registration challenge for the user and returns the creation options
+func (m *PasskeyMaster) BeginRegistration(ctx context.Context, tx pgx.Tx, user *dbmodels.User, ipAddress string) (*webauthn.CreationOptions, *faulterr.FaultErr) {
+	challenge, err := m.issueChallenge(ctx, tx, ceremonyRegister, null.Int64From(user.ID), ipAddress)
+	if err != nil {
+		return nil, err
+	}
+
+	existing, err := m.credentialIDs(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	account := user.Email
+	if account == "" {
+		account = user.Phone
+	}
+	userEntity := webauthn.UserEntity{
+		ID:          m.UserHandle(user.ID),
+		Name:        account,
+		DisplayName: strings.TrimSpace(fmt.Sprintf("%s %s", user.FirstName, user.LastName)),
+	}
+	return m.rp.CreationOptions(challenge, userEntity, existing), nil
+}
+
+// UseRegistrationChallenge marks the challenge a registration response answers as used
+func (m *PasskeyMaster) UseRegistrationChallenge(ctx context.Context, tx pgx.Tx, req *models.PasskeyRegistration) (*dbmodels.WebAuthnChallenge, *faulterr.FaultErr) {
+	clientDataJSON, err := decodeField(req.ClientDataJSON, "client data")
+	if err != nil {
+		return nil, err
+	}
+	return m.useChallenge(ctx, tx, ceremonyRegister, clientDataJSON)
+}
+
+// FinishRegistration verifies a registration response of the user against its used challenge
+// and stores the new passkey
+func (m *PasskeyMaster) FinishRegistration(ctx context.Context, tx pgx.Tx, user *dbmodels.User, challenge *dbmodels.WebAuthnChallenge, req *models.PasskeyRegistration) (*dbmodels.WebAuthnCredential, *faulterr.FaultErr) {
+	clientDataJSON, err := decodeField(req.ClientDataJSON, "client data")
+	if err != nil {
+		return nil, err
+	}
+	attestationObject, err := decodeField(req.AttestationObject, "attestation object")
+	if err != nil {
+		return nil, err
+	}
+
+	if !challenge.UserID.Valid || challenge.UserID.Int64 != user.ID {
+		return nil, invalidPasskeyError()
+	}
+
+	credential, verr := m.rp.VerifyRegistration(clientDataJSON, attestationObject, m.challengeOf(clientDataJSON))
+	if verr != nil {
+		return nil, invalidPasskeyError()
+	}
+
+	credentialID := webauthn.Encoding.EncodeToString(credential.ID)
+	if _, err := m.dbstore.WebAuthnCredentialStore.GetByCredentialID(ctx, credentialID); err == nil {
+		return nil, faulterr.NewBadRequestError("passkey is already registered")
+	} else if err.Status != http.StatusNotFound {
+		return nil, err
+	}
+
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		name = "Passkey"
+	}
+	return m.dbstore.WebAuthnCredentialStore.Insert(ctx, tx, &dbmodels.WebAuthnCredential{
+		UserID:       user.ID,
+		CredentialID: credentialID,
+		PublicKey:    credential.PublicKey,
+		Algorithm:    credential.Algorithm,
+		SignCount:    int64(credential.SignCount),
+		Name:         name,
+	})
+}
+
+// BeginLogin issues a login challenge, when the user is known its passkeys are offered,
+// otherwise the authenticator offers its discoverable passkeys
+func (m *PasskeyMaster) BeginLogin(ctx context.Context, tx pgx.Tx, user *dbmodels.User, ipAddress string) (*webauthn.RequestOptions, *faulterr.FaultErr) {
+	userID := null.Int64{}
+	allowed := [][]byte{}
+	if user != nil {
+		userID = null.Int64From(user.ID)
+
+		ids, err := m.credentialIDs(ctx, user.ID)
+		if err != nil {
+			return nil, err
+		}
+		allowed = ids
+	}
+
+	challenge, err := m.issueChallenge(ctx, tx, ceremonyLogin, userID, ipAddress)
+	if err != nil {
+		return nil, err
+	}
+	return m.rp.RequestOptions(challenge, allowed), nil
+}
+
+// UseLoginChallenge marks the challenge an assertion answers as used
+func (m *PasskeyMaster) UseLoginChallenge(ctx context.Context, tx pgx.Tx, req *models.PasskeyAssertion) (*dbmodels.WebAuthnChallenge, *faulterr.FaultErr) {
+	clientDataJSON, err := decodeField(req.ClientDataJSON, "client data")
+	if err != nil {
+		return nil, err
+	}
+	return m.useChallenge(ctx, tx, ceremonyLogin, clientDataJSON)
+}
+
+// VerifyLogin verifies an assertion made with the passkey against its used login challenge
+func (m *PasskeyMaster) VerifyLogin(obj *dbmodels.WebAuthnCredential, challenge *dbmodels.WebAuthnChallenge, req *models.PasskeyAssertion) (*webauthn.AuthenticatorData, *faulterr.FaultErr) {
+	clientDataJSON, err := decodeField(req.ClientDataJSON, "client data")
+	if err != nil {
+		return nil, err
+	}
+	authData, err := decodeField(req.AuthenticatorData, "authenticator data")
+	if err != nil {
+		return nil, err
+	}
+	signature, err := decodeField(req.Signature, "signature")
+	if err != nil {
+		return nil, err
+	}
+
+	if challenge.UserID.Valid && challenge.UserID.Int64 != obj.UserID {
+		return nil, invalidPasskeyError()
+	}
+	if req.UserHandle != "" && req.UserHandle != m.UserHandle(obj.UserID) {
+		return nil, invalidPasskeyError()
+	}
+
+	result, verr := m.rp.VerifyAssertion(clientDataJSON, authData, signature, m.challengeOf(clientDataJSON), obj.PublicKey)
+	if verr != nil {
+		return nil, invalidPasskeyError()
+	}
+	return result, nil
+}
+
+// CheckSignCount tells whether the sign count of an assertion moved forward,
+// a count that did not suggests the authenticator was cloned
+func (m *PasskeyMaster) CheckSignCount(obj *dbmodels.WebAuthnCredential, authData *webauthn.AuthenticatorData) bool {
+	return webauthn.SignCountValid(uint32(obj.SignCount), authData.SignCount)
+}
+
+// RecordUse stores the sign count and the time of a successful login
+func (m *PasskeyMaster) RecordUse(ctx context.Context, tx pgx.Tx, obj *dbmodels.WebAuthnCredential, authData *webauthn.AuthenticatorData) *faulterr.FaultErr {
+	obj.SignCount = int64(authData.SignCount)
+	obj.LastUsedAt = null.TimeFrom(time.Now())
+	return m.dbstore.WebAuthnCredentialStore.Update(ctx, tx, obj)
+}
+
+// UserHandle is the opaque user id given to authenticators, it does not reveal the user id
+func (m *PasskeyMaster) UserHandle(userID int64) string {
+	return webauthn.Encoding.EncodeToString([]byte(m.hasher.Hash(fmt.Sprintf("webauthn-user-%d", userID))[:32]))
+}
+
+// Helpers
+
+func (m *PasskeyMaster) issueChallenge(ctx context.Context, tx pgx.Tx, ceremony string, userID null.Int64, ipAddress string) (string, *faulterr.FaultErr) {
+	challenge, err := webauthn.NewChallenge()
+	if err != nil {
+		return "", faulterr.NewInternalServerError("error when trying to generate webauthn challenge")
+	}
+
+	_, ferr := m.dbstore.WebAuthnChallengeStore.Insert(ctx, tx, &dbmodels.WebAuthnChallenge{
+		UserID:        userID,
+		Ceremony:      ceremony,
+		ChallengeHash: m.hasher.Hash(challenge),
+		IPAddress:     ipAddress,
+		ExpiresAt:     time.Now().Add(webauthn.Timeout),
+	})
+	if ferr != nil {
+		return "", ferr
+	}
+	return challenge, nil
+}
+
+// useChallenge finds the challenge named in the client data and marks it used, a challenge is good for one
+// ceremony whether or not its response verifies, so callers use it up in a transaction of its own
+func (m *PasskeyMaster) useChallenge(ctx context.Context, tx pgx.Tx, ceremony string, clientDataJSON []byte) (*dbmodels.WebAuthnChallenge, *faulterr.FaultErr) {
+	challenge := m.challengeOf(clientDataJSON)
+	if challenge == "" {
+		return nil, invalidPasskeyError()
+	}
+
+	obj, err := m.dbstore.WebAuthnChallengeStore.GetByChallengeHash(ctx, ceremony, m.hasher.Hash(challenge))
+	if err != nil {
+		if err.Status == http.StatusNotFound {
+			return nil, invalidPasskeyError()
+		}
+		return nil, err
+	}
+	if obj.IsUsed || time.Now().After(obj.ExpiresAt) {
+		return nil, faulterr.NewUnauthorizedError("passkey challenge is expired").WithCode(constants.ErrCodeInvalidPasskey)
+	}
+
+	marked, err := m.dbstore.WebAuthnChallengeStore.MarkUsed(ctx, tx, obj.ID)
+	if err != nil {
+		return nil, err
+	}
+	if !marked {
+		return nil, faulterr.NewUnauthorizedError("passkey challenge is expired").WithCode(constants.ErrCodeInvalidPasskey)
+	}
+	return obj, nil
+}
+
+func (m *PasskeyMaster) challengeOf(clientDataJSON []byte) string {
+	clientData, err := webauthn.ParseClientData(clientDataJSON)
+	if err != nil {
+		return ""
+	}
+	return clientData.Challenge
+}
+
+func (m *PasskeyMaster) credentialIDs(ctx context.Context, userID int64) ([][]byte, *faulterr.FaultErr) {
+	objs, err := m.dbstore.WebAuthnCredentialStore.ListByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := [][]byte{}
+	for _, obj := range objs {
+		id, derr := webauthn.Encoding.DecodeString(obj.CredentialID)
+		if derr == nil {
+			result = append(result, id)
+		}
+	}
+	return result, nil
+}
+
+// decodeField decodes a base64url field of a ceremony response, padding is tolerated
+func decodeField(value string, name string) ([]byte, *faulterr.FaultErr) {
+	b, err := webauthn.Encoding.DecodeString(strings.TrimRight(value, "="))
+	if err != nil || len(b) == 0 {
+		return nil, faulterr.NewBadRequestError(fmt.Sprintf("%s is not valid base64url", name))
+	}
+	return b, nil
+}
+
+func invalidPasskeyError() *faulterr.FaultErr {
+	return faulterr.NewUnauthorizedError("passkey is invalid").WithCode(constants.ErrCodeInvalidPasskey)
+}
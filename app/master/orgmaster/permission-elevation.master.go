@@ -0,0 +1,105 @@
+package orgmaster
+
+import (
+	"context"
+	"fmt"
+	"gogql/app/models"
+	"gogql/app/models/constants"
+	"gogql/app/models/dbmodels"
+	"gogql/app/store/dbstore"
+	"gogql/utils/faulterr"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/volatiletech/null"
+)
+
+// an elevation is granted for this long at most, longer access belongs in a role
+const permissionElevationMaxDuration = 7 * 24 * time.Hour
+
+type PermissionElevationMaster struct {
+	dbstore *dbstore.DBStore
+}
+
+func NewPermissionElevationMaster(s *dbstore.DBStore) *PermissionElevationMaster {
+	return &PermissionElevationMaster{s}
+}
+
+// Create stores an elevation of a user, it is approved right away when an approver is given
+// and pending otherwise
+func (m *PermissionElevationMaster) Create(ctx context.Context, tx pgx.Tx, user *dbmodels.User, req dbmodels.PermissionElevationRequest, requestedBy int64, approvedBy null.Int64) (*dbmodels.PermissionElevation, *faulterr.FaultErr) {
+	if err := m.validate(req); err != nil {
+		return nil, err
+	}
+	if !user.OrgUID.Valid {
+		return nil, faulterr.NewBadRequestError("user is not a member of an organization")
+	}
+
+	obj := &dbmodels.PermissionElevation{
+		OrgUID:        user.OrgUID.UUID,
+		UserID:        user.ID,
+		Permission:    req.Permission,
+		Justification: strings.TrimSpace(req.Justification),
+		Status:        constants.StatusPending,
+		StartsAt:      time.Now(),
+		EndsAt:        req.EndsAt,
+		RequestedBy:   requestedBy,
+	}
+	if req.StartsAt.Valid {
+		obj.StartsAt = req.StartsAt.Time
+	}
+	if approvedBy.Valid {
+		obj.Status = constants.StatusApproved
+		obj.DecidedBy = approvedBy
+		obj.DecidedAt = null.TimeFrom(time.Now())
+	}
+
+	return m.dbstore.PermissionElevationStore.Insert(ctx, tx, obj)
+}
+
+// Decide approves or denies a pending elevation, elevations that already ended can not be approved
+func (m *PermissionElevationMaster) Decide(ctx context.Context, tx pgx.Tx, obj *dbmodels.PermissionElevation, status string, decidedBy int64) *faulterr.FaultErr {
+	if obj.Status != constants.StatusPending {
+		return faulterr.NewBadRequestError("elevation is not pending")
+	}
+	if status == constants.StatusApproved && !obj.EndsAt.After(time.Now()) {
+		return faulterr.NewBadRequestError("elevation already ended")
+	}
+
+	obj.Status = status
+	obj.DecidedBy = null.Int64From(decidedBy)
+	obj.DecidedAt = null.TimeFrom(time.Now())
+	return m.dbstore.PermissionElevationStore.Update(ctx, tx, obj)
+}
+
+// Revoke ends a pending or approved elevation before its end
+func (m *PermissionElevationMaster) Revoke(ctx context.Context, tx pgx.Tx, obj *dbmodels.PermissionElevation) *faulterr.FaultErr {
+	if obj.Status != constants.StatusPending && obj.Status != constants.StatusApproved {
+		return faulterr.NewBadRequestError("elevation is already closed")
+	}
+
+	obj.Status = constants.StatusRevoked
+	return m.dbstore.PermissionElevationStore.Update(ctx, tx, obj)
+}
+
+func (m *PermissionElevationMaster) validate(req dbmodels.PermissionElevationRequest) *faulterr.FaultErr {
+	if !models.IsPermission(req.Permission) {
+		return faulterr.NewBadRequestError(fmt.Sprintf("unknown permission %s", req.Permission))
+	}
+	if strings.TrimSpace(req.Justification) == "" {
+		return faulterr.NewBadRequestError("justification is required")
+	}
+
+	startsAt := time.Now()
+	if req.StartsAt.Valid && req.StartsAt.Time.After(startsAt) {
+		startsAt = req.StartsAt.Time
+	}
+	if !req.EndsAt.After(startsAt) {
+		return faulterr.NewBadRequestError("elevation has to end after it starts")
+	}
+	if req.EndsAt.Sub(startsAt) > permissionElevationMaxDuration {
+		return faulterr.NewBadRequestError(fmt.Sprintf("elevation can last %d days at most", int(permissionElevationMaxDuration.Hours()/24)))
+	}
+	return nil
+}
@@ -0,0 +1,101 @@
+package orgmaster
+
+import (
+	"context"
+	"fmt"
+	"gogql/app/models"
+	"gogql/app/models/dbmodels"
+	"gogql/app/store/dbstore"
+	"gogql/utils/faulterr"
+
+	"github.com/gofrs/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+type PermissionMaster struct {
+	dbstore *dbstore.DBStore
+}
+
+func NewPermissionMaster(s *dbstore.DBStore) *PermissionMaster {
+	return &PermissionMaster{s}
+}
+
+// Sync writes the permission registry into the permissions table and marks the permissions
+// missing from it removed, roles, api keys, separation rules and elevations of a renamed
+// permission are migrated
+func (m *PermissionMaster) Sync(ctx context.Context, tx pgx.Tx) *faulterr.FaultErr {
+	for _, def := range models.PermissionRegistry {
+		arg := m.construct(def)
+		if _, err := m.dbstore.PermissionStore.Upsert(ctx, tx, arg); err != nil {
+			return err
+		}
+	}
+	if _, err := m.dbstore.PermissionStore.MarkRemovedExcept(ctx, tx, models.ListPermissions()); err != nil {
+		return err
+	}
+
+	for from, to := range models.RenamedPermissions {
+		if _, err := m.Rename(ctx, tx, from, to); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Rename replaces a permission in every role, api key, separation rule and elevation of it and
+// returns how many were changed, the new name has to be in the registry
+func (m *PermissionMaster) Rename(ctx context.Context, tx pgx.Tx, from string, to string) (int64, *faulterr.FaultErr) {
+	if from == "" || from == to {
+		return 0, faulterr.NewBadRequestError("permission to rename is invalid")
+	}
+	if !models.IsPermission(to) {
+		return 0, faulterr.NewBadRequestError(fmt.Sprintf("unknown permission %s", to))
+	}
+
+	roles, err := m.dbstore.RoleStore.RenamePermission(ctx, tx, from, to)
+	if err != nil {
+		return 0, err
+	}
+	keys, err := m.dbstore.APIKeyStore.RenamePermission(ctx, tx, from, to)
+	if err != nil {
+		return 0, err
+	}
+	rules, err := m.dbstore.SeparationRuleStore.RenamePermission(ctx, tx, from, to)
+	if err != nil {
+		return 0, err
+	}
+	elevations, err := m.dbstore.PermissionElevationStore.RenamePermission(ctx, tx, from, to)
+	if err != nil {
+		return 0, err
+	}
+	return roles + keys + rules + elevations, nil
+}
+
+// UnknownPermissionRoles reports the roles granting permissions that are not in the registry,
+// a nil orgUID reports the roles of every organization
+func (m *PermissionMaster) UnknownPermissionRoles(ctx context.Context, orgUID *uuid.UUID) ([]models.RolePermissionIssue, *faulterr.FaultErr) {
+	roles, err := m.dbstore.RoleStore.ListWithUnknownPermissions(ctx, models.ListPermissions(), orgUID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := []models.RolePermissionIssue{}
+	for _, role := range roles {
+		issue := models.RolePermissionIssue{Role: role, UnknownPermissions: []string{}}
+		for _, perm := range role.Permissions {
+			if !models.IsPermission(perm) {
+				issue.UnknownPermissions = append(issue.UnknownPermissions, perm)
+			}
+		}
+		result = append(result, issue)
+	}
+	return result, nil
+}
+
+func (m *PermissionMaster) construct(def models.PermissionDefinition) *dbmodels.Permission {
+	return &dbmodels.Permission{
+		Name:        def.Name,
+		Group:       def.Group,
+		Description: def.Description,
+	}
+}
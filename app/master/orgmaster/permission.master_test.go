@@ -0,0 +1,72 @@
+package orgmaster
+
+import (
+	"context"
+	"gogql/app/models"
+	"gogql/app/store/dbstore"
+	"gogql/app/store/dbstore/orgstore"
+	"strings"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// renameTx records the statements run in it, every update changes one row and queries return none
+type renameTx struct {
+	pgx.Tx
+	statements []string
+}
+
+func (t *renameTx) Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error) {
+	t.statements = append(t.statements, sql)
+	return pgconn.NewCommandTag("UPDATE 1"), nil
+}
+
+func (t *renameTx) Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+	t.statements = append(t.statements, sql)
+	return &emptyRows{}, nil
+}
+
+type emptyRows struct {
+	pgx.Rows
+}
+
+func (r *emptyRows) Next() bool { return false }
+func (r *emptyRows) Close()     {}
+func (r *emptyRows) Err() error { return nil }
+
+func TestPermissionRename(t *testing.T) {
+	dbs := &dbstore.DBStore{
+		RoleStore:                orgstore.NewRoleStore(nil),
+		APIKeyStore:              orgstore.NewAPIKeyStore(nil),
+		SeparationRuleStore:      orgstore.NewSeparationRuleStore(nil),
+		PermissionElevationStore: orgstore.NewPermissionElevationStore(nil),
+	}
+	m := NewPermissionMaster(dbs)
+	tx := &renameTx{}
+
+	count, err := m.Rename(context.Background(), tx, "LEGACY_READ_USER", models.ReadUser)
+	if err != nil {
+		t.Fatal(err.Message)
+	}
+	if count != 3 {
+		t.Errorf("got %d changed, want the api key, separation rule and elevation", count)
+	}
+
+	for _, table := range []string{"roles", "api_keys", "separation_rules", "permission_elevations"} {
+		found := false
+		for _, stmt := range tx.statements {
+			if strings.Contains(stmt, "UPDATE "+table) {
+				found = true
+			}
+		}
+		if !found {
+			t.Errorf("%s are not renamed", table)
+		}
+	}
+
+	if _, err := m.Rename(context.Background(), tx, "LEGACY_READ_USER", "UNKNOWN"); err == nil {
+		t.Error("rename to a permission outside the registry is allowed")
+	}
+}
@@ -51,6 +51,9 @@ func (m *RoleMaster) BulkCreate(ctx context.Context, tx pgx.Tx, requests []dbmod
 		if arg.Status == "" {
 			arg.Status = constants.StatusCreated
 		}
+		if err := m.CheckSeparation(ctx, *arg); err != nil {
+			return nil, err
+		}
 
 		// insert into db
 		obj, err := m.dbstore.RoleStore.Insert(ctx, tx, *arg)
@@ -73,9 +76,16 @@ func (m *RoleMaster) construct(r dbmodels.RoleRequest) *dbmodels.Role {
 		IsManagement: r.IsManagement,
 		IsFinal:      r.IsFinal,
 		IsArchived:   false,
+
+		PermissionScopes: r.PermissionScopes,
 	}
 }
 
+// CheckSeparation fails when a role breaks an active separation rule of its organization
+func (m *RoleMaster) CheckSeparation(ctx context.Context, role dbmodels.Role) *faulterr.FaultErr {
+	return checkSeparation(ctx, m.dbstore, role.OrgUID, role.Permissions, "role")
+}
+
 func (m *RoleMaster) GrantPermission(ctx context.Context, roleID int64, permission string) *faulterr.FaultErr {
 	role, err := m.dbstore.RoleStore.GetByID(ctx, roleID)
 	if err != nil {
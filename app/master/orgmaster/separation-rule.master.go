@@ -0,0 +1,180 @@
+package orgmaster
+
+import (
+	"context"
+	"fmt"
+	"gogql/app/helpers"
+	"gogql/app/models"
+	"gogql/app/models/constants"
+	"gogql/app/models/dbmodels"
+	"gogql/app/store/dbstore"
+	"gogql/utils/faulterr"
+	"strings"
+
+	"github.com/gofrs/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+type SeparationRuleMaster struct {
+	dbstore *dbstore.DBStore
+}
+
+func NewSeparationRuleMaster(s *dbstore.DBStore) *SeparationRuleMaster {
+	return &SeparationRuleMaster{s}
+}
+
+// Create stores a separation rule, roles and users already breaking it are listed by Violations
+func (m *SeparationRuleMaster) Create(ctx context.Context, tx pgx.Tx, req dbmodels.SeparationRuleRequest, createdBy int64) (*dbmodels.SeparationRule, *faulterr.FaultErr) {
+	if err := m.validate(req); err != nil {
+		return nil, err
+	}
+
+	obj := &dbmodels.SeparationRule{
+		OrgUID:      req.OrgUID,
+		Name:        strings.TrimSpace(req.Name),
+		Permissions: m.uniquePermissions(req.Permissions),
+		IsActive:    req.IsActive,
+		CreatedBy:   createdBy,
+	}
+	return m.dbstore.SeparationRuleStore.Insert(ctx, tx, obj)
+}
+
+// Update replaces the name, permissions and state of a separation rule
+func (m *SeparationRuleMaster) Update(ctx context.Context, tx pgx.Tx, obj *dbmodels.SeparationRule, req dbmodels.SeparationRuleRequest) *faulterr.FaultErr {
+	if err := m.validate(req); err != nil {
+		return err
+	}
+
+	obj.Name = strings.TrimSpace(req.Name)
+	obj.Permissions = m.uniquePermissions(req.Permissions)
+	obj.IsActive = req.IsActive
+	return m.dbstore.SeparationRuleStore.Update(ctx, tx, obj)
+}
+
+// Violations lists the roles and the users of an organization breaking its active separation rules.
+// Management roles and their users are left out, they hold every permission by design.
+func (m *SeparationRuleMaster) Violations(ctx context.Context, orgUID uuid.UUID) ([]models.SeparationViolation, *faulterr.FaultErr) {
+	result := []models.SeparationViolation{}
+
+	active := true
+	rules, err := m.dbstore.SeparationRuleStore.List(ctx, orgUID, &active)
+	if err != nil {
+		return nil, err
+	}
+	permissions := []string{}
+	for _, rule := range rules {
+		permissions = append(permissions, rule.Permissions...)
+	}
+	if len(permissions) == 0 {
+		return result, nil
+	}
+
+	// roles granting the permissions on their own
+	roles, err := m.dbstore.RoleStore.ListWithAnyPermission(ctx, permissions, orgUID)
+	if err != nil {
+		return nil, err
+	}
+	rolesByID := map[int64]dbmodels.Role{}
+	roleIDs := []int64{}
+	for i := range roles {
+		rolesByID[roles[i].ID] = roles[i]
+		roleIDs = append(roleIDs, roles[i].ID)
+		if roles[i].IsManagement {
+			continue
+		}
+		for _, rule := range rules {
+			if conflicts := models.SeparationConflicts(rule, roles[i].Permissions); len(conflicts) > 0 {
+				result = append(result, models.SeparationViolation{Rule: rule, Role: &roles[i], Permissions: conflicts})
+			}
+		}
+	}
+	if len(roleIDs) == 0 {
+		return result, nil
+	}
+
+	// users granted the permissions by their roles together
+	assignments, err := m.dbstore.UserRoleStore.ListActiveByRoleIDs(ctx, roleIDs)
+	if err != nil {
+		return nil, err
+	}
+	userPermissions := map[int64][]string{}
+	managers := map[int64]bool{}
+	userIDs := []int64{}
+	for _, assignment := range assignments {
+		if _, ok := userPermissions[assignment.UserID]; !ok {
+			userIDs = append(userIDs, assignment.UserID)
+		}
+		role := rolesByID[assignment.RoleID]
+		if role.IsManagement {
+			managers[assignment.UserID] = true
+		}
+		userPermissions[assignment.UserID] = append(userPermissions[assignment.UserID], role.Permissions...)
+	}
+	if len(userIDs) == 0 {
+		return result, nil
+	}
+
+	users, err := m.dbstore.UserStore.GetManyByIDs(ctx, userIDs)
+	if err != nil {
+		return nil, err
+	}
+	for _, user := range users {
+		if managers[user.ID] {
+			continue
+		}
+		for _, rule := range rules {
+			if conflicts := models.SeparationConflicts(rule, userPermissions[user.ID]); len(conflicts) > 0 {
+				result = append(result, models.SeparationViolation{Rule: rule, User: user, Permissions: conflicts})
+			}
+		}
+	}
+	return result, nil
+}
+
+func (m *SeparationRuleMaster) validate(r dbmodels.SeparationRuleRequest) *faulterr.FaultErr {
+	if strings.TrimSpace(r.Name) == "" {
+		return faulterr.NewBadRequestError("rule name is required")
+	}
+	for _, perm := range r.Permissions {
+		if !models.IsPermission(perm) {
+			return faulterr.NewBadRequestError(fmt.Sprintf("unknown permission %s", perm))
+		}
+	}
+	if len(m.uniquePermissions(r.Permissions)) < 2 {
+		return faulterr.NewBadRequestError("a rule separates at least two permissions")
+	}
+	return nil
+}
+
+func (m *SeparationRuleMaster) uniquePermissions(list []string) []string {
+	result := []string{}
+	for _, perm := range list {
+		if !helpers.StringSliceExist(result, perm) {
+			result = append(result, perm)
+		}
+	}
+	return result
+}
+
+// checkSeparation fails on the first active separation rule of the organization the permissions
+// of a role or a user break, holder names which one in the message
+func checkSeparation(ctx context.Context, s *dbstore.DBStore, orgUID uuid.UUID, permissions []string, holder string) *faulterr.FaultErr {
+	active := true
+	rules, err := s.SeparationRuleStore.List(ctx, orgUID, &active)
+	if err != nil {
+		return err
+	}
+
+	for _, rule := range rules {
+		conflicts := models.SeparationConflicts(rule, permissions)
+		if len(conflicts) == 0 {
+			continue
+		}
+		msg := fmt.Sprintf("%s can not hold %s together, separation rule %s", holder, strings.Join(conflicts, ", "), rule.Name)
+		return faulterr.NewBadRequestError(msg).
+			WithCode(constants.ErrCodeSeparationOfDuties).
+			WithDetail("rule", rule.Name).
+			WithDetail("permissions", strings.Join(conflicts, ","))
+	}
+	return nil
+}
@@ -0,0 +1,157 @@
+package orgmaster
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base32"
+	"gogql/app/models"
+	"gogql/app/models/dbmodels"
+	"gogql/app/store/dbstore"
+	"gogql/utils/encrypt"
+	"gogql/utils/faulterr"
+	"gogql/utils/totp"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/volatiletech/null"
+)
+
+const recoveryCodeCount = 10
+
+type TwoFactorMaster struct {
+	dbstore *dbstore.DBStore
+	hasher  *encrypt.TokenHasher
+	box     *encrypt.SecretBox
+	issuer  string
+}
+
+func NewTwoFactorMaster(s *dbstore.DBStore, hasher *encrypt.TokenHasher, box *encrypt.SecretBox, issuer string) *TwoFactorMaster {
+	return &TwoFactorMaster{s, hasher, box, issuer}
+}
+
+// GetEnabledTOTP returns the confirmed totp credential of a user, nil if the user has none
+func (m *TwoFactorMaster) GetEnabledTOTP(ctx context.Context, userID int64) (*dbmodels.TOTPCredential, *faulterr.FaultErr) {
+	obj, err := m.dbstore.TOTPCredentialStore.GetByUserID(ctx, userID)
+	if err != nil {
+		if err.Status == http.StatusNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if !obj.IsEnabled {
+		return nil, nil
+	}
+	return obj, nil
+}
+
+// EnrollTOTP stores a new pending secret for the user, replacing an unconfirmed one
+func (m *TwoFactorMaster) EnrollTOTP(ctx context.Context, tx pgx.Tx, user *dbmodels.User) (*models.TOTPEnrollment, *faulterr.FaultErr) {
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return nil, faulterr.NewInternalServerError("error when trying to generate totp secret")
+	}
+
+	_, ferr := m.dbstore.TOTPCredentialStore.Upsert(ctx, tx, &dbmodels.TOTPCredential{
+		UserID: user.ID,
+		Secret: m.box.Seal(secret),
+	})
+	if ferr != nil {
+		return nil, ferr
+	}
+
+	account := user.Email
+	if account == "" {
+		account = user.Phone
+	}
+	return &models.TOTPEnrollment{Secret: secret, URI: totp.URI(m.issuer, account, secret)}, nil
+}
+
+// VerifyTOTP checks a code against the credential, a code can only be used once
+func (m *TwoFactorMaster) VerifyTOTP(ctx context.Context, tx pgx.Tx, obj *dbmodels.TOTPCredential, code string) (bool, *faulterr.FaultErr) {
+	secret, err := m.box.Open(obj.Secret)
+	if err != nil {
+		return false, faulterr.NewInternalServerError("error when trying to read totp secret")
+	}
+
+	step, ok := totp.Validate(secret, code, time.Now())
+	if !ok || step <= obj.LastUsedStep {
+		return false, nil
+	}
+
+	obj.LastUsedStep = step
+	if err := m.dbstore.TOTPCredentialStore.Update(ctx, tx, obj); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// Confirm enables a pending credential
+func (m *TwoFactorMaster) Confirm(ctx context.Context, tx pgx.Tx, obj *dbmodels.TOTPCredential) *faulterr.FaultErr {
+	obj.IsEnabled = true
+	obj.ConfirmedAt = null.TimeFrom(time.Now())
+	return m.dbstore.TOTPCredentialStore.Update(ctx, tx, obj)
+}
+
+// CreateRecoveryCodes replaces the recovery codes of a user and returns the plain codes,
+// only their hashes are stored
+func (m *TwoFactorMaster) CreateRecoveryCodes(ctx context.Context, tx pgx.Tx, userID int64) ([]string, *faulterr.FaultErr) {
+	if err := m.dbstore.RecoveryCodeStore.DeleteByUserID(ctx, tx, userID); err != nil {
+		return nil, err
+	}
+
+	codes := []string{}
+	for i := 0; i < recoveryCodeCount; i++ {
+		code, err := generateRecoveryCode()
+		if err != nil {
+			return nil, faulterr.NewInternalServerError("error when trying to generate recovery codes")
+		}
+
+		_, ferr := m.dbstore.RecoveryCodeStore.Insert(ctx, tx, &dbmodels.RecoveryCode{
+			UserID:   userID,
+			CodeHash: m.hasher.Hash(normalizeRecoveryCode(code)),
+		})
+		if ferr != nil {
+			return nil, ferr
+		}
+		codes = append(codes, code)
+	}
+	return codes, nil
+}
+
+// UseRecoveryCode burns an unused recovery code of the user, it returns false if the code does not match
+func (m *TwoFactorMaster) UseRecoveryCode(ctx context.Context, tx pgx.Tx, userID int64, code string) (bool, *faulterr.FaultErr) {
+	obj, err := m.dbstore.RecoveryCodeStore.GetUnusedByUserIDAndCodeHash(ctx, userID, m.hasher.Hash(normalizeRecoveryCode(code)))
+	if err != nil {
+		if err.Status == http.StatusNotFound {
+			return false, nil
+		}
+		return false, err
+	}
+	return m.dbstore.RecoveryCodeStore.MarkUsed(ctx, tx, obj.ID)
+}
+
+// Disable removes the totp credential and the recovery codes of a user
+func (m *TwoFactorMaster) Disable(ctx context.Context, tx pgx.Tx, userID int64) *faulterr.FaultErr {
+	if err := m.dbstore.RecoveryCodeStore.DeleteByUserID(ctx, tx, userID); err != nil {
+		return err
+	}
+	return m.dbstore.TOTPCredentialStore.DeleteByUserID(ctx, tx, userID)
+}
+
+// Helpers
+
+// generateRecoveryCode returns a code like "k7qzm-3xw2p"
+func generateRecoveryCode() (string, error) {
+	b := make([]byte, 7)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	code := strings.ToLower(base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(b))[:10]
+	return code[:5] + "-" + code[5:], nil
+}
+
+func normalizeRecoveryCode(code string) string {
+	return strings.ToLower(strings.ReplaceAll(strings.TrimSpace(code), "-", ""))
+}
@@ -4,17 +4,20 @@ import (
 	"context"
 	"gogql/app/models/dbmodels"
 	"gogql/app/store/dbstore"
+	"gogql/utils/encrypt"
 	"gogql/utils/faulterr"
 
+	"github.com/gofrs/uuid"
 	"github.com/jackc/pgx/v5"
 )
 
 type UserActivityMaster struct {
 	dbstore *dbstore.DBStore
+	hasher  *encrypt.TokenHasher
 }
 
-func NewUserActivityMaster(s *dbstore.DBStore) *UserActivityMaster {
-	return &UserActivityMaster{s}
+func NewUserActivityMaster(s *dbstore.DBStore, hasher *encrypt.TokenHasher) *UserActivityMaster {
+	return &UserActivityMaster{s, hasher}
 }
 
 func (m *UserActivityMaster) Create(ctx context.Context, tx pgx.Tx, req dbmodels.UserActivityRequest) (*dbmodels.UserActivity, *faulterr.FaultErr) {
@@ -27,10 +30,14 @@ func (m *UserActivityMaster) Create(ctx context.Context, tx pgx.Tx, req dbmodels
 
 func (m *UserActivityMaster) construct(req dbmodels.UserActivityRequest) (*dbmodels.UserActivity, *faulterr.FaultErr) {
 	obj := &dbmodels.UserActivity{
-		ObjectID:     req.ObjectID,
-		ObjectType:   req.ObjectType,
-		OrgUID:       req.OrgUID,
-		SessionToken: req.SessionToken,
+		ObjectID:   req.ObjectID,
+		ObjectType: req.ObjectType,
+		OrgUID:     req.OrgUID,
+	}
+
+	// activities reference the session by its token hash, system activities have no session
+	if req.SessionToken != uuid.Nil {
+		obj.SessionToken = m.hasher.Hash(req.SessionToken.String())
 	}
 
 	if req.UserID > 0 {
@@ -6,16 +6,20 @@ import (
 	"gogql/app/models/dbmodels"
 	"gogql/app/store/dbstore"
 	"gogql/utils/faulterr"
+	"gogql/utils/phone"
 
+	"github.com/gofrs/uuid"
 	"github.com/jackc/pgx/v5"
+	"github.com/volatiletech/null"
 )
 
 type UserMaster struct {
-	dbstore *dbstore.DBStore
+	dbstore            *dbstore.DBStore
+	defaultPhoneRegion string
 }
 
-func NewUserMaster(dbstore *dbstore.DBStore) *UserMaster {
-	return &UserMaster{dbstore}
+func NewUserMaster(dbstore *dbstore.DBStore, defaultPhoneRegion string) *UserMaster {
+	return &UserMaster{dbstore, defaultPhoneRegion}
 }
 
 // CreateUser creates and saves user in the db
@@ -23,6 +27,13 @@ func (m *UserMaster) CreateOne(ctx context.Context, tx pgx.Tx, r dbmodels.UserRe
 	if err := m.validate(r); err != nil {
 		return nil, err
 	}
+	if r.Phone != "" {
+		number, err := m.NormalizePhone(r.Phone, m.PhoneRegion(ctx, r.OrgUID))
+		if err != nil {
+			return nil, err
+		}
+		r.Phone = number
+	}
 
 	obj := dbmodels.User{
 		FirstName: r.FirstName,
@@ -33,7 +44,15 @@ func (m *UserMaster) CreateOne(ctx context.Context, tx pgx.Tx, r dbmodels.UserRe
 		RoleID:    r.RoleID,
 		IsAdmin:   r.IsAdmin,
 		IsFinal:   true,
-		Status:    constants.StatusActive,
+		Status:    r.Status,
+	}
+
+	// invited users stay drafts until they accept their invitation
+	if obj.Status == "" {
+		obj.Status = constants.StatusActive
+	}
+	if obj.Status == constants.StatusPending {
+		obj.IsFinal = false
 	}
 
 	err := m.verifyUniqueFields(ctx, obj)
@@ -41,35 +60,99 @@ func (m *UserMaster) CreateOne(ctx context.Context, tx pgx.Tx, r dbmodels.UserRe
 		return nil, err
 	}
 
-	return m.dbstore.UserStore.Insert(ctx, tx, obj)
+	user, err := m.dbstore.UserStore.Insert(ctx, tx, obj)
+	if err != nil {
+		return nil, err
+	}
+
+	// the primary role is assigned like any other role
+	if user.RoleID.Valid {
+		if _, err := m.AssignRole(ctx, tx, user.ID, user.RoleID.Int64, null.Time{}); err != nil {
+			return nil, err
+		}
+	}
+	return user, nil
 }
 
-func (s *UserMaster) Update(ctx context.Context, tx pgx.Tx, obj dbmodels.User, req dbmodels.UserRequest) (*dbmodels.User, *faulterr.FaultErr) {
-	// Verify request fields
-	if req.FirstName != "" {
+// Update saves the name of a user and reports whether it changed, the email and phone
+// are login identifiers and only change through a confirmed contact change
+func (s *UserMaster) Update(ctx context.Context, tx pgx.Tx, obj dbmodels.User, req dbmodels.UserRequest) (*dbmodels.User, bool, *faulterr.FaultErr) {
+	updated := false
+	if req.FirstName != "" && req.FirstName != obj.FirstName {
 		obj.FirstName = req.FirstName
+		updated = true
 	}
-	if req.LastName != "" {
+	if req.LastName != "" && req.LastName != obj.LastName {
 		obj.LastName = req.LastName
+		updated = true
 	}
-	if req.Email != "" && req.Email != obj.Email {
-		// verify unique email
-		_, err := s.dbstore.UserStore.GetByEmail(ctx, req.Email)
-		if err == nil {
-			return nil, faulterr.NewBadRequestError("email already registered")
+	if !updated {
+		return &obj, false, nil
+	}
+
+	if err := s.dbstore.UserStore.Update(ctx, tx, obj); err != nil {
+		return nil, false, err
+	}
+	return &obj, true, nil
+}
+
+// AssignRole assigns a role to a user until it expires, assigning an assigned role replaces its expiry.
+// The roles of the user together may not break a separation rule of the organization.
+func (m *UserMaster) AssignRole(ctx context.Context, tx pgx.Tx, userID int64, roleID int64, expiresAt null.Time) (*dbmodels.UserRole, *faulterr.FaultErr) {
+	if err := m.verifySeparation(ctx, userID, roleID, null.Int64{}); err != nil {
+		return nil, err
+	}
+	return m.assignRole(ctx, tx, userID, roleID, expiresAt)
+}
+
+// ReplacePrimaryRole moves the primary role assignment of a user from the old role to the new one
+func (m *UserMaster) ReplacePrimaryRole(ctx context.Context, tx pgx.Tx, userID int64, oldRoleID null.Int64, newRoleID null.Int64) *faulterr.FaultErr {
+	if oldRoleID == newRoleID {
+		return nil
+	}
+	if newRoleID.Valid {
+		if err := m.verifySeparation(ctx, userID, newRoleID.Int64, oldRoleID); err != nil {
+			return err
 		}
-		obj.Email = req.Email
 	}
-	if req.Phone != "" && req.Phone != obj.Phone {
-		// verify unique phone
-		_, err := s.dbstore.UserStore.GetByPhone(ctx, req.Phone)
-		if err == nil {
-			return nil, faulterr.NewBadRequestError("phone already registered")
+	if oldRoleID.Valid {
+		if _, err := m.dbstore.UserRoleStore.Delete(ctx, tx, userID, oldRoleID.Int64); err != nil {
+			return err
+		}
+	}
+	if newRoleID.Valid {
+		if _, err := m.assignRole(ctx, tx, userID, newRoleID.Int64, null.Time{}); err != nil {
+			return err
 		}
-		obj.Phone = req.Phone
 	}
+	return nil
+}
+
+// PhoneRegion is the region national phone numbers of the users of an organization are read in
+func (m *UserMaster) PhoneRegion(ctx context.Context, orgUID uuid.NullUUID) string {
+	if !orgUID.Valid {
+		return m.defaultPhoneRegion
+	}
+	org, err := m.dbstore.OrganizationStore.GetByUID(ctx, orgUID.UUID)
+	if err != nil || org.PhoneRegion == "" {
+		return m.defaultPhoneRegion
+	}
+	return org.PhoneRegion
+}
 
-	return &obj, nil
+// NormalizePhone returns a phone number in E.164, national numbers are read in the region
+func (m *UserMaster) NormalizePhone(number string, region string) (string, *faulterr.FaultErr) {
+	result, err := phone.Normalize(number, region)
+	if err != nil {
+		return "", faulterr.NewBadRequestError("phone number is invalid")
+	}
+	return result, nil
+}
+
+// GetByPhone gets a user by a phone number however it is formatted, national numbers
+// are read in the region of the organization
+func (m *UserMaster) GetByPhone(ctx context.Context, number string, orgUID uuid.NullUUID) (*dbmodels.User, *faulterr.FaultErr) {
+	return m.dbstore.UserStore.GetByPhone(ctx, number, m.PhoneRegion(ctx, orgUID))
 }
 
 // Validators
@@ -82,10 +165,12 @@ func (m *UserMaster) verifyUniqueFields(ctx context.Context, u dbmodels.User) *f
 		return faulterr.NewBadRequestError("email already registered")
 	}
 
-	// Verify unique phone
-	_, err = m.dbstore.UserStore.GetByPhone(ctx, u.Phone)
-	if err == nil {
-		return faulterr.NewBadRequestError("phone already registered")
+	// Verify unique phone, users provisioned through single sign-on have none
+	if u.Phone != "" {
+		_, err = m.dbstore.UserStore.GetByPhone(ctx, u.Phone, m.defaultPhoneRegion)
+		if err == nil {
+			return faulterr.NewBadRequestError("phone already registered")
+		}
 	}
 
 	return nil
@@ -105,3 +190,73 @@ func (m *UserMaster) validate(r dbmodels.UserRequest) *faulterr.FaultErr {
 
 	return nil
 }
+
+func (m *UserMaster) assignRole(ctx context.Context, tx pgx.Tx, userID int64, roleID int64, expiresAt null.Time) (*dbmodels.UserRole, *faulterr.FaultErr) {
+	arg := &dbmodels.UserRole{
+		UserID:    userID,
+		RoleID:    roleID,
+		ExpiresAt: expiresAt,
+	}
+	return m.dbstore.UserRoleStore.Upsert(ctx, tx, arg)
+}
+
+// DepartmentIDs gets the departments of the unexpired roles a user holds
+func (m *UserMaster) DepartmentIDs(ctx context.Context, userID int64) ([]int64, *faulterr.FaultErr) {
+	assignments, err := m.dbstore.UserRoleStore.ListActiveByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if len(assignments) == 0 {
+		return []int64{}, nil
+	}
+
+	ids := []int64{}
+	for _, assignment := range assignments {
+		ids = append(ids, assignment.RoleID)
+	}
+	roles, err := m.dbstore.RoleStore.GetManyByIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	departmentIDs := []int64{}
+	for _, role := range roles {
+		departmentIDs = append(departmentIDs, role.DepartmentID)
+	}
+	return departmentIDs, nil
+}
+
+// verifySeparation checks the permissions a user would hold with the role on top of its other
+// active roles, without the replaced role
+func (m *UserMaster) verifySeparation(ctx context.Context, userID int64, roleID int64, replacedRoleID null.Int64) *faulterr.FaultErr {
+	role, err := m.dbstore.RoleStore.GetByID(ctx, roleID)
+	if err != nil {
+		return err
+	}
+	assignments, err := m.dbstore.UserRoleStore.ListActiveByUserID(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	ids := []int64{}
+	for _, assignment := range assignments {
+		if assignment.RoleID == roleID || (replacedRoleID.Valid && assignment.RoleID == replacedRoleID.Int64) {
+			continue
+		}
+		ids = append(ids, assignment.RoleID)
+	}
+	roles := []*dbmodels.Role{role}
+	if len(ids) > 0 {
+		others, err := m.dbstore.RoleStore.GetManyByIDs(ctx, ids)
+		if err != nil {
+			return err
+		}
+		roles = append(roles, others...)
+	}
+
+	permissions := []string{}
+	for _, r := range roles {
+		permissions = append(permissions, r.Permissions...)
+	}
+	return checkSeparation(ctx, m.dbstore, role.OrgUID, permissions, "user")
+}
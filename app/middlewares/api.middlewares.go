@@ -2,40 +2,211 @@ package middlewares
 
 import (
 	"context"
+	"gogql/app/models"
+	"gogql/app/models/constants"
+	"gogql/utils/authtoken"
+	"gogql/utils/faulterr"
+	"net"
 	"net/http"
+	"strings"
+
+	"github.com/go-chi/render"
+	"github.com/gofrs/uuid"
 )
 
-// AuthTokenReader decodes the share session cookie and packs the session into context
-func AuthTokenReader() func(http.Handler) http.Handler {
+// APIKeyVerifier resolves an api key to the auther it acts as
+type APIKeyVerifier interface {
+	GetAutherByAPIKey(ctx context.Context, key string, ipAddress string) (*models.Auther, *faulterr.FaultErr)
+}
+
+// SessionVerifier resolves a session token to the auther signed in with it
+type SessionVerifier interface {
+	GetAutherByToken(ctx context.Context, token uuid.UUID) (*models.Auther, *faulterr.FaultErr)
+}
+
+// AuthTokenReader reads the session token, jwt or api key from the cookie or the authorization header
+// and packs it into context, jwts and api keys are verified here so resolvers can use them directly.
+// Api keys may also be sent in the X-API-Key header. It has to run after ClientIPReader.
+func AuthTokenReader(keys *authtoken.KeySet, apiKeys APIKeyVerifier) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			var tokenString string
 			cookie, _ := r.Cookie("jwt")
 
 			if cookie == nil {
-				tokenString = r.Header.Get("Authorization")
+				tokenString = strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
 			} else {
 				tokenString = cookie.Value
 			}
+			if apiKey := r.Header.Get("X-API-Key"); apiKey != "" {
+				tokenString = apiKey
+			}
 
 			// put it in context and call the next with our new context
 			ctx := context.WithValue(r.Context(), authTokenCtxKey, tokenString)
+			ctx = context.WithValue(ctx, autherMemoCtxKey, &autherMemo{})
+			if authtoken.IsJWT(tokenString) {
+				auther, err := keys.Decode(tokenString)
+				ctx = context.WithValue(ctx, jwtCtxKey, &autherResult{auther, err})
+			} else if authtoken.IsAPIKey(tokenString) {
+				auther, err := apiKeys.GetAutherByAPIKey(ctx, tokenString, GetClientIP(ctx))
+				ctx = context.WithValue(ctx, apiKeyCtxKey, &autherResult{auther, err})
+			}
+			r = r.WithContext(ctx)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// OrgScopeReader resolves the caller and packs the organization the request is scoped to into context.
+// Members are scoped to their own organization and an Organization header naming another one is rejected,
+// super admins are scoped to the organization of the header or to every organization without it.
+// Requests without a valid caller keep the header as an unverified hint. It has to run after AuthTokenReader.
+func OrgScopeReader(sessions SessionVerifier) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var header *uuid.UUID
+			if value := r.Header.Get("Organization"); value != "" {
+				uid, err := uuid.FromString(value)
+				if err != nil {
+					rejectRequest(w, r, faulterr.NewBadRequestError("organization header is not a valid uid"))
+					return
+				}
+				header = &uid
+			}
+
+			// put it in context and call the next with our new context
+			ctx := context.WithValue(r.Context(), orgCtxKey, header)
+			if auther := callerFromContext(ctx, sessions); auther != nil {
+				scope, err := newOrgScope(auther, header)
+				if err != nil {
+					rejectRequest(w, r, err)
+					return
+				}
+				ctx = context.WithValue(ctx, orgScopeCtxKey, scope)
+			}
 			r = r.WithContext(ctx)
 			next.ServeHTTP(w, r)
 		})
 	}
 }
 
-// OrgUIDReader decodes the share session cookie and packs the session into context
-func OrgUIDReader() func(http.Handler) http.Handler {
+// ClientIPReader reads the caller ip address and packs it into context, it is the direct peer
+// unless that is one of the trusted proxies forwarding the request
+func ClientIPReader(trustedProxies []*net.IPNet) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			orgUID := r.Header.Get("Organization")
+			ip := clientIP(r, trustedProxies)
+
+			// put it in context and call the next with our new context
+			ctx := context.WithValue(r.Context(), clientIPCtxKey, ip)
+			r = r.WithContext(ctx)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
 
+// UserAgentReader reads the caller user agent and packs it into context
+func UserAgentReader() func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			// put it in context and call the next with our new context
-			ctx := context.WithValue(r.Context(), orgCtxKey, orgUID)
+			ctx := context.WithValue(r.Context(), userAgentCtxKey, r.UserAgent())
 			r = r.WithContext(ctx)
 			next.ServeHTTP(w, r)
 		})
 	}
 }
+
+// Helpers
+
+// clientIP reads the forwarding headers only when the direct peer is a trusted proxy, the client
+// is the right-most address of X-Forwarded-For that is not a trusted proxy. X-Real-IP is read
+// when the proxies do not forward X-Forwarded-For.
+func clientIP(r *http.Request, trustedProxies []*net.IPNet) string {
+	ip := r.RemoteAddr
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		ip = host
+	}
+	if !isTrustedProxy(ip, trustedProxies) {
+		return ip
+	}
+
+	if forwarded := r.Header.Values("X-Forwarded-For"); len(forwarded) > 0 {
+		hops := strings.Split(strings.Join(forwarded, ","), ",")
+		for i := len(hops) - 1; i >= 0; i-- {
+			hop := strings.TrimSpace(hops[i])
+			if net.ParseIP(hop) == nil {
+				// a malformed hop was not written by a trusted proxy, stop at the last known one
+				return ip
+			}
+			ip = hop
+			if !isTrustedProxy(hop, trustedProxies) {
+				return ip
+			}
+		}
+		return ip
+	}
+	if realIP := strings.TrimSpace(r.Header.Get("X-Real-IP")); net.ParseIP(realIP) != nil {
+		return realIP
+	}
+	return ip
+}
+
+// isTrustedProxy reports whether an ip address is one of the trusted proxies
+func isTrustedProxy(ip string, trustedProxies []*net.IPNet) bool {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return false
+	}
+	for _, proxy := range trustedProxies {
+		if proxy.Contains(parsed) {
+			return true
+		}
+	}
+	return false
+}
+
+// callerFromContext resolves the auther of the jwt, api key or session token of the request,
+// nil if the request has no valid credentials. Sessions go through the auther memo of the request.
+func callerFromContext(ctx context.Context, sessions SessionVerifier) *models.Auther {
+	if result := jwtFromContext(ctx); result != nil {
+		return result.auther
+	}
+	if result := apiKeyFromContext(ctx); result != nil {
+		return result.auther
+	}
+
+	token := GetSessionToken(ctx)
+	if token == nil {
+		return nil
+	}
+	auther, _ := MemoizeAuther(ctx, func() (*models.Auther, *faulterr.FaultErr) {
+		return sessions.GetAutherByToken(ctx, *token)
+	})
+	return auther
+}
+
+// newOrgScope scopes an auther to the organization of the header, members may only name their own
+func newOrgScope(auther *models.Auther, header *uuid.UUID) (*orgScope, *faulterr.FaultErr) {
+	if auther.IsAdmin {
+		return &orgScope{orgUID: header, isAdmin: true}, nil
+	}
+
+	// members without an organization get the nil uid which matches nothing
+	orgUID := auther.OrgUID.UUID
+	if header != nil && *header != orgUID {
+		return nil, faulterr.NewFrobiddenError("not a member of the organization").WithCode(constants.ErrCodeOrganizationForbidden)
+	}
+	return &orgScope{orgUID: &orgUID}, nil
+}
+
+// rejectRequest ends the request with the error in the body of the rest responses
+func rejectRequest(w http.ResponseWriter, r *http.Request, err *faulterr.FaultErr) {
+	render.Status(r, err.Status)
+	render.JSON(w, r, map[string]interface{}{
+		"data":    nil,
+		"message": err.Message,
+		"status":  err.Status,
+	})
+}
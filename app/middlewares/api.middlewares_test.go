@@ -0,0 +1,102 @@
+package middlewares
+
+import (
+	"errors"
+	"gogql/app/models"
+	"gogql/app/models/constants"
+	"gogql/utils/faulterr"
+	"net"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofrs/uuid"
+	"github.com/volatiletech/null"
+)
+
+func TestClientIP(t *testing.T) {
+	_, proxies, _ := net.ParseCIDR("10.0.0.0/8")
+	trusted := []*net.IPNet{proxies}
+
+	tests := []struct {
+		name       string
+		remoteAddr string
+		forwarded  string
+		realIP     string
+		want       string
+	}{
+		{"direct peer", "203.0.113.7:5000", "", "", "203.0.113.7"},
+		{"untrusted peer sending headers", "203.0.113.7:5000", "198.51.100.1", "198.51.100.2", "203.0.113.7"},
+		{"trusted proxy", "10.0.0.2:5000", "198.51.100.1", "", "198.51.100.1"},
+		{"spoofed left-most hop", "10.0.0.2:5000", "1.2.3.4, 198.51.100.1", "", "198.51.100.1"},
+		{"chained proxies", "10.0.0.2:5000", "198.51.100.1, 10.0.0.3", "", "198.51.100.1"},
+		{"malformed hop", "10.0.0.2:5000", "198.51.100.1, nonsense", "", "10.0.0.2"},
+		{"real ip from trusted proxy", "10.0.0.2:5000", "", "198.51.100.9", "198.51.100.9"},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest("GET", "/", nil)
+		r.RemoteAddr = tt.remoteAddr
+		if tt.forwarded != "" {
+			r.Header.Set("X-Forwarded-For", tt.forwarded)
+		}
+		if tt.realIP != "" {
+			r.Header.Set("X-Real-IP", tt.realIP)
+		}
+		if got := clientIP(r, trusted); got != tt.want {
+			t.Errorf("%s: got %s, want %s", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestNewOrgScope(t *testing.T) {
+	own := uuid.Must(uuid.NewV4())
+	foreign := uuid.Must(uuid.NewV4())
+	member := &models.Auther{ID: 1, OrgUID: uuid.NullUUID{UUID: own, Valid: true}, RoleID: null.Int64From(1)}
+	admin := &models.Auther{ID: 2, IsAdmin: true}
+
+	tests := []struct {
+		name    string
+		auther  *models.Auther
+		header  *uuid.UUID
+		want    *uuid.UUID
+		isAdmin bool
+	}{
+		{"member without header", member, nil, &own, false},
+		{"member naming its organization", member, &own, &own, false},
+		{"member without an organization", &models.Auther{ID: 3}, nil, &uuid.Nil, false},
+		{"admin without header", admin, nil, nil, true},
+		{"admin naming an organization", admin, &foreign, &foreign, true},
+	}
+	for _, tt := range tests {
+		scope, err := newOrgScope(tt.auther, tt.header)
+		if err != nil {
+			t.Errorf("%s: %s", tt.name, err.Message)
+			continue
+		}
+		if scope.isAdmin != tt.isAdmin {
+			t.Errorf("%s: got admin scope %v, want %v", tt.name, scope.isAdmin, tt.isAdmin)
+		}
+		if (scope.orgUID == nil) != (tt.want == nil) || (scope.orgUID != nil && *scope.orgUID != *tt.want) {
+			t.Errorf("%s: got organization %v, want %v", tt.name, scope.orgUID, tt.want)
+		}
+	}
+
+	rejected := []struct {
+		name   string
+		auther *models.Auther
+		header *uuid.UUID
+	}{
+		{"member naming a foreign organization", member, &foreign},
+		{"member without an organization naming one", &models.Auther{ID: 3}, &own},
+	}
+	for _, tt := range rejected {
+		_, err := newOrgScope(tt.auther, tt.header)
+		if err == nil {
+			t.Errorf("%s: got no error", tt.name)
+			continue
+		}
+		var codedErr *faulterr.CodedError
+		if !errors.As(err.Error, &codedErr) || codedErr.Code != constants.ErrCodeOrganizationForbidden {
+			t.Errorf("%s: got %v, want %s", tt.name, err.Error, constants.ErrCodeOrganizationForbidden)
+		}
+	}
+}
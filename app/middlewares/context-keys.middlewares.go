@@ -8,4 +8,10 @@ type contextKey struct {
 // to prevent collisions between different context uses
 
 var authTokenCtxKey = &contextKey{"auth_token_ctx"}
+var jwtCtxKey = &contextKey{"jwt_ctx"}
+var apiKeyCtxKey = &contextKey{"api_key_ctx"}
+var autherMemoCtxKey = &contextKey{"auther_memo_ctx"}
 var orgCtxKey = &contextKey{"org_ctx"}
+var orgScopeCtxKey = &contextKey{"org_scope_ctx"}
+var clientIPCtxKey = &contextKey{"client_ip_ctx"}
+var userAgentCtxKey = &contextKey{"user_agent_ctx"}
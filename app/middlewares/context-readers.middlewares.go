@@ -1,6 +1,34 @@
 package middlewares
 
-import "context"
+import (
+	"context"
+	"gogql/app/models"
+	"gogql/utils/faulterr"
+	"sync"
+
+	"github.com/gofrs/uuid"
+)
+
+// autherResult is the outcome of verifying a jwt or an api key in AuthTokenReader
+type autherResult struct {
+	auther *models.Auther
+	err    *faulterr.FaultErr
+}
+
+// autherMemo keeps the auther of a session token for the rest of the request,
+// resolvers of one request run concurrently so it is loaded at most once under the lock
+type autherMemo struct {
+	mu     sync.Mutex
+	done   bool
+	result autherResult
+}
+
+// orgScope is the organization a request is scoped to, super admins without an
+// Organization header have a nil orgUID and see every organization
+type orgScope struct {
+	orgUID  *uuid.UUID
+	isAdmin bool
+}
 
 // AuthTokenFromContext finds the user from the context. REQUIRES Middleware to have run.
 func authTokenFromContext(ctx context.Context) string {
@@ -8,7 +36,44 @@ func authTokenFromContext(ctx context.Context) string {
 	return authToken
 }
 
-// orgUIDFromContext finds the user from the context. REQUIRES Middleware to have run.
-func orgUIDFromContext(ctx context.Context) string {
-	return ctx.Value(orgCtxKey).(string)
+// orgUIDFromContext finds the unverified organization header from the context, nil if it was not sent
+func orgUIDFromContext(ctx context.Context) *uuid.UUID {
+	orgUID, _ := ctx.Value(orgCtxKey).(*uuid.UUID)
+	return orgUID
+}
+
+// orgScopeFromContext finds the organization scope of the caller, nil if the request has no valid caller
+func orgScopeFromContext(ctx context.Context) *orgScope {
+	scope, _ := ctx.Value(orgScopeCtxKey).(*orgScope)
+	return scope
+}
+
+// clientIPFromContext finds the caller ip from the context. REQUIRES Middleware to have run.
+func clientIPFromContext(ctx context.Context) string {
+	ip, _ := ctx.Value(clientIPCtxKey).(string)
+	return ip
+}
+
+// userAgentFromContext finds the caller user agent from the context. REQUIRES Middleware to have run.
+func userAgentFromContext(ctx context.Context) string {
+	userAgent, _ := ctx.Value(userAgentCtxKey).(string)
+	return userAgent
+}
+
+// jwtFromContext finds the verified jwt from the context, nil if the request did not carry a jwt
+func jwtFromContext(ctx context.Context) *autherResult {
+	result, _ := ctx.Value(jwtCtxKey).(*autherResult)
+	return result
+}
+
+// apiKeyFromContext finds the verified api key from the context, nil if the request did not carry an api key
+func apiKeyFromContext(ctx context.Context) *autherResult {
+	result, _ := ctx.Value(apiKeyCtxKey).(*autherResult)
+	return result
+}
+
+// autherMemoFromContext finds the auther memo of the request, nil if AuthTokenReader did not run
+func autherMemoFromContext(ctx context.Context) *autherMemo {
+	memo, _ := ctx.Value(autherMemoCtxKey).(*autherMemo)
+	return memo
 }
@@ -2,6 +2,9 @@ package middlewares
 
 import (
 	"context"
+	"gogql/app/models"
+	"gogql/app/models/constants"
+	"gogql/utils/faulterr"
 
 	"github.com/gofrs/uuid"
 )
@@ -17,15 +20,87 @@ func GetSessionToken(ctx context.Context) *uuid.UUID {
 	return &token
 }
 
-// GetOrgUID reads and returns orgUID from context
-func GetOrgUID(ctx context.Context) *uuid.UUID {
-	uidStr := orgUIDFromContext(ctx)
-	if uidStr == "" {
-		return nil
+// GetJWTAuther returns the auther of a verified jwt or the verification error,
+// both are nil if the request did not carry a jwt
+func GetJWTAuther(ctx context.Context) (*models.Auther, *faulterr.FaultErr) {
+	result := jwtFromContext(ctx)
+	if result == nil {
+		return nil, nil
 	}
-	uid, err := uuid.FromString(uidStr)
-	if err != nil {
-		return nil
+	return result.auther, result.err
+}
+
+// GetAPIKeyAuther returns the auther of a verified api key or the verification error,
+// both are nil if the request did not carry an api key
+func GetAPIKeyAuther(ctx context.Context) (*models.Auther, *faulterr.FaultErr) {
+	result := apiKeyFromContext(ctx)
+	if result == nil {
+		return nil, nil
+	}
+	return result.auther, result.err
+}
+
+// GetOrgScope returns the organization the caller is scoped to, nil only for super admins scoped to
+// every organization. Requests without a valid caller get the nil uid so they match nothing.
+func GetOrgScope(ctx context.Context) *uuid.UUID {
+	scope := orgScopeFromContext(ctx)
+	if scope == nil {
+		orgUID := uuid.Nil
+		return &orgUID
+	}
+	return scope.orgUID
+}
+
+// NarrowOrgScope scopes the request to the requested organization, super admins may request any
+// organization and members only their own. Without a request it is the scope of the caller.
+func NarrowOrgScope(ctx context.Context, orgUID *uuid.UUID) (*uuid.UUID, *faulterr.FaultErr) {
+	scope := GetOrgScope(ctx)
+	if orgUID == nil {
+		return scope, nil
+	}
+	if s := orgScopeFromContext(ctx); s != nil && s.isAdmin {
+		return orgUID, nil
+	}
+	if scope == nil || *scope != *orgUID {
+		return nil, faulterr.NewFrobiddenError("not a member of the organization").WithCode(constants.ErrCodeOrganizationForbidden)
+	}
+	return scope, nil
+}
+
+// GetOrgHint returns the Organization header without verifying it, requests without a caller like
+// logins use it to find the organization of the user. It must never be used to authorize.
+func GetOrgHint(ctx context.Context) *uuid.UUID {
+	return orgUIDFromContext(ctx)
+}
+
+// GetClientIP reads and returns the caller ip address from context
+func GetClientIP(ctx context.Context) string {
+	return clientIPFromContext(ctx)
+}
+
+// GetUserAgent reads and returns the caller user agent from context
+func GetUserAgent(ctx context.Context) string {
+	return userAgentFromContext(ctx)
+}
+
+// MemoizeAuther returns the auther loaded by fn, fn runs once per request and later calls get
+// a copy of its result, requests without the memo always run fn
+func MemoizeAuther(ctx context.Context, fn func() (*models.Auther, *faulterr.FaultErr)) (*models.Auther, *faulterr.FaultErr) {
+	memo := autherMemoFromContext(ctx)
+	if memo == nil {
+		return fn()
+	}
+
+	memo.mu.Lock()
+	defer memo.mu.Unlock()
+
+	if !memo.done {
+		memo.result.auther, memo.result.err = fn()
+		memo.done = true
+	}
+	if memo.result.auther == nil {
+		return nil, memo.result.err
 	}
-	return &uid
+	auther := *memo.result.auther
+	return &auther, nil
 }
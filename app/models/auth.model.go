@@ -1,28 +1,198 @@
 package models
 
 import (
+	"gogql/app/models/dbmodels"
+	"time"
+
 	"github.com/gofrs/uuid"
 	"github.com/volatiletech/null"
 )
 
 type Auther struct {
-	ID           int64         `json:"id"`
-	Name         string        `json:"name"`
-	IsAdmin      bool          `json:"IsAdmin"`
-	OrgUID       uuid.NullUUID `json:"orgaUID"`
-	RoleID       null.Int64    `json:"roleID"`
-	SessionToken uuid.UUID     `json:"sessionToken"`
+	ID             int64         `json:"id"`
+	Name           string        `json:"name"`
+	IsAdmin        bool          `json:"IsAdmin"`
+	OrgUID         uuid.NullUUID `json:"orgaUID"`
+	RoleID         null.Int64    `json:"roleID"`
+	SessionToken   uuid.UUID     `json:"sessionToken"`
+	SessionID      int64         `json:"sessionID"`
+	RefreshToken   string        `json:"-"`
+	ExpiresAt      time.Time     `json:"expiresAt"`
+	AssuranceLevel string        `json:"assuranceLevel"`
+
+	// APIKeyID is set when the request authenticated with an api key, the key
+	// is limited to its Permissions on top of the permissions of its user
+	APIKeyID    null.Int64 `json:"apiKeyID"`
+	Permissions []string   `json:"permissions"`
+
+	// ImpersonatorID is set when a super admin acts as this user, the auther
+	// has the permissions of the user and activities record the impersonator
+	ImpersonatorID null.Int64 `json:"impersonatorID"`
+}
+
+// Assurance levels of a session, a PARTIAL session passed the otp but still needs a second factor
+const (
+	AssurancePartial string = "PARTIAL"
+	AssuranceOTP     string = "OTP"
+	AssuranceMFA     string = "MFA"
+	AssuranceAPIKey  string = "API_KEY"
+)
+
+// AuthTokens are the plain tokens of a session, they are only returned once and never stored
+type AuthTokens struct {
+	AccessToken  uuid.UUID `json:"accessToken"`
+	RefreshToken string    `json:"refreshToken"`
+	ExpiresAt    time.Time `json:"expiresAt"`
 }
 
 type OTPRequest struct {
-	Email null.String `json:"email"`
-	Phone null.String `json:"phone"`
+	Email     null.String `json:"email"`
+	Phone     null.String `json:"phone"`
+	IPAddress string      `json:"ipAddress"`
+
+	// OrgUID is the organization of the organization header, national phone numbers are read in its region
+	OrgUID uuid.NullUUID `json:"orgUID"`
+}
+
+const (
+	OTPChannelEmail string = "EMAIL"
+	OTPChannelSMS   string = "SMS"
+)
+
+// OTPAcknowledgement confirms that an otp was sent without revealing it
+type OTPAcknowledgement struct {
+	Channel     string    `json:"channel"`
+	Destination string    `json:"destination"`
+	ExpiresAt   time.Time `json:"expiresAt"`
 }
 
 type LoginRequest struct {
-	Email null.String `json:"email"`
-	Phone null.String `json:"phone"`
-	OTP   string      `json:"otp"`
+	Email       null.String `json:"email"`
+	Phone       null.String `json:"phone"`
+	OTP         string      `json:"otp"`
+	IPAddress   string      `json:"ipAddress"`
+	UserAgent   string      `json:"userAgent"`
+	DeviceLabel string      `json:"deviceLabel"`
+
+	// OrgUID is the organization of the organization header, national phone numbers are read in its region
+	OrgUID uuid.NullUUID `json:"orgUID"`
+}
+
+// SecondFactorRequest carries a totp or recovery code
+type SecondFactorRequest struct {
+	Code      string `json:"code"`
+	IPAddress string `json:"ipAddress"`
+}
+
+// TOTPEnrollment is shown once to set up an authenticator app
+type TOTPEnrollment struct {
+	Secret string `json:"secret"`
+	URI    string `json:"uri"`
+}
+
+// TOTPConfirmation returns the recovery codes once, and the upgraded auther if the session was partial
+type TOTPConfirmation struct {
+	RecoveryCodes []string `json:"recoveryCodes"`
+	Auther        *Auther  `json:"auther"`
+}
+
+// PasskeyOptions wraps the options passed to navigator.credentials.create / get as publicKey
+type PasskeyOptions struct {
+	PublicKey interface{} `json:"publicKey"`
+}
+
+// PasskeyRegistration is the response of navigator.credentials.create, binary fields are base64url
+type PasskeyRegistration struct {
+	ClientDataJSON    string `json:"clientDataJSON"`
+	AttestationObject string `json:"attestationObject"`
+	Name              string `json:"name"`
+}
+
+type PasskeyLoginRequest struct {
+	Email     null.String `json:"email"`
+	Phone     null.String `json:"phone"`
+	IPAddress string      `json:"ipAddress"`
+
+	// OrgUID is the organization of the organization header, national phone numbers are read in its region
+	OrgUID uuid.NullUUID `json:"orgUID"`
+}
+
+// PasskeyAssertion is the response of navigator.credentials.get, binary fields are base64url
+type PasskeyAssertion struct {
+	CredentialID      string `json:"credentialID"`
+	ClientDataJSON    string `json:"clientDataJSON"`
+	AuthenticatorData string `json:"authenticatorData"`
+	Signature         string `json:"signature"`
+	UserHandle        string `json:"userHandle"`
+	IPAddress         string `json:"ipAddress"`
+	UserAgent         string `json:"userAgent"`
+	DeviceLabel       string `json:"deviceLabel"`
+}
+
+// OIDCCallbackRequest is the redirect of an identity provider back to the api
+type OIDCCallbackRequest struct {
+	OrgCode     string `json:"orgCode"`
+	State       string `json:"state"`
+	Code        string `json:"code"`
+	Error       string `json:"error"`
+	IPAddress   string `json:"ipAddress"`
+	UserAgent   string `json:"userAgent"`
+	DeviceLabel string `json:"deviceLabel"`
+}
+
+// OIDCConfig is the single sign-on config of an organization, the client secret is never returned
+type OIDCConfig struct {
+	ID              int64      `json:"id"`
+	OrgUID          uuid.UUID  `json:"orgUID"`
+	Issuer          string     `json:"issuer"`
+	ClientID        string     `json:"clientID"`
+	HasClientSecret bool       `json:"hasClientSecret"`
+	AllowedDomains  []string   `json:"allowedDomains"`
+	IsEnabled       bool       `json:"isEnabled"`
+	JITProvisioning bool       `json:"jitProvisioning"`
+	DefaultRoleID   null.Int64 `json:"defaultRoleID"`
+	RedirectURI     string     `json:"redirectURI"`
+	UpdatedAt       time.Time  `json:"updatedAt"`
+}
+
+// APIKeyCreated returns the plain key once, only its hash is stored
+type APIKeyCreated struct {
+	APIKey *dbmodels.APIKey `json:"apiKey"`
+	Key    string           `json:"key"`
+}
+
+// ImpersonationRequest starts a session of the user on behalf of the auther
+type ImpersonationRequest struct {
+	UserID    int64  `json:"userID"`
+	IPAddress string `json:"ipAddress"`
+	UserAgent string `json:"userAgent"`
+}
+
+type RefreshRequest struct {
+	RefreshToken string `json:"refreshToken"`
+}
+
+// Session is an auth session as shown to its owner or an admin, without the token
+type Session struct {
+	ID          int64     `json:"id"`
+	UserID      int64     `json:"userID"`
+	IPAddress   string    `json:"ipAddress"`
+	UserAgent   string    `json:"userAgent"`
+	DeviceLabel string    `json:"deviceLabel"`
+	LastSeenAt  time.Time `json:"lastSeenAt"`
+	ExpiresAt   time.Time `json:"expiresAt"`
+	CreatedAt   time.Time `json:"createdAt"`
+	IsCurrent   bool      `json:"isCurrent"`
+
+	ImpersonatorID null.Int64 `json:"impersonatorID"`
+}
+
+// CacheStats are the counters of a process cache since the api started
+type CacheStats struct {
+	Name    string `json:"name"`
+	Hits    int64  `json:"hits"`
+	Misses  int64  `json:"misses"`
+	Entries int    `json:"entries"`
 }
 
 // ValueToken struct
@@ -0,0 +1,30 @@
+package constants
+
+// Error codes returned to api clients in graphql error extensions
+const (
+	ErrCodeOTPThrottled    string = "OTP_THROTTLED"
+	ErrCodeLoginThrottled  string = "LOGIN_THROTTLED"
+	ErrCodeAccountLocked   string = "ACCOUNT_LOCKED"
+	ErrCodeInvalidOTP      string = "INVALID_OTP"
+	ErrCodeAccountPending  string = "ACCOUNT_PENDING"
+	ErrCodeAccountArchived string = "ACCOUNT_ARCHIVED"
+
+	ErrCodeSessionExpired     string = "SESSION_EXPIRED"
+	ErrCodeRefreshTokenReused string = "REFRESH_TOKEN_REUSED"
+
+	ErrCodeSecondFactorRequired string = "SECOND_FACTOR_REQUIRED"
+	ErrCodeInvalidSecondFactor  string = "INVALID_SECOND_FACTOR"
+
+	ErrCodeInvalidPasskey string = "INVALID_PASSKEY"
+	ErrCodePasskeyCloned  string = "PASSKEY_CLONED"
+
+	ErrCodeInvalidSSO string = "INVALID_SSO"
+
+	ErrCodeImpersonationForbidden string = "IMPERSONATION_FORBIDDEN"
+
+	ErrCodeOrganizationForbidden string = "ORGANIZATION_FORBIDDEN"
+
+	ErrCodePermissionDenied string = "PERMISSION_DENIED"
+
+	ErrCodeSeparationOfDuties string = "SEPARATION_OF_DUTIES"
+)
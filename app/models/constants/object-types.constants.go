@@ -4,6 +4,8 @@ type ObjectType string
 
 const (
 	LoginAction     string = "LOGIN"
+	LogoutAction    string = "LOGOUT"
+	RevokeAction    string = "REVOKE"
 	CreateAction    string = "CREATE"
 	UpdateAction    string = "UPDATE"
 	FinalizeAction  string = "FINALIZE"
@@ -11,17 +13,45 @@ const (
 	DeclineAction   string = "DECLINE"
 	ArchiveAction   string = "ARCHIVE"
 	UnarchiveAction string = "UNARCHIVE"
+	EnableAction    string = "ENABLE"
+	DisableAction   string = "DISABLE"
+	VerifyAction    string = "VERIFY"
+	DeleteAction    string = "DELETE"
+	CloneAction     string = "CLONE"
+	LockoutAction   string = "LOCKOUT"
+	ThrottleAction  string = "THROTTLE"
+	ApproveAction   string = "APPROVE"
+	ExpireAction    string = "EXPIRE"
+	CertifyAction   string = "CERTIFY"
+	CloseAction     string = "CLOSE"
+
+	// Auth attempts
+	OTPRequestAction   string = "OTP_REQUEST"
+	SessionReuseAction string = "SESSION_REUSE"
+	ImpersonateAction  string = "IMPERSONATE"
 )
 
 const (
-	SelfObject   ObjectType = "SELF"
-	AutherObject ObjectType = "AUTHER"
-	FileObject   ObjectType = "FILE"
+	SelfObject      ObjectType = "SELF"
+	AutherObject    ObjectType = "AUTHER"
+	FileObject      ObjectType = "FILE"
+	OTPObject       ObjectType = "OTP"
+	SessionObject   ObjectType = "SESSION"
+	TwoFactorObject ObjectType = "TWO_FACTOR"
+	PasskeyObject   ObjectType = "PASSKEY"
+	SSOObject       ObjectType = "SSO"
+	APIKeyObject    ObjectType = "API_KEY"
 
 	// Company
 	OrganizationObject ObjectType = "ORGANIZATION"
 	DepartmentObject   ObjectType = "DEPARTMENT"
 	RoleObject         ObjectType = "ROLE"
 	UserObject         ObjectType = "USER"
+	InvitationObject   ObjectType = "INVITATION"
 	ContactObject      ObjectType = "CONTACT"
+
+	PermissionElevationObject ObjectType = "PERMISSION_ELEVATION"
+	SeparationRuleObject      ObjectType = "SEPARATION_RULE"
+	AccessReviewObject        ObjectType = "ACCESS_REVIEW"
+	AccessReviewItemObject    ObjectType = "ACCESS_REVIEW_ITEM"
 )
@@ -10,4 +10,27 @@ const (
 	StatusOpen     string = "OPEN"
 	StatusAccepted string = "ACCEPTED"
 	StatusDeclined string = "DECLINED"
+	StatusRevoked  string = "REVOKED"
+
+	// Confirmation Statuses
+	StatusConfirmed string = "CONFIRMED"
+	StatusCancelled string = "CANCELLED"
+
+	// User Statuses, invited users are pending until they accept the invitation
+	StatusPending  string = "PENDING"
+	StatusVerified string = "VERIFIED"
+
+	// Elevation Statuses, pending elevations wait for a management role to approve them
+	StatusApproved string = "APPROVED"
+	StatusExpired  string = "EXPIRED"
+
+	// Access Review Statuses, campaigns are open until closed and their items pending until reviewed
+	StatusClosed    string = "CLOSED"
+	StatusCertified string = "CERTIFIED"
+)
+
+// Revocations of an access review, applied when the campaign closes
+const (
+	RevokeArchiveUser  string = "ARCHIVE_USER"
+	RevokeReassignRole string = "REASSIGN_ROLE"
 )
@@ -12,10 +12,13 @@ import (
 ///////////////////
 
 type Permission struct {
-	ID        int64     `json:"id"`
-	Name      string    `json:"name"`
-	CreatedAt time.Time `json:"createdAt"`
-	UpdatedAt time.Time `json:"updatedAt"`
+	ID          int64     `json:"id"`
+	Name        string    `json:"name"`
+	CreatedAt   time.Time `json:"createdAt"`
+	UpdatedAt   time.Time `json:"updatedAt"`
+	Group       string    `json:"group"`
+	Description string    `json:"description"`
+	IsRemoved   bool      `json:"isRemoved"`
 }
 
 type Organization struct {
@@ -31,6 +34,9 @@ type Organization struct {
 	IsArchived bool        `json:"isArchived"`
 	CreatedAt  time.Time   `json:"createdAt"`
 	UpdatedAt  time.Time   `json:"updatedAt"`
+
+	RequireManagement2FA bool   `json:"requireManagement2FA"`
+	PhoneRegion          string `json:"phoneRegion"`
 }
 
 type Department struct {
@@ -58,44 +64,273 @@ type Role struct {
 	IsArchived   bool      `json:"isArchived"`
 	CreatedAt    time.Time `json:"createdAt"`
 	UpdatedAt    time.Time `json:"updatedAt"`
+
+	// PermissionScopes narrows permissions to a department or the own records,
+	// permissions missing from it are granted organization wide
+	PermissionScopes map[string]string `json:"permissionScopes"`
 }
 
 type User struct {
-	ID         int64         `json:"id"`
-	FirstName  string        `json:"firstName"`
-	LastName   string        `json:"lastName"`
-	Email      string        `json:"email"`
-	Phone      string        `json:"phone"`
-	IsAdmin    bool          `json:"isAdmin"`
-	OrgUID     uuid.NullUUID `json:"orgUID"`
-	RoleID     null.Int64    `json:"roleID"`
-	Status     string        `json:"status"`
-	IsFinal    bool          `json:"isFinal"`
-	IsArchived bool          `json:"isArchived"`
-	CreatedAt  time.Time     `json:"createdAt"`
-	UpdatedAt  time.Time     `json:"updatedAt"`
+	ID                int64         `json:"id"`
+	FirstName         string        `json:"firstName"`
+	LastName          string        `json:"lastName"`
+	Email             string        `json:"email"`
+	Phone             string        `json:"phone"`
+	IsAdmin           bool          `json:"isAdmin"`
+	OrgUID            uuid.NullUUID `json:"orgUID"`
+	RoleID            null.Int64    `json:"roleID"`
+	Status            string        `json:"status"`
+	IsFinal           bool          `json:"isFinal"`
+	IsArchived        bool          `json:"isArchived"`
+	CreatedAt         time.Time     `json:"createdAt"`
+	UpdatedAt         time.Time     `json:"updatedAt"`
+	FailedLoginCount  int           `json:"failedLoginCount"`
+	LastFailedLoginAt null.Time     `json:"lastFailedLoginAt"`
+	LockedUntil       null.Time     `json:"lockedUntil"`
 }
 
-type OTPSession struct {
+// UserRole assigns a role to a user, an assignment with an expiry stops granting once expired
+type UserRole struct {
 	ID        int64     `json:"id"`
 	UserID    int64     `json:"userID"`
-	Token     string    `json:"token"`
-	IsValid   bool      `json:"isValid"`
-	ExpiresAt time.Time `json:"expiresAt"`
+	RoleID    int64     `json:"roleID"`
+	ExpiresAt null.Time `json:"expiresAt"`
 	CreatedAt time.Time `json:"createdAt"`
 	UpdatedAt time.Time `json:"updatedAt"`
 }
 
+// PermissionElevation grants a user one permission between StartsAt and EndsAt once approved
+type PermissionElevation struct {
+	ID            int64      `json:"id"`
+	OrgUID        uuid.UUID  `json:"orgUID"`
+	UserID        int64      `json:"userID"`
+	Permission    string     `json:"permission"`
+	Justification string     `json:"justification"`
+	Status        string     `json:"status"`
+	StartsAt      time.Time  `json:"startsAt"`
+	EndsAt        time.Time  `json:"endsAt"`
+	RequestedBy   int64      `json:"requestedBy"`
+	DecidedBy     null.Int64 `json:"decidedBy"`
+	DecidedAt     null.Time  `json:"decidedAt"`
+	CreatedAt     time.Time  `json:"createdAt"`
+	UpdatedAt     time.Time  `json:"updatedAt"`
+}
+
+type SeparationRule struct {
+	ID          int64     `json:"id"`
+	OrgUID      uuid.UUID `json:"orgUID"`
+	Name        string    `json:"name"`
+	Permissions []string  `json:"permissions"`
+	IsActive    bool      `json:"isActive"`
+	CreatedBy   int64     `json:"createdBy"`
+	CreatedAt   time.Time `json:"createdAt"`
+	UpdatedAt   time.Time `json:"updatedAt"`
+}
+
+type AccessReviewCampaign struct {
+	ID           int64      `json:"id"`
+	OrgUID       uuid.UUID  `json:"orgUID"`
+	DepartmentID null.Int64 `json:"departmentID"`
+	Name         string     `json:"name"`
+	Status       string     `json:"status"`
+	ReviewerIDs  []int64    `json:"reviewerIDs"`
+	StartedBy    int64      `json:"startedBy"`
+	ClosedBy     null.Int64 `json:"closedBy"`
+	ClosedAt     null.Time  `json:"closedAt"`
+	CreatedAt    time.Time  `json:"createdAt"`
+	UpdatedAt    time.Time  `json:"updatedAt"`
+}
+
+type AccessReviewItem struct {
+	ID             int64      `json:"id"`
+	CampaignID     int64      `json:"campaignID"`
+	UserID         int64      `json:"userID"`
+	RoleID         int64      `json:"roleID"`
+	IsPrimary      bool       `json:"isPrimary"`
+	ReviewerID     int64      `json:"reviewerID"`
+	Decision       string     `json:"decision"`
+	RevokeAction   string     `json:"revokeAction"`
+	ReassignRoleID null.Int64 `json:"reassignRoleID"`
+	Note           string     `json:"note"`
+	DecidedAt      null.Time  `json:"decidedAt"`
+	AppliedAt      null.Time  `json:"appliedAt"`
+	CreatedAt      time.Time  `json:"createdAt"`
+	UpdatedAt      time.Time  `json:"updatedAt"`
+}
+
+type OTPSession struct {
+	ID             int64     `json:"id"`
+	UserID         int64     `json:"userID"`
+	TokenHash      string    `json:"-"`
+	IsValid        bool      `json:"isValid"`
+	ExpiresAt      time.Time `json:"expiresAt"`
+	CreatedAt      time.Time `json:"createdAt"`
+	UpdatedAt      time.Time `json:"updatedAt"`
+	IPAddress      string    `json:"ipAddress"`
+	FailedAttempts int       `json:"failedAttempts"`
+}
+
+type AuthAttempt struct {
+	ID        int64      `json:"id"`
+	UserID    null.Int64 `json:"userID"`
+	IPAddress string     `json:"ipAddress"`
+	Action    string     `json:"action"`
+	IsSuccess bool       `json:"isSuccess"`
+	CreatedAt time.Time  `json:"createdAt"`
+	UpdatedAt time.Time  `json:"updatedAt"`
+}
+
 type AuthSession struct {
+	ID                int64      `json:"id"`
+	UserID            int64      `json:"userID"`
+	TokenHash         string     `json:"-"`
+	IsValid           bool       `json:"isValid"`
+	ExpiresAt         time.Time  `json:"expiresAt"`
+	CreatedAt         time.Time  `json:"createdAt"`
+	UpdatedAt         time.Time  `json:"updatedAt"`
+	IPAddress         string     `json:"ipAddress"`
+	UserAgent         string     `json:"userAgent"`
+	DeviceLabel       string     `json:"deviceLabel"`
+	LastSeenAt        time.Time  `json:"lastSeenAt"`
+	AbsoluteExpiresAt time.Time  `json:"absoluteExpiresAt"`
+	AssuranceLevel    string     `json:"assuranceLevel"`
+	ImpersonatorID    null.Int64 `json:"impersonatorID"`
+}
+
+type RefreshToken struct {
 	ID        int64     `json:"id"`
-	UserID    int64     `json:"userID"`
-	Token     uuid.UUID `json:"token"`
-	IsValid   bool      `json:"isValid"`
+	SessionID int64     `json:"sessionID"`
+	TokenHash string    `json:"-"`
+	IsUsed    bool      `json:"isUsed"`
 	ExpiresAt time.Time `json:"expiresAt"`
 	CreatedAt time.Time `json:"createdAt"`
 	UpdatedAt time.Time `json:"updatedAt"`
 }
 
+type TOTPCredential struct {
+	ID           int64     `json:"id"`
+	UserID       int64     `json:"userID"`
+	Secret       string    `json:"-"`
+	IsEnabled    bool      `json:"isEnabled"`
+	LastUsedStep int64     `json:"lastUsedStep"`
+	ConfirmedAt  null.Time `json:"confirmedAt"`
+	CreatedAt    time.Time `json:"createdAt"`
+	UpdatedAt    time.Time `json:"updatedAt"`
+}
+
+type RecoveryCode struct {
+	ID        int64     `json:"id"`
+	UserID    int64     `json:"userID"`
+	CodeHash  string    `json:"-"`
+	UsedAt    null.Time `json:"usedAt"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+type WebAuthnCredential struct {
+	ID           int64     `json:"id"`
+	UserID       int64     `json:"userID"`
+	CredentialID string    `json:"credentialID"`
+	PublicKey    []byte    `json:"-"`
+	Algorithm    int64     `json:"algorithm"`
+	SignCount    int64     `json:"signCount"`
+	Name         string    `json:"name"`
+	IsCloned     bool      `json:"isCloned"`
+	LastUsedAt   null.Time `json:"lastUsedAt"`
+	CreatedAt    time.Time `json:"createdAt"`
+	UpdatedAt    time.Time `json:"updatedAt"`
+}
+
+type WebAuthnChallenge struct {
+	ID            int64      `json:"id"`
+	UserID        null.Int64 `json:"userID"`
+	Ceremony      string     `json:"ceremony"`
+	ChallengeHash string     `json:"-"`
+	IPAddress     string     `json:"ipAddress"`
+	IsUsed        bool       `json:"isUsed"`
+	ExpiresAt     time.Time  `json:"expiresAt"`
+	CreatedAt     time.Time  `json:"createdAt"`
+	UpdatedAt     time.Time  `json:"updatedAt"`
+}
+
+type OrganizationOIDCConfig struct {
+	ID              int64      `json:"id"`
+	OrgUID          uuid.UUID  `json:"orgUID"`
+	Issuer          string     `json:"issuer"`
+	ClientID        string     `json:"clientID"`
+	ClientSecret    string     `json:"-"`
+	AllowedDomains  []string   `json:"allowedDomains"`
+	IsEnabled       bool       `json:"isEnabled"`
+	JITProvisioning bool       `json:"jitProvisioning"`
+	DefaultRoleID   null.Int64 `json:"defaultRoleID"`
+	CreatedAt       time.Time  `json:"createdAt"`
+	UpdatedAt       time.Time  `json:"updatedAt"`
+}
+
+type APIKey struct {
+	ID          int64         `json:"id"`
+	OrgUID      uuid.NullUUID `json:"orgUID"`
+	UserID      null.Int64    `json:"userID"`
+	CreatedBy   int64         `json:"createdBy"`
+	Name        string        `json:"name"`
+	Prefix      string        `json:"prefix"`
+	KeyHash     string        `json:"-"`
+	Permissions []string      `json:"permissions"`
+	AllowedIPs  []string      `json:"allowedIPs"`
+	ExpiresAt   null.Time     `json:"expiresAt"`
+	LastUsedAt  null.Time     `json:"lastUsedAt"`
+	LastUsedIP  string        `json:"lastUsedIP"`
+	IsRevoked   bool          `json:"isRevoked"`
+	CreatedAt   time.Time     `json:"createdAt"`
+	UpdatedAt   time.Time     `json:"updatedAt"`
+}
+
+type Invitation struct {
+	ID         int64     `json:"id"`
+	OrgUID     uuid.UUID `json:"orgUID"`
+	UserID     int64     `json:"userID"`
+	Email      string    `json:"email"`
+	TokenHash  string    `json:"-"`
+	Status     string    `json:"status"`
+	InvitedBy  int64     `json:"invitedBy"`
+	ExpiresAt  time.Time `json:"expiresAt"`
+	AcceptedAt null.Time `json:"acceptedAt"`
+	SendCount  int       `json:"sendCount"`
+	LastSentAt time.Time `json:"lastSentAt"`
+	CreatedAt  time.Time `json:"createdAt"`
+	UpdatedAt  time.Time `json:"updatedAt"`
+}
+
+type ContactChange struct {
+	ID              int64     `json:"id"`
+	UserID          int64     `json:"userID"`
+	Channel         string    `json:"channel"`
+	OldValue        string    `json:"oldValue"`
+	NewValue        string    `json:"newValue"`
+	CodeHash        string    `json:"-"`
+	CancelTokenHash string    `json:"-"`
+	Status          string    `json:"status"`
+	FailedAttempts  int       `json:"failedAttempts"`
+	RequestedBy     int64     `json:"requestedBy"`
+	ExpiresAt       time.Time `json:"expiresAt"`
+	ConfirmedAt     null.Time `json:"confirmedAt"`
+	CreatedAt       time.Time `json:"createdAt"`
+	UpdatedAt       time.Time `json:"updatedAt"`
+}
+
+type OIDCLoginState struct {
+	ID           int64     `json:"id"`
+	OrgUID       uuid.UUID `json:"orgUID"`
+	StateHash    string    `json:"-"`
+	Nonce        string    `json:"-"`
+	CodeVerifier string    `json:"-"`
+	IPAddress    string    `json:"ipAddress"`
+	IsUsed       bool      `json:"isUsed"`
+	ExpiresAt    time.Time `json:"expiresAt"`
+	CreatedAt    time.Time `json:"createdAt"`
+	UpdatedAt    time.Time `json:"updatedAt"`
+}
+
 type UserActivity struct {
 	ID           int64         `json:"id"`
 	UserID       int64         `json:"userID"`
@@ -103,9 +338,10 @@ type UserActivity struct {
 	Action       string        `json:"action"`
 	ObjectID     null.Int64    `json:"objectID"`
 	ObjectType   null.String   `json:"objectType"`
-	SessionToken uuid.UUID     `json:"sessiosToken"`
+	SessionToken string        `json:"sessiosToken"`
 	CreatedAt    time.Time     `json:"createdAt"`
 	UpdatedAt    time.Time     `json:"updatedAt"`
+	ActorID      int64         `json:"actorID"`
 }
 
 ////////////////////////
@@ -119,6 +355,66 @@ type OrganizationRequest struct {
 	Sector     string      `json:"sector"`
 	Status     string      `json:"status"`
 	IsArchived bool        `json:"isArchived"`
+
+	RequireManagement2FA null.Bool   `json:"requireManagement2FA"`
+	PhoneRegion          null.String `json:"phoneRegion"`
+}
+
+// OIDCConfigRequest sets the single sign-on config of an organization, the client secret is kept when not given
+type OIDCConfigRequest struct {
+	Issuer          string      `json:"issuer"`
+	ClientID        string      `json:"clientID"`
+	ClientSecret    null.String `json:"clientSecret"`
+	AllowedDomains  []string    `json:"allowedDomains"`
+	IsEnabled       bool        `json:"isEnabled"`
+	JITProvisioning bool        `json:"jitProvisioning"`
+	DefaultRoleID   null.Int64  `json:"defaultRoleID"`
+}
+
+// APIKeyRequest creates an api key, keys without an organization are personal access tokens
+type APIKeyRequest struct {
+	Name        string        `json:"name"`
+	OrgUID      uuid.NullUUID `json:"orgUID"`
+	Permissions []string      `json:"permissions"`
+	AllowedIPs  []string      `json:"allowedIPs"`
+	ExpiresAt   null.Time     `json:"expiresAt"`
+}
+
+// PermissionElevationRequest requests a temporary permission, for the requester when no user is given.
+// Management roles get it approved right away unless they ask for an approval.
+type PermissionElevationRequest struct {
+	UserID          null.Int64 `json:"userID"`
+	Permission      string     `json:"permission"`
+	Justification   string     `json:"justification"`
+	StartsAt        null.Time  `json:"startsAt"`
+	EndsAt          time.Time  `json:"endsAt"`
+	RequireApproval bool       `json:"requireApproval"`
+}
+
+// SeparationRuleRequest lists permissions no single role or user of the organization may hold together
+type SeparationRuleRequest struct {
+	OrgUID      uuid.UUID `json:"orgUID"`
+	Name        string    `json:"name"`
+	Permissions []string  `json:"permissions"`
+	IsActive    bool      `json:"isActive"`
+}
+
+// AccessReviewCampaignRequest starts a review of the role assignments of an organization, or of
+// one of its departments, the reviewers share the assignments between them
+type AccessReviewCampaignRequest struct {
+	OrgUID       uuid.UUID  `json:"orgUID"`
+	DepartmentID null.Int64 `json:"departmentID"`
+	Name         string     `json:"name"`
+	ReviewerIDs  []int64    `json:"reviewerIDs"`
+}
+
+// AccessReviewDecisionRequest certifies or revokes a role assignment, a revocation archives the user
+// or reassigns the role to the reassigned role when the campaign closes
+type AccessReviewDecisionRequest struct {
+	Decision       string     `json:"decision"`
+	RevokeAction   string     `json:"revokeAction"`
+	ReassignRoleID null.Int64 `json:"reassignRoleID"`
+	Note           string     `json:"note"`
 }
 
 type OrganizationRegisterRequest struct {
@@ -130,6 +426,8 @@ type OrganizationRegisterRequest struct {
 	LastName  string      `json:"lastName"`
 	Email     string      `json:"email"`
 	Phone     string      `json:"phone"`
+
+	PhoneRegion null.String `json:"phoneRegion"`
 }
 
 type DepartmentRequest struct {
@@ -146,6 +444,8 @@ type RoleRequest struct {
 	IsManagement bool      `json:"isManagement"`
 	Status       string    `json:"status"`
 	IsFinal      bool      `json:"isFinal"`
+
+	PermissionScopes map[string]string `json:"permissionScopes"`
 }
 
 type SuperAdminRequest struct {
@@ -168,6 +468,15 @@ type UserRequest struct {
 	IsFinal   bool          `json:"isFInal"`
 }
 
+type AuthSessionRequest struct {
+	UserID         int64      `json:"userID"`
+	IPAddress      string     `json:"ipAddress"`
+	UserAgent      string     `json:"userAgent"`
+	DeviceLabel    string     `json:"deviceLabel"`
+	AssuranceLevel string     `json:"assuranceLevel"`
+	ImpersonatorID null.Int64 `json:"impersonatorID"`
+}
+
 type UserActivityRequest struct {
 	UserID       int64         `json:"userID"`
 	OrgUID       uuid.NullUUID `json:"orgUID"`
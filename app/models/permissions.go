@@ -1,5 +1,7 @@
 package models
 
+import "gogql/app/models/dbmodels"
+
 const (
 	UploadFile string = "UPLOAD_FILE"
 
@@ -25,26 +27,189 @@ const (
 	UpdateContact      string = "UPDATE_CONTRACT"
 	DeleteContact      string = "DELETE_CONTRACT"
 	ReadUserActivity   string = "READ_USER_ACTIVITY"
+	ManageAPIKey       string = "MANAGE_API_KEY"
+)
+
+// Permission groups
+const (
+	FilePermissions         string = "FILES"
+	OrganizationPermissions string = "ORGANIZATIONS"
+	DepartmentPermissions   string = "DEPARTMENTS"
+	RolePermissions         string = "ROLES"
+	UserPermissions         string = "USERS"
+	ContractPermissions     string = "CONTRACTS"
+	APIKeyPermissions       string = "API_KEYS"
 )
 
+// PermissionDefinition describes a permission of the catalogue
+type PermissionDefinition struct {
+	Name        string `json:"name"`
+	Group       string `json:"group"`
+	Description string `json:"description"`
+}
+
+// PermissionRegistry is the catalogue of the permissions roles and api keys can be granted,
+// it is synced into the permissions table at startup
+var PermissionRegistry = []PermissionDefinition{
+	{UploadFile, FilePermissions, "Upload files"},
+
+	{CreateOrganization, OrganizationPermissions, "Register organizations"},
+	{ReadOrganization, OrganizationPermissions, "View organizations"},
+	{UpdateOrganization, OrganizationPermissions, "Update organizations and their settings"},
+	{DeleteOrganization, OrganizationPermissions, "Delete organizations"},
+
+	{CreateDepartment, DepartmentPermissions, "Create departments"},
+	{ReadDepartment, DepartmentPermissions, "View departments"},
+	{UpdateDepartment, DepartmentPermissions, "Update, finalize and archive departments"},
+	{DeleteDepartment, DepartmentPermissions, "Delete departments"},
+
+	{CreateRole, RolePermissions, "Create roles"},
+	{ReadRole, RolePermissions, "View roles and their permissions"},
+	{UpdateRole, RolePermissions, "Update, finalize and archive roles"},
+	{DeleteRole, RolePermissions, "Delete roles"},
+
+	{CreateUser, UserPermissions, "Invite users"},
+	{ReadUser, UserPermissions, "View users and their sessions"},
+	{UpdateUser, UserPermissions, "Update and archive users"},
+	{DeleteUser, UserPermissions, "Delete users"},
+	{ReadUserActivity, UserPermissions, "View the activity log of users"},
+
+	{CreateContact, ContractPermissions, "Create contracts"},
+	{ReadContact, ContractPermissions, "View contracts"},
+	{UpdateContact, ContractPermissions, "Update contracts"},
+	{DeleteContact, ContractPermissions, "Delete contracts"},
+
+	{ManageAPIKey, APIKeyPermissions, "Create and revoke the api keys of the organization"},
+}
+
+// RenamedPermissions maps the old name of a renamed permission to its new name, roles and api keys
+// still holding the old name are migrated when the catalogue is synced
+var RenamedPermissions = map[string]string{}
+
+// RolePermissionIssue is a role granting permissions that are not in the registry
+type RolePermissionIssue struct {
+	Role               dbmodels.Role `json:"role"`
+	UnknownPermissions []string      `json:"unknownPermissions"`
+}
+
+// SeparationViolation is a role or a user holding two or more permissions of a separation rule,
+// the user is left empty for roles and the role for users
+type SeparationViolation struct {
+	Rule        dbmodels.SeparationRule `json:"rule"`
+	Role        *dbmodels.Role          `json:"role"`
+	User        *dbmodels.User          `json:"user"`
+	Permissions []string                `json:"permissions"`
+}
+
+// ListPermissions lists the names of the permissions in the registry
 func ListPermissions() []string {
-	return []string{
-		UploadFile,
-		CreateOrganization,
-		ReadOrganization,
-		UpdateOrganization,
-		DeleteOrganization,
-		CreateDepartment,
-		ReadDepartment,
-		UpdateDepartment,
-		DeleteDepartment,
-		CreateRole,
-		ReadRole,
-		UpdateRole,
-		DeleteRole,
-		CreateUser,
-		ReadUser,
-		UpdateUser,
-		DeleteUser,
+	result := []string{}
+	for _, perm := range PermissionRegistry {
+		result = append(result, perm.Name)
+	}
+	return result
+}
+
+// IsPermission reports whether a permission is in the registry
+func IsPermission(name string) bool {
+	for _, perm := range PermissionRegistry {
+		if perm.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Scopes a role grants a permission with, permissions without a scope cover the organization
+const (
+	ScopeOrganization string = "ORGANIZATION"
+	ScopeDepartment   string = "DEPARTMENT"
+	ScopeOwn          string = "OWN"
+)
+
+// PermissionScope is the part of the organization a permission was granted for, department
+// scoped grants add to DepartmentIDs and a grant of the own records sets UserID. The roles
+// of a user add up, records inside any of the departments or of the user are in scope.
+type PermissionScope struct {
+	Scope         string  `json:"scope"`
+	DepartmentIDs []int64 `json:"departmentIDs"`
+	UserID        *int64  `json:"userID"`
+}
+
+// OrganizationScope is the scope of super admins, management roles and organization wide grants
+func OrganizationScope() PermissionScope {
+	return PermissionScope{Scope: ScopeOrganization}
+}
+
+// Covers reports whether the records of a user holding roles of the departments are in the
+// scope, it decides like the scoped list queries
+func (p PermissionScope) Covers(userID int64, departmentIDs []int64) bool {
+	if p.Scope == ScopeOrganization || (p.DepartmentIDs == nil && p.UserID == nil) {
+		return true
+	}
+	if p.UserID != nil && *p.UserID == userID {
+		return true
+	}
+	for _, id := range departmentIDs {
+		for _, scoped := range p.DepartmentIDs {
+			if id == scoped {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// IsPermissionScope reports whether a scope is known
+func IsPermissionScope(scope string) bool {
+	return scope == ScopeOrganization || scope == ScopeDepartment || scope == ScopeOwn
+}
+
+// Reasons a permission check was granted or denied with
+const (
+	PermissionReasonAdmin          string = "ADMIN"
+	PermissionReasonManagementRole string = "MANAGEMENT_ROLE"
+	PermissionReasonRole           string = "ROLE"
+	PermissionReasonAPIKey         string = "API_KEY"
+	PermissionReasonElevation      string = "ELEVATION"
+
+	PermissionReasonUnknown       string = "UNKNOWN_PERMISSION"
+	PermissionReasonAPIKeyMissing string = "API_KEY_MISSING"
+	PermissionReasonMissing       string = "MISSING"
+	PermissionReasonInactive      string = "INACTIVE"
+)
+
+// PermissionDecision explains a permission check, Roles are the roles granting the permission
+// and Scope the part of the organization it was granted for
+type PermissionDecision struct {
+	Permission  string          `json:"permission"`
+	Granted     bool            `json:"granted"`
+	Reason      string          `json:"reason"`
+	Explanation string          `json:"explanation"`
+	Roles       []dbmodels.Role `json:"roles"`
+	Scope       PermissionScope `json:"scope"`
+}
+
+// PermissionHolder is a user granted a permission and the decision granting it
+type PermissionHolder struct {
+	UserID   int64               `json:"userID"`
+	Decision *PermissionDecision `json:"decision"`
+}
+
+// SeparationConflicts returns the permissions of a separation rule found in permissions, it is
+// empty unless two or more of them are held together
+func SeparationConflicts(rule dbmodels.SeparationRule, permissions []string) []string {
+	result := []string{}
+	for _, perm := range rule.Permissions {
+		for _, held := range permissions {
+			if held == perm {
+				result = append(result, perm)
+				break
+			}
+		}
+	}
+	if len(result) < 2 {
+		return []string{}
 	}
+	return result
 }
@@ -0,0 +1,57 @@
+package models
+
+import (
+	"gogql/app/models/dbmodels"
+	"strings"
+	"testing"
+)
+
+func TestSeparationConflicts(t *testing.T) {
+	rule := dbmodels.SeparationRule{Name: "User admin", Permissions: []string{CreateUser, UpdateRole, DeleteUser}}
+
+	tests := []struct {
+		name        string
+		permissions []string
+		want        string
+	}{
+		{"none", []string{ReadUser}, ""},
+		{"one", []string{CreateUser, ReadUser}, ""},
+		{"pair", []string{UpdateRole, ReadUser, CreateUser}, "CREATE_USER,UPDATE_ROLE"},
+		{"duplicates", []string{CreateUser, CreateUser}, ""},
+		{"all", []string{DeleteUser, UpdateRole, CreateUser}, "CREATE_USER,UPDATE_ROLE,DELETE_USER"},
+	}
+	for _, tt := range tests {
+		got := strings.Join(SeparationConflicts(rule, tt.permissions), ",")
+		if got != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestPermissionScopeCovers(t *testing.T) {
+	userID := int64(7)
+	department := PermissionScope{Scope: ScopeDepartment, DepartmentIDs: []int64{3}}
+	own := PermissionScope{Scope: ScopeOwn, UserID: &userID}
+	both := PermissionScope{Scope: ScopeDepartment, DepartmentIDs: []int64{3}, UserID: &userID}
+
+	tests := []struct {
+		name          string
+		scope         PermissionScope
+		userID        int64
+		departmentIDs []int64
+		want          bool
+	}{
+		{"organization", OrganizationScope(), 9, nil, true},
+		{"department member", department, 9, []int64{1, 3}, true},
+		{"other department", department, 9, []int64{1}, false},
+		{"no department", department, 9, nil, false},
+		{"own record", own, 7, nil, true},
+		{"record of another user", own, 9, []int64{3}, false},
+		{"own record with a department grant", both, 7, nil, true},
+	}
+	for _, tt := range tests {
+		if got := tt.scope.Covers(tt.userID, tt.departmentIDs); got != tt.want {
+			t.Errorf("%s: got %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
@@ -5,7 +5,9 @@ import (
 	"gogql/app/services/authservice"
 	"gogql/app/services/orgservice"
 	"gogql/app/services/settingservice"
+	"gogql/app/store/cachestore"
 	"gogql/app/store/dbstore"
+	"gogql/app/store/messagestore"
 )
 
 type Services struct {
@@ -21,21 +23,25 @@ type Services struct {
 	RoleService         *orgservice.RoleService
 	UserService         *orgservice.UserService
 	UserActivityService *orgservice.UserActivityService
+	InvitationService   *orgservice.InvitationService
+	AccessReviewService *orgservice.AccessReviewService
 }
 
-func NewService(dbs *dbstore.DBStore, master *master.Master) *Services {
+func NewService(dbs *dbstore.DBStore, master *master.Master, ms *messagestore.MessageStore, cs *cachestore.CacheStore) *Services {
 	return &Services{
 		// settings
 		settingservice.NewDBTX(dbs),
 
 		// authentication
-		authservice.NewAuthService(dbs, master),
+		authservice.NewAuthService(dbs, master, ms, cs),
 
 		// companies
 		orgservice.NewOrganizationService(dbs, master),
 		orgservice.NewDepartmentService(dbs, master),
-		orgservice.NewRoleService(dbs, master),
-		orgservice.NewUserService(dbs, master),
+		orgservice.NewRoleService(dbs, master, cs),
+		orgservice.NewUserService(dbs, master, ms, cs),
 		orgservice.NewUserActivityService(dbs, master),
+		orgservice.NewInvitationService(dbs, master, ms),
+		orgservice.NewAccessReviewService(dbs, master, cs),
 	}
 }
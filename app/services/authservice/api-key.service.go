@@ -0,0 +1,152 @@
+package authservice
+
+import (
+	"context"
+	"fmt"
+	"gogql/app/models"
+	"gogql/app/models/dbmodels"
+	"gogql/utils/faulterr"
+	"net/http"
+
+	"github.com/gofrs/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/volatiletech/null"
+)
+
+// GetAutherByAPIKey returns the auther of an api key. Personal access tokens act as their user,
+// organization keys act as the organization and are attributed to the user who created them.
+func (s *AuthService) GetAutherByAPIKey(ctx context.Context, key string, ipAddress string) (*models.Auther, *faulterr.FaultErr) {
+	obj, err := s.master.APIKeyMaster.Verify(ctx, key, ipAddress)
+	if err != nil {
+		return nil, err
+	}
+
+	auther := &models.Auther{
+		ID:             obj.CreatedBy,
+		Name:           obj.Name,
+		OrgUID:         obj.OrgUID,
+		SessionToken:   uuid.Nil,
+		ExpiresAt:      obj.ExpiresAt.Time,
+		AssuranceLevel: models.AssuranceAPIKey,
+		APIKeyID:       null.Int64From(obj.ID),
+		Permissions:    obj.Permissions,
+	}
+	if obj.UserID.Valid {
+		user, err := s.dbstore.UserStore.GetByID(ctx, obj.UserID.Int64)
+		if err != nil {
+			return nil, err
+		}
+		if user.IsArchived {
+			return nil, faulterr.NewUnauthorizedError("api key is not valid")
+		}
+		if err := s.checkLockout(user); err != nil {
+			return nil, err
+		}
+
+		auther.ID = user.ID
+		auther.Name = fmt.Sprintf("%s %s", user.FirstName, user.LastName)
+		auther.OrgUID = user.OrgUID
+		auther.RoleID = user.RoleID
+	}
+
+	s.master.APIKeyMaster.Touch(ctx, obj, ipAddress)
+	return auther, nil
+}
+
+// CreateAPIKey creates a personal access token of the auther, or a key of the organization when one is given.
+// A key can only be given permissions its creator holds, for a key of the organization organization wide.
+func (s *AuthService) CreateAPIKey(ctx context.Context, tx pgx.Tx, auther *models.Auther, req dbmodels.APIKeyRequest) (*models.APIKeyCreated, *faulterr.FaultErr) {
+	obj := dbmodels.APIKey{
+		CreatedBy:   auther.ID,
+		Name:        req.Name,
+		Permissions: req.Permissions,
+		AllowedIPs:  req.AllowedIPs,
+		ExpiresAt:   req.ExpiresAt,
+	}
+
+	if req.OrgUID.Valid {
+		if err := s.authorizeOrgAPIKeys(ctx, auther, req.OrgUID.UUID); err != nil {
+			return nil, err
+		}
+		if _, err := s.dbstore.OrganizationStore.GetByUID(ctx, req.OrgUID.UUID); err != nil {
+			return nil, err
+		}
+		obj.OrgUID = req.OrgUID
+	} else {
+		obj.UserID = null.Int64From(auther.ID)
+		obj.OrgUID = auther.OrgUID
+	}
+
+	if err := s.authorizeAPIKeyPermissions(ctx, auther, req.Permissions, req.OrgUID.Valid); err != nil {
+		return nil, err
+	}
+
+	result, key, err := s.master.APIKeyMaster.Create(ctx, tx, obj)
+	if err != nil {
+		return nil, err
+	}
+	return &models.APIKeyCreated{APIKey: result, Key: key}, nil
+}
+
+// ListAPIKeys lists the personal access tokens of the auther, or the keys of the organization when one is given
+func (s *AuthService) ListAPIKeys(ctx context.Context, auther *models.Auther, orgUID *uuid.UUID) ([]dbmodels.APIKey, *faulterr.FaultErr) {
+	if orgUID == nil {
+		return s.dbstore.APIKeyStore.ListByUserID(ctx, auther.ID)
+	}
+	if err := s.authorizeOrgAPIKeys(ctx, auther, *orgUID); err != nil {
+		return nil, err
+	}
+	return s.dbstore.APIKeyStore.ListByOrgUID(ctx, *orgUID)
+}
+
+// RevokeAPIKey revokes a personal access token of the auther or a key of an organization the auther manages
+func (s *AuthService) RevokeAPIKey(ctx context.Context, tx pgx.Tx, auther *models.Auther, id int64) (*dbmodels.APIKey, *faulterr.FaultErr) {
+	obj, err := s.dbstore.APIKeyStore.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if obj.UserID.Valid {
+		if obj.UserID.Int64 != auther.ID && !auther.IsAdmin {
+			return nil, faulterr.NewNotFoundError("api key not found")
+		}
+	} else if err := s.authorizeOrgAPIKeys(ctx, auther, obj.OrgUID.UUID); err != nil {
+		if err.Status == http.StatusUnauthorized {
+			return nil, faulterr.NewNotFoundError("api key not found")
+		}
+		return nil, err
+	}
+
+	return s.master.APIKeyMaster.Revoke(ctx, tx, obj)
+}
+
+// Helpers
+
+// authorizeOrgAPIKeys allows admins and members of the organization with the api key permission
+func (s *AuthService) authorizeOrgAPIKeys(ctx context.Context, auther *models.Auther, orgUID uuid.UUID) *faulterr.FaultErr {
+	if auther.IsAdmin {
+		return nil
+	}
+	if !auther.OrgUID.Valid || auther.OrgUID.UUID != orgUID {
+		return faulterr.NewUnauthorizedError("permission denied")
+	}
+	return s.GrantPermission(ctx, auther, models.ManageAPIKey)
+}
+
+// authorizeAPIKeyPermissions allows the permissions the auther holds. Keys of an organization have no role
+// and act on the whole organization, so their permissions have to be held organization wide.
+func (s *AuthService) authorizeAPIKeyPermissions(ctx context.Context, auther *models.Auther, permissions []string, orgKey bool) *faulterr.FaultErr {
+	for _, perm := range permissions {
+		scope, err := s.GetPermissionScope(ctx, auther, perm)
+		if err != nil {
+			if err.Status == http.StatusUnauthorized {
+				return faulterr.NewFrobiddenError(fmt.Sprintf("permission %s is not granted to you", perm))
+			}
+			return err
+		}
+		if orgKey && scope.Scope != models.ScopeOrganization {
+			return faulterr.NewFrobiddenError(fmt.Sprintf("permission %s is not granted to you for the whole organization", perm))
+		}
+	}
+	return nil
+}
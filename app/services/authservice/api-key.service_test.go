@@ -0,0 +1,54 @@
+package authservice
+
+import (
+	"context"
+	"gogql/app/models"
+	"gogql/app/models/dbmodels"
+	"gogql/app/store/cachestore"
+	"testing"
+	"time"
+
+	"github.com/volatiletech/null"
+)
+
+// newCachedService builds an auth service whose permission checks of the user are served from the cache
+func newCachedService(userID int64, roles ...dbmodels.Role) *AuthService {
+	cs := cachestore.NewCacheStore(time.Minute)
+	userRoles := []dbmodels.UserRole{}
+	for _, role := range roles {
+		cs.SetRole(role)
+		userRoles = append(userRoles, dbmodels.UserRole{UserID: userID, RoleID: role.ID})
+	}
+	cs.SetUserRoles(userID, userRoles)
+	cs.SetElevations(userID, []dbmodels.PermissionElevation{})
+	return NewAuthService(nil, nil, nil, cs)
+}
+
+func TestAuthorizeAPIKeyPermissions(t *testing.T) {
+	ctx := context.Background()
+	member := &models.Auther{ID: 7, RoleID: null.Int64From(1)}
+	s := newCachedService(member.ID, dbmodels.Role{
+		ID:               1,
+		DepartmentID:     3,
+		Permissions:      []string{models.ManageAPIKey, models.ReadUser, models.ReadRole},
+		PermissionScopes: map[string]string{models.ReadUser: models.ScopeDepartment},
+	})
+
+	tests := []struct {
+		name        string
+		permissions []string
+		orgKey      bool
+		wantErr     bool
+	}{
+		{"personal key with a department grant", []string{models.ReadUser}, false, false},
+		{"organization key with an organization grant", []string{models.ReadRole}, true, false},
+		{"organization key with a department grant", []string{models.ReadRole, models.ReadUser}, true, true},
+		{"permission not held", []string{models.UpdateUser}, false, true},
+	}
+	for _, tt := range tests {
+		err := s.authorizeAPIKeyPermissions(ctx, member, tt.permissions, tt.orgKey)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: got error %v, want error %v", tt.name, err != nil, tt.wantErr)
+		}
+	}
+}
@@ -0,0 +1,181 @@
+package authservice
+
+import (
+	"context"
+	"gogql/app/models"
+	"gogql/app/models/constants"
+	"gogql/app/models/dbmodels"
+	"gogql/utils/faulterr"
+	"net/http"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// sessions are marked as seen at most once per interval to avoid a write on every request
+const lastSeenInterval = time.Minute
+
+// ListSessions lists the active sessions of a user, the session used by the auther is marked as current
+func (s *AuthService) ListSessions(ctx context.Context, auther *models.Auther, userID int64) ([]models.Session, *faulterr.FaultErr) {
+	objs, err := s.dbstore.AuthSessionStore.ListActiveByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := []models.Session{}
+	for _, obj := range objs {
+		result = append(result, s.getSession(obj, auther.SessionID))
+	}
+	return result, nil
+}
+
+// Logout invalidates the session used by the auther
+func (s *AuthService) Logout(ctx context.Context, tx pgx.Tx, auther *models.Auther) *faulterr.FaultErr {
+	obj, err := s.dbstore.AuthSessionStore.GetByID(ctx, auther.SessionID)
+	if err != nil {
+		return err
+	}
+
+	obj.IsValid = false
+	if err := s.dbstore.AuthSessionStore.Update(ctx, tx, obj); err != nil {
+		return err
+	}
+	s.dbstore.DBTX.InvalidateAfterCommit(ctx, tx, func() { s.cachestore.InvalidateSession(obj.ID) })
+	return nil
+}
+
+// RevokeSession invalidates one of the auther's sessions, super admins can revoke any session
+func (s *AuthService) RevokeSession(ctx context.Context, tx pgx.Tx, auther *models.Auther, id int64) (*models.Session, *faulterr.FaultErr) {
+	obj, err := s.dbstore.AuthSessionStore.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if obj.UserID != auther.ID && !auther.IsAdmin {
+		return nil, faulterr.NewNotFoundError("session not found")
+	}
+	if !obj.IsValid {
+		return nil, faulterr.NewBadRequestError("session is already revoked")
+	}
+
+	obj.IsValid = false
+	if err := s.dbstore.AuthSessionStore.Update(ctx, tx, obj); err != nil {
+		return nil, err
+	}
+	s.dbstore.DBTX.InvalidateAfterCommit(ctx, tx, func() { s.cachestore.InvalidateSession(obj.ID) })
+
+	result := s.getSession(*obj, auther.SessionID)
+	return &result, nil
+}
+
+// RefreshSession exchanges a refresh token for a new session token and refresh token,
+// reusing a rotated refresh token revokes the whole session as the token family is compromised
+func (s *AuthService) RefreshSession(ctx context.Context, tx pgx.Tx, req *models.RefreshRequest) (*models.Auther, *faulterr.FaultErr) {
+	refreshToken, err := s.master.AuthSessionMaster.GetRefreshToken(ctx, req.RefreshToken)
+	if err != nil {
+		if err.Status == http.StatusNotFound {
+			return nil, faulterr.NewUnauthorizedError("refresh token is invalid")
+		}
+		return nil, err
+	}
+	authSession, err := s.dbstore.AuthSessionStore.GetByID(ctx, refreshToken.SessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	if refreshToken.IsUsed {
+		s.revokeReusedSession(ctx, authSession.ID, authSession.UserID)
+		return nil, faulterr.NewUnauthorizedError("refresh token was already used").WithCode(constants.ErrCodeRefreshTokenReused)
+	}
+	if err := s.master.AuthSessionMaster.CheckRefreshable(authSession, refreshToken); err != nil {
+		return nil, err
+	}
+
+	// a concurrent refresh may have used the token since it was read
+	marked, err := s.dbstore.RefreshTokenStore.MarkUsed(ctx, tx, refreshToken.ID)
+	if err != nil {
+		return nil, err
+	}
+	if !marked {
+		s.revokeReusedSession(ctx, authSession.ID, authSession.UserID)
+		return nil, faulterr.NewUnauthorizedError("refresh token was already used").WithCode(constants.ErrCodeRefreshTokenReused)
+	}
+
+	tokens, err := s.master.AuthSessionMaster.Rotate(ctx, tx, authSession)
+	if err != nil {
+		return nil, err
+	}
+	s.dbstore.DBTX.InvalidateAfterCommit(ctx, tx, func() { s.cachestore.InvalidateSession(authSession.ID) })
+
+	user, err := s.dbstore.UserStore.GetByID(ctx, authSession.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if user.IsArchived {
+		return nil, faulterr.NewUnauthorizedError("account is archived").WithCode(constants.ErrCodeAccountArchived)
+	}
+
+	auther := s.getAuther(user, authSession, tokens.AccessToken)
+	auther.RefreshToken = tokens.RefreshToken
+	return auther, nil
+}
+
+// LogoutAllSessions invalidates every session of the auther including the current one
+func (s *AuthService) LogoutAllSessions(ctx context.Context, tx pgx.Tx, auther *models.Auther) (int64, *faulterr.FaultErr) {
+	count, err := s.dbstore.AuthSessionStore.InvalidateByUserID(ctx, tx, auther.ID)
+	if err != nil {
+		return 0, err
+	}
+	s.dbstore.DBTX.InvalidateAfterCommit(ctx, tx, func() { s.cachestore.InvalidateUser(auther.ID) })
+	return count, nil
+}
+
+// Helpers
+
+// revokeReusedSession invalidates a session whose refresh token was reused and records it,
+// in its own transaction so the revocation survives the failed request
+func (s *AuthService) revokeReusedSession(ctx context.Context, sessionID int64, userID int64) {
+	user, err := s.dbstore.UserStore.GetByID(ctx, userID)
+	if err != nil {
+		return
+	}
+
+	s.withSecurityTx(ctx, func(tx pgx.Tx) *faulterr.FaultErr {
+		return s.revokeSessionFamily(ctx, tx, sessionID, user)
+	})
+	s.cachestore.InvalidateSession(sessionID)
+}
+
+// revokeSessionFamily invalidates the session the refresh tokens of a family belong to, the session
+// read by the request may be stale so only its validity is written
+func (s *AuthService) revokeSessionFamily(ctx context.Context, tx pgx.Tx, sessionID int64, user *dbmodels.User) *faulterr.FaultErr {
+	if err := s.dbstore.AuthSessionStore.InvalidateByID(ctx, tx, sessionID); err != nil {
+		return err
+	}
+	return s.insertSecurityActivity(ctx, tx, user, constants.AutherObject, constants.SessionReuseAction)
+}
+
+// touchSession records that the session was used, failures are logged by the store and ignored,
+// the cached session is dropped so it is not touched again on every request
+func (s *AuthService) touchSession(ctx context.Context, obj *dbmodels.AuthSession) {
+	if time.Since(obj.LastSeenAt) < lastSeenInterval {
+		return
+	}
+	s.dbstore.AuthSessionStore.UpdateLastSeen(ctx, obj.ID)
+	s.cachestore.InvalidateSession(obj.ID)
+}
+
+func (s *AuthService) getSession(obj dbmodels.AuthSession, currentSessionID int64) models.Session {
+	return models.Session{
+		ID:          obj.ID,
+		UserID:      obj.UserID,
+		IPAddress:   obj.IPAddress,
+		UserAgent:   obj.UserAgent,
+		DeviceLabel: obj.DeviceLabel,
+		LastSeenAt:  obj.LastSeenAt,
+		ExpiresAt:   obj.ExpiresAt,
+		CreatedAt:   obj.CreatedAt,
+		IsCurrent:   obj.ID == currentSessionID,
+
+		ImpersonatorID: obj.ImpersonatorID,
+	}
+}
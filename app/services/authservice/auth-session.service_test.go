@@ -0,0 +1,127 @@
+package authservice
+
+import (
+	"context"
+	"errors"
+	"gogql/app/master"
+	"gogql/app/master/orgmaster"
+	"gogql/app/models/constants"
+	"gogql/app/models/dbmodels"
+	"gogql/app/store/cachestore"
+	"gogql/app/store/dbstore"
+	"gogql/app/store/dbstore/orgstore"
+	"gogql/config"
+	"gogql/utils/encrypt"
+	"gogql/utils/faulterr"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofrs/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// recordingTx records the statements run in it, rows scan nothing
+type recordingTx struct {
+	pgx.Tx
+	statements []string
+	args       [][]any
+}
+
+func (t *recordingTx) Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error) {
+	t.statements = append(t.statements, sql)
+	t.args = append(t.args, args)
+	return pgconn.CommandTag{}, nil
+}
+
+func (t *recordingTx) QueryRow(ctx context.Context, sql string, args ...any) pgx.Row {
+	t.statements = append(t.statements, sql)
+	t.args = append(t.args, args)
+	return recordedRow{}
+}
+
+type recordedRow struct{}
+
+func (recordedRow) Scan(dest ...any) error { return nil }
+
+// newRecordingService builds an auth service on stores that only run statements in a recordingTx
+func newRecordingService() *AuthService {
+	dbs := &dbstore.DBStore{
+		AuthSessionStore:  orgstore.NewAuthSessionStore(nil),
+		UserActivityStore: orgstore.NewUserActivityStore(nil),
+	}
+	m := &master.Master{
+		UserActivityMaster: orgmaster.NewUserActivityMaster(dbs, encrypt.NewTokenHasher("test")),
+	}
+	return NewAuthService(dbs, m, nil, cachestore.NewCacheStore(time.Minute))
+}
+
+func TestRevokeSessionFamily(t *testing.T) {
+	s := newRecordingService()
+	tx := &recordingTx{}
+	user := &dbmodels.User{ID: 10}
+
+	if err := s.revokeSessionFamily(context.Background(), tx, 7, user); err != nil {
+		t.Fatal(err.Message)
+	}
+	if len(tx.statements) != 2 {
+		t.Fatalf("got %d statements, want the revocation and its activity", len(tx.statements))
+	}
+
+	revocation := tx.statements[0]
+	if !strings.Contains(revocation, "is_valid=FALSE") || strings.Contains(revocation, "token_hash") {
+		t.Errorf("revocation writes more than the validity: %s", revocation)
+	}
+	if len(tx.args[0]) != 1 || tx.args[0][0] != int64(7) {
+		t.Errorf("revocation: got args %v, want the session id", tx.args[0])
+	}
+
+	if !strings.Contains(tx.statements[1], "user_activities") {
+		t.Fatalf("activity is not recorded: %s", tx.statements[1])
+	}
+	if action := *tx.args[1][2].(*string); action != string(constants.AutherObject)+"_"+constants.SessionReuseAction {
+		t.Errorf("activity: got action %s", action)
+	}
+}
+
+func TestArchivedUserLosesAccess(t *testing.T) {
+	ctx := context.Background()
+	s := newRecordingService()
+	hasher := encrypt.NewTokenHasher("test")
+	s.master.AuthSessionMaster = orgmaster.NewAuthSessionMaster(s.dbstore, hasher, &config.Security{SessionIdleTimeout: time.Hour})
+
+	token := uuid.Must(uuid.NewV4())
+	session := dbmodels.AuthSession{
+		ID:                1,
+		UserID:            10,
+		IsValid:           true,
+		LastSeenAt:        time.Now(),
+		ExpiresAt:         time.Now().Add(time.Hour),
+		AbsoluteExpiresAt: time.Now().Add(time.Hour),
+	}
+	user := dbmodels.User{ID: 10}
+	s.cachestore.SetSession(hasher.Hash(token.String()), session, user)
+	if _, err := s.autherByToken(ctx, token, false); err != nil {
+		t.Fatalf("active user: %s", err.Message)
+	}
+
+	// a session still cached with the user when they are archived by a review
+	user.IsArchived = true
+	s.cachestore.SetSession(hasher.Hash(token.String()), session, user)
+	if _, err := s.autherByToken(ctx, token, false); errCode(err) != constants.ErrCodeAccountArchived {
+		t.Errorf("archived user: got code %q, want the archived code", errCode(err))
+	}
+	if err := s.checkLockout(&user); errCode(err) != constants.ErrCodeAccountArchived {
+		t.Errorf("archived user can sign in: got code %q", errCode(err))
+	}
+}
+
+// errCode is the code of a coded fault error
+func errCode(err *faulterr.FaultErr) string {
+	var codedErr *faulterr.CodedError
+	if err == nil || !errors.As(err.Error, &codedErr) {
+		return ""
+	}
+	return codedErr.Code
+}
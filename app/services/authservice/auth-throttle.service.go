@@ -0,0 +1,259 @@
+package authservice
+
+import (
+	"context"
+	"fmt"
+	"gogql/app/models/constants"
+	"gogql/app/models/dbmodels"
+	"gogql/utils/faulterr"
+	"math"
+	"time"
+
+	"github.com/gofrs/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/volatiletech/null"
+)
+
+// Brute force protection policy
+const (
+	throttleWindow = time.Hour
+
+	maxOTPsPerUser       = 5  // otps issued to one user per window
+	maxOTPRequestsPerIP  = 20 // otp requests from one ip per window
+	maxOTPFailedAttempts = 3  // wrong codes before an otp is invalidated
+	otpBaseBackoff       = 30 * time.Second
+	maxFailedLogins      = 5  // consecutive failed logins before lockout
+	maxFailedLoginsPerIP = 20 // failed logins from one ip per window
+	loginBaseBackoff     = 2 * time.Second
+	maxLoginBackoff      = 2 * time.Minute
+	accountLockoutPeriod = 15 * time.Minute
+)
+
+// backoff doubles the base delay for every previous attempt, capped at max
+func backoff(base, max time.Duration, attempts int) time.Duration {
+	if attempts <= 0 {
+		return 0
+	}
+	wait := time.Duration(float64(base) * math.Pow(2, float64(attempts-1)))
+	if wait > max || wait <= 0 {
+		return max
+	}
+	return wait
+}
+
+func retryIn(wait time.Duration) string {
+	return fmt.Sprintf("try again in %d seconds", int(math.Ceil(wait.Seconds())))
+}
+
+// checkOTPRequestIP throttles otp requests coming from a single ip address
+func (s *AuthService) checkOTPRequestIP(ctx context.Context, ipAddress string) *faulterr.FaultErr {
+	if ipAddress == "" {
+		return nil
+	}
+	since := time.Now().Add(-throttleWindow)
+
+	issued, err := s.dbstore.OTPSessionStore.CountCreatedSince(ctx, nil, &ipAddress, since)
+	if err != nil {
+		return err
+	}
+	failed, err := s.dbstore.AuthAttemptStore.CountFailedSince(ctx, ipAddress, constants.OTPRequestAction, since)
+	if err != nil {
+		return err
+	}
+	if issued+failed >= maxOTPRequestsPerIP {
+		return faulterr.NewTooManyRequestsError("too many otp requests from this address, try again later").WithCode(constants.ErrCodeOTPThrottled)
+	}
+	return nil
+}
+
+// checkOTPIssuance caps otps issued to a user per window and spaces them with a growing backoff
+func (s *AuthService) checkOTPIssuance(ctx context.Context, user *dbmodels.User) *faulterr.FaultErr {
+	if err := s.checkLockout(user); err != nil {
+		return err
+	}
+
+	issued, err := s.dbstore.OTPSessionStore.CountCreatedSince(ctx, &user.ID, nil, time.Now().Add(-throttleWindow))
+	if err != nil {
+		return err
+	}
+	if issued >= maxOTPsPerUser {
+		s.recordSecurityActivity(ctx, user, constants.OTPObject, constants.ThrottleAction)
+		return faulterr.NewTooManyRequestsError("too many otps requested, try again later").WithCode(constants.ErrCodeOTPThrottled)
+	}
+	if issued == 0 {
+		return nil
+	}
+
+	latest, err := s.dbstore.OTPSessionStore.GetLatestByUserID(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+	wait := backoff(otpBaseBackoff, throttleWindow, issued) - time.Since(latest.CreatedAt)
+	if wait > 0 {
+		s.recordSecurityActivity(ctx, user, constants.OTPObject, constants.ThrottleAction)
+		return faulterr.NewTooManyRequestsError(fmt.Sprintf("otp was requested recently, %s", retryIn(wait))).WithCode(constants.ErrCodeOTPThrottled)
+	}
+	return nil
+}
+
+// checkLoginIP throttles failed logins coming from a single ip address
+func (s *AuthService) checkLoginIP(ctx context.Context, ipAddress string) *faulterr.FaultErr {
+	if ipAddress == "" {
+		return nil
+	}
+	failed, err := s.dbstore.AuthAttemptStore.CountFailedSince(ctx, ipAddress, constants.LoginAction, time.Now().Add(-throttleWindow))
+	if err != nil {
+		return err
+	}
+	if failed >= maxFailedLoginsPerIP {
+		return faulterr.NewTooManyRequestsError("too many failed logins from this address, try again later").WithCode(constants.ErrCodeLoginThrottled)
+	}
+	return nil
+}
+
+// checkLoginAllowed rejects logins of locked users and logins attempted before the backoff elapsed
+func (s *AuthService) checkLoginAllowed(ctx context.Context, user *dbmodels.User) *faulterr.FaultErr {
+	if err := s.checkLockout(user); err != nil {
+		return err
+	}
+
+	if user.FailedLoginCount > 0 && user.LastFailedLoginAt.Valid {
+		wait := backoff(loginBaseBackoff, maxLoginBackoff, user.FailedLoginCount) - time.Since(user.LastFailedLoginAt.Time)
+		if wait > 0 {
+			s.recordSecurityActivity(ctx, user, constants.AutherObject, constants.ThrottleAction)
+			return faulterr.NewTooManyRequestsError(fmt.Sprintf("too many failed logins, %s", retryIn(wait))).WithCode(constants.ErrCodeLoginThrottled)
+		}
+	}
+	return nil
+}
+
+// checkLockout rejects users who can not sign in, archived users, locked users and invited users
+// who did not accept their invitation yet
+func (s *AuthService) checkLockout(user *dbmodels.User) *faulterr.FaultErr {
+	if user.IsArchived {
+		return faulterr.NewUnauthorizedError("account is archived").WithCode(constants.ErrCodeAccountArchived)
+	}
+	if user.Status == constants.StatusPending {
+		return faulterr.NewUnauthorizedError("account is pending, accept the invitation first").WithCode(constants.ErrCodeAccountPending)
+	}
+	if user.LockedUntil.Valid && time.Now().Before(user.LockedUntil.Time) {
+		wait := time.Until(user.LockedUntil.Time)
+		return faulterr.NewUnauthorizedError(fmt.Sprintf("account is temporarily locked, %s", retryIn(wait))).WithCode(constants.ErrCodeAccountLocked)
+	}
+	return nil
+}
+
+// registerFailedOTPRequest records an otp request for an unknown user against the ip address.
+// It runs in its own transaction as the request transaction is rolled back on error.
+func (s *AuthService) registerFailedOTPRequest(ctx context.Context, ipAddress string) {
+	s.withSecurityTx(ctx, func(tx pgx.Tx) *faulterr.FaultErr {
+		_, err := s.dbstore.AuthAttemptStore.Insert(ctx, tx, dbmodels.AuthAttempt{
+			IPAddress: ipAddress,
+			Action:    constants.OTPRequestAction,
+		})
+		return err
+	})
+}
+
+// registerFailedLogin counts a failed login against the ip address, the user and their latest otp,
+// and locks the user once the limit is reached. It returns the lockout error when the user got locked.
+// It runs in its own transaction as the request transaction is rolled back on error.
+func (s *AuthService) registerFailedLogin(ctx context.Context, user *dbmodels.User, ipAddress string) *faulterr.FaultErr {
+	locked := false
+
+	s.withSecurityTx(ctx, func(tx pgx.Tx) *faulterr.FaultErr {
+		attempt := dbmodels.AuthAttempt{
+			IPAddress: ipAddress,
+			Action:    constants.LoginAction,
+		}
+		if user == nil || user.ID == 0 {
+			_, err := s.dbstore.AuthAttemptStore.Insert(ctx, tx, attempt)
+			return err
+		}
+
+		attempt.UserID = null.Int64From(user.ID)
+		if _, err := s.dbstore.AuthAttemptStore.Insert(ctx, tx, attempt); err != nil {
+			return err
+		}
+
+		// burn the latest otp after too many wrong codes
+		otp, err := s.dbstore.OTPSessionStore.GetLatestByUserID(ctx, user.ID)
+		if err == nil && otp.IsValid {
+			otp.FailedAttempts++
+			if otp.FailedAttempts >= maxOTPFailedAttempts {
+				otp.IsValid = false
+			}
+			if err := s.dbstore.OTPSessionStore.Update(ctx, tx, otp); err != nil {
+				return err
+			}
+		}
+
+		now := time.Now()
+		user.FailedLoginCount++
+		user.LastFailedLoginAt = null.TimeFrom(now)
+		if user.FailedLoginCount >= maxFailedLogins {
+			user.FailedLoginCount = 0
+			user.LockedUntil = null.TimeFrom(now.Add(accountLockoutPeriod))
+			locked = true
+		}
+		if err := s.dbstore.UserStore.UpdateLoginAttempts(ctx, tx, *user); err != nil {
+			return err
+		}
+
+		if locked {
+			return s.insertSecurityActivity(ctx, tx, user, constants.AutherObject, constants.LockoutAction)
+		}
+		return nil
+	})
+
+	if locked {
+		return s.checkLockout(user)
+	}
+	return nil
+}
+
+// resetFailedLogins clears the failed login counters after a successful login
+func (s *AuthService) resetFailedLogins(ctx context.Context, tx pgx.Tx, user *dbmodels.User) *faulterr.FaultErr {
+	if user.FailedLoginCount == 0 && !user.LockedUntil.Valid {
+		return nil
+	}
+	user.FailedLoginCount = 0
+	user.LastFailedLoginAt = null.Time{}
+	user.LockedUntil = null.Time{}
+	return s.dbstore.UserStore.UpdateLoginAttempts(ctx, tx, *user)
+}
+
+// recordSecurityActivity records a throttle or lockout as user activity in its own transaction
+func (s *AuthService) recordSecurityActivity(ctx context.Context, user *dbmodels.User, object constants.ObjectType, action string) {
+	s.withSecurityTx(ctx, func(tx pgx.Tx) *faulterr.FaultErr {
+		return s.insertSecurityActivity(ctx, tx, user, object, action)
+	})
+}
+
+func (s *AuthService) insertSecurityActivity(ctx context.Context, tx pgx.Tx, user *dbmodels.User, object constants.ObjectType, action string) *faulterr.FaultErr {
+	actReq := dbmodels.UserActivityRequest{
+		UserID:       user.ID,
+		OrgUID:       user.OrgUID,
+		Action:       fmt.Sprintf("%s_%s", object, action),
+		ObjectID:     null.Int64From(user.ID),
+		ObjectType:   null.StringFrom(string(object)),
+		SessionToken: uuid.Nil,
+	}
+	_, err := s.master.UserActivityMaster.Create(ctx, tx, actReq)
+	return err
+}
+
+// withSecurityTx runs fn in a separate transaction so that its writes survive
+// the rollback of the request transaction, callers that do not depend on the writes ignore failures
+func (s *AuthService) withSecurityTx(ctx context.Context, fn func(tx pgx.Tx) *faulterr.FaultErr) *faulterr.FaultErr {
+	tx, err := s.dbstore.DBTX.BeginTx(ctx)
+	if err != nil {
+		return err
+	}
+	defer s.dbstore.DBTX.RollbackTx(ctx, tx)
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+	return s.dbstore.DBTX.CommitTx(ctx, tx)
+}
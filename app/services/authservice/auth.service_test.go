@@ -0,0 +1,59 @@
+package authservice
+
+import (
+	"gogql/app/models"
+	"gogql/app/models/constants"
+	"gogql/app/models/dbmodels"
+	"testing"
+	"time"
+
+	"github.com/volatiletech/null"
+)
+
+func TestDecidePermission(t *testing.T) {
+	member := &models.Auther{ID: 7, RoleID: null.Int64From(1)}
+	sales := &dbmodels.Role{ID: 1, Name: "Sales", DepartmentID: 3, Permissions: []string{models.ReadUser},
+		PermissionScopes: map[string]string{models.ReadUser: models.ScopeDepartment}}
+	support := &dbmodels.Role{ID: 2, Name: "Support", DepartmentID: 4, Permissions: []string{models.ReadUser},
+		PermissionScopes: map[string]string{models.ReadUser: models.ScopeDepartment}}
+	manager := &dbmodels.Role{ID: 3, Name: "Manager", IsManagement: true}
+
+	now := time.Now()
+	elevated := []dbmodels.PermissionElevation{
+		{Permission: models.UpdateUser, Status: constants.StatusApproved, StartsAt: now.Add(-time.Hour), EndsAt: now.Add(time.Hour)},
+		{Permission: models.DeleteUser, Status: constants.StatusApproved, StartsAt: now.Add(time.Hour), EndsAt: now.Add(2 * time.Hour)},
+		{Permission: models.CreateUser, Status: constants.StatusPending, StartsAt: now.Add(-time.Hour), EndsAt: now.Add(time.Hour)},
+	}
+
+	tests := []struct {
+		name       string
+		auther     *models.Auther
+		roles      []*dbmodels.Role
+		elevations []dbmodels.PermissionElevation
+		perm       string
+		granted    bool
+		reason     string
+	}{
+		{"unknown", member, []*dbmodels.Role{manager}, nil, "FLY", false, models.PermissionReasonUnknown},
+		{"admin", &models.Auther{IsAdmin: true}, nil, nil, models.UpdateUser, true, models.PermissionReasonAdmin},
+		{"management", member, []*dbmodels.Role{sales, manager}, nil, models.UpdateUser, true, models.PermissionReasonManagementRole},
+		{"role", member, []*dbmodels.Role{sales, support}, nil, models.ReadUser, true, models.PermissionReasonRole},
+		{"missing", member, []*dbmodels.Role{sales}, nil, models.UpdateUser, false, models.PermissionReasonMissing},
+		{"elevation", member, []*dbmodels.Role{sales}, elevated, models.UpdateUser, true, models.PermissionReasonElevation},
+		{"elevation not started", member, []*dbmodels.Role{sales}, elevated, models.DeleteUser, false, models.PermissionReasonMissing},
+		{"elevation pending", member, []*dbmodels.Role{sales}, elevated, models.CreateUser, false, models.PermissionReasonMissing},
+		{"api key", &models.Auther{APIKeyID: null.Int64From(1), Permissions: []string{models.ReadUser}}, nil, nil, models.ReadUser, true, models.PermissionReasonAPIKey},
+		{"api key missing", &models.Auther{APIKeyID: null.Int64From(1), IsAdmin: true}, nil, nil, models.ReadUser, false, models.PermissionReasonAPIKeyMissing},
+	}
+	for _, tt := range tests {
+		decision := decidePermission(tt.auther, tt.roles, tt.elevations, tt.perm, now)
+		if decision.Granted != tt.granted || decision.Reason != tt.reason {
+			t.Errorf("%s: got granted %v with %s, want %v with %s", tt.name, decision.Granted, decision.Reason, tt.granted, tt.reason)
+		}
+	}
+
+	decision := decidePermission(member, []*dbmodels.Role{sales, support}, nil, models.ReadUser, now)
+	if decision.Scope.Scope != models.ScopeDepartment || len(decision.Scope.DepartmentIDs) != 2 || len(decision.Roles) != 2 {
+		t.Errorf("role: department grants do not add up: %+v", decision.Scope)
+	}
+}
@@ -0,0 +1,50 @@
+package authservice
+
+import (
+	"context"
+	"gogql/app/models"
+	"gogql/app/models/dbmodels"
+	"gogql/utils/faulterr"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/volatiletech/null"
+)
+
+// ImpersonateUser starts a session of a user on behalf of a super admin. The session is limited to the
+// impersonation lifetime, has the permissions of the user, and keeps the super admin as its impersonator.
+// Super admins can not be impersonated, so an impersonated session can not impersonate again.
+func (s *AuthService) ImpersonateUser(ctx context.Context, tx pgx.Tx, auther *models.Auther, req *models.ImpersonationRequest) (*models.Auther, *faulterr.FaultErr) {
+	if !auther.IsAdmin || auther.APIKeyID.Valid {
+		return nil, faulterr.NewUnauthorizedError("only super admins can impersonate users")
+	}
+
+	user, err := s.dbstore.UserStore.GetByID(ctx, req.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if user.ID == auther.ID {
+		return nil, faulterr.NewBadRequestError("you cannot impersonate yourself")
+	}
+	if user.IsAdmin {
+		return nil, faulterr.NewFrobiddenError("super admins cannot be impersonated")
+	}
+	if user.IsArchived {
+		return nil, faulterr.NewFrobiddenError("archived users cannot be impersonated")
+	}
+
+	sessionReq := dbmodels.AuthSessionRequest{
+		UserID:         user.ID,
+		IPAddress:      req.IPAddress,
+		UserAgent:      req.UserAgent,
+		AssuranceLevel: auther.AssuranceLevel,
+		ImpersonatorID: null.Int64From(auther.ID),
+	}
+	authSession, tokens, err := s.master.AuthSessionMaster.Create(ctx, tx, sessionReq)
+	if err != nil {
+		return nil, err
+	}
+
+	result := s.getAuther(user, authSession, tokens.AccessToken)
+	result.RefreshToken = tokens.RefreshToken
+	return result, nil
+}
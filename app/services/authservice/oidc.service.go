@@ -0,0 +1,167 @@
+package authservice
+
+import (
+	"context"
+	"gogql/app/helpers"
+	"gogql/app/models"
+	"gogql/app/models/constants"
+	"gogql/app/models/dbmodels"
+	"gogql/utils/faulterr"
+	"gogql/utils/oidc"
+	"net/http"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/volatiletech/null"
+)
+
+// BeginOIDCLogin returns the url of the organization's identity provider to send the user to
+func (s *AuthService) BeginOIDCLogin(ctx context.Context, tx pgx.Tx, orgCode string, ipAddress string) (string, *faulterr.FaultErr) {
+	if err := s.checkLoginIP(ctx, ipAddress); err != nil {
+		return "", err
+	}
+
+	org, config, err := s.getOIDCConfig(ctx, orgCode)
+	if err != nil {
+		return "", err
+	}
+	return s.master.OIDCMaster.BeginLogin(ctx, tx, org, config, ipAddress)
+}
+
+// OIDCLogin completes the sign in at the identity provider and starts a session. Users are matched
+// by the verified email of the id token, unknown users are provisioned when the organization allows it.
+func (s *AuthService) OIDCLogin(ctx context.Context, tx pgx.Tx, req *models.OIDCCallbackRequest) (*models.Auther, *faulterr.FaultErr) {
+	if err := s.checkLoginIP(ctx, req.IPAddress); err != nil {
+		return nil, err
+	}
+	if req.Error != "" {
+		return nil, faulterr.NewUnauthorizedError("sign in was denied by the identity provider").WithCode(constants.ErrCodeInvalidSSO)
+	}
+
+	org, config, err := s.getOIDCConfig(ctx, req.OrgCode)
+	if err != nil {
+		return nil, err
+	}
+
+	claims, err := s.master.OIDCMaster.CompleteLogin(ctx, tx, org, config, req.State, req.Code)
+	if err != nil {
+		if err.Status == http.StatusUnauthorized {
+			s.registerFailedLogin(ctx, nil, req.IPAddress)
+		}
+		return nil, err
+	}
+
+	email := strings.TrimSpace(claims.Email)
+	if email == "" || !claims.EmailVerified {
+		return nil, faulterr.NewFrobiddenError("identity provider did not return a verified email").WithCode(constants.ErrCodeInvalidSSO)
+	}
+	if !s.master.OIDCMaster.EmailAllowed(config, email) {
+		return nil, faulterr.NewFrobiddenError("email domain is not allowed for this organization").WithCode(constants.ErrCodeInvalidSSO)
+	}
+
+	user, err := s.dbstore.UserStore.GetByEmail(ctx, email)
+	if err != nil {
+		if err.Status != http.StatusNotFound {
+			return nil, err
+		}
+		if !config.JITProvisioning || !config.DefaultRoleID.Valid {
+			return nil, faulterr.NewFrobiddenError("no user found with given email")
+		}
+		user, err = s.provisionOIDCUser(ctx, tx, org, config, claims, email)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if !user.OrgUID.Valid || user.OrgUID.UUID != org.UID {
+		return nil, faulterr.NewFrobiddenError("user is not a member of this organization").WithCode(constants.ErrCodeInvalidSSO)
+	}
+
+	// reject locked users and attempts within the backoff period
+	if err := s.checkLoginAllowed(ctx, user); err != nil {
+		return nil, err
+	}
+
+	// clear failed login counters
+	if err := s.resetFailedLogins(ctx, tx, user); err != nil {
+		return nil, err
+	}
+
+	sessionReq := dbmodels.AuthSessionRequest{
+		UserID:      user.ID,
+		IPAddress:   req.IPAddress,
+		UserAgent:   req.UserAgent,
+		DeviceLabel: req.DeviceLabel,
+	}
+	return s.createLoginSession(ctx, tx, user, sessionReq, false)
+}
+
+// Helpers
+
+// getOIDCConfig returns the organization and its enabled oidc config, organizations without one are not found
+func (s *AuthService) getOIDCConfig(ctx context.Context, orgCode string) (*dbmodels.Organization, *dbmodels.OrganizationOIDCConfig, *faulterr.FaultErr) {
+	errMsg := "single sign-on is not enabled for this organization"
+
+	org, err := s.dbstore.OrganizationStore.GetByCode(ctx, orgCode)
+	if err != nil {
+		if err.Status == http.StatusNotFound {
+			return nil, nil, faulterr.NewNotFoundError(errMsg)
+		}
+		return nil, nil, err
+	}
+	if org.IsArchived {
+		return nil, nil, faulterr.NewNotFoundError(errMsg)
+	}
+
+	config, err := s.master.OIDCMaster.GetConfig(ctx, org)
+	if err != nil {
+		return nil, nil, err
+	}
+	if config == nil || !config.IsEnabled {
+		return nil, nil, faulterr.NewNotFoundError(errMsg)
+	}
+	return org, config, nil
+}
+
+// provisionOIDCUser creates the user of a first sign in with the default role of the organization
+func (s *AuthService) provisionOIDCUser(ctx context.Context, tx pgx.Tx, org *dbmodels.Organization, config *dbmodels.OrganizationOIDCConfig, claims *oidc.Claims, email string) (*dbmodels.User, *faulterr.FaultErr) {
+	firstName, lastName := oidcUserNames(claims, email)
+
+	user, err := s.master.UserMaster.CreateOne(ctx, tx, dbmodels.UserRequest{
+		FirstName: firstName,
+		LastName:  lastName,
+		Email:     email,
+		OrgUID:    helpers.NullUUIDFromUUID(org.UID),
+		RoleID:    null.Int64From(config.DefaultRoleID.Int64),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.insertSecurityActivity(ctx, tx, user, constants.SSOObject, constants.CreateAction); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// oidcUserNames takes the names from the id token, falling back to the full name and the email
+func oidcUserNames(claims *oidc.Claims, email string) (string, string) {
+	firstName := strings.TrimSpace(claims.GivenName)
+	lastName := strings.TrimSpace(claims.FamilyName)
+
+	if firstName == "" {
+		parts := strings.Fields(claims.Name)
+		if len(parts) > 0 {
+			firstName = parts[0]
+			if lastName == "" {
+				lastName = strings.Join(parts[1:], " ")
+			}
+		}
+	}
+	if firstName == "" {
+		firstName = email[:strings.LastIndex(email, "@")]
+	}
+	if lastName == "" {
+		lastName = firstName
+	}
+	return firstName, lastName
+}
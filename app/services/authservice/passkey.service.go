@@ -0,0 +1,183 @@
+package authservice
+
+import (
+	"context"
+	"gogql/app/models"
+	"gogql/app/models/constants"
+	"gogql/app/models/dbmodels"
+	"gogql/utils/faulterr"
+	"gogql/utils/webauthn"
+	"net/http"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// BeginPasskeyRegistration returns the options for registering a passkey of the auther
+func (s *AuthService) BeginPasskeyRegistration(ctx context.Context, tx pgx.Tx, auther *models.Auther, ipAddress string) (*models.PasskeyOptions, *faulterr.FaultErr) {
+	user, err := s.dbstore.UserStore.GetByID(ctx, auther.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	options, err := s.master.PasskeyMaster.BeginRegistration(ctx, tx, user, ipAddress)
+	if err != nil {
+		return nil, err
+	}
+	return &models.PasskeyOptions{PublicKey: options}, nil
+}
+
+// FinishPasskeyRegistration verifies the registration response and stores the passkey of the auther
+func (s *AuthService) FinishPasskeyRegistration(ctx context.Context, tx pgx.Tx, auther *models.Auther, req *models.PasskeyRegistration) (*dbmodels.WebAuthnCredential, *faulterr.FaultErr) {
+	user, err := s.dbstore.UserStore.GetByID(ctx, auther.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	// the challenge is used up on its own so a failed registration can not replay it
+	var challenge *dbmodels.WebAuthnChallenge
+	err = s.withSecurityTx(ctx, func(tx pgx.Tx) *faulterr.FaultErr {
+		obj, err := s.master.PasskeyMaster.UseRegistrationChallenge(ctx, tx, req)
+		challenge = obj
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.master.PasskeyMaster.FinishRegistration(ctx, tx, user, challenge, req)
+}
+
+// BeginPasskeyLogin returns the options for a passkey login. Unknown users get options without
+// passkeys, like a discoverable login, so the response does not reveal whether a user exists.
+func (s *AuthService) BeginPasskeyLogin(ctx context.Context, tx pgx.Tx, req *models.PasskeyLoginRequest) (*models.PasskeyOptions, *faulterr.FaultErr) {
+	if err := s.checkLoginIP(ctx, req.IPAddress); err != nil {
+		return nil, err
+	}
+
+	var user *dbmodels.User
+	var err *faulterr.FaultErr
+	if req.Email.Valid && req.Email.String != "" {
+		user, err = s.dbstore.UserStore.GetByEmail(ctx, req.Email.String)
+	} else if req.Phone.Valid && req.Phone.String != "" {
+		user, err = s.master.UserMaster.GetByPhone(ctx, req.Phone.String, req.OrgUID)
+	}
+	if err != nil {
+		if err.Status != http.StatusNotFound {
+			return nil, err
+		}
+		user = nil
+	}
+
+	options, err := s.master.PasskeyMaster.BeginLogin(ctx, tx, user, req.IPAddress)
+	if err != nil {
+		return nil, err
+	}
+	return &models.PasskeyOptions{PublicKey: options}, nil
+}
+
+// PasskeyLogin verifies a passkey assertion and starts a session. Failures count towards the lockout
+// like failed otp logins, and a sign count that did not move forward flags the passkey as cloned.
+func (s *AuthService) PasskeyLogin(ctx context.Context, tx pgx.Tx, req *models.PasskeyAssertion) (*models.Auther, *faulterr.FaultErr) {
+	if err := s.checkLoginIP(ctx, req.IPAddress); err != nil {
+		return nil, err
+	}
+
+	credential, err := s.dbstore.WebAuthnCredentialStore.GetByCredentialID(ctx, strings.TrimRight(req.CredentialID, "="))
+	if err != nil {
+		if err.Status == http.StatusNotFound {
+			s.registerFailedLogin(ctx, nil, req.IPAddress)
+			return nil, faulterr.NewUnauthorizedError("passkey is invalid").WithCode(constants.ErrCodeInvalidPasskey)
+		}
+		return nil, err
+	}
+
+	user, err := s.dbstore.UserStore.GetByID(ctx, credential.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	// reject locked users and attempts within the backoff period
+	if err := s.checkLoginAllowed(ctx, user); err != nil {
+		return nil, err
+	}
+	if credential.IsCloned {
+		return nil, faulterr.NewUnauthorizedError("passkey is disabled as it may have been cloned").WithCode(constants.ErrCodePasskeyCloned)
+	}
+
+	// the challenge is used up on its own so a failed login can not replay it
+	var challenge *dbmodels.WebAuthnChallenge
+	err = s.withSecurityTx(ctx, func(tx pgx.Tx) *faulterr.FaultErr {
+		obj, err := s.master.PasskeyMaster.UseLoginChallenge(ctx, tx, req)
+		challenge = obj
+		return err
+	})
+	var authData *webauthn.AuthenticatorData
+	if err == nil {
+		authData, err = s.master.PasskeyMaster.VerifyLogin(credential, challenge, req)
+	}
+	if err != nil {
+		if err.Status == http.StatusUnauthorized {
+			if lockErr := s.registerFailedLogin(ctx, user, req.IPAddress); lockErr != nil {
+				return nil, lockErr
+			}
+		}
+		return nil, err
+	}
+
+	if !s.master.PasskeyMaster.CheckSignCount(credential, authData) {
+		s.disableClonedPasskey(ctx, user, credential)
+		return nil, faulterr.NewUnauthorizedError("passkey is disabled as it may have been cloned").WithCode(constants.ErrCodePasskeyCloned)
+	}
+	if err := s.master.PasskeyMaster.RecordUse(ctx, tx, credential, authData); err != nil {
+		return nil, err
+	}
+
+	// clear failed login counters
+	if err := s.resetFailedLogins(ctx, tx, user); err != nil {
+		return nil, err
+	}
+
+	// a passkey verified with a pin or biometric proves possession and the user, so it counts as multi factor
+	sessionReq := dbmodels.AuthSessionRequest{
+		UserID:      user.ID,
+		IPAddress:   req.IPAddress,
+		UserAgent:   req.UserAgent,
+		DeviceLabel: req.DeviceLabel,
+	}
+	return s.createLoginSession(ctx, tx, user, sessionReq, authData.UserVerified())
+}
+
+// ListPasskeys lists the passkeys of the auther
+func (s *AuthService) ListPasskeys(ctx context.Context, auther *models.Auther) ([]dbmodels.WebAuthnCredential, *faulterr.FaultErr) {
+	return s.dbstore.WebAuthnCredentialStore.ListByUserID(ctx, auther.ID)
+}
+
+// DeletePasskey deletes one of the auther's passkeys
+func (s *AuthService) DeletePasskey(ctx context.Context, tx pgx.Tx, auther *models.Auther, id int64) (*dbmodels.WebAuthnCredential, *faulterr.FaultErr) {
+	obj, err := s.dbstore.WebAuthnCredentialStore.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if obj.UserID != auther.ID {
+		return nil, faulterr.NewNotFoundError("passkey not found")
+	}
+
+	if err := s.dbstore.WebAuthnCredentialStore.Delete(ctx, tx, obj.ID); err != nil {
+		return nil, err
+	}
+	return obj, nil
+}
+
+// Helpers
+
+// disableClonedPasskey flags a passkey whose sign count went backwards and records it,
+// in its own transaction so the flag survives the failed request
+func (s *AuthService) disableClonedPasskey(ctx context.Context, user *dbmodels.User, obj *dbmodels.WebAuthnCredential) {
+	s.withSecurityTx(ctx, func(tx pgx.Tx) *faulterr.FaultErr {
+		obj.IsCloned = true
+		if err := s.dbstore.WebAuthnCredentialStore.Update(ctx, tx, obj); err != nil {
+			return err
+		}
+		return s.insertSecurityActivity(ctx, tx, user, constants.PasskeyObject, constants.CloneAction)
+	})
+}
@@ -0,0 +1,199 @@
+package authservice
+
+import (
+	"context"
+	"fmt"
+	"gogql/app/helpers"
+	"gogql/app/models"
+	"gogql/app/models/constants"
+	"gogql/app/models/dbmodels"
+	"gogql/utils/faulterr"
+	"time"
+
+	"github.com/gofrs/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/volatiletech/null"
+)
+
+// RequestPermissionElevation requests a temporary permission for the auther or, with the update user
+// permission, for another member. Elevations requested by super admins and management roles are
+// approved right away unless they ask for an approval, the others wait for one.
+func (s *AuthService) RequestPermissionElevation(ctx context.Context, tx pgx.Tx, auther *models.Auther, req dbmodels.PermissionElevationRequest, orgUID *uuid.UUID) (*dbmodels.PermissionElevation, *faulterr.FaultErr) {
+	userID := auther.ID
+	if req.UserID.Valid && req.UserID.Int64 != auther.ID {
+		if err := s.GrantPermission(ctx, auther, models.UpdateUser); err != nil {
+			return nil, err
+		}
+		userID = req.UserID.Int64
+	}
+	user, err := s.getOrgUser(ctx, userID, orgUID)
+	if err != nil {
+		return nil, err
+	}
+
+	approvedBy := null.Int64{}
+	if !req.RequireApproval {
+		canApprove, err := s.IsManager(ctx, auther)
+		if err != nil {
+			return nil, err
+		}
+		if canApprove {
+			approvedBy = null.Int64From(auther.ID)
+		}
+	}
+
+	obj, err := s.master.PermissionElevationMaster.Create(ctx, tx, user, req, auther.ID, approvedBy)
+	if err != nil {
+		return nil, err
+	}
+	s.dbstore.DBTX.InvalidateAfterCommit(ctx, tx, func() { s.cachestore.InvalidateElevations(obj.UserID) })
+	return obj, nil
+}
+
+// ApprovePermissionElevation approves a pending elevation, only super admins and management roles
+// approve and nobody approves an elevation of their own or requested by themselves
+func (s *AuthService) ApprovePermissionElevation(ctx context.Context, tx pgx.Tx, auther *models.Auther, id int64, orgUID *uuid.UUID) (*dbmodels.PermissionElevation, *faulterr.FaultErr) {
+	return s.decidePermissionElevation(ctx, tx, auther, id, orgUID, constants.StatusApproved)
+}
+
+// DenyPermissionElevation denies a pending elevation like ApprovePermissionElevation approves it
+func (s *AuthService) DenyPermissionElevation(ctx context.Context, tx pgx.Tx, auther *models.Auther, id int64, orgUID *uuid.UUID) (*dbmodels.PermissionElevation, *faulterr.FaultErr) {
+	return s.decidePermissionElevation(ctx, tx, auther, id, orgUID, constants.StatusDeclined)
+}
+
+// RevokePermissionElevation ends an elevation early, it is revoked by its user, its requester
+// or anyone approving elevations
+func (s *AuthService) RevokePermissionElevation(ctx context.Context, tx pgx.Tx, auther *models.Auther, id int64, orgUID *uuid.UUID) (*dbmodels.PermissionElevation, *faulterr.FaultErr) {
+	obj, err := s.getPermissionElevation(ctx, id, orgUID)
+	if err != nil {
+		return nil, err
+	}
+	if obj.UserID != auther.ID && obj.RequestedBy != auther.ID {
+		canApprove, err := s.IsManager(ctx, auther)
+		if err != nil {
+			return nil, err
+		}
+		if !canApprove {
+			return nil, faulterr.NewNotFoundError("elevation not found")
+		}
+	}
+
+	if err := s.master.PermissionElevationMaster.Revoke(ctx, tx, obj); err != nil {
+		return nil, err
+	}
+	s.dbstore.DBTX.InvalidateAfterCommit(ctx, tx, func() { s.cachestore.InvalidateElevations(obj.UserID) })
+	return obj, nil
+}
+
+// ListPermissionElevations lists the elevations of a user, the ones of other users need the read
+// user permission. Without a user approvers get every elevation of the organization and the
+// others their own.
+func (s *AuthService) ListPermissionElevations(ctx context.Context, auther *models.Auther, userID *int64, status string, orgUID *uuid.UUID) ([]dbmodels.PermissionElevation, *faulterr.FaultErr) {
+	if userID == nil {
+		canApprove, err := s.IsManager(ctx, auther)
+		if err != nil {
+			return nil, err
+		}
+		if !canApprove {
+			userID = &auther.ID
+		}
+	} else if *userID != auther.ID {
+		if err := s.GrantPermission(ctx, auther, models.ReadUser); err != nil {
+			return nil, err
+		}
+	}
+	return s.dbstore.PermissionElevationStore.List(ctx, orgUID, userID, status)
+}
+
+// ExpirePermissionElevations closes the elevations that ended and records their expiry, elevations
+// stop granting their permission at their end whether or not they were closed yet
+func (s *AuthService) ExpirePermissionElevations(ctx context.Context, tx pgx.Tx) ([]dbmodels.PermissionElevation, *faulterr.FaultErr) {
+	result, err := s.dbstore.PermissionElevationStore.ExpireEnded(ctx, tx)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, obj := range result {
+		actReq := dbmodels.UserActivityRequest{
+			UserID:       obj.UserID,
+			OrgUID:       helpers.NullUUIDFromUUID(obj.OrgUID),
+			Action:       fmt.Sprintf("%s_%s", constants.PermissionElevationObject, constants.ExpireAction),
+			ObjectID:     null.Int64From(obj.ID),
+			ObjectType:   null.StringFrom(string(constants.PermissionElevationObject)),
+			SessionToken: uuid.Nil,
+		}
+		if _, err := s.master.UserActivityMaster.Create(ctx, tx, actReq); err != nil {
+			return nil, err
+		}
+		userID := obj.UserID
+		s.dbstore.DBTX.InvalidateAfterCommit(ctx, tx, func() { s.cachestore.InvalidateElevations(userID) })
+	}
+	return result, nil
+}
+
+// Helpers
+
+func (s *AuthService) decidePermissionElevation(ctx context.Context, tx pgx.Tx, auther *models.Auther, id int64, orgUID *uuid.UUID, status string) (*dbmodels.PermissionElevation, *faulterr.FaultErr) {
+	canApprove, err := s.IsManager(ctx, auther)
+	if err != nil {
+		return nil, err
+	}
+	if !canApprove {
+		return nil, faulterr.NewUnauthorizedError("only management roles decide elevations")
+	}
+
+	obj, err := s.getPermissionElevation(ctx, id, orgUID)
+	if err != nil {
+		return nil, err
+	}
+	if obj.UserID == auther.ID || obj.RequestedBy == auther.ID {
+		return nil, faulterr.NewFrobiddenError("elevations are decided by someone else than their user and requester")
+	}
+
+	if err := s.master.PermissionElevationMaster.Decide(ctx, tx, obj, status, auther.ID); err != nil {
+		return nil, err
+	}
+	s.dbstore.DBTX.InvalidateAfterCommit(ctx, tx, func() { s.cachestore.InvalidateElevations(obj.UserID) })
+	return obj, nil
+}
+
+func (s *AuthService) getPermissionElevation(ctx context.Context, id int64, orgUID *uuid.UUID) (*dbmodels.PermissionElevation, *faulterr.FaultErr) {
+	obj, err := s.dbstore.PermissionElevationStore.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if orgUID != nil && *orgUID != obj.OrgUID {
+		return nil, faulterr.NewNotFoundError("elevation not found")
+	}
+	return obj, nil
+}
+
+func (s *AuthService) getOrgUser(ctx context.Context, id int64, orgUID *uuid.UUID) (*dbmodels.User, *faulterr.FaultErr) {
+	user, err := s.dbstore.UserStore.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if orgUID != nil && user.OrgUID.Valid && *orgUID != user.OrgUID.UUID {
+		return nil, faulterr.NewNotFoundError("object not found")
+	}
+	return user, nil
+}
+
+// getElevations gets the approved elevations of a user that did not end yet, they are cached like the roles
+func (s *AuthService) getElevations(ctx context.Context, userID int64) ([]dbmodels.PermissionElevation, *faulterr.FaultErr) {
+	if result, ok := s.cachestore.GetElevations(userID); ok {
+		return result, nil
+	}
+
+	result, err := s.dbstore.PermissionElevationStore.ListApprovedByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	s.cachestore.SetElevations(userID, result)
+	return result, nil
+}
+
+// isActiveElevation reports whether an elevation grants its permission at now
+func isActiveElevation(obj dbmodels.PermissionElevation, now time.Time) bool {
+	return obj.Status == constants.StatusApproved && !now.Before(obj.StartsAt) && now.Before(obj.EndsAt)
+}
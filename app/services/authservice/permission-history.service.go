@@ -0,0 +1,135 @@
+package authservice
+
+import (
+	"context"
+	"fmt"
+	"gogql/app/models"
+	"gogql/app/models/constants"
+	"gogql/app/models/dbmodels"
+	"gogql/utils/faulterr"
+	"time"
+
+	"github.com/gofrs/uuid"
+)
+
+// CheckPermissionAt decides a permission check of a user as it would have been decided at a point in
+// time, from the versions of the user, its roles and assignments and the elevations active then.
+// Users of another organization, not created yet, deleted or archived by then are denied as inactive.
+func (s *AuthService) CheckPermissionAt(ctx context.Context, userID int64, orgUID *uuid.UUID, perm string, at time.Time) (*models.PermissionDecision, *faulterr.FaultErr) {
+	users, err := s.dbstore.UserStore.ListAsOf(ctx, []int64{userID}, orgUID, at)
+	if err != nil {
+		return nil, err
+	}
+	if len(users) == 0 || users[0].IsArchived {
+		return &models.PermissionDecision{
+			Permission:  perm,
+			Reason:      models.PermissionReasonInactive,
+			Explanation: fmt.Sprintf("the user was not an active member on %s", at.Format(time.RFC3339)),
+			Roles:       []dbmodels.Role{},
+		}, nil
+	}
+
+	decisions, err := s.decidePermissionsAt(ctx, users, perm, at)
+	if err != nil {
+		return nil, err
+	}
+	return decisions[0], nil
+}
+
+// PermissionHoldersAt lists the active members of an organization and the super admins that were
+// granted a permission at a point in time
+func (s *AuthService) PermissionHoldersAt(ctx context.Context, orgUID uuid.UUID, perm string, at time.Time) ([]models.PermissionHolder, *faulterr.FaultErr) {
+	users, err := s.dbstore.UserStore.ListAsOf(ctx, nil, &orgUID, at)
+	if err != nil {
+		return nil, err
+	}
+	active := []dbmodels.User{}
+	for _, user := range users {
+		if !user.IsArchived {
+			active = append(active, user)
+		}
+	}
+
+	decisions, err := s.decidePermissionsAt(ctx, active, perm, at)
+	if err != nil {
+		return nil, err
+	}
+	result := []models.PermissionHolder{}
+	for i, decision := range decisions {
+		if decision.Granted {
+			result = append(result, models.PermissionHolder{UserID: active[i].ID, Decision: decision})
+		}
+	}
+	return result, nil
+}
+
+// decidePermissionsAt decides a permission check for each user version on the roles, assignments and
+// elevations in place at a point in time
+func (s *AuthService) decidePermissionsAt(ctx context.Context, users []dbmodels.User, perm string, at time.Time) ([]*models.PermissionDecision, *faulterr.FaultErr) {
+	userIDs := make([]int64, len(users))
+	for i, user := range users {
+		userIDs[i] = user.ID
+	}
+
+	userRoles, err := s.dbstore.UserRoleStore.ListAsOfByUserIDs(ctx, userIDs, at)
+	if err != nil {
+		return nil, err
+	}
+	roleIDs := assignedRoleIDs(users, userRoles)
+	ids := []int64{}
+	for _, user := range users {
+		ids = append(ids, roleIDs[user.ID]...)
+	}
+
+	roles, err := s.dbstore.RoleStore.ListAsOf(ctx, ids, at)
+	if err != nil {
+		return nil, err
+	}
+	elevations, err := s.dbstore.PermissionElevationStore.ListActiveAsOfByUserIDs(ctx, userIDs, at)
+	if err != nil {
+		return nil, err
+	}
+	return s.decideHistoric(users, roleIDs, roles, elevations, perm, at), nil
+}
+
+// assignedRoleIDs groups the roles assigned at a point in time by user, users without assignments
+// then fall back to their primary role
+func assignedRoleIDs(users []dbmodels.User, userRoles []dbmodels.UserRole) map[int64][]int64 {
+	result := map[int64][]int64{}
+	for _, userRole := range userRoles {
+		result[userRole.UserID] = append(result[userRole.UserID], userRole.RoleID)
+	}
+	for _, user := range users {
+		if len(result[user.ID]) == 0 && user.RoleID.Valid {
+			result[user.ID] = []int64{user.RoleID.Int64}
+		}
+	}
+	return result
+}
+
+// decideHistoric decides a permission check for each user on the role and elevation versions of a
+// point in time, roles without a version then are skipped
+func (s *AuthService) decideHistoric(users []dbmodels.User, roleIDs map[int64][]int64, roleList []*dbmodels.Role, elevationList []dbmodels.PermissionElevation, perm string, at time.Time) []*models.PermissionDecision {
+	roles := map[int64]*dbmodels.Role{}
+	for _, role := range roleList {
+		roles[role.ID] = role
+	}
+	elevations := map[int64][]dbmodels.PermissionElevation{}
+	for _, elevation := range elevationList {
+		// revoked and expired elevations were approved at the time
+		elevation.Status = constants.StatusApproved
+		elevations[elevation.UserID] = append(elevations[elevation.UserID], elevation)
+	}
+
+	result := make([]*models.PermissionDecision, len(users))
+	for i := range users {
+		userRoles := []*dbmodels.Role{}
+		for _, id := range roleIDs[users[i].ID] {
+			if role, ok := roles[id]; ok {
+				userRoles = append(userRoles, role)
+			}
+		}
+		result[i] = decidePermission(s.UserAuther(&users[i]), userRoles, elevations[users[i].ID], perm, at)
+	}
+	return result
+}
@@ -0,0 +1,84 @@
+package authservice
+
+import (
+	"gogql/app/models"
+	"gogql/app/models/constants"
+	"gogql/app/models/dbmodels"
+	"testing"
+	"time"
+
+	"github.com/volatiletech/null"
+)
+
+func TestAssignedRoleIDs(t *testing.T) {
+	users := []dbmodels.User{
+		{ID: 1, RoleID: null.Int64From(10)},
+		{ID: 2, RoleID: null.Int64From(10)},
+		{ID: 3},
+	}
+	userRoles := []dbmodels.UserRole{
+		{UserID: 1, RoleID: 10},
+		{UserID: 1, RoleID: 11},
+	}
+
+	roleIDs := assignedRoleIDs(users, userRoles)
+	if len(roleIDs[1]) != 2 {
+		t.Errorf("assigned: got %v, want the two assigned roles", roleIDs[1])
+	}
+	if len(roleIDs[2]) != 1 || roleIDs[2][0] != 10 {
+		t.Errorf("unassigned: got %v, want the primary role", roleIDs[2])
+	}
+	if len(roleIDs[3]) != 0 {
+		t.Errorf("no role: got %v, want none", roleIDs[3])
+	}
+}
+
+func TestDecideHistoric(t *testing.T) {
+	s := &AuthService{}
+	at := time.Now().Add(-24 * time.Hour)
+
+	users := []dbmodels.User{
+		{ID: 1, RoleID: null.Int64From(10)},
+		{ID: 2, RoleID: null.Int64From(20)},
+		{ID: 3, RoleID: null.Int64From(10)},
+		{ID: 4, IsAdmin: true},
+	}
+	roleIDs := map[int64][]int64{1: {10}, 2: {20}, 3: {10, 30}}
+	// role 20 was created later and has no version at the time
+	roles := []*dbmodels.Role{
+		{ID: 10, Name: "Sales", Permissions: []string{models.ReadUser}},
+		{ID: 30, Name: "Manager", IsManagement: true},
+	}
+	elevations := []dbmodels.PermissionElevation{
+		{UserID: 2, Permission: models.UpdateUser, Status: constants.StatusRevoked, StartsAt: at.Add(-time.Hour), EndsAt: at.Add(time.Hour)},
+	}
+
+	tests := []struct {
+		name    string
+		index   int
+		perm    string
+		granted bool
+		reason  string
+	}{
+		{"role", 0, models.ReadUser, true, models.PermissionReasonRole},
+		{"missing", 0, models.UpdateUser, false, models.PermissionReasonMissing},
+		{"role without version", 1, models.ReadUser, false, models.PermissionReasonMissing},
+		{"revoked elevation", 1, models.UpdateUser, true, models.PermissionReasonElevation},
+		{"management", 2, models.UpdateUser, true, models.PermissionReasonManagementRole},
+		{"admin", 3, models.UpdateUser, true, models.PermissionReasonAdmin},
+	}
+	for _, tt := range tests {
+		decisions := s.decideHistoric(users, roleIDs, roles, elevations, tt.perm, at)
+		if len(decisions) != len(users) {
+			t.Fatalf("%s: got %d decisions, want %d", tt.name, len(decisions), len(users))
+		}
+		decision := decisions[tt.index]
+		if decision.Granted != tt.granted || decision.Reason != tt.reason {
+			t.Errorf("%s: got granted %v with %s, want %v with %s", tt.name, decision.Granted, decision.Reason, tt.granted, tt.reason)
+		}
+	}
+
+	if elevations[0].Status != constants.StatusRevoked {
+		t.Errorf("elevations: status of the listed elevation changed to %s", elevations[0].Status)
+	}
+}
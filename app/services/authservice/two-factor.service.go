@@ -0,0 +1,264 @@
+package authservice
+
+import (
+	"context"
+	"gogql/app/models"
+	"gogql/app/models/constants"
+	"gogql/app/models/dbmodels"
+	"gogql/utils/faulterr"
+	"net/http"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// EnrollTOTP starts the totp enrollment of the auther, partial sessions may enroll when the
+// organization requires a second factor and the user has none yet
+func (s *AuthService) EnrollTOTP(ctx context.Context, tx pgx.Tx, auther *models.Auther) (*models.TOTPEnrollment, *faulterr.FaultErr) {
+	user, err := s.dbstore.UserStore.GetByID(ctx, auther.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	cred, err := s.master.TwoFactorMaster.GetEnabledTOTP(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	if cred != nil {
+		return nil, faulterr.NewBadRequestError("two factor authentication is already enabled")
+	}
+
+	return s.master.TwoFactorMaster.EnrollTOTP(ctx, tx, user)
+}
+
+// ConfirmTOTP enables a pending totp enrollment with a first code and returns the recovery codes,
+// a partial session is upgraded as the second factor was just proven
+func (s *AuthService) ConfirmTOTP(ctx context.Context, tx pgx.Tx, auther *models.Auther, req *models.SecondFactorRequest) (*models.TOTPConfirmation, *faulterr.FaultErr) {
+	user, err := s.dbstore.UserStore.GetByID(ctx, auther.ID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkLoginAllowed(ctx, user); err != nil {
+		return nil, err
+	}
+
+	cred, err := s.dbstore.TOTPCredentialStore.GetByUserID(ctx, user.ID)
+	if err != nil {
+		if err.Status == http.StatusNotFound {
+			return nil, faulterr.NewBadRequestError("no pending two factor enrollment")
+		}
+		return nil, err
+	}
+	if cred.IsEnabled {
+		return nil, faulterr.NewBadRequestError("two factor authentication is already enabled")
+	}
+
+	ok, err := s.master.TwoFactorMaster.VerifyTOTP(ctx, tx, cred, req.Code)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, s.failSecondFactor(ctx, user, auther, req.IPAddress)
+	}
+
+	if err := s.master.TwoFactorMaster.Confirm(ctx, tx, cred); err != nil {
+		return nil, err
+	}
+	codes, err := s.master.TwoFactorMaster.CreateRecoveryCodes(ctx, tx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &models.TOTPConfirmation{RecoveryCodes: codes}
+	if auther.AssuranceLevel == models.AssurancePartial {
+		result.Auther, err = s.upgradeSession(ctx, tx, user, auther)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+// VerifySecondFactor completes the login of a partial session with a totp or recovery code,
+// failures count towards the account lockout like failed logins
+func (s *AuthService) VerifySecondFactor(ctx context.Context, tx pgx.Tx, auther *models.Auther, req *models.SecondFactorRequest) (*models.Auther, *faulterr.FaultErr) {
+	if auther.AssuranceLevel != models.AssurancePartial {
+		return nil, faulterr.NewBadRequestError("session does not need a second factor")
+	}
+
+	user, err := s.dbstore.UserStore.GetByID(ctx, auther.ID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkLoginAllowed(ctx, user); err != nil {
+		return nil, err
+	}
+
+	cred, err := s.master.TwoFactorMaster.GetEnabledTOTP(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	if cred == nil {
+		return nil, faulterr.NewFrobiddenError("two factor authentication must be enrolled first").WithCode(constants.ErrCodeSecondFactorRequired)
+	}
+
+	ok, err := s.verifyCode(ctx, tx, cred, req.Code, true)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, s.failSecondFactor(ctx, user, auther, req.IPAddress)
+	}
+
+	if err := s.resetFailedLogins(ctx, tx, user); err != nil {
+		return nil, err
+	}
+	return s.upgradeSession(ctx, tx, user, auther)
+}
+
+// DisableTOTP removes the second factor of the auther after checking a code,
+// it is not allowed while the organization requires a second factor for the user
+func (s *AuthService) DisableTOTP(ctx context.Context, tx pgx.Tx, auther *models.Auther, req *models.SecondFactorRequest) *faulterr.FaultErr {
+	user, cred, err := s.getTwoFactorUser(ctx, auther)
+	if err != nil {
+		return err
+	}
+
+	required, err := s.requiresSecondFactor(ctx, user)
+	if err != nil {
+		return err
+	}
+	if required {
+		return faulterr.NewFrobiddenError("organization requires two factor authentication for management roles")
+	}
+
+	ok, err := s.verifyCode(ctx, tx, cred, req.Code, true)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return s.failSecondFactor(ctx, user, auther, req.IPAddress)
+	}
+
+	return s.master.TwoFactorMaster.Disable(ctx, tx, user.ID)
+}
+
+// RegenerateRecoveryCodes replaces the recovery codes of the auther after checking a totp code
+func (s *AuthService) RegenerateRecoveryCodes(ctx context.Context, tx pgx.Tx, auther *models.Auther, req *models.SecondFactorRequest) ([]string, *faulterr.FaultErr) {
+	user, cred, err := s.getTwoFactorUser(ctx, auther)
+	if err != nil {
+		return nil, err
+	}
+
+	ok, err := s.verifyCode(ctx, tx, cred, req.Code, false)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, s.failSecondFactor(ctx, user, auther, req.IPAddress)
+	}
+
+	return s.master.TwoFactorMaster.CreateRecoveryCodes(ctx, tx, user.ID)
+}
+
+// Helpers
+
+// needsSecondFactor tells whether a login of the user must be completed with a second factor
+func (s *AuthService) needsSecondFactor(ctx context.Context, user *dbmodels.User) (bool, *faulterr.FaultErr) {
+	cred, err := s.master.TwoFactorMaster.GetEnabledTOTP(ctx, user.ID)
+	if err != nil {
+		return false, err
+	}
+	if cred != nil {
+		return true, nil
+	}
+	return s.requiresSecondFactor(ctx, user)
+}
+
+// requiresSecondFactor tells whether the organization of the user requires a second factor for its roles,
+// any unexpired management role of the user requires it
+func (s *AuthService) requiresSecondFactor(ctx context.Context, user *dbmodels.User) (bool, *faulterr.FaultErr) {
+	if !user.OrgUID.Valid {
+		return false, nil
+	}
+
+	org, err := s.dbstore.OrganizationStore.GetByUID(ctx, user.OrgUID.UUID)
+	if err != nil {
+		return false, err
+	}
+	if !org.RequireManagement2FA {
+		return false, nil
+	}
+
+	roles, err := s.getUserRoles(ctx, s.UserAuther(user))
+	if err != nil {
+		return false, err
+	}
+	for _, role := range roles {
+		if role.IsManagement {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (s *AuthService) getTwoFactorUser(ctx context.Context, auther *models.Auther) (*dbmodels.User, *dbmodels.TOTPCredential, *faulterr.FaultErr) {
+	user, err := s.dbstore.UserStore.GetByID(ctx, auther.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := s.checkLoginAllowed(ctx, user); err != nil {
+		return nil, nil, err
+	}
+
+	cred, err := s.master.TwoFactorMaster.GetEnabledTOTP(ctx, user.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if cred == nil {
+		return nil, nil, faulterr.NewBadRequestError("two factor authentication is not enabled")
+	}
+	return user, cred, nil
+}
+
+// verifyCode checks a totp code and, if allowed, falls back to a recovery code
+func (s *AuthService) verifyCode(ctx context.Context, tx pgx.Tx, cred *dbmodels.TOTPCredential, code string, allowRecovery bool) (bool, *faulterr.FaultErr) {
+	ok, err := s.master.TwoFactorMaster.VerifyTOTP(ctx, tx, cred, code)
+	if err != nil || ok || !allowRecovery {
+		return ok, err
+	}
+	return s.master.TwoFactorMaster.UseRecoveryCode(ctx, tx, cred.UserID, code)
+}
+
+// failSecondFactor registers the failed attempt and returns the lockout error if the user got locked,
+// a lockout also ends the partial session
+func (s *AuthService) failSecondFactor(ctx context.Context, user *dbmodels.User, auther *models.Auther, ipAddress string) *faulterr.FaultErr {
+	if lockErr := s.registerFailedLogin(ctx, user, ipAddress); lockErr != nil {
+		if auther.AssuranceLevel == models.AssurancePartial {
+			s.withSecurityTx(ctx, func(tx pgx.Tx) *faulterr.FaultErr {
+				_, err := s.dbstore.AuthSessionStore.InvalidateByUserID(ctx, tx, user.ID)
+				return err
+			})
+			s.cachestore.InvalidateUser(user.ID)
+		}
+		return lockErr
+	}
+	return faulterr.NewUnauthorizedError("second factor code is invalid").WithCode(constants.ErrCodeInvalidSecondFactor)
+}
+
+// upgradeSession raises the session of the auther to MFA and returns the auther with the rotated tokens
+func (s *AuthService) upgradeSession(ctx context.Context, tx pgx.Tx, user *dbmodels.User, auther *models.Auther) (*models.Auther, *faulterr.FaultErr) {
+	authSession, err := s.dbstore.AuthSessionStore.GetByID(ctx, auther.SessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	tokens, err := s.master.AuthSessionMaster.Upgrade(ctx, tx, authSession, models.AssuranceMFA)
+	if err != nil {
+		return nil, err
+	}
+	s.dbstore.DBTX.InvalidateAfterCommit(ctx, tx, func() { s.cachestore.InvalidateSession(authSession.ID) })
+
+	result := s.getAuther(user, authSession, tokens.AccessToken)
+	result.RefreshToken = tokens.RefreshToken
+	return result, nil
+}
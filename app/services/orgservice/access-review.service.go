@@ -0,0 +1,183 @@
+package orgservice
+
+import (
+	"context"
+	"gogql/app/master"
+	"gogql/app/models/constants"
+	"gogql/app/models/dbmodels"
+	"gogql/app/store/cachestore"
+	"gogql/app/store/dbstore"
+	"gogql/utils/faulterr"
+	"time"
+
+	"github.com/gofrs/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/volatiletech/null"
+)
+
+type AccessReviewService struct {
+	dbstore    *dbstore.DBStore
+	master     *master.Master
+	cachestore *cachestore.CacheStore
+}
+
+var _ AccessReviewServiceInterface = &AccessReviewService{}
+
+type AccessReviewServiceInterface interface {
+	ListCampaigns(ctx context.Context, orgUID *uuid.UUID, reviewerID *int64, status string) ([]dbmodels.AccessReviewCampaign, *faulterr.FaultErr)
+	GetCampaign(ctx context.Context, id int64, orgUID *uuid.UUID, reviewerID *int64) (*dbmodels.AccessReviewCampaign, *faulterr.FaultErr)
+	ListItems(ctx context.Context, campaignID int64, orgUID *uuid.UUID, reviewerID *int64) ([]dbmodels.AccessReviewItem, *faulterr.FaultErr)
+	StartCampaign(ctx context.Context, tx pgx.Tx, req dbmodels.AccessReviewCampaignRequest, startedBy int64) (*dbmodels.AccessReviewCampaign, *faulterr.FaultErr)
+	DecideItem(ctx context.Context, tx pgx.Tx, id int64, req dbmodels.AccessReviewDecisionRequest, reviewerID int64, orgUID *uuid.UUID) (*dbmodels.AccessReviewItem, *faulterr.FaultErr)
+	CloseCampaign(ctx context.Context, tx pgx.Tx, id int64, orgUID *uuid.UUID, closedBy int64) (*dbmodels.AccessReviewCampaign, *faulterr.FaultErr)
+	ExportCampaign(ctx context.Context, id int64, orgUID *uuid.UUID) (string, *faulterr.FaultErr)
+}
+
+func NewAccessReviewService(s *dbstore.DBStore, m *master.Master, cs *cachestore.CacheStore) *AccessReviewService {
+	return &AccessReviewService{s, m, cs}
+}
+
+// ListCampaigns lists the access review campaigns of an organization, a reviewer only gets the
+// campaigns they review
+func (s *AccessReviewService) ListCampaigns(ctx context.Context, orgUID *uuid.UUID, reviewerID *int64, status string) ([]dbmodels.AccessReviewCampaign, *faulterr.FaultErr) {
+	return s.dbstore.AccessReviewCampaignStore.List(ctx, orgUID, reviewerID, status)
+}
+
+// GetCampaign gets an access review campaign, a reviewer only gets the campaigns they review
+func (s *AccessReviewService) GetCampaign(ctx context.Context, id int64, orgUID *uuid.UUID, reviewerID *int64) (*dbmodels.AccessReviewCampaign, *faulterr.FaultErr) {
+	obj, err := s.dbstore.AccessReviewCampaignStore.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if orgUID != nil && *orgUID != obj.OrgUID {
+		return nil, faulterr.NewNotFoundError("campaign not found")
+	}
+	if reviewerID != nil {
+		for _, id := range obj.ReviewerIDs {
+			if id == *reviewerID {
+				return obj, nil
+			}
+		}
+		return nil, faulterr.NewNotFoundError("campaign not found")
+	}
+	return obj, nil
+}
+
+// ListItems lists the users and roles to review in a campaign, a reviewer only gets their own items
+func (s *AccessReviewService) ListItems(ctx context.Context, campaignID int64, orgUID *uuid.UUID, reviewerID *int64) ([]dbmodels.AccessReviewItem, *faulterr.FaultErr) {
+	if _, err := s.GetCampaign(ctx, campaignID, orgUID, reviewerID); err != nil {
+		return nil, err
+	}
+	return s.dbstore.AccessReviewItemStore.ListByCampaignID(ctx, campaignID, reviewerID)
+}
+
+// StartCampaign opens a campaign reviewing the role assignments of an organization or of one of its departments
+func (s *AccessReviewService) StartCampaign(ctx context.Context, tx pgx.Tx, req dbmodels.AccessReviewCampaignRequest, startedBy int64) (*dbmodels.AccessReviewCampaign, *faulterr.FaultErr) {
+	if _, err := s.master.OrganizationMaster.VerifyOrganizationExists(ctx, req.OrgUID); err != nil {
+		return nil, err
+	}
+	return s.master.AccessReviewMaster.Start(ctx, tx, req, startedBy)
+}
+
+// DecideItem certifies or revokes an item, only its reviewer decides it
+func (s *AccessReviewService) DecideItem(ctx context.Context, tx pgx.Tx, id int64, req dbmodels.AccessReviewDecisionRequest, reviewerID int64, orgUID *uuid.UUID) (*dbmodels.AccessReviewItem, *faulterr.FaultErr) {
+	item, err := s.dbstore.AccessReviewItemStore.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if item.ReviewerID != reviewerID {
+		return nil, faulterr.NewNotFoundError("item not found")
+	}
+	campaign, err := s.GetCampaign(ctx, item.CampaignID, orgUID, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.master.AccessReviewMaster.Decide(ctx, tx, campaign, item, req); err != nil {
+		return nil, err
+	}
+	return item, nil
+}
+
+// CloseCampaign closes a campaign and applies its revocations, pending items are left as they are
+func (s *AccessReviewService) CloseCampaign(ctx context.Context, tx pgx.Tx, id int64, orgUID *uuid.UUID, closedBy int64) (*dbmodels.AccessReviewCampaign, *faulterr.FaultErr) {
+	campaign, err := s.GetCampaign(ctx, id, orgUID, nil)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.master.AccessReviewMaster.Close(ctx, tx, campaign, closedBy); err != nil {
+		return nil, err
+	}
+
+	items, err := s.dbstore.AccessReviewItemStore.ListByCampaignID(ctx, campaign.ID, nil)
+	if err != nil {
+		return nil, err
+	}
+	for i := range items {
+		if items[i].Decision != constants.StatusRevoked {
+			continue
+		}
+		if err := s.applyRevocation(ctx, tx, &items[i]); err != nil {
+			return nil, err
+		}
+	}
+	return campaign, nil
+}
+
+// ExportCampaign exports the results of a campaign as csv
+func (s *AccessReviewService) ExportCampaign(ctx context.Context, id int64, orgUID *uuid.UUID) (string, *faulterr.FaultErr) {
+	campaign, err := s.GetCampaign(ctx, id, orgUID, nil)
+	if err != nil {
+		return "", err
+	}
+	return s.master.AccessReviewMaster.Export(ctx, campaign)
+}
+
+// applyRevocation archives the user of a revoked item and ends their sessions, or takes the reviewed role
+// from them, the role is replaced by the reassigned role when there is one
+func (s *AccessReviewService) applyRevocation(ctx context.Context, tx pgx.Tx, item *dbmodels.AccessReviewItem) *faulterr.FaultErr {
+	user, err := s.dbstore.UserStore.GetByID(ctx, item.UserID)
+	if err != nil {
+		return err
+	}
+
+	switch item.RevokeAction {
+	case constants.RevokeArchiveUser:
+		if !user.IsArchived {
+			user.IsArchived = true
+			user.Status = constants.StatusArchived
+			if err := s.dbstore.UserStore.Update(ctx, tx, *user); err != nil {
+				return err
+			}
+		}
+		if _, err := s.dbstore.AuthSessionStore.InvalidateByUserID(ctx, tx, user.ID); err != nil {
+			return err
+		}
+	case constants.RevokeReassignRole:
+		if user.RoleID.Valid && user.RoleID.Int64 == item.RoleID {
+			if err := s.master.UserMaster.ReplacePrimaryRole(ctx, tx, user.ID, user.RoleID, item.ReassignRoleID); err != nil {
+				return err
+			}
+			user.RoleID = item.ReassignRoleID
+			if err := s.dbstore.UserStore.Update(ctx, tx, *user); err != nil {
+				return err
+			}
+		} else {
+			if _, err := s.dbstore.UserRoleStore.Delete(ctx, tx, user.ID, item.RoleID); err != nil {
+				return err
+			}
+			if item.ReassignRoleID.Valid {
+				if _, err := s.master.UserMaster.AssignRole(ctx, tx, user.ID, item.ReassignRoleID.Int64, null.Time{}); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	s.dbstore.DBTX.InvalidateAfterCommit(ctx, tx, func() {
+		s.cachestore.InvalidateUser(user.ID)
+		s.cachestore.InvalidateUserRoles(user.ID)
+	})
+
+	item.AppliedAt = null.TimeFrom(time.Now())
+	return s.dbstore.AccessReviewItemStore.Update(ctx, tx, item)
+}
@@ -20,7 +20,7 @@ type DepartmentService struct {
 var _ DepartmentServiceInterface = &DepartmentService{}
 
 type DepartmentServiceInterface interface {
-	List(ctx context.Context, filter models.SearchFilter, orgUID *uuid.UUID) ([]dbmodels.Department, int, *faulterr.FaultErr)
+	List(ctx context.Context, filter models.SearchFilter, orgUID *uuid.UUID, scope models.PermissionScope) ([]dbmodels.Department, int, *faulterr.FaultErr)
 	GetByID(ctx context.Context, id int64, orgUID *uuid.UUID) (*dbmodels.Department, *faulterr.FaultErr)
 	GetByCode(ctx context.Context, code string, orgUID *uuid.UUID) (*dbmodels.Department, *faulterr.FaultErr)
 
@@ -34,8 +34,8 @@ func NewDepartmentService(s *dbstore.DBStore, m *master.Master) *DepartmentServi
 }
 
 // List gets all departments for super admin and associated organization departments for members
-func (s *DepartmentService) List(ctx context.Context, filter models.SearchFilter, orgUID *uuid.UUID) ([]dbmodels.Department, int, *faulterr.FaultErr) {
-	return s.dbstore.DepartmentStore.List(ctx, filter, orgUID)
+func (s *DepartmentService) List(ctx context.Context, filter models.SearchFilter, orgUID *uuid.UUID, scope models.PermissionScope) ([]dbmodels.Department, int, *faulterr.FaultErr) {
+	return s.dbstore.DepartmentStore.List(ctx, filter, orgUID, scope)
 }
 
 // GetByID gets a department by department id
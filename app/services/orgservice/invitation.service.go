@@ -0,0 +1,200 @@
+package orgservice
+
+import (
+	"context"
+	"fmt"
+	"gogql/app/master"
+	"gogql/app/models"
+	"gogql/app/models/constants"
+	"gogql/app/models/dbmodels"
+	"gogql/app/store/dbstore"
+	"gogql/app/store/messagestore"
+	"gogql/utils/faulterr"
+	"net/http"
+	"time"
+
+	"github.com/gofrs/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+type InvitationService struct {
+	dbstore      *dbstore.DBStore
+	master       *master.Master
+	messagestore *messagestore.MessageStore
+}
+
+var _ InvitationServiceInterface = &InvitationService{}
+
+type InvitationServiceInterface interface {
+	List(ctx context.Context, filter models.SearchFilter, orgUID *uuid.UUID, status *string, scope models.PermissionScope) ([]dbmodels.Invitation, int, *faulterr.FaultErr)
+	GetByID(ctx context.Context, id int64, orgUID *uuid.UUID) (*dbmodels.Invitation, *faulterr.FaultErr)
+	Invite(ctx context.Context, tx pgx.Tx, auther *models.Auther, request dbmodels.UserRequest) (*dbmodels.Invitation, *faulterr.FaultErr)
+	Resend(ctx context.Context, tx pgx.Tx, id int64, orgUID *uuid.UUID) (*dbmodels.Invitation, *faulterr.FaultErr)
+	Revoke(ctx context.Context, tx pgx.Tx, id int64, orgUID *uuid.UUID) (*dbmodels.Invitation, *faulterr.FaultErr)
+	Accept(ctx context.Context, tx pgx.Tx, token string) (*dbmodels.User, *faulterr.FaultErr)
+}
+
+func NewInvitationService(s *dbstore.DBStore, m *master.Master, ms *messagestore.MessageStore) *InvitationService {
+	return &InvitationService{s, m, ms}
+}
+
+// List gets all invitations
+func (s *InvitationService) List(ctx context.Context, filter models.SearchFilter, orgUID *uuid.UUID, status *string, scope models.PermissionScope) ([]dbmodels.Invitation, int, *faulterr.FaultErr) {
+	return s.dbstore.InvitationStore.List(ctx, filter, orgUID, status, scope)
+}
+
+// GetByID gets an invitation by its ID
+func (s *InvitationService) GetByID(ctx context.Context, id int64, orgUID *uuid.UUID) (*dbmodels.Invitation, *faulterr.FaultErr) {
+	obj, err := s.dbstore.InvitationStore.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if orgUID != nil && *orgUID != obj.OrgUID {
+		return nil, faulterr.NewNotFoundError("object not found")
+	}
+
+	return obj, nil
+}
+
+// Invite creates a pending user and emails it an invitation. Inviting the email of a pending user
+// of the same organization again updates the user and replaces its open invitations.
+func (s *InvitationService) Invite(ctx context.Context, tx pgx.Tx, auther *models.Auther, request dbmodels.UserRequest) (*dbmodels.Invitation, *faulterr.FaultErr) {
+	role, err := s.dbstore.RoleStore.GetByID(ctx, request.RoleID.Int64)
+	if err != nil {
+		return nil, err
+	}
+	if role.OrgUID != request.OrgUID.UUID {
+		return nil, faulterr.NewBadRequestError("role does not belong to the organization")
+	}
+	org, err := s.dbstore.OrganizationStore.GetByUID(ctx, request.OrgUID.UUID)
+	if err != nil {
+		return nil, err
+	}
+	if request.Phone != "" {
+		number, err := s.master.UserMaster.NormalizePhone(request.Phone, org.PhoneRegion)
+		if err != nil {
+			return nil, err
+		}
+		request.Phone = number
+	}
+
+	user, err := s.pendingUser(ctx, tx, request)
+	if err != nil {
+		return nil, err
+	}
+
+	obj, token, err := s.master.InvitationMaster.Create(ctx, tx, user, auther.ID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.send(ctx, org, obj, token); err != nil {
+		return nil, err
+	}
+	return obj, nil
+}
+
+// Resend emails an open invitation again with a new token, the previous token stops working
+func (s *InvitationService) Resend(ctx context.Context, tx pgx.Tx, id int64, orgUID *uuid.UUID) (*dbmodels.Invitation, *faulterr.FaultErr) {
+	obj, err := s.GetByID(ctx, id, orgUID)
+	if err != nil {
+		return nil, err
+	}
+	org, err := s.dbstore.OrganizationStore.GetByUID(ctx, obj.OrgUID)
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := s.master.InvitationMaster.Renew(ctx, tx, obj)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.send(ctx, org, obj, token); err != nil {
+		return nil, err
+	}
+	return obj, nil
+}
+
+// Revoke closes an open invitation, the user stays pending and can be invited again
+func (s *InvitationService) Revoke(ctx context.Context, tx pgx.Tx, id int64, orgUID *uuid.UUID) (*dbmodels.Invitation, *faulterr.FaultErr) {
+	obj, err := s.GetByID(ctx, id, orgUID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.master.InvitationMaster.Revoke(ctx, tx, obj); err != nil {
+		return nil, err
+	}
+	return obj, nil
+}
+
+// Accept uses an invitation token and verifies its user, the user can sign in afterwards
+func (s *InvitationService) Accept(ctx context.Context, tx pgx.Tx, token string) (*dbmodels.User, *faulterr.FaultErr) {
+	obj, err := s.master.InvitationMaster.Accept(ctx, tx, token)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := s.dbstore.UserStore.GetByID(ctx, obj.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if user.Status != constants.StatusPending || user.IsArchived {
+		return nil, faulterr.NewUnauthorizedError("invitation is invalid or expired")
+	}
+
+	user.Status = constants.StatusVerified
+	user.IsFinal = true
+	if err := s.dbstore.UserStore.Update(ctx, tx, *user); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// Helpers
+
+// pendingUser creates the pending user of an invitation, or updates the pending user already invited with the email
+func (s *InvitationService) pendingUser(ctx context.Context, tx pgx.Tx, request dbmodels.UserRequest) (*dbmodels.User, *faulterr.FaultErr) {
+	user, err := s.dbstore.UserStore.GetByEmail(ctx, request.Email)
+	if err != nil {
+		if err.Status != http.StatusNotFound {
+			return nil, err
+		}
+		request.Status = constants.StatusPending
+		return s.master.UserMaster.CreateOne(ctx, tx, request)
+	}
+	if user.Status != constants.StatusPending || user.OrgUID.UUID != request.OrgUID.UUID {
+		return nil, faulterr.NewBadRequestError("email already registered")
+	}
+
+	if request.Phone != "" && request.Phone != user.Phone {
+		if _, err := s.master.UserMaster.GetByPhone(ctx, request.Phone, request.OrgUID); err == nil {
+			return nil, faulterr.NewBadRequestError("phone already registered")
+		}
+	}
+	oldRoleID := user.RoleID
+	user.FirstName = request.FirstName
+	user.LastName = request.LastName
+	user.Phone = request.Phone
+	user.RoleID = request.RoleID
+	if err := s.dbstore.UserStore.Update(ctx, tx, *user); err != nil {
+		return nil, err
+	}
+	if err := s.master.UserMaster.ReplacePrimaryRole(ctx, tx, user.ID, oldRoleID, user.RoleID); err != nil {
+		return nil, err
+	}
+	if err := s.dbstore.InvitationStore.RevokeOpenByUserID(ctx, tx, user.ID); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+func (s *InvitationService) send(ctx context.Context, org *dbmodels.Organization, obj *dbmodels.Invitation, token string) *faulterr.FaultErr {
+	msg := messagestore.Message{
+		To:      obj.Email,
+		Subject: fmt.Sprintf("You are invited to %s", org.Name),
+		Body: fmt.Sprintf("You have been invited to join %s. Accept the invitation at %s. It expires in %d hours.",
+			org.Name, s.master.InvitationMaster.AcceptURL(token), int(time.Until(obj.ExpiresAt).Round(time.Hour).Hours())),
+	}
+	return s.messagestore.SendEmail(ctx, msg)
+}
@@ -8,6 +8,7 @@ import (
 	"gogql/app/models/dbmodels"
 	"gogql/app/store/dbstore"
 	"gogql/utils/faulterr"
+	"strings"
 
 	"github.com/gofrs/uuid"
 	"github.com/jackc/pgx/v5"
@@ -29,6 +30,9 @@ type OrganizationServiceInterface interface {
 	Archive(ctx context.Context, tx pgx.Tx, uid uuid.UUID) (*dbmodels.Organization, *faulterr.FaultErr)
 	Unarchive(ctx context.Context, tx pgx.Tx, uid uuid.UUID) (*dbmodels.Organization, *faulterr.FaultErr)
 	Delete(ctx context.Context, tx pgx.Tx, uid uuid.UUID) *faulterr.FaultErr
+
+	GetOIDCConfig(ctx context.Context, uid uuid.UUID, orgUID *uuid.UUID) (*models.OIDCConfig, *faulterr.FaultErr)
+	UpdateOIDCConfig(ctx context.Context, tx pgx.Tx, uid uuid.UUID, req dbmodels.OIDCConfigRequest, orgUID *uuid.UUID) (*models.OIDCConfig, *faulterr.FaultErr)
 }
 
 func NewOrganizationService(s *dbstore.DBStore, m *master.Master) *OrganizationService {
@@ -72,6 +76,8 @@ func (s *OrganizationService) Create(ctx context.Context, tx pgx.Tx, req dbmodel
 		Website: req.Website,
 		Logo:    req.Logo,
 		Sector:  req.Sector,
+
+		PhoneRegion: req.PhoneRegion,
 	}
 
 	org, err := s.master.OrganizationMaster.CreateOne(ctx, tx, orgReq)
@@ -79,6 +85,12 @@ func (s *OrganizationService) Create(ctx context.Context, tx pgx.Tx, req dbmodel
 		return nil, nil, err
 	}
 
+	// the organization is not committed yet, so the phone is read in its region here
+	phone, err := s.master.UserMaster.NormalizePhone(req.Phone, org.PhoneRegion)
+	if err != nil {
+		return nil, nil, err
+	}
+
 	// create management department
 	deptReq := dbmodels.DepartmentRequest{
 		OrgUID:  org.UID,
@@ -110,7 +122,7 @@ func (s *OrganizationService) Create(ctx context.Context, tx pgx.Tx, req dbmodel
 		FirstName: req.FirstName,
 		LastName:  req.LastName,
 		Email:     req.Email,
-		Phone:     req.Phone,
+		Phone:     phone,
 		OrgUID:    helpers.NullUUIDFromUUID(org.UID),
 		RoleID:    null.Int64From(role.ID),
 	}
@@ -140,6 +152,15 @@ func (s *OrganizationService) Update(ctx context.Context, tx pgx.Tx, uid uuid.UU
 		obj.Name = req.Name
 	}
 	obj.Website = req.Website
+	if req.RequireManagement2FA.Valid {
+		obj.RequireManagement2FA = req.RequireManagement2FA.Bool
+	}
+	if req.PhoneRegion.Valid && req.PhoneRegion.String != "" {
+		if err := s.master.OrganizationMaster.ValidatePhoneRegion(req.PhoneRegion.String); err != nil {
+			return nil, err
+		}
+		obj.PhoneRegion = strings.ToUpper(req.PhoneRegion.String)
+	}
 
 	if err := s.dbstore.OrganizationStore.Update(ctx, tx, *obj); err != nil {
 		return nil, err
@@ -148,6 +169,34 @@ func (s *OrganizationService) Update(ctx context.Context, tx pgx.Tx, uid uuid.UU
 	return obj, nil
 }
 
+// GetOIDCConfig gets the single sign-on config of an organization, without its client secret
+func (s *OrganizationService) GetOIDCConfig(ctx context.Context, uid uuid.UUID, orgUID *uuid.UUID) (*models.OIDCConfig, *faulterr.FaultErr) {
+	org, err := s.GetByUID(ctx, uid, orgUID)
+	if err != nil {
+		return nil, err
+	}
+
+	obj, err := s.master.OIDCMaster.GetConfig(ctx, org)
+	if err != nil {
+		return nil, err
+	}
+	return s.master.OIDCMaster.View(org, obj), nil
+}
+
+// UpdateOIDCConfig sets the single sign-on config of an organization
+func (s *OrganizationService) UpdateOIDCConfig(ctx context.Context, tx pgx.Tx, uid uuid.UUID, req dbmodels.OIDCConfigRequest, orgUID *uuid.UUID) (*models.OIDCConfig, *faulterr.FaultErr) {
+	org, err := s.GetByUID(ctx, uid, orgUID)
+	if err != nil {
+		return nil, err
+	}
+
+	obj, err := s.master.OIDCMaster.UpdateConfig(ctx, tx, org, req)
+	if err != nil {
+		return nil, err
+	}
+	return s.master.OIDCMaster.View(org, obj), nil
+}
+
 func (s *OrganizationService) Archive(ctx context.Context, tx pgx.Tx, uid uuid.UUID) (*dbmodels.Organization, *faulterr.FaultErr) {
 	obj, err := s.GetByUID(ctx, uid, nil)
 	if err != nil {
@@ -5,6 +5,7 @@ import (
 	"gogql/app/master"
 	"gogql/app/models"
 	"gogql/app/models/dbmodels"
+	"gogql/app/store/cachestore"
 	"gogql/app/store/dbstore"
 	"gogql/utils/faulterr"
 
@@ -13,14 +14,15 @@ import (
 )
 
 type RoleService struct {
-	dbstore *dbstore.DBStore
-	master  *master.Master
+	dbstore    *dbstore.DBStore
+	master     *master.Master
+	cachestore *cachestore.CacheStore
 }
 
 var _ RoleServiceInterface = &RoleService{}
 
 type RoleServiceInterface interface {
-	List(ctx context.Context, filter models.SearchFilter, orgUID *uuid.UUID, deptID *int64) ([]dbmodels.Role, int, *faulterr.FaultErr)
+	List(ctx context.Context, filter models.SearchFilter, orgUID *uuid.UUID, deptID *int64, scope models.PermissionScope) ([]dbmodels.Role, int, *faulterr.FaultErr)
 	GetByID(ctx context.Context, id int64, orgUID *uuid.UUID) (*dbmodels.Role, *faulterr.FaultErr)
 	GetByCode(ctx context.Context, code string, orgUID *uuid.UUID) (*dbmodels.Role, *faulterr.FaultErr)
 	Create(ctx context.Context, tx pgx.Tx, req dbmodels.RoleRequest) (*dbmodels.Role, *faulterr.FaultErr)
@@ -28,13 +30,13 @@ type RoleServiceInterface interface {
 	Delete(ctx context.Context, tx pgx.Tx, id int64, orgUID *uuid.UUID) *faulterr.FaultErr
 }
 
-func NewRoleService(s *dbstore.DBStore, m *master.Master) *RoleService {
-	return &RoleService{s, m}
+func NewRoleService(s *dbstore.DBStore, m *master.Master, cs *cachestore.CacheStore) *RoleService {
+	return &RoleService{s, m, cs}
 }
 
 // List gets all roles for super admin and associated organization roles for members
-func (s *RoleService) List(ctx context.Context, filter models.SearchFilter, orgUID *uuid.UUID, deptID *int64) ([]dbmodels.Role, int, *faulterr.FaultErr) {
-	return s.dbstore.RoleStore.List(ctx, filter, orgUID, deptID)
+func (s *RoleService) List(ctx context.Context, filter models.SearchFilter, orgUID *uuid.UUID, deptID *int64, scope models.PermissionScope) ([]dbmodels.Role, int, *faulterr.FaultErr) {
+	return s.dbstore.RoleStore.List(ctx, filter, orgUID, deptID, scope)
 }
 
 // GetByID gets a role by role id
@@ -99,12 +101,17 @@ func (s *RoleService) Update(ctx context.Context, tx pgx.Tx, id int64, req dbmod
 	}
 	obj.IsManagement = req.IsManagement
 	obj.Permissions = req.Permissions
+	obj.PermissionScopes = req.PermissionScopes
 	obj.IsFinal = req.IsFinal
+	if err := s.master.RoleMaster.CheckSeparation(ctx, *obj); err != nil {
+		return nil, err
+	}
 
 	// update role
 	if err := s.dbstore.RoleStore.Update(ctx, tx, *obj); err != nil {
 		return nil, err
 	}
+	s.dbstore.DBTX.InvalidateAfterCommit(ctx, tx, func() { s.cachestore.InvalidateRole(obj.ID) })
 
 	return obj, nil
 }
@@ -122,6 +129,7 @@ func (s *RoleService) Finalize(ctx context.Context, tx pgx.Tx, id int64, orgUID
 	if err := s.dbstore.RoleStore.Update(ctx, tx, *obj); err != nil {
 		return nil, err
 	}
+	s.dbstore.DBTX.InvalidateAfterCommit(ctx, tx, func() { s.cachestore.InvalidateRole(obj.ID) })
 
 	return obj, nil
 }
@@ -139,6 +147,7 @@ func (s *RoleService) Archive(ctx context.Context, tx pgx.Tx, id int64, orgUID *
 	if err := s.dbstore.RoleStore.Update(ctx, tx, *obj); err != nil {
 		return nil, err
 	}
+	s.dbstore.DBTX.InvalidateAfterCommit(ctx, tx, func() { s.cachestore.InvalidateRole(obj.ID) })
 
 	return obj, nil
 }
@@ -156,6 +165,7 @@ func (s *RoleService) Unarchive(ctx context.Context, tx pgx.Tx, id int64, orgUID
 	if err := s.dbstore.RoleStore.Update(ctx, tx, *obj); err != nil {
 		return nil, err
 	}
+	s.dbstore.DBTX.InvalidateAfterCommit(ctx, tx, func() { s.cachestore.InvalidateRole(obj.ID) })
 
 	return obj, nil
 }
@@ -165,7 +175,100 @@ func (s *RoleService) Delete(ctx context.Context, tx pgx.Tx, id int64, orgUID *u
 	if err != nil {
 		return err
 	}
-	return s.dbstore.RoleStore.Delete(ctx, tx, id)
+	if err := s.dbstore.RoleStore.Delete(ctx, tx, id); err != nil {
+		return err
+	}
+	s.dbstore.DBTX.InvalidateAfterCommit(ctx, tx, func() { s.cachestore.InvalidateRole(id) })
+	return nil
+}
+
+// ListPermissions gets the permission catalogue, removed permissions are only listed on request
+func (s *RoleService) ListPermissions(ctx context.Context, includeRemoved bool) ([]dbmodels.Permission, *faulterr.FaultErr) {
+	return s.dbstore.PermissionStore.List(ctx, includeRemoved)
+}
+
+// SyncPermissions writes the permission registry into the permissions table
+func (s *RoleService) SyncPermissions(ctx context.Context, tx pgx.Tx) *faulterr.FaultErr {
+	if err := s.master.PermissionMaster.Sync(ctx, tx); err != nil {
+		return err
+	}
+	s.dbstore.DBTX.InvalidateAfterCommit(ctx, tx, func() {
+		s.cachestore.InvalidateRoles()
+		s.cachestore.InvalidateAllElevations()
+	})
+	return nil
+}
+
+// RenamePermission migrates the roles, api keys, separation rules and elevations of every organization
+// from one permission to another
+func (s *RoleService) RenamePermission(ctx context.Context, tx pgx.Tx, from string, to string) (int64, *faulterr.FaultErr) {
+	count, err := s.master.PermissionMaster.Rename(ctx, tx, from, to)
+	if err != nil {
+		return 0, err
+	}
+	s.dbstore.DBTX.InvalidateAfterCommit(ctx, tx, func() {
+		s.cachestore.InvalidateRoles()
+		s.cachestore.InvalidateAllElevations()
+	})
+	return count, nil
+}
+
+// UnknownPermissionRoles reports the roles granting permissions that are not in the registry
+func (s *RoleService) UnknownPermissionRoles(ctx context.Context, orgUID *uuid.UUID) ([]models.RolePermissionIssue, *faulterr.FaultErr) {
+	return s.master.PermissionMaster.UnknownPermissionRoles(ctx, orgUID)
+}
+
+// ListSeparationRules gets the separation rules of an organization, every rule when is active is left empty
+func (s *RoleService) ListSeparationRules(ctx context.Context, orgUID uuid.UUID, isActive *bool) ([]dbmodels.SeparationRule, *faulterr.FaultErr) {
+	return s.dbstore.SeparationRuleStore.List(ctx, orgUID, isActive)
+}
+
+// CreateSeparationRule saves permissions no single role or user of the organization may hold together
+func (s *RoleService) CreateSeparationRule(ctx context.Context, tx pgx.Tx, req dbmodels.SeparationRuleRequest, createdBy int64) (*dbmodels.SeparationRule, *faulterr.FaultErr) {
+	if _, err := s.master.OrganizationMaster.VerifyOrganizationExists(ctx, req.OrgUID); err != nil {
+		return nil, err
+	}
+	return s.master.SeparationRuleMaster.Create(ctx, tx, req, createdBy)
+}
+
+// UpdateSeparationRule replaces a separation rule, roles and users already breaking it are not changed
+func (s *RoleService) UpdateSeparationRule(ctx context.Context, tx pgx.Tx, id int64, req dbmodels.SeparationRuleRequest, orgUID *uuid.UUID) (*dbmodels.SeparationRule, *faulterr.FaultErr) {
+	obj, err := s.getSeparationRule(ctx, id, orgUID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.master.SeparationRuleMaster.Update(ctx, tx, obj, req); err != nil {
+		return nil, err
+	}
+	return obj, nil
+}
+
+// DeleteSeparationRule deletes a separation rule
+func (s *RoleService) DeleteSeparationRule(ctx context.Context, tx pgx.Tx, id int64, orgUID *uuid.UUID) (*dbmodels.SeparationRule, *faulterr.FaultErr) {
+	obj, err := s.getSeparationRule(ctx, id, orgUID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.dbstore.SeparationRuleStore.Delete(ctx, tx, obj.ID); err != nil {
+		return nil, err
+	}
+	return obj, nil
+}
+
+// SeparationViolations reports the roles and users of an organization breaking its active separation rules
+func (s *RoleService) SeparationViolations(ctx context.Context, orgUID uuid.UUID) ([]models.SeparationViolation, *faulterr.FaultErr) {
+	return s.master.SeparationRuleMaster.Violations(ctx, orgUID)
+}
+
+func (s *RoleService) getSeparationRule(ctx context.Context, id int64, orgUID *uuid.UUID) (*dbmodels.SeparationRule, *faulterr.FaultErr) {
+	obj, err := s.dbstore.SeparationRuleStore.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if orgUID != nil && *orgUID != obj.OrgUID {
+		return nil, faulterr.NewNotFoundError("object not found")
+	}
+	return obj, nil
 }
 
 // Unique permissions
@@ -20,7 +20,7 @@ type UserActivityService struct {
 var _ UserActivityServiceInterface = &UserActivityService{}
 
 type UserActivityServiceInterface interface {
-	List(ctx context.Context, filter models.SearchFilter, userID *int64, orgUID *uuid.UUID) ([]dbmodels.UserActivity, int, *faulterr.FaultErr)
+	List(ctx context.Context, filter models.SearchFilter, userID *int64, orgUID *uuid.UUID, scope models.PermissionScope) ([]dbmodels.UserActivity, int, *faulterr.FaultErr)
 	GetByID(ctx context.Context, id int64, orgUID *uuid.UUID) (*dbmodels.UserActivity, *faulterr.FaultErr)
 }
 
@@ -29,8 +29,8 @@ func NewUserActivityService(s *dbstore.DBStore, m *master.Master) *UserActivityS
 }
 
 // List gets all user activities
-func (s *UserActivityService) List(ctx context.Context, filter models.SearchFilter, userID *int64, orgUID *uuid.UUID) ([]dbmodels.UserActivity, int, *faulterr.FaultErr) {
-	return s.dbstore.UserActivityStore.List(ctx, filter, userID, orgUID)
+func (s *UserActivityService) List(ctx context.Context, filter models.SearchFilter, userID *int64, orgUID *uuid.UUID, scope models.PermissionScope) ([]dbmodels.UserActivity, int, *faulterr.FaultErr) {
+	return s.dbstore.UserActivityStore.List(ctx, filter, userID, orgUID, scope)
 }
 
 func (s *UserActivityService) GetByID(ctx context.Context, id int64, orgUID *uuid.UUID) (*dbmodels.UserActivity, *faulterr.FaultErr) {
@@ -2,34 +2,52 @@ package orgservice
 
 import (
 	"context"
+	"fmt"
+	"gogql/app/helpers"
 	"gogql/app/master"
 	"gogql/app/models"
 	"gogql/app/models/constants"
 	"gogql/app/models/dbmodels"
+	"gogql/app/store/cachestore"
 	"gogql/app/store/dbstore"
+	"gogql/app/store/messagestore"
 	"gogql/utils/faulterr"
+	"net/http"
+	"time"
 
 	"github.com/gofrs/uuid"
 	"github.com/jackc/pgx/v5"
+	"github.com/volatiletech/null"
 )
 
 type UserService struct {
-	dbstore *dbstore.DBStore
-	master  *master.Master
+	dbstore      *dbstore.DBStore
+	master       *master.Master
+	messagestore *messagestore.MessageStore
+	cachestore   *cachestore.CacheStore
 }
 
 var _ UserServiceInterface = &UserService{}
 
 type UserServiceInterface interface {
 	Me(ctx context.Context, userID int64) (*dbmodels.User, *faulterr.FaultErr)
-	List(ctx context.Context, filter models.SearchFilter, orgUID *uuid.UUID, roleID *int64) ([]dbmodels.User, int, *faulterr.FaultErr)
+	List(ctx context.Context, filter models.SearchFilter, orgUID *uuid.UUID, roleID *int64, scope models.PermissionScope) ([]dbmodels.User, int, *faulterr.FaultErr)
 	GetByID(ctx context.Context, id int64, orgUID *uuid.UUID) (*dbmodels.User, *faulterr.FaultErr)
-	Update(ctx context.Context, tx pgx.Tx, id int64, request dbmodels.UserRequest, orgUID *uuid.UUID) (*dbmodels.User, *faulterr.FaultErr)
+	GetByIDInScope(ctx context.Context, id int64, orgUID *uuid.UUID, scope models.PermissionScope) (*dbmodels.User, *faulterr.FaultErr)
+	CheckScope(ctx context.Context, obj *dbmodels.User, scope models.PermissionScope) *faulterr.FaultErr
+	Update(ctx context.Context, tx pgx.Tx, id int64, request dbmodels.UserRequest, orgUID *uuid.UUID, auther *models.Auther) (*dbmodels.User, bool, *faulterr.FaultErr)
 	Delete(ctx context.Context, tx pgx.Tx, id int64, orgUID *uuid.UUID) *faulterr.FaultErr
+
+	AssignRole(ctx context.Context, tx pgx.Tx, userID int64, roleID int64, expiresAt null.Time, orgUID *uuid.UUID) (*dbmodels.User, *faulterr.FaultErr)
+	RevokeRole(ctx context.Context, tx pgx.Tx, userID int64, roleID int64, orgUID *uuid.UUID) (*dbmodels.User, *faulterr.FaultErr)
+
+	ListContactChanges(ctx context.Context, userID int64, orgUID *uuid.UUID) ([]dbmodels.ContactChange, *faulterr.FaultErr)
+	ConfirmContactChange(ctx context.Context, tx pgx.Tx, auther *models.Auther, id int64, code string) (*dbmodels.User, *faulterr.FaultErr)
+	CancelContactChange(ctx context.Context, tx pgx.Tx, token string) (*dbmodels.ContactChange, *faulterr.FaultErr)
 }
 
-func NewUserService(s *dbstore.DBStore, m *master.Master) *UserService {
-	return &UserService{s, m}
+func NewUserService(s *dbstore.DBStore, m *master.Master, ms *messagestore.MessageStore, cs *cachestore.CacheStore) *UserService {
+	return &UserService{s, m, ms, cs}
 }
 
 // ListCustomers gets aa user by id
@@ -38,8 +56,8 @@ func (s *UserService) Me(ctx context.Context, userID int64) (*dbmodels.User, *fa
 }
 
 // List gets all admin, members, and consumers
-func (s *UserService) List(ctx context.Context, filter models.SearchFilter, orgUID *uuid.UUID, roleID *int64) ([]dbmodels.User, int, *faulterr.FaultErr) {
-	return s.dbstore.UserStore.List(ctx, filter, orgUID, roleID)
+func (s *UserService) List(ctx context.Context, filter models.SearchFilter, orgUID *uuid.UUID, roleID *int64, scope models.PermissionScope) ([]dbmodels.User, int, *faulterr.FaultErr) {
+	return s.dbstore.UserStore.List(ctx, filter, orgUID, roleID, scope)
 }
 
 // GetByID gets a user by its ID
@@ -56,6 +74,33 @@ func (s *UserService) GetByID(ctx context.Context, id int64, orgUID *uuid.UUID)
 	return obj, nil
 }
 
+// GetByIDInScope gets a user by its ID, users outside the scope are not found
+func (s *UserService) GetByIDInScope(ctx context.Context, id int64, orgUID *uuid.UUID, scope models.PermissionScope) (*dbmodels.User, *faulterr.FaultErr) {
+	obj, err := s.GetByID(ctx, id, orgUID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.CheckScope(ctx, obj, scope); err != nil {
+		return nil, err
+	}
+	return obj, nil
+}
+
+// CheckScope verifies a user is inside the scope a permission was granted with
+func (s *UserService) CheckScope(ctx context.Context, obj *dbmodels.User, scope models.PermissionScope) *faulterr.FaultErr {
+	if scope.Covers(obj.ID, nil) {
+		return nil
+	}
+	departmentIDs, err := s.master.UserMaster.DepartmentIDs(ctx, obj.ID)
+	if err != nil {
+		return err
+	}
+	if !scope.Covers(obj.ID, departmentIDs) {
+		return faulterr.NewNotFoundError("object not found")
+	}
+	return nil
+}
+
 // GetByEmail gets a user by user profile
 func (s *UserService) GetByEmail(ctx context.Context, email string, orgUID *uuid.UUID) (*dbmodels.User, *faulterr.FaultErr) {
 	obj, err := s.dbstore.UserStore.GetByEmail(ctx, email)
@@ -72,7 +117,12 @@ func (s *UserService) GetByEmail(ctx context.Context, email string, orgUID *uuid
 
 // GetByPhone gets a user by user profile
 func (s *UserService) GetByPhone(ctx context.Context, phone string, orgUID *uuid.UUID) (*dbmodels.User, *faulterr.FaultErr) {
-	obj, err := s.dbstore.UserStore.GetByPhone(ctx, phone)
+	// national numbers are read in the region of the organization
+	phoneOrgUID := uuid.NullUUID{}
+	if orgUID != nil {
+		phoneOrgUID = helpers.NullUUIDFromUUID(*orgUID)
+	}
+	obj, err := s.master.UserMaster.GetByPhone(ctx, phone, phoneOrgUID)
 	if err != nil {
 		return nil, err
 	}
@@ -89,12 +139,65 @@ func (s *UserService) Create(ctx context.Context, tx pgx.Tx, request dbmodels.Us
 	return s.master.UserMaster.CreateOne(ctx, tx, request)
 }
 
-func (s *UserService) Update(ctx context.Context, tx pgx.Tx, id int64, request dbmodels.UserRequest, orgUID *uuid.UUID) (*dbmodels.User, *faulterr.FaultErr) {
+// Update saves the name of a user right away and reports whether it changed. A new email or phone
+// stays pending until it is confirmed with the otp sent to it, the old value is notified with a cancel link.
+func (s *UserService) Update(ctx context.Context, tx pgx.Tx, id int64, request dbmodels.UserRequest, orgUID *uuid.UUID, auther *models.Auther) (*dbmodels.User, bool, *faulterr.FaultErr) {
 	obj, err := s.GetByID(ctx, id, orgUID)
 	if err != nil {
-		return nil, err
+		return nil, false, err
+	}
+
+	if request.Email != "" && request.Email != obj.Email {
+		if err := s.requestContactChange(ctx, tx, obj, models.OTPChannelEmail, request.Email, auther); err != nil {
+			return nil, false, err
+		}
+	}
+	if request.Phone != "" {
+		number, err := s.master.UserMaster.NormalizePhone(request.Phone, s.master.UserMaster.PhoneRegion(ctx, obj.OrgUID))
+		if err != nil {
+			return nil, false, err
+		}
+		request.Phone = number
+	}
+	if request.Phone != "" && request.Phone != obj.Phone {
+		if err := s.requestContactChange(ctx, tx, obj, models.OTPChannelSMS, request.Phone, auther); err != nil {
+			return nil, false, err
+		}
 	}
-	return s.master.UserMaster.Update(ctx, tx, *obj, request)
+
+	user, updated, err := s.master.UserMaster.Update(ctx, tx, *obj, request)
+	if err != nil {
+		return nil, false, err
+	}
+	if request.RoleID.Valid && request.RoleID != user.RoleID {
+		if err := s.replacePrimaryRole(ctx, tx, user, request.RoleID.Int64, orgUID); err != nil {
+			return nil, false, err
+		}
+		s.dbstore.DBTX.InvalidateAfterCommit(ctx, tx, func() { s.cachestore.InvalidateUserRoles(user.ID) })
+		updated = true
+	}
+	if updated {
+		s.dbstore.DBTX.InvalidateAfterCommit(ctx, tx, func() { s.cachestore.InvalidateUser(user.ID) })
+	}
+	return user, updated, nil
+}
+
+// replacePrimaryRole moves the primary role of a user to another role of its organization,
+// the roles of the user together may not break a separation rule
+func (s *UserService) replacePrimaryRole(ctx context.Context, tx pgx.Tx, user *dbmodels.User, roleID int64, orgUID *uuid.UUID) *faulterr.FaultErr {
+	_, role, err := s.getUserRole(ctx, user.ID, roleID, orgUID)
+	if err != nil {
+		return err
+	}
+	if role.IsArchived {
+		return faulterr.NewBadRequestError("role is archived")
+	}
+
+	if err := s.master.UserMaster.ReplacePrimaryRole(ctx, tx, user.ID, user.RoleID, null.Int64From(role.ID)); err != nil {
+		return err
+	}
+	user.RoleID = null.Int64From(role.ID)
+	return s.dbstore.UserStore.Update(ctx, tx, *user)
 }
 
 func (s *UserService) Delete(ctx context.Context, tx pgx.Tx, id int64, orgUID *uuid.UUID) *faulterr.FaultErr {
@@ -102,7 +205,11 @@ func (s *UserService) Delete(ctx context.Context, tx pgx.Tx, id int64, orgUID *u
 	if err != nil {
 		return err
 	}
-	return s.dbstore.UserStore.Delete(ctx, tx, id)
+	if err := s.dbstore.UserStore.Delete(ctx, tx, id); err != nil {
+		return err
+	}
+	s.dbstore.DBTX.InvalidateAfterCommit(ctx, tx, func() { s.cachestore.InvalidateUser(id) })
+	return nil
 }
 
 // Archive updates a user object in db
@@ -120,6 +227,11 @@ func (s *UserService) Archive(ctx context.Context, tx pgx.Tx, id int64, orgUID *
 	if err := s.dbstore.UserStore.Update(ctx, tx, *obj); err != nil {
 		return nil, err
 	}
+	// archived users are signed out of every session
+	if _, err := s.dbstore.AuthSessionStore.InvalidateByUserID(ctx, tx, obj.ID); err != nil {
+		return nil, err
+	}
+	s.dbstore.DBTX.InvalidateAfterCommit(ctx, tx, func() { s.cachestore.InvalidateUser(obj.ID) })
 
 	return obj, nil
 }
@@ -139,6 +251,199 @@ func (s *UserService) Unarchive(ctx context.Context, tx pgx.Tx, id int64, orgUID
 	if err := s.dbstore.UserStore.Update(ctx, tx, *obj); err != nil {
 		return nil, err
 	}
+	s.dbstore.DBTX.InvalidateAfterCommit(ctx, tx, func() { s.cachestore.InvalidateUser(obj.ID) })
 
 	return obj, nil
 }
+
+// AssignRole assigns another role of the organization of a user, an assignment with an expiry
+// stops granting its permissions once expired
+func (s *UserService) AssignRole(ctx context.Context, tx pgx.Tx, userID int64, roleID int64, expiresAt null.Time, orgUID *uuid.UUID) (*dbmodels.User, *faulterr.FaultErr) {
+	user, role, err := s.getUserRole(ctx, userID, roleID, orgUID)
+	if err != nil {
+		return nil, err
+	}
+	if role.IsArchived {
+		return nil, faulterr.NewBadRequestError("role is archived")
+	}
+	if expiresAt.Valid {
+		if user.RoleID.Valid && user.RoleID.Int64 == role.ID {
+			return nil, faulterr.NewBadRequestError("primary role can not expire")
+		}
+		if !expiresAt.Time.After(time.Now()) {
+			return nil, faulterr.NewBadRequestError("expiry has to be in the future")
+		}
+	}
+
+	if _, err := s.master.UserMaster.AssignRole(ctx, tx, user.ID, role.ID, expiresAt); err != nil {
+		return nil, err
+	}
+	s.dbstore.DBTX.InvalidateAfterCommit(ctx, tx, func() { s.cachestore.InvalidateUserRoles(user.ID) })
+
+	return user, nil
+}
+
+// RevokeRole removes a role assigned to a user, the primary role is changed by updating the user
+func (s *UserService) RevokeRole(ctx context.Context, tx pgx.Tx, userID int64, roleID int64, orgUID *uuid.UUID) (*dbmodels.User, *faulterr.FaultErr) {
+	user, role, err := s.getUserRole(ctx, userID, roleID, orgUID)
+	if err != nil {
+		return nil, err
+	}
+	if user.RoleID.Valid && user.RoleID.Int64 == role.ID {
+		return nil, faulterr.NewBadRequestError("primary role can not be revoked")
+	}
+
+	count, err := s.dbstore.UserRoleStore.Delete(ctx, tx, user.ID, role.ID)
+	if err != nil {
+		return nil, err
+	}
+	if count == 0 {
+		return nil, faulterr.NewNotFoundError("role is not assigned to user")
+	}
+	s.dbstore.DBTX.InvalidateAfterCommit(ctx, tx, func() { s.cachestore.InvalidateUserRoles(user.ID) })
+
+	return user, nil
+}
+
+// ListContactChanges gets the open email and phone changes of a user
+func (s *UserService) ListContactChanges(ctx context.Context, userID int64, orgUID *uuid.UUID) ([]dbmodels.ContactChange, *faulterr.FaultErr) {
+	if _, err := s.GetByID(ctx, userID, orgUID); err != nil {
+		return nil, err
+	}
+	return s.dbstore.ContactChangeStore.ListOpenByUserID(ctx, userID)
+}
+
+// ConfirmContactChange applies a pending email or phone change with the otp sent to the new value,
+// only the user and the requester of the change can confirm it
+func (s *UserService) ConfirmContactChange(ctx context.Context, tx pgx.Tx, auther *models.Auther, id int64, code string) (*dbmodels.User, *faulterr.FaultErr) {
+	obj, err := s.dbstore.ContactChangeStore.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if obj.UserID != auther.ID && obj.RequestedBy != auther.ID {
+		return nil, faulterr.NewNotFoundError("object not found")
+	}
+
+	user, err := s.dbstore.UserStore.GetByID(ctx, obj.UserID)
+	if err != nil {
+		return nil, err
+	}
+	// the value may have been taken since the change was requested
+	if err := s.verifyUniqueContact(ctx, obj.Channel, obj.NewValue); err != nil {
+		return nil, err
+	}
+
+	if err := s.master.ContactChangeMaster.Confirm(ctx, tx, obj, code); err != nil {
+		return nil, err
+	}
+
+	if obj.Channel == models.OTPChannelSMS {
+		user.Phone = obj.NewValue
+	} else {
+		user.Email = obj.NewValue
+	}
+	if err := s.dbstore.UserStore.Update(ctx, tx, *user); err != nil {
+		return nil, err
+	}
+	s.dbstore.DBTX.InvalidateAfterCommit(ctx, tx, func() { s.cachestore.InvalidateUser(user.ID) })
+	return user, nil
+}
+
+// CancelContactChange closes a pending email or phone change with the token of the cancel link
+func (s *UserService) CancelContactChange(ctx context.Context, tx pgx.Tx, token string) (*dbmodels.ContactChange, *faulterr.FaultErr) {
+	return s.master.ContactChangeMaster.Cancel(ctx, tx, token)
+}
+
+// Helpers
+
+// requestContactChange replaces the open changes of a channel with a new one, sends its otp to the
+// new value and notifies the old value, users without a phone are notified by email. A super admin
+// impersonating a user may not start one as it could move the account to a contact it controls.
+func (s *UserService) requestContactChange(ctx context.Context, tx pgx.Tx, user *dbmodels.User, channel string, newValue string, auther *models.Auther) *faulterr.FaultErr {
+	if auther.ImpersonatorID.Valid {
+		return faulterr.NewFrobiddenError("not allowed while impersonating a user").WithCode(constants.ErrCodeImpersonationForbidden)
+	}
+	if err := s.verifyUniqueContact(ctx, channel, newValue); err != nil {
+		return err
+	}
+	if err := s.dbstore.ContactChangeStore.CancelOpenByUserID(ctx, tx, user.ID, channel); err != nil {
+		return err
+	}
+
+	obj, code, cancelToken, err := s.master.ContactChangeMaster.Create(ctx, tx, user, channel, newValue, auther.ID)
+	if err != nil {
+		return err
+	}
+
+	field := "email"
+	if channel == models.OTPChannelSMS {
+		field = "phone"
+	}
+	confirm := messagestore.Message{
+		To:      obj.NewValue,
+		Subject: fmt.Sprintf("Confirm your new %s", field),
+		Body: fmt.Sprintf("Your confirmation code is %s. It expires in %d minutes.",
+			code, int(time.Until(obj.ExpiresAt).Round(time.Minute).Minutes())),
+	}
+	notice := messagestore.Message{
+		To:      obj.OldValue,
+		Subject: fmt.Sprintf("Your %s is being changed", field),
+		Body: fmt.Sprintf("A change of the %s of your account was requested. If you did not request it, cancel it at %s.",
+			field, s.master.ContactChangeMaster.CancelURL(cancelToken)),
+	}
+
+	if channel == models.OTPChannelSMS {
+		if err := s.messagestore.SendSMS(ctx, confirm); err != nil {
+			return err
+		}
+		if obj.OldValue != "" {
+			return s.messagestore.SendSMS(ctx, notice)
+		}
+		notice.To = user.Email
+		return s.messagestore.SendEmail(ctx, notice)
+	}
+
+	if err := s.messagestore.SendEmail(ctx, confirm); err != nil {
+		return err
+	}
+	return s.messagestore.SendEmail(ctx, notice)
+}
+
+// verifyUniqueContact verifies that no user has the email or phone yet
+func (s *UserService) verifyUniqueContact(ctx context.Context, channel string, value string) *faulterr.FaultErr {
+	if channel == models.OTPChannelSMS {
+		_, err := s.master.UserMaster.GetByPhone(ctx, value, uuid.NullUUID{})
+		if err == nil {
+			return faulterr.NewBadRequestError("phone already registered")
+		}
+		if err.Status != http.StatusNotFound {
+			return err
+		}
+		return nil
+	}
+
+	_, err := s.dbstore.UserStore.GetByEmail(ctx, value)
+	if err == nil {
+		return faulterr.NewBadRequestError("email already registered")
+	}
+	if err.Status != http.StatusNotFound {
+		return err
+	}
+	return nil
+}
+
+// getUserRole gets a user in scope and a role of the organization of the user
+func (s *UserService) getUserRole(ctx context.Context, userID int64, roleID int64, orgUID *uuid.UUID) (*dbmodels.User, *dbmodels.Role, *faulterr.FaultErr) {
+	user, err := s.GetByID(ctx, userID, orgUID)
+	if err != nil {
+		return nil, nil, err
+	}
+	role, err := s.dbstore.RoleStore.GetByID(ctx, roleID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !user.OrgUID.Valid || user.OrgUID.UUID != role.OrgUID {
+		return nil, nil, faulterr.NewNotFoundError("object not found")
+	}
+	return user, role, nil
+}
@@ -0,0 +1,150 @@
+package cachestore
+
+import (
+	"gogql/app/models"
+	"gogql/app/models/dbmodels"
+	"time"
+)
+
+// SessionEntry is a valid session cached with its user under the hash of its token
+type SessionEntry struct {
+	Session dbmodels.AuthSession
+	User    dbmodels.User
+}
+
+// CacheStore keeps sessions, role permissions, role assignments and permission elevations in
+// process for a short ttl so resolvers do not query them on every request. Writes to them
+// invalidate their entries explicitly, a ttl of zero disables the cache.
+type CacheStore struct {
+	sessions  *ttlCache[string, SessionEntry]
+	roles     *ttlCache[int64, dbmodels.Role]
+	userRoles *ttlCache[int64, []dbmodels.UserRole]
+
+	elevations *ttlCache[int64, []dbmodels.PermissionElevation]
+}
+
+func NewCacheStore(ttl time.Duration) *CacheStore {
+	return &CacheStore{
+		sessions:  newTTLCache[string, SessionEntry](ttl),
+		roles:     newTTLCache[int64, dbmodels.Role](ttl),
+		userRoles: newTTLCache[int64, []dbmodels.UserRole](ttl),
+
+		elevations: newTTLCache[int64, []dbmodels.PermissionElevation](ttl),
+	}
+}
+
+// Sessions
+
+// GetSession gets a cached session by the hash of its token
+func (cs *CacheStore) GetSession(tokenHash string) (*SessionEntry, bool) {
+	entry, ok := cs.sessions.get(tokenHash)
+	if !ok {
+		return nil, false
+	}
+	return &entry, true
+}
+
+// SetSession caches a session and its user by the hash of its token
+func (cs *CacheStore) SetSession(tokenHash string, session dbmodels.AuthSession, user dbmodels.User) {
+	cs.sessions.set(tokenHash, SessionEntry{session, user})
+}
+
+// InvalidateSession drops a session, its token hash changes when it is rotated
+// so it is matched by id
+func (cs *CacheStore) InvalidateSession(sessionID int64) {
+	cs.sessions.deleteFunc(func(entry SessionEntry) bool {
+		return entry.Session.ID == sessionID
+	})
+}
+
+// InvalidateUser drops every session of a user, sessions carry the user so changes
+// to the user invalidate them as well
+func (cs *CacheStore) InvalidateUser(userID int64) {
+	cs.sessions.deleteFunc(func(entry SessionEntry) bool {
+		return entry.Session.UserID == userID
+	})
+}
+
+// Roles
+
+// GetRole gets a cached role by id
+func (cs *CacheStore) GetRole(id int64) (*dbmodels.Role, bool) {
+	role, ok := cs.roles.get(id)
+	if !ok {
+		return nil, false
+	}
+	return &role, true
+}
+
+// SetRole caches a role by id
+func (cs *CacheStore) SetRole(role dbmodels.Role) {
+	cs.roles.set(role.ID, role)
+}
+
+// InvalidateRole drops a role
+func (cs *CacheStore) InvalidateRole(id int64) {
+	cs.roles.delete(id)
+}
+
+// InvalidateRoles drops every role, for changes that touch the roles of many organizations
+func (cs *CacheStore) InvalidateRoles() {
+	cs.roles.deleteFunc(func(dbmodels.Role) bool {
+		return true
+	})
+}
+
+// User roles
+
+// GetUserRoles gets the cached role assignments of a user, they are cached with their
+// expiry so expired assignments have to be skipped by the caller
+func (cs *CacheStore) GetUserRoles(userID int64) ([]dbmodels.UserRole, bool) {
+	return cs.userRoles.get(userID)
+}
+
+// SetUserRoles caches the role assignments of a user
+func (cs *CacheStore) SetUserRoles(userID int64, userRoles []dbmodels.UserRole) {
+	cs.userRoles.set(userID, userRoles)
+}
+
+// InvalidateUserRoles drops the role assignments of a user
+func (cs *CacheStore) InvalidateUserRoles(userID int64) {
+	cs.userRoles.delete(userID)
+}
+
+// Permission elevations
+
+// GetElevations gets the cached approved elevations of a user, they are cached with their
+// start and end so the caller has to skip the ones not active
+func (cs *CacheStore) GetElevations(userID int64) ([]dbmodels.PermissionElevation, bool) {
+	return cs.elevations.get(userID)
+}
+
+// SetElevations caches the approved elevations of a user
+func (cs *CacheStore) SetElevations(userID int64, elevations []dbmodels.PermissionElevation) {
+	cs.elevations.set(userID, elevations)
+}
+
+// InvalidateElevations drops the approved elevations of a user
+func (cs *CacheStore) InvalidateElevations(userID int64) {
+	cs.elevations.delete(userID)
+}
+
+// InvalidateAllElevations drops the elevations of every user, for changes that touch the
+// elevations of many users
+func (cs *CacheStore) InvalidateAllElevations() {
+	cs.elevations.deleteFunc(func([]dbmodels.PermissionElevation) bool {
+		return true
+	})
+}
+
+// Stats
+
+// Stats returns the hit and miss counters of the caches since the process started
+func (cs *CacheStore) Stats() []models.CacheStats {
+	return []models.CacheStats{
+		{Name: "sessions", Hits: cs.sessions.hits.Load(), Misses: cs.sessions.misses.Load(), Entries: cs.sessions.size()},
+		{Name: "roles", Hits: cs.roles.hits.Load(), Misses: cs.roles.misses.Load(), Entries: cs.roles.size()},
+		{Name: "user_roles", Hits: cs.userRoles.hits.Load(), Misses: cs.userRoles.misses.Load(), Entries: cs.userRoles.size()},
+		{Name: "permission_elevations", Hits: cs.elevations.hits.Load(), Misses: cs.elevations.misses.Load(), Entries: cs.elevations.size()},
+	}
+}
@@ -0,0 +1,70 @@
+package cachestore
+
+import (
+	"gogql/app/models/dbmodels"
+	"testing"
+	"time"
+)
+
+func TestSessionInvalidation(t *testing.T) {
+	cs := NewCacheStore(time.Minute)
+	cs.SetSession("a", dbmodels.AuthSession{ID: 1, UserID: 10}, dbmodels.User{ID: 10})
+	cs.SetSession("b", dbmodels.AuthSession{ID: 2, UserID: 10}, dbmodels.User{ID: 10})
+	cs.SetSession("c", dbmodels.AuthSession{ID: 3, UserID: 20}, dbmodels.User{ID: 20})
+
+	cs.InvalidateSession(1)
+	if _, ok := cs.GetSession("a"); ok {
+		t.Fatal("InvalidateSession: session is still cached")
+	}
+	if _, ok := cs.GetSession("b"); !ok {
+		t.Fatal("InvalidateSession: other session of the user was dropped")
+	}
+
+	cs.InvalidateUser(10)
+	if _, ok := cs.GetSession("b"); ok {
+		t.Fatal("InvalidateUser: session of the user is still cached")
+	}
+	if _, ok := cs.GetSession("c"); !ok {
+		t.Fatal("InvalidateUser: session of another user was dropped")
+	}
+}
+
+func TestRoleExpiry(t *testing.T) {
+	cs := NewCacheStore(time.Millisecond)
+	cs.SetRole(dbmodels.Role{ID: 1})
+	if _, ok := cs.GetRole(1); !ok {
+		t.Fatal("GetRole: role is not cached")
+	}
+
+	time.Sleep(2 * time.Millisecond)
+	if _, ok := cs.GetRole(1); ok {
+		t.Fatal("GetRole: expired role is still cached")
+	}
+
+	stats := cs.Stats()
+	if stats[1].Hits != 1 || stats[1].Misses != 1 {
+		t.Fatal("Stats: hits and misses are not counted")
+	}
+}
+
+func TestUserRolesInvalidation(t *testing.T) {
+	cs := NewCacheStore(time.Minute)
+	cs.SetUserRoles(10, []dbmodels.UserRole{{ID: 1, UserID: 10, RoleID: 1}})
+	cs.SetUserRoles(20, []dbmodels.UserRole{{ID: 2, UserID: 20, RoleID: 1}})
+
+	cs.InvalidateUserRoles(10)
+	if _, ok := cs.GetUserRoles(10); ok {
+		t.Fatal("InvalidateUserRoles: role assignments are still cached")
+	}
+	if _, ok := cs.GetUserRoles(20); !ok {
+		t.Fatal("InvalidateUserRoles: role assignments of another user were dropped")
+	}
+}
+
+func TestDisabledCache(t *testing.T) {
+	cs := NewCacheStore(0)
+	cs.SetRole(dbmodels.Role{ID: 1})
+	if _, ok := cs.GetRole(1); ok {
+		t.Fatal("SetRole: role is cached with a zero ttl")
+	}
+}
@@ -18,6 +18,30 @@ type DBStore struct {
 	OTPSessionStore   *orgstore.OTPSessionStore
 	AuthSessionStore  *orgstore.AuthSessionStore
 	UserActivityStore *orgstore.UserActivityStore
+	AuthAttemptStore  *orgstore.AuthAttemptStore
+	RefreshTokenStore *orgstore.RefreshTokenStore
+
+	TOTPCredentialStore *orgstore.TOTPCredentialStore
+	RecoveryCodeStore   *orgstore.RecoveryCodeStore
+
+	WebAuthnCredentialStore *orgstore.WebAuthnCredentialStore
+	WebAuthnChallengeStore  *orgstore.WebAuthnChallengeStore
+
+	OrganizationOIDCConfigStore *orgstore.OrganizationOIDCConfigStore
+	OIDCLoginStateStore         *orgstore.OIDCLoginStateStore
+
+	APIKeyStore *orgstore.APIKeyStore
+
+	InvitationStore    *orgstore.InvitationStore
+	ContactChangeStore *orgstore.ContactChangeStore
+
+	PermissionStore          *orgstore.PermissionStore
+	UserRoleStore            *orgstore.UserRoleStore
+	PermissionElevationStore *orgstore.PermissionElevationStore
+	SeparationRuleStore      *orgstore.SeparationRuleStore
+
+	AccessReviewCampaignStore *orgstore.AccessReviewCampaignStore
+	AccessReviewItemStore     *orgstore.AccessReviewItemStore
 }
 
 func NewDBStore(conn *pgxpool.Pool) *DBStore {
@@ -33,5 +57,29 @@ func NewDBStore(conn *pgxpool.Pool) *DBStore {
 		orgstore.NewOTPSessionStore(conn),
 		orgstore.NewAuthSessionStore(conn),
 		orgstore.NewUserActivityStore(conn),
+		orgstore.NewAuthAttemptStore(conn),
+		orgstore.NewRefreshTokenStore(conn),
+
+		orgstore.NewTOTPCredentialStore(conn),
+		orgstore.NewRecoveryCodeStore(conn),
+
+		orgstore.NewWebAuthnCredentialStore(conn),
+		orgstore.NewWebAuthnChallengeStore(conn),
+
+		orgstore.NewOrganizationOIDCConfigStore(conn),
+		orgstore.NewOIDCLoginStateStore(conn),
+
+		orgstore.NewAPIKeyStore(conn),
+
+		orgstore.NewInvitationStore(conn),
+		orgstore.NewContactChangeStore(conn),
+
+		orgstore.NewPermissionStore(conn),
+		orgstore.NewUserRoleStore(conn),
+		orgstore.NewPermissionElevationStore(conn),
+		orgstore.NewSeparationRuleStore(conn),
+
+		orgstore.NewAccessReviewCampaignStore(conn),
+		orgstore.NewAccessReviewItemStore(conn),
 	}
 }
@@ -9,4 +9,5 @@ const (
 	RolesTable          dbTable = "roles"
 	UsersTable          dbTable = "users"
 	UserActivitiesTable dbTable = "user_activities"
+	InvitationsTable    dbTable = "invitations"
 )
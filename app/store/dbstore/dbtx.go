@@ -14,6 +14,12 @@ type DBTX struct {
 	conn *pgxpool.Pool
 }
 
+// hookedTx is a transaction begun by DBTX, it holds the hooks to run once it is committed
+type hookedTx struct {
+	pgx.Tx
+	afterCommit []func(ctx context.Context) *faulterr.FaultErr
+}
+
 func NewDBTX(conn *pgxpool.Pool) *DBTX {
 	return &DBTX{conn}
 }
@@ -31,7 +37,7 @@ func (t *DBTX) BeginTx(ctx context.Context) (pgx.Tx, *faulterr.FaultErr) {
 	}
 	logger.Info("Beign Transaction")
 
-	return tx, nil
+	return &hookedTx{Tx: tx}, nil
 
 }
 
@@ -42,10 +48,44 @@ func (t *DBTX) CommitTx(ctx context.Context, tx pgx.Tx) *faulterr.FaultErr {
 	}
 	logger.Info("Commit Transaction")
 
+	if hooked, ok := tx.(*hookedTx); ok {
+		hooks := hooked.afterCommit
+		hooked.afterCommit = nil
+		for _, fn := range hooks {
+			if err := fn(ctx); err != nil {
+				return err
+			}
+		}
+	}
 	return nil
 }
 
+// AfterCommit runs fn once tx is committed, a rolled back transaction drops its hooks.
+// Work that others may observe, such as sending messages or dropping cached rows, waits
+// for the commit this way. Transactions not begun by BeginTx run fn at once.
+func (t *DBTX) AfterCommit(ctx context.Context, tx pgx.Tx, fn func(ctx context.Context) *faulterr.FaultErr) *faulterr.FaultErr {
+	hooked, ok := tx.(*hookedTx)
+	if !ok {
+		return fn(ctx)
+	}
+	hooked.afterCommit = append(hooked.afterCommit, fn)
+	return nil
+}
+
+// InvalidateAfterCommit drops cached rows now and again once tx is committed, a concurrent request
+// reading the old row before the commit would otherwise cache it again until it expires
+func (t *DBTX) InvalidateAfterCommit(ctx context.Context, tx pgx.Tx, invalidate func()) {
+	invalidate()
+	t.AfterCommit(ctx, tx, func(ctx context.Context) *faulterr.FaultErr {
+		invalidate()
+		return nil
+	})
+}
+
 func (t *DBTX) RollbackTx(ctx context.Context, tx pgx.Tx) *faulterr.FaultErr {
+	if hooked, ok := tx.(*hookedTx); ok {
+		hooked.afterCommit = nil
+	}
 	err := tx.Rollback(ctx)
 	if err != nil {
 		// return faulterr.NewInternalServerError(err.Error())
@@ -0,0 +1,98 @@
+package dbstore
+
+import (
+	"context"
+	"gogql/app/models/dbmodels"
+	"gogql/app/store/cachestore"
+	"gogql/utils/faulterr"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// fakeTx only commits and rolls back
+type fakeTx struct {
+	pgx.Tx
+	committed bool
+}
+
+func (t *fakeTx) Commit(ctx context.Context) error {
+	t.committed = true
+	return nil
+}
+
+func (t *fakeTx) Rollback(ctx context.Context) error {
+	if t.committed {
+		return pgx.ErrTxClosed
+	}
+	return nil
+}
+
+func TestAfterCommit(t *testing.T) {
+	ctx := context.Background()
+	dbtx := &DBTX{}
+
+	ran := 0
+	hook := func(ctx context.Context) *faulterr.FaultErr {
+		ran++
+		return nil
+	}
+
+	tx := &hookedTx{Tx: &fakeTx{}}
+	dbtx.AfterCommit(ctx, tx, hook)
+	if ran != 0 {
+		t.Fatal("AfterCommit: hook ran before the commit")
+	}
+	if err := dbtx.CommitTx(ctx, tx); err != nil {
+		t.Fatal(err.Message)
+	}
+	dbtx.RollbackTx(ctx, tx)
+	if ran != 1 {
+		t.Fatalf("CommitTx: hook ran %d times, want once", ran)
+	}
+
+	rolledBack := &hookedTx{Tx: &fakeTx{}}
+	dbtx.AfterCommit(ctx, rolledBack, hook)
+	dbtx.RollbackTx(ctx, rolledBack)
+	if ran != 1 {
+		t.Fatal("RollbackTx: hook of a rolled back transaction ran")
+	}
+
+	failing := &hookedTx{Tx: &fakeTx{}}
+	dbtx.AfterCommit(ctx, failing, func(ctx context.Context) *faulterr.FaultErr {
+		return faulterr.NewInternalServerError("send failed")
+	})
+	if err := dbtx.CommitTx(ctx, failing); err == nil {
+		t.Fatal("CommitTx: error of a hook is not returned")
+	}
+}
+
+// TestInvalidateAfterCommit replays a request reading a session while another revokes it: the
+// reader misses the cache before the revocation commits and caches the old valid session again
+func TestInvalidateAfterCommit(t *testing.T) {
+	ctx := context.Background()
+	dbtx := &DBTX{}
+	cs := cachestore.NewCacheStore(time.Minute)
+
+	session := dbmodels.AuthSession{ID: 1, UserID: 10, IsValid: true}
+	user := dbmodels.User{ID: 10}
+	cs.SetSession("token", session, user)
+
+	// the revoking request invalidates the session within its transaction
+	tx := &hookedTx{Tx: &fakeTx{}}
+	dbtx.InvalidateAfterCommit(ctx, tx, func() { cs.InvalidateSession(session.ID) })
+	if _, ok := cs.GetSession("token"); ok {
+		t.Fatal("InvalidateAfterCommit: session is still cached before the commit")
+	}
+
+	// the reading request still sees the committed valid session
+	cs.SetSession("token", session, user)
+
+	if err := dbtx.CommitTx(ctx, tx); err != nil {
+		t.Fatal(err.Message)
+	}
+	if _, ok := cs.GetSession("token"); ok {
+		t.Fatal("CommitTx: session read before the commit is still cached")
+	}
+}
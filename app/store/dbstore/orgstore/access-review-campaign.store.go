@@ -0,0 +1,174 @@
+package orgstore
+
+import (
+	"context"
+	"gogql/app/models/dbmodels"
+	"gogql/utils/faulterr"
+
+	"github.com/gofrs/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type AccessReviewCampaignStore struct {
+	conn *pgxpool.Pool
+}
+
+var _ AccessReviewCampaignStoreInterface = &AccessReviewCampaignStore{}
+
+type AccessReviewCampaignStoreInterface interface {
+	GetByID(ctx context.Context, id int64) (*dbmodels.AccessReviewCampaign, *faulterr.FaultErr)
+	List(ctx context.Context, orgUID *uuid.UUID, reviewerID *int64, status string) ([]dbmodels.AccessReviewCampaign, *faulterr.FaultErr)
+
+	Insert(ctx context.Context, tx pgx.Tx, arg *dbmodels.AccessReviewCampaign) (*dbmodels.AccessReviewCampaign, *faulterr.FaultErr)
+	Update(ctx context.Context, tx pgx.Tx, arg *dbmodels.AccessReviewCampaign) *faulterr.FaultErr
+}
+
+func NewAccessReviewCampaignStore(conn *pgxpool.Pool) *AccessReviewCampaignStore {
+	return &AccessReviewCampaignStore{conn}
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////
+//////////////////////////////////////////****Read****/////////////////////////////////////////
+///////////////////////////////////////////////////////////////////////////////////////////////
+
+// GetByID gets an access review campaign by id
+func (s *AccessReviewCampaignStore) GetByID(ctx context.Context, id int64) (*dbmodels.AccessReviewCampaign, *faulterr.FaultErr) {
+	errMsg := "error when trying to get access review campaign by id"
+
+	queryStmt := `SELECT * FROM access_review_campaigns WHERE id=$1`
+
+	row := s.conn.QueryRow(ctx, queryStmt, id)
+	obj, err := s.scanRow(row)
+	if err != nil {
+		return nil, faulterr.NewPostgresError(err, errMsg)
+	}
+	return obj, nil
+}
+
+// List gets the access review campaigns of an organization, of a reviewer or with a status,
+// filters left empty match every campaign
+func (s *AccessReviewCampaignStore) List(ctx context.Context, orgUID *uuid.UUID, reviewerID *int64, status string) ([]dbmodels.AccessReviewCampaign, *faulterr.FaultErr) {
+	errMsg := "error when trying to list access review campaigns"
+
+	queryStmt := `
+	SELECT * FROM access_review_campaigns
+	WHERE ($1::UUID IS NULL OR access_review_campaigns.org_uid = $1)
+	AND ($2::BIGINT IS NULL OR $2 = ANY(access_review_campaigns.reviewer_ids))
+	AND ($3 = '' OR access_review_campaigns.status = $3)
+	ORDER BY access_review_campaigns.created_at DESC
+	`
+
+	rows, err := s.conn.Query(ctx, queryStmt, orgUID, reviewerID, status)
+	if err != nil {
+		return nil, faulterr.NewPostgresError(err, errMsg)
+	}
+	defer rows.Close()
+
+	result, err := s.scanRows(rows)
+	if err != nil {
+		return nil, faulterr.NewPostgresError(err, errMsg)
+	}
+	return result, nil
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////
+//////////////////////////////////////////****Mutate****///////////////////////////////////////
+///////////////////////////////////////////////////////////////////////////////////////////////
+
+// Insert inserts an access review campaign in database
+func (s *AccessReviewCampaignStore) Insert(ctx context.Context, tx pgx.Tx, arg *dbmodels.AccessReviewCampaign) (*dbmodels.AccessReviewCampaign, *faulterr.FaultErr) {
+	errMsg := "error when trying to insert access review campaign"
+
+	queryStmt := `
+	INSERT INTO
+	access_review_campaigns(
+		org_uid,
+		department_id,
+		name,
+		status,
+		reviewer_ids,
+		started_by
+	)
+	VALUES ($1, $2, $3, $4, $5, $6)
+	RETURNING *
+	`
+
+	row := tx.QueryRow(ctx, queryStmt,
+		arg.OrgUID,
+		arg.DepartmentID,
+		arg.Name,
+		arg.Status,
+		arg.ReviewerIDs,
+		arg.StartedBy,
+	)
+
+	obj, err := s.scanRow(row)
+	if err != nil {
+		return nil, faulterr.NewPostgresError(err, errMsg)
+	}
+	return obj, nil
+}
+
+// Update updates the status of an access review campaign and who closed it
+func (s *AccessReviewCampaignStore) Update(ctx context.Context, tx pgx.Tx, arg *dbmodels.AccessReviewCampaign) *faulterr.FaultErr {
+	errMsg := "error when trying to update access review campaign"
+
+	queryStmt := `
+	UPDATE access_review_campaigns
+	SET
+		status=$1,
+		closed_by=$2,
+		closed_at=$3
+	WHERE id=$4
+	`
+
+	_, err := tx.Exec(ctx, queryStmt,
+		&arg.Status,
+		&arg.ClosedBy,
+		&arg.ClosedAt,
+		&arg.ID,
+	)
+	if err != nil {
+		return faulterr.NewPostgresError(err, errMsg)
+	}
+	return nil
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////
+//////////////////////////////////////////****Helpers****//////////////////////////////////////
+///////////////////////////////////////////////////////////////////////////////////////////////
+
+func (s *AccessReviewCampaignStore) scanRows(rows pgx.Rows) ([]dbmodels.AccessReviewCampaign, error) {
+	result := []dbmodels.AccessReviewCampaign{}
+
+	for rows.Next() {
+		obj, err := s.scanRow(rows)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, *obj)
+	}
+	return result, nil
+}
+
+func (s *AccessReviewCampaignStore) scanRow(row pgx.Row) (*dbmodels.AccessReviewCampaign, error) {
+	obj := dbmodels.AccessReviewCampaign{}
+
+	if err := row.Scan(
+		&obj.ID,
+		&obj.OrgUID,
+		&obj.DepartmentID,
+		&obj.Name,
+		&obj.Status,
+		&obj.ReviewerIDs,
+		&obj.StartedBy,
+		&obj.ClosedBy,
+		&obj.ClosedAt,
+		&obj.CreatedAt,
+		&obj.UpdatedAt,
+	); err != nil {
+		return nil, err
+	}
+	return &obj, nil
+}
@@ -0,0 +1,180 @@
+package orgstore
+
+import (
+	"context"
+	"gogql/app/models/dbmodels"
+	"gogql/utils/faulterr"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type AccessReviewItemStore struct {
+	conn *pgxpool.Pool
+}
+
+var _ AccessReviewItemStoreInterface = &AccessReviewItemStore{}
+
+type AccessReviewItemStoreInterface interface {
+	GetByID(ctx context.Context, id int64) (*dbmodels.AccessReviewItem, *faulterr.FaultErr)
+	ListByCampaignID(ctx context.Context, campaignID int64, reviewerID *int64) ([]dbmodels.AccessReviewItem, *faulterr.FaultErr)
+
+	Insert(ctx context.Context, tx pgx.Tx, arg *dbmodels.AccessReviewItem) (*dbmodels.AccessReviewItem, *faulterr.FaultErr)
+	Update(ctx context.Context, tx pgx.Tx, arg *dbmodels.AccessReviewItem) *faulterr.FaultErr
+}
+
+func NewAccessReviewItemStore(conn *pgxpool.Pool) *AccessReviewItemStore {
+	return &AccessReviewItemStore{conn}
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////
+//////////////////////////////////////////****Read****/////////////////////////////////////////
+///////////////////////////////////////////////////////////////////////////////////////////////
+
+// GetByID gets an access review item by id
+func (s *AccessReviewItemStore) GetByID(ctx context.Context, id int64) (*dbmodels.AccessReviewItem, *faulterr.FaultErr) {
+	errMsg := "error when trying to get access review item by id"
+
+	queryStmt := `SELECT * FROM access_review_items WHERE id=$1`
+
+	row := s.conn.QueryRow(ctx, queryStmt, id)
+	obj, err := s.scanRow(row)
+	if err != nil {
+		return nil, faulterr.NewPostgresError(err, errMsg)
+	}
+	return obj, nil
+}
+
+// ListByCampaignID gets the items of a campaign, the ones of every reviewer when the reviewer is left empty
+func (s *AccessReviewItemStore) ListByCampaignID(ctx context.Context, campaignID int64, reviewerID *int64) ([]dbmodels.AccessReviewItem, *faulterr.FaultErr) {
+	errMsg := "error when trying to get access review items by campaign id"
+
+	queryStmt := `
+	SELECT * FROM access_review_items
+	WHERE access_review_items.campaign_id = $1
+	AND ($2::BIGINT IS NULL OR access_review_items.reviewer_id = $2)
+	ORDER BY access_review_items.id
+	`
+
+	rows, err := s.conn.Query(ctx, queryStmt, campaignID, reviewerID)
+	if err != nil {
+		return nil, faulterr.NewPostgresError(err, errMsg)
+	}
+	defer rows.Close()
+
+	result, err := s.scanRows(rows)
+	if err != nil {
+		return nil, faulterr.NewPostgresError(err, errMsg)
+	}
+	return result, nil
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////
+//////////////////////////////////////////****Mutate****///////////////////////////////////////
+///////////////////////////////////////////////////////////////////////////////////////////////
+
+// Insert inserts an access review item in database
+func (s *AccessReviewItemStore) Insert(ctx context.Context, tx pgx.Tx, arg *dbmodels.AccessReviewItem) (*dbmodels.AccessReviewItem, *faulterr.FaultErr) {
+	errMsg := "error when trying to insert access review item"
+
+	queryStmt := `
+	INSERT INTO
+	access_review_items(
+		campaign_id,
+		user_id,
+		role_id,
+		is_primary,
+		reviewer_id,
+		decision
+	)
+	VALUES ($1, $2, $3, $4, $5, $6)
+	RETURNING *
+	`
+
+	row := tx.QueryRow(ctx, queryStmt,
+		arg.CampaignID,
+		arg.UserID,
+		arg.RoleID,
+		arg.IsPrimary,
+		arg.ReviewerID,
+		arg.Decision,
+	)
+
+	obj, err := s.scanRow(row)
+	if err != nil {
+		return nil, faulterr.NewPostgresError(err, errMsg)
+	}
+	return obj, nil
+}
+
+// Update updates the decision of an access review item and when it was applied
+func (s *AccessReviewItemStore) Update(ctx context.Context, tx pgx.Tx, arg *dbmodels.AccessReviewItem) *faulterr.FaultErr {
+	errMsg := "error when trying to update access review item"
+
+	queryStmt := `
+	UPDATE access_review_items
+	SET
+		decision=$1,
+		revoke_action=$2,
+		reassign_role_id=$3,
+		note=$4,
+		decided_at=$5,
+		applied_at=$6
+	WHERE id=$7
+	`
+
+	_, err := tx.Exec(ctx, queryStmt,
+		&arg.Decision,
+		&arg.RevokeAction,
+		&arg.ReassignRoleID,
+		&arg.Note,
+		&arg.DecidedAt,
+		&arg.AppliedAt,
+		&arg.ID,
+	)
+	if err != nil {
+		return faulterr.NewPostgresError(err, errMsg)
+	}
+	return nil
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////
+//////////////////////////////////////////****Helpers****//////////////////////////////////////
+///////////////////////////////////////////////////////////////////////////////////////////////
+
+func (s *AccessReviewItemStore) scanRows(rows pgx.Rows) ([]dbmodels.AccessReviewItem, error) {
+	result := []dbmodels.AccessReviewItem{}
+
+	for rows.Next() {
+		obj, err := s.scanRow(rows)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, *obj)
+	}
+	return result, nil
+}
+
+func (s *AccessReviewItemStore) scanRow(row pgx.Row) (*dbmodels.AccessReviewItem, error) {
+	obj := dbmodels.AccessReviewItem{}
+
+	if err := row.Scan(
+		&obj.ID,
+		&obj.CampaignID,
+		&obj.UserID,
+		&obj.RoleID,
+		&obj.IsPrimary,
+		&obj.ReviewerID,
+		&obj.Decision,
+		&obj.RevokeAction,
+		&obj.ReassignRoleID,
+		&obj.Note,
+		&obj.DecidedAt,
+		&obj.AppliedAt,
+		&obj.CreatedAt,
+		&obj.UpdatedAt,
+	); err != nil {
+		return nil, err
+	}
+	return &obj, nil
+}
@@ -0,0 +1,252 @@
+package orgstore
+
+import (
+	"context"
+	"gogql/app/models/dbmodels"
+	"gogql/utils/faulterr"
+
+	"github.com/gofrs/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type APIKeyStore struct {
+	conn *pgxpool.Pool
+}
+
+var _ APIKeyStoreInterface = &APIKeyStore{}
+
+type APIKeyStoreInterface interface {
+	ListByUserID(ctx context.Context, userID int64) ([]dbmodels.APIKey, *faulterr.FaultErr)
+	ListByOrgUID(ctx context.Context, orgUID uuid.UUID) ([]dbmodels.APIKey, *faulterr.FaultErr)
+	GetByID(ctx context.Context, id int64) (*dbmodels.APIKey, *faulterr.FaultErr)
+	GetByKeyHash(ctx context.Context, keyHash string) (*dbmodels.APIKey, *faulterr.FaultErr)
+
+	Insert(ctx context.Context, tx pgx.Tx, arg *dbmodels.APIKey) (*dbmodels.APIKey, *faulterr.FaultErr)
+	Revoke(ctx context.Context, tx pgx.Tx, id int64) *faulterr.FaultErr
+	UpdateLastUsed(ctx context.Context, id int64, ipAddress string) *faulterr.FaultErr
+	RenamePermission(ctx context.Context, tx pgx.Tx, from string, to string) (int64, *faulterr.FaultErr)
+}
+
+func NewAPIKeyStore(conn *pgxpool.Pool) *APIKeyStore {
+	return &APIKeyStore{conn}
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////
+//////////////////////////////////////////****Read****/////////////////////////////////////////
+///////////////////////////////////////////////////////////////////////////////////////////////
+
+// ListByUserID lists the personal access tokens of a user
+func (s *APIKeyStore) ListByUserID(ctx context.Context, userID int64) ([]dbmodels.APIKey, *faulterr.FaultErr) {
+	errMsg := "error when trying to get api keys by user id"
+
+	queryStmt := `SELECT * FROM api_keys WHERE user_id=$1 ORDER BY created_at DESC`
+
+	rows, err := s.conn.Query(ctx, queryStmt, userID)
+	if err != nil {
+		return nil, faulterr.NewPostgresError(err, errMsg)
+	}
+	defer rows.Close()
+
+	result, err := s.scanRows(rows)
+	if err != nil {
+		return nil, faulterr.NewPostgresError(err, errMsg)
+	}
+	return result, nil
+}
+
+// ListByOrgUID lists the keys of an organization, personal access tokens are not included
+func (s *APIKeyStore) ListByOrgUID(ctx context.Context, orgUID uuid.UUID) ([]dbmodels.APIKey, *faulterr.FaultErr) {
+	errMsg := "error when trying to get api keys by org uid"
+
+	queryStmt := `SELECT * FROM api_keys WHERE org_uid=$1 AND user_id IS NULL ORDER BY created_at DESC`
+
+	rows, err := s.conn.Query(ctx, queryStmt, orgUID)
+	if err != nil {
+		return nil, faulterr.NewPostgresError(err, errMsg)
+	}
+	defer rows.Close()
+
+	result, err := s.scanRows(rows)
+	if err != nil {
+		return nil, faulterr.NewPostgresError(err, errMsg)
+	}
+	return result, nil
+}
+
+// GetByID gets an api key by id
+func (s *APIKeyStore) GetByID(ctx context.Context, id int64) (*dbmodels.APIKey, *faulterr.FaultErr) {
+	errMsg := "error when trying to get api key by id"
+
+	queryStmt := `SELECT * FROM api_keys WHERE id=$1`
+
+	row := s.conn.QueryRow(ctx, queryStmt, id)
+	obj, err := s.scanRow(row)
+	if err != nil {
+		return nil, faulterr.NewPostgresError(err, errMsg)
+	}
+	return obj, nil
+}
+
+// GetByKeyHash gets an api key by the hash of the key
+func (s *APIKeyStore) GetByKeyHash(ctx context.Context, keyHash string) (*dbmodels.APIKey, *faulterr.FaultErr) {
+	errMsg := "error when trying to get api key"
+
+	queryStmt := `SELECT * FROM api_keys WHERE key_hash=$1`
+
+	row := s.conn.QueryRow(ctx, queryStmt, keyHash)
+	obj, err := s.scanRow(row)
+	if err != nil {
+		return nil, faulterr.NewPostgresError(err, errMsg)
+	}
+	return obj, nil
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////
+//////////////////////////////////////////****Mutate****///////////////////////////////////////
+///////////////////////////////////////////////////////////////////////////////////////////////
+
+// Insert inserts an api key
+func (s *APIKeyStore) Insert(ctx context.Context, tx pgx.Tx, arg *dbmodels.APIKey) (*dbmodels.APIKey, *faulterr.FaultErr) {
+	errMsg := "error when trying to insert api key"
+
+	queryStmt := `
+	INSERT INTO
+	api_keys(
+		org_uid,
+		user_id,
+		created_by,
+		name,
+		prefix,
+		key_hash,
+		permissions,
+		allowed_ips,
+		expires_at
+	)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+	RETURNING *
+	`
+
+	row := tx.QueryRow(ctx, queryStmt,
+		arg.OrgUID,
+		arg.UserID,
+		arg.CreatedBy,
+		arg.Name,
+		arg.Prefix,
+		arg.KeyHash,
+		arg.Permissions,
+		arg.AllowedIPs,
+		arg.ExpiresAt,
+	)
+
+	obj, err := s.scanRow(row)
+	if err != nil {
+		return nil, faulterr.NewPostgresError(err, errMsg)
+	}
+	return obj, nil
+}
+
+// Revoke revokes an api key
+func (s *APIKeyStore) Revoke(ctx context.Context, tx pgx.Tx, id int64) *faulterr.FaultErr {
+	errMsg := "error when trying to revoke api key"
+
+	queryStmt := `UPDATE api_keys SET is_revoked=TRUE WHERE id=$1`
+
+	_, err := tx.Exec(ctx, queryStmt, id)
+	if err != nil {
+		return faulterr.NewPostgresError(err, errMsg)
+	}
+	return nil
+}
+
+// UpdateLastUsed marks an api key as used now, it runs outside of the request transaction
+// so requests that fail later still count as use
+func (s *APIKeyStore) UpdateLastUsed(ctx context.Context, id int64, ipAddress string) *faulterr.FaultErr {
+	errMsg := "error when trying to update api key last used"
+
+	queryStmt := `UPDATE api_keys SET last_used_at=NOW(), last_used_ip=$1 WHERE id=$2`
+
+	_, err := s.conn.Exec(ctx, queryStmt, ipAddress, id)
+	if err != nil {
+		return faulterr.NewPostgresError(err, errMsg)
+	}
+	return nil
+}
+
+// RenamePermission replaces a permission in every api key holding it and returns how many keys changed
+func (s *APIKeyStore) RenamePermission(ctx context.Context, tx pgx.Tx, from string, to string) (int64, *faulterr.FaultErr) {
+	errMsg := "error when trying to rename api key permission"
+
+	queryStmt := `
+	UPDATE api_keys
+	SET
+		permissions=ARRAY(
+			SELECT p FROM unnest(array_replace(permissions, $1::varchar, $2::varchar)) WITH ORDINALITY AS t(p, i)
+			GROUP BY p ORDER BY MIN(i)
+		)
+	WHERE $1 = ANY(permissions)
+	`
+
+	tag, err := tx.Exec(ctx, queryStmt, from, to)
+	if err != nil {
+		return 0, faulterr.NewPostgresError(err, errMsg)
+	}
+	return tag.RowsAffected(), nil
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////
+//////////////////////////////////////////****Helpers****//////////////////////////////////////
+///////////////////////////////////////////////////////////////////////////////////////////////
+
+func (s *APIKeyStore) scanRows(rows pgx.Rows) ([]dbmodels.APIKey, error) {
+	result := []dbmodels.APIKey{}
+	obj := dbmodels.APIKey{}
+
+	for rows.Next() {
+		if err := rows.Scan(
+			&obj.ID,
+			&obj.OrgUID,
+			&obj.UserID,
+			&obj.CreatedBy,
+			&obj.Name,
+			&obj.Prefix,
+			&obj.KeyHash,
+			&obj.Permissions,
+			&obj.AllowedIPs,
+			&obj.ExpiresAt,
+			&obj.LastUsedAt,
+			&obj.LastUsedIP,
+			&obj.IsRevoked,
+			&obj.CreatedAt,
+			&obj.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		result = append(result, obj)
+	}
+	return result, nil
+}
+
+func (s *APIKeyStore) scanRow(row pgx.Row) (*dbmodels.APIKey, error) {
+	obj := &dbmodels.APIKey{}
+	if err := row.Scan(
+		&obj.ID,
+		&obj.OrgUID,
+		&obj.UserID,
+		&obj.CreatedBy,
+		&obj.Name,
+		&obj.Prefix,
+		&obj.KeyHash,
+		&obj.Permissions,
+		&obj.AllowedIPs,
+		&obj.ExpiresAt,
+		&obj.LastUsedAt,
+		&obj.LastUsedIP,
+		&obj.IsRevoked,
+		&obj.CreatedAt,
+		&obj.UpdatedAt,
+	); err != nil {
+		return nil, err
+	}
+	return obj, nil
+}
@@ -0,0 +1,101 @@
+package orgstore
+
+import (
+	"context"
+	"gogql/app/models/dbmodels"
+	"gogql/utils/faulterr"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type AuthAttemptStore struct {
+	conn *pgxpool.Pool
+}
+
+var _ AuthAttemptStoreInterface = &AuthAttemptStore{}
+
+type AuthAttemptStoreInterface interface {
+	CountFailedSince(ctx context.Context, ipAddress string, action string, since time.Time) (int, *faulterr.FaultErr)
+	Insert(ctx context.Context, tx pgx.Tx, arg dbmodels.AuthAttempt) (*dbmodels.AuthAttempt, *faulterr.FaultErr)
+}
+
+func NewAuthAttemptStore(conn *pgxpool.Pool) *AuthAttemptStore {
+	return &AuthAttemptStore{conn}
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////
+//////////////////////////////////////////****Read****/////////////////////////////////////////
+///////////////////////////////////////////////////////////////////////////////////////////////
+
+// CountFailedSince counts failed attempts of an action from an ip address after the given time
+func (s *AuthAttemptStore) CountFailedSince(ctx context.Context, ipAddress string, action string, since time.Time) (int, *faulterr.FaultErr) {
+	errMsg := "error when trying to count auth attempts"
+
+	queryStmt := `
+	SELECT COUNT(*) FROM auth_attempts
+	WHERE ip_address = $1
+	AND action = $2
+	AND is_success = FALSE
+	AND created_at > $3
+	`
+
+	var count int
+	row := s.conn.QueryRow(ctx, queryStmt, ipAddress, action, since)
+	if err := row.Scan(&count); err != nil {
+		return 0, faulterr.NewPostgresError(err, errMsg)
+	}
+	return count, nil
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////
+//////////////////////////////////////////****Mutate****///////////////////////////////////////
+///////////////////////////////////////////////////////////////////////////////////////////////
+
+// Insert inserts an auth attempt in database
+func (s *AuthAttemptStore) Insert(ctx context.Context, tx pgx.Tx, arg dbmodels.AuthAttempt) (*dbmodels.AuthAttempt, *faulterr.FaultErr) {
+	errMsg := "error when trying to insert auth attempt"
+
+	queryStmt := `
+	INSERT INTO
+	auth_attempts(
+		user_id,
+		ip_address,
+		action,
+		is_success
+	)
+	VALUES ($1, $2, $3, $4)
+	RETURNING *
+	`
+
+	row := tx.QueryRow(ctx, queryStmt,
+		arg.UserID,
+		arg.IPAddress,
+		arg.Action,
+		arg.IsSuccess,
+	)
+
+	obj, err := s.scanRow(row)
+	if err != nil {
+		return nil, faulterr.NewPostgresError(err, errMsg)
+	}
+	return obj, nil
+}
+
+func (s *AuthAttemptStore) scanRow(row pgx.Row) (*dbmodels.AuthAttempt, error) {
+	obj := dbmodels.AuthAttempt{}
+
+	if err := row.Scan(
+		&obj.ID,
+		&obj.UserID,
+		&obj.IPAddress,
+		&obj.Action,
+		&obj.IsSuccess,
+		&obj.CreatedAt,
+		&obj.UpdatedAt,
+	); err != nil {
+		return nil, err
+	}
+	return &obj, nil
+}
@@ -5,7 +5,6 @@ import (
 	"gogql/app/models/dbmodels"
 	"gogql/utils/faulterr"
 
-	"github.com/gofrs/uuid"
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
@@ -17,9 +16,13 @@ type AuthSessionStore struct {
 var _ AuthSessionStoreInterface = &AuthSessionStore{}
 
 type AuthSessionStoreInterface interface {
-	GetByToken(ctx context.Context, token uuid.UUID) (*dbmodels.AuthSession, *faulterr.FaultErr)
+	GetByTokenHash(ctx context.Context, tokenHash string) (*dbmodels.AuthSession, *faulterr.FaultErr)
+	GetByID(ctx context.Context, id int64) (*dbmodels.AuthSession, *faulterr.FaultErr)
+	ListActiveByUserID(ctx context.Context, userID int64) ([]dbmodels.AuthSession, *faulterr.FaultErr)
 	Insert(ctx context.Context, tx pgx.Tx, arg *dbmodels.AuthSession) (*dbmodels.AuthSession, *faulterr.FaultErr)
 	Update(ctx context.Context, tx pgx.Tx, arg *dbmodels.AuthSession) *faulterr.FaultErr
+	UpdateLastSeen(ctx context.Context, id int64) *faulterr.FaultErr
+	InvalidateByUserID(ctx context.Context, tx pgx.Tx, userID int64) (int64, *faulterr.FaultErr)
 }
 
 func NewAuthSessionStore(conn *pgxpool.Pool) *AuthSessionStore {
@@ -30,16 +33,16 @@ func NewAuthSessionStore(conn *pgxpool.Pool) *AuthSessionStore {
 //////////////////////////////////////////****Read****/////////////////////////////////////////
 ///////////////////////////////////////////////////////////////////////////////////////////////
 
-// GetByToken gets auth session by code from database
-func (s *AuthSessionStore) GetByToken(ctx context.Context, token uuid.UUID) (*dbmodels.AuthSession, *faulterr.FaultErr) {
+// GetByTokenHash gets auth session by the hash of its token from database
+func (s *AuthSessionStore) GetByTokenHash(ctx context.Context, tokenHash string) (*dbmodels.AuthSession, *faulterr.FaultErr) {
 	errMsg := "error when trying to get auth session by token"
 
 	queryStmt := `
 	SELECT * FROM auth_sessions
-	WHERE auth_sessions.token = $1
+	WHERE auth_sessions.token_hash = $1
 	`
 
-	row := s.conn.QueryRow(ctx, queryStmt, token)
+	row := s.conn.QueryRow(ctx, queryStmt, tokenHash)
 	obj, err := s.scanRow(row)
 	if err != nil {
 		return nil, faulterr.NewPostgresError(err, errMsg)
@@ -47,6 +50,45 @@ func (s *AuthSessionStore) GetByToken(ctx context.Context, token uuid.UUID) (*db
 	return obj, nil
 }
 
+// GetByID gets auth session by id from database
+func (s *AuthSessionStore) GetByID(ctx context.Context, id int64) (*dbmodels.AuthSession, *faulterr.FaultErr) {
+	errMsg := "error when trying to get auth session by id"
+
+	queryStmt := `SELECT * FROM auth_sessions WHERE id=$1`
+
+	row := s.conn.QueryRow(ctx, queryStmt, id)
+	obj, err := s.scanRow(row)
+	if err != nil {
+		return nil, faulterr.NewPostgresError(err, errMsg)
+	}
+	return obj, nil
+}
+
+// ListActiveByUserID gets the valid and unexpired auth sessions of a user, most recently used first
+func (s *AuthSessionStore) ListActiveByUserID(ctx context.Context, userID int64) ([]dbmodels.AuthSession, *faulterr.FaultErr) {
+	errMsg := "error when trying to get auth sessions by user id"
+
+	queryStmt := `
+	SELECT * FROM auth_sessions
+	WHERE auth_sessions.user_id = $1
+	AND auth_sessions.is_valid = TRUE
+	AND auth_sessions.absolute_expires_at > NOW()
+	ORDER BY auth_sessions.last_seen_at DESC
+	`
+
+	rows, err := s.conn.Query(ctx, queryStmt, userID)
+	if err != nil {
+		return nil, faulterr.NewPostgresError(err, errMsg)
+	}
+	defer rows.Close()
+
+	result, err := s.scanRows(rows)
+	if err != nil {
+		return nil, faulterr.NewPostgresError(err, errMsg)
+	}
+	return result, nil
+}
+
 ///////////////////////////////////////////////////////////////////////////////////////////////
 //////////////////////////////////////////****Mutate****///////////////////////////////////////
 ///////////////////////////////////////////////////////////////////////////////////////////////
@@ -59,19 +101,33 @@ func (s *AuthSessionStore) Insert(ctx context.Context, tx pgx.Tx, arg *dbmodels.
 	INSERT INTO
 	auth_sessions(
 		user_id,
-		token,
+		token_hash,
 		is_valid,
-		expires_at
+		expires_at,
+		ip_address,
+		user_agent,
+		device_label,
+		last_seen_at,
+		absolute_expires_at,
+		assurance_level,
+		impersonator_id
 	)
-	VALUES ($1, $2, $3, $4)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
 	RETURNING *
 	`
 
 	row := tx.QueryRow(ctx, queryStmt,
 		arg.UserID,
-		arg.Token,
+		arg.TokenHash,
 		arg.IsValid,
 		arg.ExpiresAt,
+		arg.IPAddress,
+		arg.UserAgent,
+		arg.DeviceLabel,
+		arg.LastSeenAt,
+		arg.AbsoluteExpiresAt,
+		arg.AssuranceLevel,
+		arg.ImpersonatorID,
 	)
 
 	obj, err := s.scanRow(row)
@@ -82,19 +138,27 @@ func (s *AuthSessionStore) Insert(ctx context.Context, tx pgx.Tx, arg *dbmodels.
 	return obj, nil
 }
 
-// Update User
+// Update updates validity, token and expiry of an auth session
 func (s *AuthSessionStore) Update(ctx context.Context, tx pgx.Tx, arg *dbmodels.AuthSession) *faulterr.FaultErr {
-	errMsg := "error when trying to update user"
+	errMsg := "error when trying to update auth session"
 
 	queryStmt := `
 	UPDATE auth_sessions
 	SET
-		is_valid=$1
-	WHERE id=$2
+		is_valid=$1,
+		token_hash=$2,
+		expires_at=$3,
+		last_seen_at=$4,
+		assurance_level=$5
+	WHERE id=$6
 	`
 
 	_, err := tx.Exec(ctx, queryStmt,
 		&arg.IsValid,
+		&arg.TokenHash,
+		&arg.ExpiresAt,
+		&arg.LastSeenAt,
+		&arg.AssuranceLevel,
 		&arg.ID,
 	)
 	if err != nil {
@@ -103,17 +167,88 @@ func (s *AuthSessionStore) Update(ctx context.Context, tx pgx.Tx, arg *dbmodels.
 	return nil
 }
 
+// UpdateLastSeen marks an auth session as used now, it runs outside of the request transaction
+// so reads that fail later still count as activity
+func (s *AuthSessionStore) UpdateLastSeen(ctx context.Context, id int64) *faulterr.FaultErr {
+	errMsg := "error when trying to update auth session last seen"
+
+	queryStmt := `UPDATE auth_sessions SET last_seen_at=NOW() WHERE id=$1`
+
+	_, err := s.conn.Exec(ctx, queryStmt, id)
+	if err != nil {
+		return faulterr.NewPostgresError(err, errMsg)
+	}
+	return nil
+}
+
+// InvalidateByID invalidates an auth session and with it the whole family of its refresh tokens,
+// only the validity is written so concurrent rotations of the session are kept
+func (s *AuthSessionStore) InvalidateByID(ctx context.Context, tx pgx.Tx, id int64) *faulterr.FaultErr {
+	errMsg := "error when trying to invalidate auth session"
+
+	queryStmt := `UPDATE auth_sessions SET is_valid=FALSE WHERE id=$1`
+
+	_, err := tx.Exec(ctx, queryStmt, id)
+	if err != nil {
+		return faulterr.NewPostgresError(err, errMsg)
+	}
+	return nil
+}
+
+// InvalidateByUserID invalidates all valid auth sessions of a user and returns how many were invalidated
+func (s *AuthSessionStore) InvalidateByUserID(ctx context.Context, tx pgx.Tx, userID int64) (int64, *faulterr.FaultErr) {
+	errMsg := "error when trying to invalidate auth sessions by user id"
+
+	queryStmt := `
+	UPDATE auth_sessions
+	SET
+		is_valid=FALSE
+	WHERE user_id=$1
+	AND is_valid=TRUE
+	`
+
+	tag, err := tx.Exec(ctx, queryStmt, userID)
+	if err != nil {
+		return 0, faulterr.NewPostgresError(err, errMsg)
+	}
+	return tag.RowsAffected(), nil
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////
+//////////////////////////////////////////****Helpers****//////////////////////////////////////
+///////////////////////////////////////////////////////////////////////////////////////////////
+
+func (s *AuthSessionStore) scanRows(rows pgx.Rows) ([]dbmodels.AuthSession, error) {
+	result := []dbmodels.AuthSession{}
+
+	for rows.Next() {
+		obj, err := s.scanRow(rows)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, *obj)
+	}
+	return result, nil
+}
+
 func (s *AuthSessionStore) scanRow(row pgx.Row) (*dbmodels.AuthSession, error) {
 	obj := dbmodels.AuthSession{}
 
 	if err := row.Scan(
 		&obj.ID,
 		&obj.UserID,
-		&obj.Token,
+		&obj.TokenHash,
 		&obj.IsValid,
 		&obj.ExpiresAt,
 		&obj.CreatedAt,
 		&obj.UpdatedAt,
+		&obj.IPAddress,
+		&obj.UserAgent,
+		&obj.DeviceLabel,
+		&obj.LastSeenAt,
+		&obj.AbsoluteExpiresAt,
+		&obj.AssuranceLevel,
+		&obj.ImpersonatorID,
 	); err != nil {
 		return nil, err
 	}
@@ -0,0 +1,230 @@
+package orgstore
+
+import (
+	"context"
+	"gogql/app/models/dbmodels"
+	"gogql/utils/faulterr"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type ContactChangeStore struct {
+	conn *pgxpool.Pool
+}
+
+var _ ContactChangeStoreInterface = &ContactChangeStore{}
+
+type ContactChangeStoreInterface interface {
+	ListOpenByUserID(ctx context.Context, userID int64) ([]dbmodels.ContactChange, *faulterr.FaultErr)
+	GetByID(ctx context.Context, id int64) (*dbmodels.ContactChange, *faulterr.FaultErr)
+	GetByCancelTokenHash(ctx context.Context, tokenHash string) (*dbmodels.ContactChange, *faulterr.FaultErr)
+
+	Insert(ctx context.Context, tx pgx.Tx, arg *dbmodels.ContactChange) (*dbmodels.ContactChange, *faulterr.FaultErr)
+	Update(ctx context.Context, tx pgx.Tx, arg *dbmodels.ContactChange) *faulterr.FaultErr
+	CancelOpenByUserID(ctx context.Context, tx pgx.Tx, userID int64, channel string) *faulterr.FaultErr
+	IncrementFailedAttempts(ctx context.Context, id int64) *faulterr.FaultErr
+}
+
+func NewContactChangeStore(conn *pgxpool.Pool) *ContactChangeStore {
+	return &ContactChangeStore{conn}
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////
+//////////////////////////////////////////****Read****/////////////////////////////////////////
+///////////////////////////////////////////////////////////////////////////////////////////////
+
+// ListOpenByUserID gets the open and unexpired contact changes of a user
+func (s *ContactChangeStore) ListOpenByUserID(ctx context.Context, userID int64) ([]dbmodels.ContactChange, *faulterr.FaultErr) {
+	errMsg := "error when trying to get contact changes by user id"
+
+	queryStmt := `
+	SELECT * FROM contact_changes
+	WHERE contact_changes.user_id = $1
+	AND contact_changes.status = 'OPEN'
+	AND contact_changes.expires_at > NOW()
+	ORDER BY contact_changes.created_at DESC
+	`
+
+	rows, err := s.conn.Query(ctx, queryStmt, userID)
+	if err != nil {
+		return nil, faulterr.NewPostgresError(err, errMsg)
+	}
+	defer rows.Close()
+
+	result, err := s.scanRows(rows)
+	if err != nil {
+		return nil, faulterr.NewPostgresError(err, errMsg)
+	}
+	return result, nil
+}
+
+// GetByID gets a contact change by id
+func (s *ContactChangeStore) GetByID(ctx context.Context, id int64) (*dbmodels.ContactChange, *faulterr.FaultErr) {
+	errMsg := "error when trying to get contact change by id"
+
+	queryStmt := `SELECT * FROM contact_changes WHERE id=$1`
+
+	row := s.conn.QueryRow(ctx, queryStmt, id)
+	obj, err := s.scanRow(row)
+	if err != nil {
+		return nil, faulterr.NewPostgresError(err, errMsg)
+	}
+	return obj, nil
+}
+
+// GetByCancelTokenHash gets a contact change by the hash of its cancel token
+func (s *ContactChangeStore) GetByCancelTokenHash(ctx context.Context, tokenHash string) (*dbmodels.ContactChange, *faulterr.FaultErr) {
+	errMsg := "error when trying to get contact change by cancel token"
+
+	queryStmt := `SELECT * FROM contact_changes WHERE cancel_token_hash=$1`
+
+	row := s.conn.QueryRow(ctx, queryStmt, tokenHash)
+	obj, err := s.scanRow(row)
+	if err != nil {
+		return nil, faulterr.NewPostgresError(err, errMsg)
+	}
+	return obj, nil
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////
+//////////////////////////////////////////****Mutate****///////////////////////////////////////
+///////////////////////////////////////////////////////////////////////////////////////////////
+
+// Insert inserts a contact change in database
+func (s *ContactChangeStore) Insert(ctx context.Context, tx pgx.Tx, arg *dbmodels.ContactChange) (*dbmodels.ContactChange, *faulterr.FaultErr) {
+	errMsg := "error when trying to insert contact change"
+
+	queryStmt := `
+	INSERT INTO
+	contact_changes(
+		user_id,
+		channel,
+		old_value,
+		new_value,
+		code_hash,
+		cancel_token_hash,
+		status,
+		requested_by,
+		expires_at
+	)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+	RETURNING *
+	`
+
+	row := tx.QueryRow(ctx, queryStmt,
+		arg.UserID,
+		arg.Channel,
+		arg.OldValue,
+		arg.NewValue,
+		arg.CodeHash,
+		arg.CancelTokenHash,
+		arg.Status,
+		arg.RequestedBy,
+		arg.ExpiresAt,
+	)
+
+	obj, err := s.scanRow(row)
+	if err != nil {
+		return nil, faulterr.NewPostgresError(err, errMsg)
+	}
+	return obj, nil
+}
+
+// Update updates the status of a contact change
+func (s *ContactChangeStore) Update(ctx context.Context, tx pgx.Tx, arg *dbmodels.ContactChange) *faulterr.FaultErr {
+	errMsg := "error when trying to update contact change"
+
+	queryStmt := `
+	UPDATE contact_changes
+	SET
+		status=$1,
+		confirmed_at=$2
+	WHERE id=$3
+	`
+
+	_, err := tx.Exec(ctx, queryStmt,
+		&arg.Status,
+		&arg.ConfirmedAt,
+		&arg.ID,
+	)
+	if err != nil {
+		return faulterr.NewPostgresError(err, errMsg)
+	}
+	return nil
+}
+
+// CancelOpenByUserID cancels the open contact changes of a user on a channel
+func (s *ContactChangeStore) CancelOpenByUserID(ctx context.Context, tx pgx.Tx, userID int64, channel string) *faulterr.FaultErr {
+	errMsg := "error when trying to cancel contact changes by user id"
+
+	queryStmt := `
+	UPDATE contact_changes
+	SET
+		status='CANCELLED'
+	WHERE user_id=$1
+	AND channel=$2
+	AND status='OPEN'
+	`
+
+	_, err := tx.Exec(ctx, queryStmt, userID, channel)
+	if err != nil {
+		return faulterr.NewPostgresError(err, errMsg)
+	}
+	return nil
+}
+
+// IncrementFailedAttempts counts a wrong code, it runs outside of the request transaction
+// so the attempt is counted even though the request fails
+func (s *ContactChangeStore) IncrementFailedAttempts(ctx context.Context, id int64) *faulterr.FaultErr {
+	errMsg := "error when trying to update contact change failed attempts"
+
+	queryStmt := `UPDATE contact_changes SET failed_attempts=failed_attempts + 1 WHERE id=$1`
+
+	_, err := s.conn.Exec(ctx, queryStmt, id)
+	if err != nil {
+		return faulterr.NewPostgresError(err, errMsg)
+	}
+	return nil
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////
+//////////////////////////////////////////****Helpers****//////////////////////////////////////
+///////////////////////////////////////////////////////////////////////////////////////////////
+
+func (s *ContactChangeStore) scanRows(rows pgx.Rows) ([]dbmodels.ContactChange, error) {
+	result := []dbmodels.ContactChange{}
+
+	for rows.Next() {
+		obj, err := s.scanRow(rows)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, *obj)
+	}
+	return result, nil
+}
+
+func (s *ContactChangeStore) scanRow(row pgx.Row) (*dbmodels.ContactChange, error) {
+	obj := dbmodels.ContactChange{}
+
+	if err := row.Scan(
+		&obj.ID,
+		&obj.UserID,
+		&obj.Channel,
+		&obj.OldValue,
+		&obj.NewValue,
+		&obj.CodeHash,
+		&obj.CancelTokenHash,
+		&obj.Status,
+		&obj.FailedAttempts,
+		&obj.RequestedBy,
+		&obj.ExpiresAt,
+		&obj.ConfirmedAt,
+		&obj.CreatedAt,
+		&obj.UpdatedAt,
+	); err != nil {
+		return nil, err
+	}
+	return &obj, nil
+}
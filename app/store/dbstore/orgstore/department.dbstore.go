@@ -25,7 +25,7 @@ type DepartmentStoreInterface interface {
 	GetCount(ctx context.Context, orgUID uuid.UUID) (int64, *faulterr.FaultErr)
 	GetManyByIDs(ctx context.Context, ids []int64) ([]*dbmodels.Department, *faulterr.FaultErr)
 
-	List(ctx context.Context, filter models.SearchFilter, orgUID *uuid.UUID) ([]dbmodels.Department, int, *faulterr.FaultErr)
+	List(ctx context.Context, filter models.SearchFilter, orgUID *uuid.UUID, scope models.PermissionScope) ([]dbmodels.Department, int, *faulterr.FaultErr)
 	GetByID(ctx context.Context, id int64) (*dbmodels.Department, *faulterr.FaultErr)
 	GetByCode(ctx context.Context, code string) (*dbmodels.Department, *faulterr.FaultErr)
 
@@ -98,8 +98,9 @@ func (s *DepartmentStore) GetManyByIDs(ctx context.Context, ids []int64) ([]*dbm
 	return result, nil
 }
 
-// List retrives all departments from database
-func (s *DepartmentStore) List(ctx context.Context, filter models.SearchFilter, orgUID *uuid.UUID) ([]dbmodels.Department, int, *faulterr.FaultErr) {
+// List retrives all departments from database, a scope only lists its departments and
+// the departments of the roles of its user
+func (s *DepartmentStore) List(ctx context.Context, filter models.SearchFilter, orgUID *uuid.UUID, scope models.PermissionScope) ([]dbmodels.Department, int, *faulterr.FaultErr) {
 	errMsg := "error when trying to get departments"
 
 	// define query
@@ -108,12 +109,20 @@ func (s *DepartmentStore) List(ctx context.Context, filter models.SearchFilter,
 	WHERE ($1::UUID IS NULL OR $1 = org_uid)
 	AND ($2::BOOLEAN IS NULL OR $2 = is_final)
 	AND ($3::BOOLEAN IS NULL OR $3 = is_archived)
+	AND (($4::INTEGER[] IS NULL AND $5::INTEGER IS NULL)
+		OR id = ANY($4)
+		OR id IN (
+			SELECT roles.department_id FROM roles
+			INNER JOIN user_roles ON user_roles.role_id = roles.id
+			WHERE user_roles.user_id = $5
+			AND (user_roles.expires_at IS NULL OR user_roles.expires_at > NOW())
+		))
 	`
 	filterQuery := dbhelpers.ResolveFilterSort(dbhelpers.DepartmentsTable, filter)
 	queryStmt := fmt.Sprintf("%s %s %s", selectQuery, conditionsQuery, filterQuery)
 
 	var queryArgs []interface{}
-	queryArgs = append(queryArgs, orgUID, filter.IsFinal, filter.IsArchived)
+	queryArgs = append(queryArgs, orgUID, filter.IsFinal, filter.IsArchived, scope.DepartmentIDs, scope.UserID)
 
 	// query rows
 	rows, err := s.conn.Query(ctx, queryStmt, queryArgs...)
@@ -0,0 +1,255 @@
+package orgstore
+
+import (
+	"context"
+	"fmt"
+	"gogql/app/models"
+	"gogql/app/models/dbmodels"
+	"gogql/app/store/dbstore/dbhelpers"
+	"gogql/utils/faulterr"
+
+	"github.com/gofrs/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type InvitationStore struct {
+	conn *pgxpool.Pool
+}
+
+var _ InvitationStoreInterface = &InvitationStore{}
+
+type InvitationStoreInterface interface {
+	List(ctx context.Context, filter models.SearchFilter, orgUID *uuid.UUID, status *string, scope models.PermissionScope) ([]dbmodels.Invitation, int, *faulterr.FaultErr)
+	GetByID(ctx context.Context, id int64) (*dbmodels.Invitation, *faulterr.FaultErr)
+	GetByTokenHash(ctx context.Context, tokenHash string) (*dbmodels.Invitation, *faulterr.FaultErr)
+
+	Insert(ctx context.Context, tx pgx.Tx, arg *dbmodels.Invitation) (*dbmodels.Invitation, *faulterr.FaultErr)
+	Update(ctx context.Context, tx pgx.Tx, arg *dbmodels.Invitation) *faulterr.FaultErr
+	MarkAccepted(ctx context.Context, tx pgx.Tx, id int64) (bool, *faulterr.FaultErr)
+	RevokeOpenByUserID(ctx context.Context, tx pgx.Tx, userID int64) *faulterr.FaultErr
+}
+
+func NewInvitationStore(conn *pgxpool.Pool) *InvitationStore {
+	return &InvitationStore{conn}
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////
+//////////////////////////////////////////****Read****/////////////////////////////////////////
+///////////////////////////////////////////////////////////////////////////////////////////////
+
+// List gets all invitations, a scope only lists the invitations to roles of its departments
+// and the invitations sent by its user
+func (s *InvitationStore) List(ctx context.Context, filter models.SearchFilter, orgUID *uuid.UUID, status *string, scope models.PermissionScope) ([]dbmodels.Invitation, int, *faulterr.FaultErr) {
+	errMsg := "error when trying to get invitations"
+
+	// define query
+	selectQuery := `SELECT * FROM invitations`
+	conditionsQuery := `
+	WHERE ($1::UUID IS NULL OR $1 = org_uid)
+	AND ($2::VARCHAR IS NULL OR $2 = status)
+	AND (($3::INTEGER[] IS NULL AND $4::INTEGER IS NULL)
+		OR invited_by = $4
+		OR user_id IN (
+			SELECT user_roles.user_id FROM user_roles
+			INNER JOIN roles ON roles.id = user_roles.role_id
+			WHERE roles.department_id = ANY($3)
+			AND (user_roles.expires_at IS NULL OR user_roles.expires_at > NOW())
+		))
+	`
+	filterQuery := dbhelpers.ResolveFilterSort(dbhelpers.InvitationsTable, filter)
+	queryStmt := fmt.Sprintf("%s %s %s", selectQuery, conditionsQuery, filterQuery)
+
+	var queryArgs []interface{}
+	queryArgs = append(queryArgs, orgUID, status, scope.DepartmentIDs, scope.UserID)
+
+	// query rows
+	rows, err := s.conn.Query(ctx, queryStmt, queryArgs...)
+	if err != nil {
+		return nil, 0, faulterr.NewPostgresError(err, errMsg)
+	}
+	defer rows.Close()
+
+	// get total
+	total, err := dbhelpers.GetGlobalTotal(ctx, s.conn, dbhelpers.InvitationsTable, conditionsQuery, queryArgs)
+	if err != nil {
+		return nil, 0, faulterr.NewPostgresError(err, errMsg)
+	}
+
+	result, err := s.scanRows(rows)
+	if err != nil {
+		return nil, 0, faulterr.NewPostgresError(err, errMsg)
+	}
+	return result, total, nil
+}
+
+// GetByID gets an invitation by id
+func (s *InvitationStore) GetByID(ctx context.Context, id int64) (*dbmodels.Invitation, *faulterr.FaultErr) {
+	errMsg := "error when trying to get invitation by id"
+
+	queryStmt := `SELECT * FROM invitations WHERE id=$1`
+
+	row := s.conn.QueryRow(ctx, queryStmt, id)
+	obj, err := s.scanRow(row)
+	if err != nil {
+		return nil, faulterr.NewPostgresError(err, errMsg)
+	}
+	return obj, nil
+}
+
+// GetByTokenHash gets an invitation by the hash of its token
+func (s *InvitationStore) GetByTokenHash(ctx context.Context, tokenHash string) (*dbmodels.Invitation, *faulterr.FaultErr) {
+	errMsg := "error when trying to get invitation by token"
+
+	queryStmt := `SELECT * FROM invitations WHERE token_hash=$1`
+
+	row := s.conn.QueryRow(ctx, queryStmt, tokenHash)
+	obj, err := s.scanRow(row)
+	if err != nil {
+		return nil, faulterr.NewPostgresError(err, errMsg)
+	}
+	return obj, nil
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////
+//////////////////////////////////////////****Mutate****///////////////////////////////////////
+///////////////////////////////////////////////////////////////////////////////////////////////
+
+// Insert inserts an invitation in database
+func (s *InvitationStore) Insert(ctx context.Context, tx pgx.Tx, arg *dbmodels.Invitation) (*dbmodels.Invitation, *faulterr.FaultErr) {
+	errMsg := "error when trying to insert invitation"
+
+	queryStmt := `
+	INSERT INTO
+	invitations(
+		org_uid,
+		user_id,
+		email,
+		token_hash,
+		status,
+		invited_by,
+		expires_at
+	)
+	VALUES ($1, $2, $3, $4, $5, $6, $7)
+	RETURNING *
+	`
+
+	row := tx.QueryRow(ctx, queryStmt,
+		arg.OrgUID,
+		arg.UserID,
+		arg.Email,
+		arg.TokenHash,
+		arg.Status,
+		arg.InvitedBy,
+		arg.ExpiresAt,
+	)
+
+	obj, err := s.scanRow(row)
+	if err != nil {
+		return nil, faulterr.NewPostgresError(err, errMsg)
+	}
+	return obj, nil
+}
+
+// Update updates token, status and delivery of an invitation
+func (s *InvitationStore) Update(ctx context.Context, tx pgx.Tx, arg *dbmodels.Invitation) *faulterr.FaultErr {
+	errMsg := "error when trying to update invitation"
+
+	queryStmt := `
+	UPDATE invitations
+	SET
+		token_hash=$1,
+		status=$2,
+		expires_at=$3,
+		send_count=$4,
+		last_sent_at=$5
+	WHERE id=$6
+	`
+
+	_, err := tx.Exec(ctx, queryStmt,
+		&arg.TokenHash,
+		&arg.Status,
+		&arg.ExpiresAt,
+		&arg.SendCount,
+		&arg.LastSentAt,
+		&arg.ID,
+	)
+	if err != nil {
+		return faulterr.NewPostgresError(err, errMsg)
+	}
+	return nil
+}
+
+// MarkAccepted accepts an open invitation, it returns false if the invitation was
+// no longer open so a token can only be used once
+func (s *InvitationStore) MarkAccepted(ctx context.Context, tx pgx.Tx, id int64) (bool, *faulterr.FaultErr) {
+	errMsg := "error when trying to accept invitation"
+
+	queryStmt := `
+	UPDATE invitations
+	SET
+		status='ACCEPTED',
+		accepted_at=NOW()
+	WHERE id=$1
+	AND status='OPEN'
+	`
+
+	tag, err := tx.Exec(ctx, queryStmt, id)
+	if err != nil {
+		return false, faulterr.NewPostgresError(err, errMsg)
+	}
+	return tag.RowsAffected() == 1, nil
+}
+
+// RevokeOpenByUserID revokes the open invitations of a user
+func (s *InvitationStore) RevokeOpenByUserID(ctx context.Context, tx pgx.Tx, userID int64) *faulterr.FaultErr {
+	errMsg := "error when trying to revoke invitations by user id"
+
+	queryStmt := `UPDATE invitations SET status='REVOKED' WHERE user_id=$1 AND status='OPEN'`
+
+	_, err := tx.Exec(ctx, queryStmt, userID)
+	if err != nil {
+		return faulterr.NewPostgresError(err, errMsg)
+	}
+	return nil
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////
+//////////////////////////////////////////****Helpers****//////////////////////////////////////
+///////////////////////////////////////////////////////////////////////////////////////////////
+
+func (s *InvitationStore) scanRows(rows pgx.Rows) ([]dbmodels.Invitation, error) {
+	result := []dbmodels.Invitation{}
+
+	for rows.Next() {
+		obj, err := s.scanRow(rows)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, *obj)
+	}
+	return result, nil
+}
+
+func (s *InvitationStore) scanRow(row pgx.Row) (*dbmodels.Invitation, error) {
+	obj := dbmodels.Invitation{}
+
+	if err := row.Scan(
+		&obj.ID,
+		&obj.OrgUID,
+		&obj.UserID,
+		&obj.Email,
+		&obj.TokenHash,
+		&obj.Status,
+		&obj.InvitedBy,
+		&obj.ExpiresAt,
+		&obj.AcceptedAt,
+		&obj.SendCount,
+		&obj.LastSentAt,
+		&obj.CreatedAt,
+		&obj.UpdatedAt,
+	); err != nil {
+		return nil, err
+	}
+	return &obj, nil
+}
@@ -0,0 +1,125 @@
+package orgstore
+
+import (
+	"context"
+	"gogql/app/models/dbmodels"
+	"gogql/utils/faulterr"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type OIDCLoginStateStore struct {
+	conn *pgxpool.Pool
+}
+
+var _ OIDCLoginStateStoreInterface = &OIDCLoginStateStore{}
+
+type OIDCLoginStateStoreInterface interface {
+	GetByStateHash(ctx context.Context, stateHash string) (*dbmodels.OIDCLoginState, *faulterr.FaultErr)
+
+	Insert(ctx context.Context, tx pgx.Tx, arg *dbmodels.OIDCLoginState) (*dbmodels.OIDCLoginState, *faulterr.FaultErr)
+	MarkUsed(ctx context.Context, tx pgx.Tx, id int64) (bool, *faulterr.FaultErr)
+}
+
+func NewOIDCLoginStateStore(conn *pgxpool.Pool) *OIDCLoginStateStore {
+	return &OIDCLoginStateStore{conn}
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////
+//////////////////////////////////////////****Read****/////////////////////////////////////////
+///////////////////////////////////////////////////////////////////////////////////////////////
+
+// GetByStateHash gets a login state by the hash of its state parameter
+func (s *OIDCLoginStateStore) GetByStateHash(ctx context.Context, stateHash string) (*dbmodels.OIDCLoginState, *faulterr.FaultErr) {
+	errMsg := "error when trying to get oidc login state"
+
+	queryStmt := `SELECT * FROM oidc_login_states WHERE state_hash=$1`
+
+	row := s.conn.QueryRow(ctx, queryStmt, stateHash)
+	obj, err := s.scanRow(row)
+	if err != nil {
+		return nil, faulterr.NewPostgresError(err, errMsg)
+	}
+	return obj, nil
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////
+//////////////////////////////////////////****Mutate****///////////////////////////////////////
+///////////////////////////////////////////////////////////////////////////////////////////////
+
+// Insert inserts a login state
+func (s *OIDCLoginStateStore) Insert(ctx context.Context, tx pgx.Tx, arg *dbmodels.OIDCLoginState) (*dbmodels.OIDCLoginState, *faulterr.FaultErr) {
+	errMsg := "error when trying to insert oidc login state"
+
+	queryStmt := `
+	INSERT INTO
+	oidc_login_states(
+		org_uid,
+		state_hash,
+		nonce,
+		code_verifier,
+		ip_address,
+		expires_at
+	)
+	VALUES ($1, $2, $3, $4, $5, $6)
+	RETURNING *
+	`
+
+	row := tx.QueryRow(ctx, queryStmt,
+		arg.OrgUID,
+		arg.StateHash,
+		arg.Nonce,
+		arg.CodeVerifier,
+		arg.IPAddress,
+		arg.ExpiresAt,
+	)
+
+	obj, err := s.scanRow(row)
+	if err != nil {
+		return nil, faulterr.NewPostgresError(err, errMsg)
+	}
+	return obj, nil
+}
+
+// MarkUsed marks an unused login state as used, it returns false if the state was already used
+func (s *OIDCLoginStateStore) MarkUsed(ctx context.Context, tx pgx.Tx, id int64) (bool, *faulterr.FaultErr) {
+	errMsg := "error when trying to mark oidc login state as used"
+
+	queryStmt := `
+	UPDATE oidc_login_states
+	SET
+		is_used=TRUE
+	WHERE id=$1
+	AND is_used=FALSE
+	`
+
+	tag, err := tx.Exec(ctx, queryStmt, id)
+	if err != nil {
+		return false, faulterr.NewPostgresError(err, errMsg)
+	}
+	return tag.RowsAffected() == 1, nil
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////
+//////////////////////////////////////////****Helpers****//////////////////////////////////////
+///////////////////////////////////////////////////////////////////////////////////////////////
+
+func (s *OIDCLoginStateStore) scanRow(row pgx.Row) (*dbmodels.OIDCLoginState, error) {
+	obj := &dbmodels.OIDCLoginState{}
+	if err := row.Scan(
+		&obj.ID,
+		&obj.OrgUID,
+		&obj.StateHash,
+		&obj.Nonce,
+		&obj.CodeVerifier,
+		&obj.IPAddress,
+		&obj.IsUsed,
+		&obj.ExpiresAt,
+		&obj.CreatedAt,
+		&obj.UpdatedAt,
+	); err != nil {
+		return nil, err
+	}
+	return obj, nil
+}
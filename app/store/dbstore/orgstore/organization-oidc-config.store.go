@@ -0,0 +1,119 @@
+package orgstore
+
+import (
+	"context"
+	"gogql/app/models/dbmodels"
+	"gogql/utils/faulterr"
+
+	"github.com/gofrs/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type OrganizationOIDCConfigStore struct {
+	conn *pgxpool.Pool
+}
+
+var _ OrganizationOIDCConfigStoreInterface = &OrganizationOIDCConfigStore{}
+
+type OrganizationOIDCConfigStoreInterface interface {
+	GetByOrgUID(ctx context.Context, orgUID uuid.UUID) (*dbmodels.OrganizationOIDCConfig, *faulterr.FaultErr)
+	Upsert(ctx context.Context, tx pgx.Tx, arg *dbmodels.OrganizationOIDCConfig) (*dbmodels.OrganizationOIDCConfig, *faulterr.FaultErr)
+}
+
+func NewOrganizationOIDCConfigStore(conn *pgxpool.Pool) *OrganizationOIDCConfigStore {
+	return &OrganizationOIDCConfigStore{conn}
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////
+//////////////////////////////////////////****Read****/////////////////////////////////////////
+///////////////////////////////////////////////////////////////////////////////////////////////
+
+// GetByOrgUID gets the oidc config of an organization
+func (s *OrganizationOIDCConfigStore) GetByOrgUID(ctx context.Context, orgUID uuid.UUID) (*dbmodels.OrganizationOIDCConfig, *faulterr.FaultErr) {
+	errMsg := "error when trying to get organization oidc config"
+
+	queryStmt := `SELECT * FROM organization_oidc_configs WHERE org_uid=$1`
+
+	row := s.conn.QueryRow(ctx, queryStmt, orgUID)
+	obj, err := s.scanRow(row)
+	if err != nil {
+		return nil, faulterr.NewPostgresError(err, errMsg)
+	}
+	return obj, nil
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////
+//////////////////////////////////////////****Mutate****///////////////////////////////////////
+///////////////////////////////////////////////////////////////////////////////////////////////
+
+// Upsert inserts the oidc config of an organization or replaces it
+func (s *OrganizationOIDCConfigStore) Upsert(ctx context.Context, tx pgx.Tx, arg *dbmodels.OrganizationOIDCConfig) (*dbmodels.OrganizationOIDCConfig, *faulterr.FaultErr) {
+	errMsg := "error when trying to upsert organization oidc config"
+
+	queryStmt := `
+	INSERT INTO
+	organization_oidc_configs(
+		org_uid,
+		issuer,
+		client_id,
+		client_secret,
+		allowed_domains,
+		is_enabled,
+		jit_provisioning,
+		default_role_id
+	)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	ON CONFLICT (org_uid) DO UPDATE
+	SET
+		issuer=EXCLUDED.issuer,
+		client_id=EXCLUDED.client_id,
+		client_secret=EXCLUDED.client_secret,
+		allowed_domains=EXCLUDED.allowed_domains,
+		is_enabled=EXCLUDED.is_enabled,
+		jit_provisioning=EXCLUDED.jit_provisioning,
+		default_role_id=EXCLUDED.default_role_id
+	RETURNING *
+	`
+
+	row := tx.QueryRow(ctx, queryStmt,
+		arg.OrgUID,
+		arg.Issuer,
+		arg.ClientID,
+		arg.ClientSecret,
+		arg.AllowedDomains,
+		arg.IsEnabled,
+		arg.JITProvisioning,
+		arg.DefaultRoleID,
+	)
+
+	obj, err := s.scanRow(row)
+	if err != nil {
+		return nil, faulterr.NewPostgresError(err, errMsg)
+	}
+	return obj, nil
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////
+//////////////////////////////////////////****Helpers****//////////////////////////////////////
+///////////////////////////////////////////////////////////////////////////////////////////////
+
+func (s *OrganizationOIDCConfigStore) scanRow(row pgx.Row) (*dbmodels.OrganizationOIDCConfig, error) {
+	obj := &dbmodels.OrganizationOIDCConfig{}
+	if err := row.Scan(
+		&obj.ID,
+		&obj.OrgUID,
+		&obj.Issuer,
+		&obj.ClientID,
+		&obj.ClientSecret,
+		&obj.AllowedDomains,
+		&obj.IsEnabled,
+		&obj.JITProvisioning,
+		&obj.DefaultRoleID,
+		&obj.CreatedAt,
+		&obj.UpdatedAt,
+	); err != nil {
+		return nil, err
+	}
+	return obj, nil
+}
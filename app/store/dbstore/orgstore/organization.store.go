@@ -184,9 +184,10 @@ func (s *OrganizationStore) Insert(ctx context.Context, tx pgx.Tx, arg dbmodels.
 		sector,
 		status,
 		is_final,
-		is_archived
+		is_archived,
+		phone_region
 	)
-	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
 	RETURNING *
 	`
 
@@ -200,6 +201,7 @@ func (s *OrganizationStore) Insert(ctx context.Context, tx pgx.Tx, arg dbmodels.
 		&arg.Status,
 		&arg.IsFinal,
 		&arg.IsArchived,
+		&arg.PhoneRegion,
 	)
 
 	obj, err := s.scanRow(row)
@@ -221,8 +223,10 @@ func (s *OrganizationStore) Update(ctx context.Context, tx pgx.Tx, arg dbmodels.
 		logo=$3,
 		sector=$4,
 		status=$5,
-		is_archived=$6
-	WHERE uid=$7
+		is_archived=$6,
+		require_management_2fa=$7,
+		phone_region=$8
+	WHERE uid=$9
 	`
 
 	_, err := tx.Exec(ctx, queryStmt,
@@ -232,6 +236,8 @@ func (s *OrganizationStore) Update(ctx context.Context, tx pgx.Tx, arg dbmodels.
 		&arg.Sector,
 		&arg.Status,
 		&arg.IsArchived,
+		&arg.RequireManagement2FA,
+		&arg.PhoneRegion,
 		&arg.UID,
 	)
 	if err != nil {
@@ -275,6 +281,8 @@ func (s *OrganizationStore) scanRows(rows pgx.Rows) ([]dbmodels.Organization, er
 			&obj.IsArchived,
 			&obj.CreatedAt,
 			&obj.UpdatedAt,
+			&obj.RequireManagement2FA,
+			&obj.PhoneRegion,
 		); err != nil {
 			return nil, err
 		}
@@ -298,6 +306,8 @@ func (s *OrganizationStore) scanRow(row pgx.Row) (*dbmodels.Organization, error)
 		&obj.IsArchived,
 		&obj.CreatedAt,
 		&obj.UpdatedAt,
+		&obj.RequireManagement2FA,
+		&obj.PhoneRegion,
 	); err != nil {
 		return nil, err
 	}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"gogql/app/models/dbmodels"
 	"gogql/utils/faulterr"
+	"time"
 
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
@@ -16,7 +17,9 @@ type OTPSessionStore struct {
 var _ OTPSessionStoreInterface = &OTPSessionStore{}
 
 type OTPSessionStoreInterface interface {
-	GetByToken(ctx context.Context, token string) (*dbmodels.OTPSession, *faulterr.FaultErr)
+	GetByUserIDAndTokenHash(ctx context.Context, userID int64, tokenHash string) (*dbmodels.OTPSession, *faulterr.FaultErr)
+	GetLatestByUserID(ctx context.Context, userID int64) (*dbmodels.OTPSession, *faulterr.FaultErr)
+	CountCreatedSince(ctx context.Context, userID *int64, ipAddress *string, since time.Time) (int, *faulterr.FaultErr)
 	Insert(ctx context.Context, tx pgx.Tx, arg *dbmodels.OTPSession) (*dbmodels.OTPSession, *faulterr.FaultErr)
 	Update(ctx context.Context, tx pgx.Tx, arg *dbmodels.OTPSession) *faulterr.FaultErr
 }
@@ -29,16 +32,19 @@ func NewOTPSessionStore(conn *pgxpool.Pool) *OTPSessionStore {
 //////////////////////////////////////////****Read****/////////////////////////////////////////
 ///////////////////////////////////////////////////////////////////////////////////////////////
 
-// GetByToken gets otp session by code from database
-func (s *OTPSessionStore) GetByToken(ctx context.Context, token string) (*dbmodels.OTPSession, *faulterr.FaultErr) {
+// GetByUserIDAndTokenHash gets the latest otp session issued to a user with the given code hash
+func (s *OTPSessionStore) GetByUserIDAndTokenHash(ctx context.Context, userID int64, tokenHash string) (*dbmodels.OTPSession, *faulterr.FaultErr) {
 	errMsg := "error when trying to get otp session by token"
 
 	queryStmt := `
 	SELECT * FROM otp_sessions
-	WHERE otp_sessions.token = $1
+	WHERE otp_sessions.user_id = $1
+	AND otp_sessions.token_hash = $2
+	ORDER BY otp_sessions.created_at DESC
+	LIMIT 1
 	`
 
-	row := s.conn.QueryRow(ctx, queryStmt, token)
+	row := s.conn.QueryRow(ctx, queryStmt, userID, tokenHash)
 	obj, err := s.scanRow(row)
 	if err != nil {
 		return nil, faulterr.NewPostgresError(err, errMsg)
@@ -46,6 +52,44 @@ func (s *OTPSessionStore) GetByToken(ctx context.Context, token string) (*dbmode
 	return obj, nil
 }
 
+// GetLatestByUserID gets the most recently issued otp session of a user
+func (s *OTPSessionStore) GetLatestByUserID(ctx context.Context, userID int64) (*dbmodels.OTPSession, *faulterr.FaultErr) {
+	errMsg := "error when trying to get latest otp session by user id"
+
+	queryStmt := `
+	SELECT * FROM otp_sessions
+	WHERE otp_sessions.user_id = $1
+	ORDER BY otp_sessions.created_at DESC
+	LIMIT 1
+	`
+
+	row := s.conn.QueryRow(ctx, queryStmt, userID)
+	obj, err := s.scanRow(row)
+	if err != nil {
+		return nil, faulterr.NewPostgresError(err, errMsg)
+	}
+	return obj, nil
+}
+
+// CountCreatedSince counts otp sessions issued for a user and/or ip address after the given time
+func (s *OTPSessionStore) CountCreatedSince(ctx context.Context, userID *int64, ipAddress *string, since time.Time) (int, *faulterr.FaultErr) {
+	errMsg := "error when trying to count otp sessions"
+
+	queryStmt := `
+	SELECT COUNT(*) FROM otp_sessions
+	WHERE ($1::BIGINT IS NULL OR $1 = user_id)
+	AND ($2::VARCHAR IS NULL OR $2 = ip_address)
+	AND created_at > $3
+	`
+
+	var count int
+	row := s.conn.QueryRow(ctx, queryStmt, userID, ipAddress, since)
+	if err := row.Scan(&count); err != nil {
+		return 0, faulterr.NewPostgresError(err, errMsg)
+	}
+	return count, nil
+}
+
 ///////////////////////////////////////////////////////////////////////////////////////////////
 //////////////////////////////////////////****Mutate****///////////////////////////////////////
 ///////////////////////////////////////////////////////////////////////////////////////////////
@@ -58,19 +102,21 @@ func (s *OTPSessionStore) Insert(ctx context.Context, tx pgx.Tx, arg *dbmodels.O
 	INSERT INTO
 	otp_sessions(
 		user_id,
-		token,
+		token_hash,
 		is_valid,
-		expires_at
+		expires_at,
+		ip_address
 	)
-	VALUES ($1, $2, $3, $4)
+	VALUES ($1, $2, $3, $4, $5)
 	RETURNING *
 	`
 
 	row := tx.QueryRow(ctx, queryStmt,
 		arg.UserID,
-		arg.Token,
+		arg.TokenHash,
 		arg.IsValid,
 		arg.ExpiresAt,
+		arg.IPAddress,
 	)
 
 	obj, err := s.scanRow(row)
@@ -80,19 +126,21 @@ func (s *OTPSessionStore) Insert(ctx context.Context, tx pgx.Tx, arg *dbmodels.O
 	return obj, nil
 }
 
-// Update User
+// Update updates validity and failed attempts of an otp session
 func (s *OTPSessionStore) Update(ctx context.Context, tx pgx.Tx, arg *dbmodels.OTPSession) *faulterr.FaultErr {
-	errMsg := "error when trying to update user"
+	errMsg := "error when trying to update otp session"
 
 	queryStmt := `
 	UPDATE otp_sessions
 	SET
-		is_valid=$1
-	WHERE id=$2
+		is_valid=$1,
+		failed_attempts=$2
+	WHERE id=$3
 	`
 
 	_, err := tx.Exec(ctx, queryStmt,
 		&arg.IsValid,
+		&arg.FailedAttempts,
 		&arg.ID,
 	)
 	if err != nil {
@@ -107,11 +155,13 @@ func (s *OTPSessionStore) scanRow(row pgx.Row) (*dbmodels.OTPSession, error) {
 	if err := row.Scan(
 		&obj.ID,
 		&obj.UserID,
-		&obj.Token,
+		&obj.TokenHash,
 		&obj.IsValid,
 		&obj.ExpiresAt,
 		&obj.CreatedAt,
 		&obj.UpdatedAt,
+		&obj.IPAddress,
+		&obj.FailedAttempts,
 	); err != nil {
 		return nil, err
 	}
@@ -0,0 +1,283 @@
+package orgstore
+
+import (
+	"context"
+	"gogql/app/models/dbmodels"
+	"gogql/utils/faulterr"
+	"time"
+
+	"github.com/gofrs/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type PermissionElevationStore struct {
+	conn *pgxpool.Pool
+}
+
+var _ PermissionElevationStoreInterface = &PermissionElevationStore{}
+
+type PermissionElevationStoreInterface interface {
+	GetByID(ctx context.Context, id int64) (*dbmodels.PermissionElevation, *faulterr.FaultErr)
+	List(ctx context.Context, orgUID *uuid.UUID, userID *int64, status string) ([]dbmodels.PermissionElevation, *faulterr.FaultErr)
+	ListApprovedByUserID(ctx context.Context, userID int64) ([]dbmodels.PermissionElevation, *faulterr.FaultErr)
+	ListActiveAsOfByUserIDs(ctx context.Context, userIDs []int64, at time.Time) ([]dbmodels.PermissionElevation, *faulterr.FaultErr)
+
+	Insert(ctx context.Context, tx pgx.Tx, arg *dbmodels.PermissionElevation) (*dbmodels.PermissionElevation, *faulterr.FaultErr)
+	Update(ctx context.Context, tx pgx.Tx, arg *dbmodels.PermissionElevation) *faulterr.FaultErr
+	ExpireEnded(ctx context.Context, tx pgx.Tx) ([]dbmodels.PermissionElevation, *faulterr.FaultErr)
+	RenamePermission(ctx context.Context, tx pgx.Tx, from string, to string) (int64, *faulterr.FaultErr)
+}
+
+func NewPermissionElevationStore(conn *pgxpool.Pool) *PermissionElevationStore {
+	return &PermissionElevationStore{conn}
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////
+//////////////////////////////////////////****Read****/////////////////////////////////////////
+///////////////////////////////////////////////////////////////////////////////////////////////
+
+// GetByID gets a permission elevation by id
+func (s *PermissionElevationStore) GetByID(ctx context.Context, id int64) (*dbmodels.PermissionElevation, *faulterr.FaultErr) {
+	errMsg := "error when trying to get permission elevation by id"
+
+	queryStmt := `SELECT * FROM permission_elevations WHERE id=$1`
+
+	row := s.conn.QueryRow(ctx, queryStmt, id)
+	obj, err := s.scanRow(row)
+	if err != nil {
+		return nil, faulterr.NewPostgresError(err, errMsg)
+	}
+	return obj, nil
+}
+
+// List gets the permission elevations of an organization, a user or with a status,
+// filters left empty match every elevation
+func (s *PermissionElevationStore) List(ctx context.Context, orgUID *uuid.UUID, userID *int64, status string) ([]dbmodels.PermissionElevation, *faulterr.FaultErr) {
+	errMsg := "error when trying to list permission elevations"
+
+	queryStmt := `
+	SELECT * FROM permission_elevations
+	WHERE ($1::UUID IS NULL OR permission_elevations.org_uid = $1)
+	AND ($2::INTEGER IS NULL OR permission_elevations.user_id = $2)
+	AND ($3 = '' OR permission_elevations.status = $3)
+	ORDER BY permission_elevations.created_at DESC
+	`
+
+	rows, err := s.conn.Query(ctx, queryStmt, orgUID, userID, status)
+	if err != nil {
+		return nil, faulterr.NewPostgresError(err, errMsg)
+	}
+	defer rows.Close()
+
+	result, err := s.scanRows(rows)
+	if err != nil {
+		return nil, faulterr.NewPostgresError(err, errMsg)
+	}
+	return result, nil
+}
+
+// ListApprovedByUserID gets the approved elevations of a user that did not end yet,
+// including the ones that did not start yet
+func (s *PermissionElevationStore) ListApprovedByUserID(ctx context.Context, userID int64) ([]dbmodels.PermissionElevation, *faulterr.FaultErr) {
+	errMsg := "error when trying to get approved permission elevations by user id"
+
+	queryStmt := `
+	SELECT * FROM permission_elevations
+	WHERE permission_elevations.user_id = $1
+	AND permission_elevations.status = 'APPROVED'
+	AND permission_elevations.ends_at > NOW()
+	ORDER BY permission_elevations.id
+	`
+
+	rows, err := s.conn.Query(ctx, queryStmt, userID)
+	if err != nil {
+		return nil, faulterr.NewPostgresError(err, errMsg)
+	}
+	defer rows.Close()
+
+	result, err := s.scanRows(rows)
+	if err != nil {
+		return nil, faulterr.NewPostgresError(err, errMsg)
+	}
+	return result, nil
+}
+
+// ListActiveAsOfByUserIDs gets the elevations of many users that were approved and running at a point
+// in time, an elevation revoked since was active until its revocation
+func (s *PermissionElevationStore) ListActiveAsOfByUserIDs(ctx context.Context, userIDs []int64, at time.Time) ([]dbmodels.PermissionElevation, *faulterr.FaultErr) {
+	errMsg := "error when trying to get permission elevations as of a time"
+
+	queryStmt := `
+	SELECT * FROM permission_elevations
+	WHERE permission_elevations.user_id = ANY($1)
+	AND permission_elevations.decided_at <= $2
+	AND permission_elevations.starts_at <= $2
+	AND permission_elevations.ends_at > $2
+	AND (permission_elevations.status IN ('APPROVED', 'EXPIRED')
+		OR (permission_elevations.status = 'REVOKED' AND permission_elevations.updated_at > $2))
+	ORDER BY permission_elevations.id
+	`
+
+	rows, err := s.conn.Query(ctx, queryStmt, userIDs, at)
+	if err != nil {
+		return nil, faulterr.NewPostgresError(err, errMsg)
+	}
+	defer rows.Close()
+
+	result, err := s.scanRows(rows)
+	if err != nil {
+		return nil, faulterr.NewPostgresError(err, errMsg)
+	}
+	return result, nil
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////
+//////////////////////////////////////////****Mutate****///////////////////////////////////////
+///////////////////////////////////////////////////////////////////////////////////////////////
+
+// Insert inserts a permission elevation in database
+func (s *PermissionElevationStore) Insert(ctx context.Context, tx pgx.Tx, arg *dbmodels.PermissionElevation) (*dbmodels.PermissionElevation, *faulterr.FaultErr) {
+	errMsg := "error when trying to insert permission elevation"
+
+	queryStmt := `
+	INSERT INTO
+	permission_elevations(
+		org_uid,
+		user_id,
+		permission,
+		justification,
+		status,
+		starts_at,
+		ends_at,
+		requested_by,
+		decided_by,
+		decided_at
+	)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+	RETURNING *
+	`
+
+	row := tx.QueryRow(ctx, queryStmt,
+		arg.OrgUID,
+		arg.UserID,
+		arg.Permission,
+		arg.Justification,
+		arg.Status,
+		arg.StartsAt,
+		arg.EndsAt,
+		arg.RequestedBy,
+		arg.DecidedBy,
+		arg.DecidedAt,
+	)
+
+	obj, err := s.scanRow(row)
+	if err != nil {
+		return nil, faulterr.NewPostgresError(err, errMsg)
+	}
+	return obj, nil
+}
+
+// Update updates the status and decision of a permission elevation
+func (s *PermissionElevationStore) Update(ctx context.Context, tx pgx.Tx, arg *dbmodels.PermissionElevation) *faulterr.FaultErr {
+	errMsg := "error when trying to update permission elevation"
+
+	queryStmt := `
+	UPDATE permission_elevations
+	SET
+		status=$1,
+		decided_by=$2,
+		decided_at=$3
+	WHERE id=$4
+	`
+
+	_, err := tx.Exec(ctx, queryStmt,
+		&arg.Status,
+		&arg.DecidedBy,
+		&arg.DecidedAt,
+		&arg.ID,
+	)
+	if err != nil {
+		return faulterr.NewPostgresError(err, errMsg)
+	}
+	return nil
+}
+
+// ExpireEnded marks the approved and pending elevations that ended expired and returns them
+func (s *PermissionElevationStore) ExpireEnded(ctx context.Context, tx pgx.Tx) ([]dbmodels.PermissionElevation, *faulterr.FaultErr) {
+	errMsg := "error when trying to expire permission elevations"
+
+	queryStmt := `
+	UPDATE permission_elevations
+	SET
+		status='EXPIRED'
+	WHERE status IN ('APPROVED', 'PENDING')
+	AND ends_at <= NOW()
+	RETURNING *
+	`
+
+	rows, err := tx.Query(ctx, queryStmt)
+	if err != nil {
+		return nil, faulterr.NewPostgresError(err, errMsg)
+	}
+	defer rows.Close()
+
+	result, err := s.scanRows(rows)
+	if err != nil {
+		return nil, faulterr.NewPostgresError(err, errMsg)
+	}
+	return result, nil
+}
+
+// RenamePermission replaces a permission in every elevation of it and returns how many elevations changed
+func (s *PermissionElevationStore) RenamePermission(ctx context.Context, tx pgx.Tx, from string, to string) (int64, *faulterr.FaultErr) {
+	errMsg := "error when trying to rename permission elevation permission"
+
+	queryStmt := `UPDATE permission_elevations SET permission=$2 WHERE permission=$1`
+
+	tag, err := tx.Exec(ctx, queryStmt, from, to)
+	if err != nil {
+		return 0, faulterr.NewPostgresError(err, errMsg)
+	}
+	return tag.RowsAffected(), nil
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////
+//////////////////////////////////////////****Helpers****//////////////////////////////////////
+///////////////////////////////////////////////////////////////////////////////////////////////
+
+func (s *PermissionElevationStore) scanRows(rows pgx.Rows) ([]dbmodels.PermissionElevation, error) {
+	result := []dbmodels.PermissionElevation{}
+
+	for rows.Next() {
+		obj, err := s.scanRow(rows)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, *obj)
+	}
+	return result, nil
+}
+
+func (s *PermissionElevationStore) scanRow(row pgx.Row) (*dbmodels.PermissionElevation, error) {
+	obj := dbmodels.PermissionElevation{}
+
+	if err := row.Scan(
+		&obj.ID,
+		&obj.OrgUID,
+		&obj.UserID,
+		&obj.Permission,
+		&obj.Justification,
+		&obj.Status,
+		&obj.StartsAt,
+		&obj.EndsAt,
+		&obj.RequestedBy,
+		&obj.DecidedBy,
+		&obj.DecidedAt,
+		&obj.CreatedAt,
+		&obj.UpdatedAt,
+	); err != nil {
+		return nil, err
+	}
+	return &obj, nil
+}
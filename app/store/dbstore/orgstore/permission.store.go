@@ -0,0 +1,145 @@
+package orgstore
+
+import (
+	"context"
+	"gogql/app/models/dbmodels"
+	"gogql/utils/faulterr"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type PermissionStore struct {
+	conn *pgxpool.Pool
+}
+
+var _ PermissionStoreInterface = &PermissionStore{}
+
+type PermissionStoreInterface interface {
+	List(ctx context.Context, includeRemoved bool) ([]dbmodels.Permission, *faulterr.FaultErr)
+
+	Upsert(ctx context.Context, tx pgx.Tx, arg *dbmodels.Permission) (*dbmodels.Permission, *faulterr.FaultErr)
+	MarkRemovedExcept(ctx context.Context, tx pgx.Tx, names []string) (int64, *faulterr.FaultErr)
+}
+
+func NewPermissionStore(conn *pgxpool.Pool) *PermissionStore {
+	return &PermissionStore{conn}
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////
+//////////////////////////////////////////****Read****/////////////////////////////////////////
+///////////////////////////////////////////////////////////////////////////////////////////////
+
+// List gets the permissions of the catalogue by group
+func (s *PermissionStore) List(ctx context.Context, includeRemoved bool) ([]dbmodels.Permission, *faulterr.FaultErr) {
+	errMsg := "error when trying to get permissions"
+
+	queryStmt := `
+	SELECT * FROM permissions
+	WHERE ($1 OR NOT permissions.is_removed)
+	ORDER BY permissions.group_name, permissions.name
+	`
+
+	rows, err := s.conn.Query(ctx, queryStmt, includeRemoved)
+	if err != nil {
+		return nil, faulterr.NewPostgresError(err, errMsg)
+	}
+	defer rows.Close()
+
+	result, err := s.scanRows(rows)
+	if err != nil {
+		return nil, faulterr.NewPostgresError(err, errMsg)
+	}
+	return result, nil
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////
+//////////////////////////////////////////****Mutate****///////////////////////////////////////
+///////////////////////////////////////////////////////////////////////////////////////////////
+
+// Upsert inserts a permission or updates the permission of the same name, a removed
+// permission that is upserted again is restored
+func (s *PermissionStore) Upsert(ctx context.Context, tx pgx.Tx, arg *dbmodels.Permission) (*dbmodels.Permission, *faulterr.FaultErr) {
+	errMsg := "error when trying to upsert permission"
+
+	queryStmt := `
+	INSERT INTO
+	permissions(
+		name,
+		group_name,
+		description
+	)
+	VALUES ($1, $2, $3)
+	ON CONFLICT (name) DO UPDATE
+	SET
+		group_name=EXCLUDED.group_name,
+		description=EXCLUDED.description,
+		is_removed=false
+	RETURNING *
+	`
+
+	row := tx.QueryRow(ctx, queryStmt,
+		arg.Name,
+		arg.Group,
+		arg.Description,
+	)
+
+	obj, err := s.scanRow(row)
+	if err != nil {
+		return nil, faulterr.NewPostgresError(err, errMsg)
+	}
+	return obj, nil
+}
+
+// MarkRemovedExcept marks every permission missing from names as removed and returns how many were marked
+func (s *PermissionStore) MarkRemovedExcept(ctx context.Context, tx pgx.Tx, names []string) (int64, *faulterr.FaultErr) {
+	errMsg := "error when trying to mark permissions removed"
+
+	queryStmt := `
+	UPDATE permissions
+	SET
+		is_removed=true
+	WHERE NOT (name = ANY($1))
+	AND NOT is_removed
+	`
+
+	tag, err := tx.Exec(ctx, queryStmt, names)
+	if err != nil {
+		return 0, faulterr.NewPostgresError(err, errMsg)
+	}
+	return tag.RowsAffected(), nil
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////
+//////////////////////////////////////////****Helpers****//////////////////////////////////////
+///////////////////////////////////////////////////////////////////////////////////////////////
+
+func (s *PermissionStore) scanRows(rows pgx.Rows) ([]dbmodels.Permission, error) {
+	result := []dbmodels.Permission{}
+
+	for rows.Next() {
+		obj, err := s.scanRow(rows)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, *obj)
+	}
+	return result, nil
+}
+
+func (s *PermissionStore) scanRow(row pgx.Row) (*dbmodels.Permission, error) {
+	obj := dbmodels.Permission{}
+
+	if err := row.Scan(
+		&obj.ID,
+		&obj.Name,
+		&obj.CreatedAt,
+		&obj.UpdatedAt,
+		&obj.Group,
+		&obj.Description,
+		&obj.IsRemoved,
+	); err != nil {
+		return nil, err
+	}
+	return &obj, nil
+}
@@ -0,0 +1,145 @@
+package orgstore
+
+import (
+	"context"
+	"gogql/app/models/dbmodels"
+	"gogql/utils/faulterr"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type RecoveryCodeStore struct {
+	conn *pgxpool.Pool
+}
+
+var _ RecoveryCodeStoreInterface = &RecoveryCodeStore{}
+
+type RecoveryCodeStoreInterface interface {
+	GetUnusedByUserIDAndCodeHash(ctx context.Context, userID int64, codeHash string) (*dbmodels.RecoveryCode, *faulterr.FaultErr)
+	CountUnusedByUserID(ctx context.Context, userID int64) (int, *faulterr.FaultErr)
+	Insert(ctx context.Context, tx pgx.Tx, arg *dbmodels.RecoveryCode) (*dbmodels.RecoveryCode, *faulterr.FaultErr)
+	MarkUsed(ctx context.Context, tx pgx.Tx, id int64) (bool, *faulterr.FaultErr)
+	DeleteByUserID(ctx context.Context, tx pgx.Tx, userID int64) *faulterr.FaultErr
+}
+
+func NewRecoveryCodeStore(conn *pgxpool.Pool) *RecoveryCodeStore {
+	return &RecoveryCodeStore{conn}
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////
+//////////////////////////////////////////****Read****/////////////////////////////////////////
+///////////////////////////////////////////////////////////////////////////////////////////////
+
+// GetUnusedByUserIDAndCodeHash gets an unused recovery code of a user by the hash of the code
+func (s *RecoveryCodeStore) GetUnusedByUserIDAndCodeHash(ctx context.Context, userID int64, codeHash string) (*dbmodels.RecoveryCode, *faulterr.FaultErr) {
+	errMsg := "error when trying to get recovery code"
+
+	queryStmt := `
+	SELECT * FROM recovery_codes
+	WHERE recovery_codes.user_id = $1
+	AND recovery_codes.code_hash = $2
+	AND recovery_codes.used_at IS NULL
+	`
+
+	row := s.conn.QueryRow(ctx, queryStmt, userID, codeHash)
+	obj, err := s.scanRow(row)
+	if err != nil {
+		return nil, faulterr.NewPostgresError(err, errMsg)
+	}
+	return obj, nil
+}
+
+// CountUnusedByUserID counts the recovery codes a user has left
+func (s *RecoveryCodeStore) CountUnusedByUserID(ctx context.Context, userID int64) (int, *faulterr.FaultErr) {
+	errMsg := "error when trying to count recovery codes"
+
+	queryStmt := `SELECT COUNT(*) FROM recovery_codes WHERE user_id=$1 AND used_at IS NULL`
+
+	var count int
+	row := s.conn.QueryRow(ctx, queryStmt, userID)
+	if err := row.Scan(&count); err != nil {
+		return 0, faulterr.NewPostgresError(err, errMsg)
+	}
+	return count, nil
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////
+//////////////////////////////////////////****Mutate****///////////////////////////////////////
+///////////////////////////////////////////////////////////////////////////////////////////////
+
+// Insert inserts a recovery code in database
+func (s *RecoveryCodeStore) Insert(ctx context.Context, tx pgx.Tx, arg *dbmodels.RecoveryCode) (*dbmodels.RecoveryCode, *faulterr.FaultErr) {
+	errMsg := "error when trying to insert recovery code"
+
+	queryStmt := `
+	INSERT INTO
+	recovery_codes(
+		user_id,
+		code_hash
+	)
+	VALUES ($1, $2)
+	RETURNING *
+	`
+
+	row := tx.QueryRow(ctx, queryStmt,
+		arg.UserID,
+		arg.CodeHash,
+	)
+
+	obj, err := s.scanRow(row)
+	if err != nil {
+		return nil, faulterr.NewPostgresError(err, errMsg)
+	}
+	return obj, nil
+}
+
+// MarkUsed marks an unused recovery code as used, it returns false if the code was already used
+func (s *RecoveryCodeStore) MarkUsed(ctx context.Context, tx pgx.Tx, id int64) (bool, *faulterr.FaultErr) {
+	errMsg := "error when trying to mark recovery code as used"
+
+	queryStmt := `
+	UPDATE recovery_codes
+	SET
+		used_at=NOW()
+	WHERE id=$1
+	AND used_at IS NULL
+	`
+
+	tag, err := tx.Exec(ctx, queryStmt, id)
+	if err != nil {
+		return false, faulterr.NewPostgresError(err, errMsg)
+	}
+	return tag.RowsAffected() == 1, nil
+}
+
+// DeleteByUserID deletes all recovery codes of a user
+func (s *RecoveryCodeStore) DeleteByUserID(ctx context.Context, tx pgx.Tx, userID int64) *faulterr.FaultErr {
+	queryStmt := `DELETE FROM recovery_codes WHERE user_id=$1`
+
+	_, err := tx.Exec(ctx, queryStmt, userID)
+	if err != nil {
+		return faulterr.NewPostgresError(err, "error when trying to delete recovery codes")
+	}
+	return nil
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////
+//////////////////////////////////////////****Helpers****//////////////////////////////////////
+///////////////////////////////////////////////////////////////////////////////////////////////
+
+func (s *RecoveryCodeStore) scanRow(row pgx.Row) (*dbmodels.RecoveryCode, error) {
+	obj := dbmodels.RecoveryCode{}
+
+	if err := row.Scan(
+		&obj.ID,
+		&obj.UserID,
+		&obj.CodeHash,
+		&obj.UsedAt,
+		&obj.CreatedAt,
+		&obj.UpdatedAt,
+	); err != nil {
+		return nil, err
+	}
+	return &obj, nil
+}
@@ -0,0 +1,122 @@
+package orgstore
+
+import (
+	"context"
+	"gogql/app/models/dbmodels"
+	"gogql/utils/faulterr"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type RefreshTokenStore struct {
+	conn *pgxpool.Pool
+}
+
+var _ RefreshTokenStoreInterface = &RefreshTokenStore{}
+
+type RefreshTokenStoreInterface interface {
+	GetByTokenHash(ctx context.Context, tokenHash string) (*dbmodels.RefreshToken, *faulterr.FaultErr)
+	Insert(ctx context.Context, tx pgx.Tx, arg *dbmodels.RefreshToken) (*dbmodels.RefreshToken, *faulterr.FaultErr)
+	MarkUsed(ctx context.Context, tx pgx.Tx, id int64) (bool, *faulterr.FaultErr)
+}
+
+func NewRefreshTokenStore(conn *pgxpool.Pool) *RefreshTokenStore {
+	return &RefreshTokenStore{conn}
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////
+//////////////////////////////////////////****Read****/////////////////////////////////////////
+///////////////////////////////////////////////////////////////////////////////////////////////
+
+// GetByTokenHash gets refresh token by the hash of its token from database
+func (s *RefreshTokenStore) GetByTokenHash(ctx context.Context, tokenHash string) (*dbmodels.RefreshToken, *faulterr.FaultErr) {
+	errMsg := "error when trying to get refresh token by token"
+
+	queryStmt := `
+	SELECT * FROM refresh_tokens
+	WHERE refresh_tokens.token_hash = $1
+	`
+
+	row := s.conn.QueryRow(ctx, queryStmt, tokenHash)
+	obj, err := s.scanRow(row)
+	if err != nil {
+		return nil, faulterr.NewPostgresError(err, errMsg)
+	}
+	return obj, nil
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////
+//////////////////////////////////////////****Mutate****///////////////////////////////////////
+///////////////////////////////////////////////////////////////////////////////////////////////
+
+// Insert inserts a refresh token in database
+func (s *RefreshTokenStore) Insert(ctx context.Context, tx pgx.Tx, arg *dbmodels.RefreshToken) (*dbmodels.RefreshToken, *faulterr.FaultErr) {
+	errMsg := "error when trying to insert refresh token"
+
+	queryStmt := `
+	INSERT INTO
+	refresh_tokens(
+		session_id,
+		token_hash,
+		is_used,
+		expires_at
+	)
+	VALUES ($1, $2, $3, $4)
+	RETURNING *
+	`
+
+	row := tx.QueryRow(ctx, queryStmt,
+		arg.SessionID,
+		arg.TokenHash,
+		arg.IsUsed,
+		arg.ExpiresAt,
+	)
+
+	obj, err := s.scanRow(row)
+	if err != nil {
+		return nil, faulterr.NewPostgresError(err, errMsg)
+	}
+	return obj, nil
+}
+
+// MarkUsed marks an unused refresh token as used, it returns false if the token was already used
+// so concurrent refreshes with the same token can not both succeed
+func (s *RefreshTokenStore) MarkUsed(ctx context.Context, tx pgx.Tx, id int64) (bool, *faulterr.FaultErr) {
+	errMsg := "error when trying to mark refresh token as used"
+
+	queryStmt := `
+	UPDATE refresh_tokens
+	SET
+		is_used=TRUE
+	WHERE id=$1
+	AND is_used=FALSE
+	`
+
+	tag, err := tx.Exec(ctx, queryStmt, id)
+	if err != nil {
+		return false, faulterr.NewPostgresError(err, errMsg)
+	}
+	return tag.RowsAffected() == 1, nil
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////
+//////////////////////////////////////////****Helpers****//////////////////////////////////////
+///////////////////////////////////////////////////////////////////////////////////////////////
+
+func (s *RefreshTokenStore) scanRow(row pgx.Row) (*dbmodels.RefreshToken, error) {
+	obj := dbmodels.RefreshToken{}
+
+	if err := row.Scan(
+		&obj.ID,
+		&obj.SessionID,
+		&obj.TokenHash,
+		&obj.IsUsed,
+		&obj.ExpiresAt,
+		&obj.CreatedAt,
+		&obj.UpdatedAt,
+	); err != nil {
+		return nil, err
+	}
+	return &obj, nil
+}
@@ -9,10 +9,12 @@ import (
 	"gogql/utils/faulterr"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/gofrs/uuid"
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/volatiletech/null"
 )
 
 type RoleStore struct {
@@ -25,13 +27,17 @@ type RoleStoreInterface interface {
 	GetCount(ctx context.Context, orgUID uuid.UUID) (int64, *faulterr.FaultErr)
 	GetManyByIDs(ctx context.Context, ids []int64) ([]*dbmodels.Role, *faulterr.FaultErr)
 
-	List(ctx context.Context, filter models.SearchFilter, orgUID *uuid.UUID, deptID *int64) ([]dbmodels.Role, int, *faulterr.FaultErr)
+	List(ctx context.Context, filter models.SearchFilter, orgUID *uuid.UUID, deptID *int64, scope models.PermissionScope) ([]dbmodels.Role, int, *faulterr.FaultErr)
 	GetByID(ctx context.Context, id int64) (*dbmodels.Role, *faulterr.FaultErr)
 	GetByCode(ctx context.Context, code string) (*dbmodels.Role, *faulterr.FaultErr)
+	ListWithUnknownPermissions(ctx context.Context, known []string, orgUID *uuid.UUID) ([]dbmodels.Role, *faulterr.FaultErr)
+	ListWithAnyPermission(ctx context.Context, permissions []string, orgUID uuid.UUID) ([]dbmodels.Role, *faulterr.FaultErr)
+	ListAsOf(ctx context.Context, ids []int64, at time.Time) ([]*dbmodels.Role, *faulterr.FaultErr)
 
 	Insert(ctx context.Context, tx pgx.Tx, r dbmodels.Role) (*dbmodels.Role, *faulterr.FaultErr)
 	Update(ctx context.Context, tx pgx.Tx, r dbmodels.Role) *faulterr.FaultErr
 	Delete(ctx context.Context, tx pgx.Tx, id int64) *faulterr.FaultErr
+	RenamePermission(ctx context.Context, tx pgx.Tx, from string, to string) (int64, *faulterr.FaultErr)
 }
 
 func NewRoleStore(conn *pgxpool.Pool) *RoleStore {
@@ -98,8 +104,9 @@ func (s *RoleStore) GetManyByIDs(ctx context.Context, ids []int64) ([]*dbmodels.
 	return result, nil
 }
 
-// List retrives all roles from database
-func (s *RoleStore) List(ctx context.Context, filter models.SearchFilter, orgUID *uuid.UUID, deptID *int64) ([]dbmodels.Role, int, *faulterr.FaultErr) {
+// List retrives all roles from database, a scope only lists the roles of its departments
+// and the roles of its user
+func (s *RoleStore) List(ctx context.Context, filter models.SearchFilter, orgUID *uuid.UUID, deptID *int64, scope models.PermissionScope) ([]dbmodels.Role, int, *faulterr.FaultErr) {
 	errMsg := "error when trying to get roles"
 
 	// define query
@@ -109,12 +116,19 @@ func (s *RoleStore) List(ctx context.Context, filter models.SearchFilter, orgUID
 	AND ($2::INTEGER IS NULL OR $2 = department_id)
 	AND ($3::BOOLEAN IS NULL OR $3 = is_final)
 	AND ($4::BOOLEAN IS NULL OR $4 = is_archived)
+	AND (($5::INTEGER[] IS NULL AND $6::INTEGER IS NULL)
+		OR department_id = ANY($5)
+		OR id IN (
+			SELECT user_roles.role_id FROM user_roles
+			WHERE user_roles.user_id = $6
+			AND (user_roles.expires_at IS NULL OR user_roles.expires_at > NOW())
+		))
 	`
 	filterQuery := dbhelpers.ResolveFilterSort(dbhelpers.RolesTable, filter)
 	queryStmt := fmt.Sprintf("%s %s %s", selectQuery, conditionsQuery, filterQuery)
 
 	var queryArgs []interface{}
-	queryArgs = append(queryArgs, orgUID, deptID, filter.IsFinal, filter.IsArchived)
+	queryArgs = append(queryArgs, orgUID, deptID, filter.IsFinal, filter.IsArchived, scope.DepartmentIDs, scope.UserID)
 
 	// query rows
 	rows, err := s.conn.Query(ctx, queryStmt, queryArgs...)
@@ -171,6 +185,103 @@ func (s *RoleStore) GetByCode(ctx context.Context, code string) (*dbmodels.Role,
 	return obj, nil
 }
 
+// ListWithUnknownPermissions gets the roles granting permissions that are not in known
+func (s *RoleStore) ListWithUnknownPermissions(ctx context.Context, known []string, orgUID *uuid.UUID) ([]dbmodels.Role, *faulterr.FaultErr) {
+	errMsg := "error when trying to get roles with unknown permissions"
+
+	queryStmt := `
+	SELECT * FROM roles
+	WHERE ($1::UUID IS NULL OR $1 = org_uid)
+	AND NOT (COALESCE(permissions, '{}') <@ $2::text[])
+	ORDER BY roles.id
+	`
+
+	rows, err := s.conn.Query(ctx, queryStmt, orgUID, known)
+	if err != nil {
+		return nil, faulterr.NewPostgresError(err, errMsg)
+	}
+	defer rows.Close()
+
+	result, err := s.scanRows(rows)
+	if err != nil {
+		return nil, faulterr.NewPostgresError(err, errMsg)
+	}
+	return result, nil
+}
+
+// ListWithAnyPermission gets the management roles of an organization and its roles granting
+// any of the permissions
+func (s *RoleStore) ListWithAnyPermission(ctx context.Context, permissions []string, orgUID uuid.UUID) ([]dbmodels.Role, *faulterr.FaultErr) {
+	errMsg := "error when trying to get roles with permissions"
+
+	queryStmt := `
+	SELECT * FROM roles
+	WHERE roles.org_uid = $1
+	AND (roles.is_management OR COALESCE(permissions, '{}') && $2::text[])
+	ORDER BY roles.id
+	`
+
+	rows, err := s.conn.Query(ctx, queryStmt, orgUID, permissions)
+	if err != nil {
+		return nil, faulterr.NewPostgresError(err, errMsg)
+	}
+	defer rows.Close()
+
+	result, err := s.scanRows(rows)
+	if err != nil {
+		return nil, faulterr.NewPostgresError(err, errMsg)
+	}
+	return result, nil
+}
+
+// ListAsOf gets roles as they were at a point in time from their versions, only the columns
+// permissions are decided on are versioned and roles deleted by then are left out
+func (s *RoleStore) ListAsOf(ctx context.Context, ids []int64, at time.Time) ([]*dbmodels.Role, *faulterr.FaultErr) {
+	errMsg := "error when trying to get roles as of a time"
+
+	queryStmt := `
+	SELECT
+		role_versions.role_id,
+		role_versions.org_uid,
+		role_versions.department_id,
+		role_versions.name,
+		role_versions.permissions,
+		role_versions.permission_scopes,
+		role_versions.is_management,
+		role_versions.is_archived
+	FROM role_versions
+	WHERE role_versions.role_id = ANY($1)
+	AND role_versions.valid_from <= $2
+	AND (role_versions.valid_to IS NULL OR role_versions.valid_to > $2)
+	ORDER BY role_versions.role_id
+	`
+
+	rows, err := s.conn.Query(ctx, queryStmt, ids, at)
+	if err != nil {
+		return nil, faulterr.NewPostgresError(err, errMsg)
+	}
+	defer rows.Close()
+
+	result := []*dbmodels.Role{}
+	for rows.Next() {
+		obj := dbmodels.Role{}
+		if err := rows.Scan(
+			&obj.ID,
+			&obj.OrgUID,
+			&obj.DepartmentID,
+			&obj.Name,
+			&obj.Permissions,
+			&obj.PermissionScopes,
+			&obj.IsManagement,
+			&obj.IsArchived,
+		); err != nil {
+			return nil, faulterr.NewPostgresError(err, errMsg)
+		}
+		result = append(result, &obj)
+	}
+	return result, nil
+}
+
 ///////////////////////////////////////////////////////////////////////////////////////////////
 //////////////////////////////////////////****Mutate****///////////////////////////////////////
 ///////////////////////////////////////////////////////////////////////////////////////////////
@@ -190,9 +301,10 @@ func (s *RoleStore) Insert(ctx context.Context, tx pgx.Tx, arg dbmodels.Role) (*
 		is_management,
 		status,
 		is_final,
-		is_archived
+		is_archived,
+		permission_scopes
 	)
-	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
 	RETURNING *
 	`
 
@@ -206,12 +318,16 @@ func (s *RoleStore) Insert(ctx context.Context, tx pgx.Tx, arg dbmodels.Role) (*
 		&arg.Status,
 		&arg.IsFinal,
 		&arg.IsArchived,
+		s.permissionScopes(arg),
 	)
 
 	obj, err := s.scanRow(row)
 	if err != nil {
 		return nil, faulterr.NewPostgresError(err, errMsg)
 	}
+	if err := s.writeVersions(ctx, tx, []int64{obj.ID}); err != nil {
+		return nil, faulterr.NewPostgresError(err, errMsg)
+	}
 	return obj, nil
 }
 
@@ -227,8 +343,9 @@ func (s *RoleStore) Update(ctx context.Context, tx pgx.Tx, arg dbmodels.Role) *f
 		is_management=$3,
 		status=$4,
 		is_final=$5,
-		is_archived=$6
-	WHERE id=$7
+		is_archived=$6,
+		permission_scopes=$7
+	WHERE id=$8
 	`
 
 	_, err := tx.Exec(ctx, queryStmt,
@@ -238,11 +355,15 @@ func (s *RoleStore) Update(ctx context.Context, tx pgx.Tx, arg dbmodels.Role) *f
 		&arg.Status,
 		&arg.IsFinal,
 		&arg.IsArchived,
+		s.permissionScopes(arg),
 		&arg.ID,
 	)
 	if err != nil {
 		return faulterr.NewPostgresError(err, errMsg)
 	}
+	if err := s.writeVersions(ctx, tx, []int64{arg.ID}); err != nil {
+		return faulterr.NewPostgresError(err, errMsg)
+	}
 	return nil
 }
 
@@ -250,22 +371,73 @@ func (s *RoleStore) Update(ctx context.Context, tx pgx.Tx, arg dbmodels.Role) *f
 func (s *RoleStore) Delete(ctx context.Context, tx pgx.Tx, id int64) *faulterr.FaultErr {
 	queryStmt := `DELETE FROM roles WHERE id=$1`
 
-	_, err := tx.Exec(ctx, queryStmt)
+	_, err := tx.Exec(ctx, queryStmt, id)
 	if err != nil {
 		return faulterr.NewPostgresError(err, "error when trying to delete role")
 	}
+	if err := s.writeVersions(ctx, tx, []int64{id}); err != nil {
+		return faulterr.NewPostgresError(err, "error when trying to delete role")
+	}
+	if err := closeRemovedVersions(ctx, tx, null.Int64{}, null.Int64From(id)); err != nil {
+		return faulterr.NewPostgresError(err, "error when trying to delete role")
+	}
 	return nil
 }
 
+// RenamePermission replaces a permission in every role granting it and returns how many roles changed,
+// roles already granting the new name keep it once and the scope of the old name moves to the new one
+func (s *RoleStore) RenamePermission(ctx context.Context, tx pgx.Tx, from string, to string) (int64, *faulterr.FaultErr) {
+	errMsg := "error when trying to rename role permission"
+
+	queryStmt := `
+	UPDATE roles
+	SET
+		permissions=ARRAY(
+			SELECT p FROM unnest(array_replace(permissions, $1::text, $2::text)) WITH ORDINALITY AS t(p, i)
+			GROUP BY p ORDER BY MIN(i)
+		),
+		permission_scopes=CASE
+			WHEN permission_scopes ? $1
+			THEN (permission_scopes - $1::text) || jsonb_build_object($2::text, permission_scopes->$1::text)
+			ELSE permission_scopes
+		END
+	WHERE $1 = ANY(permissions)
+	RETURNING id
+	`
+
+	rows, err := tx.Query(ctx, queryStmt, from, to)
+	if err != nil {
+		return 0, faulterr.NewPostgresError(err, errMsg)
+	}
+	ids := []int64{}
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return 0, faulterr.NewPostgresError(err, errMsg)
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, faulterr.NewPostgresError(err, errMsg)
+	}
+
+	if err := s.writeVersions(ctx, tx, ids); err != nil {
+		return 0, faulterr.NewPostgresError(err, errMsg)
+	}
+	return int64(len(ids)), nil
+}
+
 ///////////////////////////////////////////////////////////////////////////////////////////////
 //////////////////////////////////////////****Helpers****//////////////////////////////////////
 ///////////////////////////////////////////////////////////////////////////////////////////////
 
 func (s *RoleStore) scanRows(rows pgx.Rows) ([]dbmodels.Role, error) {
 	result := []dbmodels.Role{}
-	obj := dbmodels.Role{}
 
 	for rows.Next() {
+		obj := dbmodels.Role{}
 		if err := rows.Scan(
 			&obj.ID,
 			&obj.Code,
@@ -279,6 +451,7 @@ func (s *RoleStore) scanRows(rows pgx.Rows) ([]dbmodels.Role, error) {
 			&obj.IsArchived,
 			&obj.CreatedAt,
 			&obj.UpdatedAt,
+			&obj.PermissionScopes,
 		); err != nil {
 			return nil, err
 		}
@@ -303,8 +476,65 @@ func (s *RoleStore) scanRow(row pgx.Row) (*dbmodels.Role, error) {
 		&obj.IsArchived,
 		&obj.CreatedAt,
 		&obj.UpdatedAt,
+		&obj.PermissionScopes,
 	); err != nil {
 		return nil, err
 	}
 	return &obj, nil
 }
+
+// writeVersions closes the current version of the roles when it no longer matches the role and
+// records the role as its new version, deleted roles only get their version closed
+func (s *RoleStore) writeVersions(ctx context.Context, tx pgx.Tx, ids []int64) error {
+	closeStmt := `
+	UPDATE role_versions
+	SET valid_to=NOW()
+	WHERE role_versions.role_id = ANY($1)
+	AND role_versions.valid_to IS NULL
+	AND NOT EXISTS (
+		SELECT 1 FROM roles
+		WHERE roles.id = role_versions.role_id
+		AND (roles.org_uid, roles.department_id, roles.name, COALESCE(roles.permissions, '{}'),
+			roles.permission_scopes, roles.is_management, roles.is_archived)
+		IS NOT DISTINCT FROM (role_versions.org_uid, role_versions.department_id, role_versions.name,
+			role_versions.permissions, role_versions.permission_scopes, role_versions.is_management,
+			role_versions.is_archived)
+	)
+	`
+	if _, err := tx.Exec(ctx, closeStmt, ids); err != nil {
+		return err
+	}
+
+	insertStmt := `
+	INSERT INTO
+	role_versions(
+		role_id,
+		org_uid,
+		department_id,
+		name,
+		permissions,
+		permission_scopes,
+		is_management,
+		is_archived,
+		valid_from
+	)
+	SELECT id, org_uid, department_id, name, COALESCE(permissions, '{}'), permission_scopes, is_management, is_archived, NOW()
+	FROM roles
+	WHERE roles.id = ANY($1)
+	AND NOT EXISTS (
+		SELECT 1 FROM role_versions
+		WHERE role_versions.role_id = roles.id
+		AND role_versions.valid_to IS NULL
+	)
+	`
+	_, err := tx.Exec(ctx, insertStmt, ids)
+	return err
+}
+
+// permissionScopes never writes a null, the column defaults to an empty object
+func (s *RoleStore) permissionScopes(arg dbmodels.Role) map[string]string {
+	if arg.PermissionScopes == nil {
+		return map[string]string{}
+	}
+	return arg.PermissionScopes
+}
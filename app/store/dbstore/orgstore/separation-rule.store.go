@@ -0,0 +1,202 @@
+package orgstore
+
+import (
+	"context"
+	"gogql/app/models/dbmodels"
+	"gogql/utils/faulterr"
+
+	"github.com/gofrs/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type SeparationRuleStore struct {
+	conn *pgxpool.Pool
+}
+
+var _ SeparationRuleStoreInterface = &SeparationRuleStore{}
+
+type SeparationRuleStoreInterface interface {
+	GetByID(ctx context.Context, id int64) (*dbmodels.SeparationRule, *faulterr.FaultErr)
+	List(ctx context.Context, orgUID uuid.UUID, isActive *bool) ([]dbmodels.SeparationRule, *faulterr.FaultErr)
+
+	Insert(ctx context.Context, tx pgx.Tx, arg *dbmodels.SeparationRule) (*dbmodels.SeparationRule, *faulterr.FaultErr)
+	Update(ctx context.Context, tx pgx.Tx, arg *dbmodels.SeparationRule) *faulterr.FaultErr
+	Delete(ctx context.Context, tx pgx.Tx, id int64) *faulterr.FaultErr
+	RenamePermission(ctx context.Context, tx pgx.Tx, from string, to string) (int64, *faulterr.FaultErr)
+}
+
+func NewSeparationRuleStore(conn *pgxpool.Pool) *SeparationRuleStore {
+	return &SeparationRuleStore{conn}
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////
+//////////////////////////////////////////****Read****/////////////////////////////////////////
+///////////////////////////////////////////////////////////////////////////////////////////////
+
+// GetByID gets a separation rule by id
+func (s *SeparationRuleStore) GetByID(ctx context.Context, id int64) (*dbmodels.SeparationRule, *faulterr.FaultErr) {
+	errMsg := "error when trying to get separation rule by id"
+
+	queryStmt := `SELECT * FROM separation_rules WHERE id=$1`
+
+	row := s.conn.QueryRow(ctx, queryStmt, id)
+	obj, err := s.scanRow(row)
+	if err != nil {
+		return nil, faulterr.NewPostgresError(err, errMsg)
+	}
+	return obj, nil
+}
+
+// List gets the separation rules of an organization, every rule when is active is left empty
+func (s *SeparationRuleStore) List(ctx context.Context, orgUID uuid.UUID, isActive *bool) ([]dbmodels.SeparationRule, *faulterr.FaultErr) {
+	errMsg := "error when trying to list separation rules"
+
+	queryStmt := `
+	SELECT * FROM separation_rules
+	WHERE separation_rules.org_uid = $1
+	AND ($2::BOOLEAN IS NULL OR separation_rules.is_active = $2)
+	ORDER BY separation_rules.id
+	`
+
+	rows, err := s.conn.Query(ctx, queryStmt, orgUID, isActive)
+	if err != nil {
+		return nil, faulterr.NewPostgresError(err, errMsg)
+	}
+	defer rows.Close()
+
+	result, err := s.scanRows(rows)
+	if err != nil {
+		return nil, faulterr.NewPostgresError(err, errMsg)
+	}
+	return result, nil
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////
+//////////////////////////////////////////****Mutate****///////////////////////////////////////
+///////////////////////////////////////////////////////////////////////////////////////////////
+
+// Insert inserts a separation rule in database
+func (s *SeparationRuleStore) Insert(ctx context.Context, tx pgx.Tx, arg *dbmodels.SeparationRule) (*dbmodels.SeparationRule, *faulterr.FaultErr) {
+	errMsg := "error when trying to insert separation rule"
+
+	queryStmt := `
+	INSERT INTO
+	separation_rules(
+		org_uid,
+		name,
+		permissions,
+		is_active,
+		created_by
+	)
+	VALUES ($1, $2, $3, $4, $5)
+	RETURNING *
+	`
+
+	row := tx.QueryRow(ctx, queryStmt,
+		arg.OrgUID,
+		arg.Name,
+		arg.Permissions,
+		arg.IsActive,
+		arg.CreatedBy,
+	)
+
+	obj, err := s.scanRow(row)
+	if err != nil {
+		return nil, faulterr.NewPostgresError(err, errMsg)
+	}
+	return obj, nil
+}
+
+// Update updates the name, permissions and state of a separation rule
+func (s *SeparationRuleStore) Update(ctx context.Context, tx pgx.Tx, arg *dbmodels.SeparationRule) *faulterr.FaultErr {
+	errMsg := "error when trying to update separation rule"
+
+	queryStmt := `
+	UPDATE separation_rules
+	SET
+		name=$1,
+		permissions=$2,
+		is_active=$3
+	WHERE id=$4
+	`
+
+	_, err := tx.Exec(ctx, queryStmt,
+		&arg.Name,
+		&arg.Permissions,
+		&arg.IsActive,
+		&arg.ID,
+	)
+	if err != nil {
+		return faulterr.NewPostgresError(err, errMsg)
+	}
+	return nil
+}
+
+// Delete deletes a separation rule
+func (s *SeparationRuleStore) Delete(ctx context.Context, tx pgx.Tx, id int64) *faulterr.FaultErr {
+	errMsg := "error when trying to delete separation rule"
+
+	queryStmt := `DELETE FROM separation_rules WHERE id=$1`
+
+	if _, err := tx.Exec(ctx, queryStmt, id); err != nil {
+		return faulterr.NewPostgresError(err, errMsg)
+	}
+	return nil
+}
+
+// RenamePermission replaces a permission in every separation rule naming it and returns how many rules changed
+func (s *SeparationRuleStore) RenamePermission(ctx context.Context, tx pgx.Tx, from string, to string) (int64, *faulterr.FaultErr) {
+	errMsg := "error when trying to rename separation rule permission"
+
+	queryStmt := `
+	UPDATE separation_rules
+	SET
+		permissions=ARRAY(
+			SELECT p FROM unnest(array_replace(permissions, $1::varchar, $2::varchar)) WITH ORDINALITY AS t(p, i)
+			GROUP BY p ORDER BY MIN(i)
+		)
+	WHERE $1 = ANY(permissions)
+	`
+
+	tag, err := tx.Exec(ctx, queryStmt, from, to)
+	if err != nil {
+		return 0, faulterr.NewPostgresError(err, errMsg)
+	}
+	return tag.RowsAffected(), nil
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////
+//////////////////////////////////////////****Helpers****//////////////////////////////////////
+///////////////////////////////////////////////////////////////////////////////////////////////
+
+func (s *SeparationRuleStore) scanRows(rows pgx.Rows) ([]dbmodels.SeparationRule, error) {
+	result := []dbmodels.SeparationRule{}
+
+	for rows.Next() {
+		obj, err := s.scanRow(rows)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, *obj)
+	}
+	return result, nil
+}
+
+func (s *SeparationRuleStore) scanRow(row pgx.Row) (*dbmodels.SeparationRule, error) {
+	obj := dbmodels.SeparationRule{}
+
+	if err := row.Scan(
+		&obj.ID,
+		&obj.OrgUID,
+		&obj.Name,
+		&obj.Permissions,
+		&obj.IsActive,
+		&obj.CreatedBy,
+		&obj.CreatedAt,
+		&obj.UpdatedAt,
+	); err != nil {
+		return nil, err
+	}
+	return &obj, nil
+}
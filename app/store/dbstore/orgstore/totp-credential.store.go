@@ -0,0 +1,145 @@
+package orgstore
+
+import (
+	"context"
+	"gogql/app/models/dbmodels"
+	"gogql/utils/faulterr"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type TOTPCredentialStore struct {
+	conn *pgxpool.Pool
+}
+
+var _ TOTPCredentialStoreInterface = &TOTPCredentialStore{}
+
+type TOTPCredentialStoreInterface interface {
+	GetByUserID(ctx context.Context, userID int64) (*dbmodels.TOTPCredential, *faulterr.FaultErr)
+	Upsert(ctx context.Context, tx pgx.Tx, arg *dbmodels.TOTPCredential) (*dbmodels.TOTPCredential, *faulterr.FaultErr)
+	Update(ctx context.Context, tx pgx.Tx, arg *dbmodels.TOTPCredential) *faulterr.FaultErr
+	DeleteByUserID(ctx context.Context, tx pgx.Tx, userID int64) *faulterr.FaultErr
+}
+
+func NewTOTPCredentialStore(conn *pgxpool.Pool) *TOTPCredentialStore {
+	return &TOTPCredentialStore{conn}
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////
+//////////////////////////////////////////****Read****/////////////////////////////////////////
+///////////////////////////////////////////////////////////////////////////////////////////////
+
+// GetByUserID gets the totp credential of a user
+func (s *TOTPCredentialStore) GetByUserID(ctx context.Context, userID int64) (*dbmodels.TOTPCredential, *faulterr.FaultErr) {
+	errMsg := "error when trying to get totp credential by user id"
+
+	queryStmt := `SELECT * FROM totp_credentials WHERE user_id=$1`
+
+	row := s.conn.QueryRow(ctx, queryStmt, userID)
+	obj, err := s.scanRow(row)
+	if err != nil {
+		return nil, faulterr.NewPostgresError(err, errMsg)
+	}
+	return obj, nil
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////
+//////////////////////////////////////////****Mutate****///////////////////////////////////////
+///////////////////////////////////////////////////////////////////////////////////////////////
+
+// Upsert inserts the totp credential of a user or replaces its secret and state
+func (s *TOTPCredentialStore) Upsert(ctx context.Context, tx pgx.Tx, arg *dbmodels.TOTPCredential) (*dbmodels.TOTPCredential, *faulterr.FaultErr) {
+	errMsg := "error when trying to upsert totp credential"
+
+	queryStmt := `
+	INSERT INTO
+	totp_credentials(
+		user_id,
+		secret,
+		is_enabled,
+		last_used_step,
+		confirmed_at
+	)
+	VALUES ($1, $2, $3, $4, $5)
+	ON CONFLICT (user_id) DO UPDATE
+	SET
+		secret=EXCLUDED.secret,
+		is_enabled=EXCLUDED.is_enabled,
+		last_used_step=EXCLUDED.last_used_step,
+		confirmed_at=EXCLUDED.confirmed_at
+	RETURNING *
+	`
+
+	row := tx.QueryRow(ctx, queryStmt,
+		arg.UserID,
+		arg.Secret,
+		arg.IsEnabled,
+		arg.LastUsedStep,
+		arg.ConfirmedAt,
+	)
+
+	obj, err := s.scanRow(row)
+	if err != nil {
+		return nil, faulterr.NewPostgresError(err, errMsg)
+	}
+	return obj, nil
+}
+
+// Update updates the state of a totp credential
+func (s *TOTPCredentialStore) Update(ctx context.Context, tx pgx.Tx, arg *dbmodels.TOTPCredential) *faulterr.FaultErr {
+	errMsg := "error when trying to update totp credential"
+
+	queryStmt := `
+	UPDATE totp_credentials
+	SET
+		is_enabled=$1,
+		last_used_step=$2,
+		confirmed_at=$3
+	WHERE id=$4
+	`
+
+	_, err := tx.Exec(ctx, queryStmt,
+		&arg.IsEnabled,
+		&arg.LastUsedStep,
+		&arg.ConfirmedAt,
+		&arg.ID,
+	)
+	if err != nil {
+		return faulterr.NewPostgresError(err, errMsg)
+	}
+	return nil
+}
+
+// DeleteByUserID deletes the totp credential of a user
+func (s *TOTPCredentialStore) DeleteByUserID(ctx context.Context, tx pgx.Tx, userID int64) *faulterr.FaultErr {
+	queryStmt := `DELETE FROM totp_credentials WHERE user_id=$1`
+
+	_, err := tx.Exec(ctx, queryStmt, userID)
+	if err != nil {
+		return faulterr.NewPostgresError(err, "error when trying to delete totp credential")
+	}
+	return nil
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////
+//////////////////////////////////////////****Helpers****//////////////////////////////////////
+///////////////////////////////////////////////////////////////////////////////////////////////
+
+func (s *TOTPCredentialStore) scanRow(row pgx.Row) (*dbmodels.TOTPCredential, error) {
+	obj := dbmodels.TOTPCredential{}
+
+	if err := row.Scan(
+		&obj.ID,
+		&obj.UserID,
+		&obj.Secret,
+		&obj.IsEnabled,
+		&obj.LastUsedStep,
+		&obj.ConfirmedAt,
+		&obj.CreatedAt,
+		&obj.UpdatedAt,
+	); err != nil {
+		return nil, err
+	}
+	return &obj, nil
+}
@@ -24,7 +24,7 @@ var _ UserActivityStoreInterface = &UserActivityStore{}
 type UserActivityStoreInterface interface {
 	GetManyByIDs(ctx context.Context, ids []int64) ([]*dbmodels.UserActivity, *faulterr.FaultErr)
 
-	List(ctx context.Context, filter models.SearchFilter, userID *int64, orgUID *uuid.UUID) ([]dbmodels.UserActivity, int, *faulterr.FaultErr)
+	List(ctx context.Context, filter models.SearchFilter, userID *int64, orgUID *uuid.UUID, scope models.PermissionScope) ([]dbmodels.UserActivity, int, *faulterr.FaultErr)
 	GetByID(ctx context.Context, id int64) (*dbmodels.UserActivity, *faulterr.FaultErr)
 
 	Insert(ctx context.Context, tx pgx.Tx, u dbmodels.UserActivity) (*dbmodels.UserActivity, *faulterr.FaultErr)
@@ -72,8 +72,9 @@ func (s *UserActivityStore) GetManyByIDs(ctx context.Context, ids []int64) ([]*d
 	return result, nil
 }
 
-// List gets all user_activities
-func (s *UserActivityStore) List(ctx context.Context, filter models.SearchFilter, userID *int64, orgUID *uuid.UUID) ([]dbmodels.UserActivity, int, *faulterr.FaultErr) {
+// List gets all user_activities, a scope only lists the activities of the users of its
+// departments and of its user
+func (s *UserActivityStore) List(ctx context.Context, filter models.SearchFilter, userID *int64, orgUID *uuid.UUID, scope models.PermissionScope) ([]dbmodels.UserActivity, int, *faulterr.FaultErr) {
 	errMsg := "error when trying to get user_activities"
 
 	// define query
@@ -81,12 +82,20 @@ func (s *UserActivityStore) List(ctx context.Context, filter models.SearchFilter
 	conditionsQuery := `
 	WHERE ($1::INTEGER IS NULL OR $1 = user_id)
 	AND ($2::UUID IS NULL OR $2 = org_uid)
+	AND (($3::INTEGER[] IS NULL AND $4::INTEGER IS NULL)
+		OR user_id = $4
+		OR user_id IN (
+			SELECT user_roles.user_id FROM user_roles
+			INNER JOIN roles ON roles.id = user_roles.role_id
+			WHERE roles.department_id = ANY($3)
+			AND (user_roles.expires_at IS NULL OR user_roles.expires_at > NOW())
+		))
 	`
 	filterQuery := dbhelpers.ResolveFilterSort(dbhelpers.UserActivitiesTable, filter)
 	queryStmt := fmt.Sprintf("%s %s %s", selectQuery, conditionsQuery, filterQuery)
 
 	var queryArgs []interface{}
-	queryArgs = append(queryArgs, userID, orgUID)
+	queryArgs = append(queryArgs, userID, orgUID, scope.DepartmentIDs, scope.UserID)
 
 	// query rows
 	rows, err := s.conn.Query(ctx, queryStmt, queryArgs...)
@@ -126,7 +135,8 @@ func (s *UserActivityStore) GetByID(ctx context.Context, id int64) (*dbmodels.Us
 //////////////////////////////////////////****Mutate****///////////////////////////////////////
 ///////////////////////////////////////////////////////////////////////////////////////////////
 
-// Insert UserActivity
+// Insert UserActivity, the actor is the impersonator of the session the activity was
+// performed with, or the user itself
 func (s *UserActivityStore) Insert(ctx context.Context, tx pgx.Tx, arg dbmodels.UserActivity) (*dbmodels.UserActivity, *faulterr.FaultErr) {
 	errMsg := "error when trying to insert user"
 
@@ -138,9 +148,13 @@ func (s *UserActivityStore) Insert(ctx context.Context, tx pgx.Tx, arg dbmodels.
 		action,
 		object_id,
 		object_type,
-		session_token
+		session_token,
+		actor_id
 	)
-	VALUES ($1, $2, $3, $4, $5, $6)
+	VALUES ($1, $2, $3, $4, $5, $6, COALESCE(
+		(SELECT impersonator_id FROM auth_sessions WHERE token_hash = $6 AND $6 <> ''),
+		$1
+	))
 	RETURNING *
 	`
 
@@ -221,6 +235,7 @@ func (s *UserActivityStore) scanRows(rows pgx.Rows) ([]dbmodels.UserActivity, er
 			&obj.SessionToken,
 			&obj.CreatedAt,
 			&obj.UpdatedAt,
+			&obj.ActorID,
 		); err != nil {
 			return nil, err
 		}
@@ -241,6 +256,7 @@ func (s *UserActivityStore) scanRow(row pgx.Row) (*dbmodels.UserActivity, error)
 		&obj.SessionToken,
 		&obj.CreatedAt,
 		&obj.UpdatedAt,
+		&obj.ActorID,
 	); err != nil {
 		return nil, err
 	}
@@ -0,0 +1,296 @@
+package orgstore
+
+import (
+	"context"
+	"gogql/app/models/dbmodels"
+	"gogql/utils/faulterr"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/volatiletech/null"
+)
+
+type UserRoleStore struct {
+	conn *pgxpool.Pool
+}
+
+var _ UserRoleStoreInterface = &UserRoleStore{}
+
+type UserRoleStoreInterface interface {
+	ListActiveByUserID(ctx context.Context, userID int64) ([]dbmodels.UserRole, *faulterr.FaultErr)
+	ListActiveByUserIDs(ctx context.Context, userIDs []int64) ([]dbmodels.UserRole, *faulterr.FaultErr)
+	ListActiveByRoleIDs(ctx context.Context, roleIDs []int64) ([]dbmodels.UserRole, *faulterr.FaultErr)
+	ListAsOfByUserIDs(ctx context.Context, userIDs []int64, at time.Time) ([]dbmodels.UserRole, *faulterr.FaultErr)
+
+	Upsert(ctx context.Context, tx pgx.Tx, arg *dbmodels.UserRole) (*dbmodels.UserRole, *faulterr.FaultErr)
+	Delete(ctx context.Context, tx pgx.Tx, userID int64, roleID int64) (int64, *faulterr.FaultErr)
+}
+
+func NewUserRoleStore(conn *pgxpool.Pool) *UserRoleStore {
+	return &UserRoleStore{conn}
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////
+//////////////////////////////////////////****Read****/////////////////////////////////////////
+///////////////////////////////////////////////////////////////////////////////////////////////
+
+// ListActiveByUserID gets the unexpired role assignments of a user
+func (s *UserRoleStore) ListActiveByUserID(ctx context.Context, userID int64) ([]dbmodels.UserRole, *faulterr.FaultErr) {
+	errMsg := "error when trying to get user roles by user id"
+
+	queryStmt := `
+	SELECT * FROM user_roles
+	WHERE user_roles.user_id = $1
+	AND (user_roles.expires_at IS NULL OR user_roles.expires_at > NOW())
+	ORDER BY user_roles.id
+	`
+
+	rows, err := s.conn.Query(ctx, queryStmt, userID)
+	if err != nil {
+		return nil, faulterr.NewPostgresError(err, errMsg)
+	}
+	defer rows.Close()
+
+	result, err := s.scanRows(rows)
+	if err != nil {
+		return nil, faulterr.NewPostgresError(err, errMsg)
+	}
+	return result, nil
+}
+
+// ListActiveByUserIDs gets the unexpired role assignments of many users
+func (s *UserRoleStore) ListActiveByUserIDs(ctx context.Context, userIDs []int64) ([]dbmodels.UserRole, *faulterr.FaultErr) {
+	errMsg := "error when trying to get user roles by user ids"
+
+	queryStmt := `
+	SELECT * FROM user_roles
+	WHERE user_roles.user_id = ANY($1)
+	AND (user_roles.expires_at IS NULL OR user_roles.expires_at > NOW())
+	ORDER BY user_roles.id
+	`
+
+	rows, err := s.conn.Query(ctx, queryStmt, userIDs)
+	if err != nil {
+		return nil, faulterr.NewPostgresError(err, errMsg)
+	}
+	defer rows.Close()
+
+	result, err := s.scanRows(rows)
+	if err != nil {
+		return nil, faulterr.NewPostgresError(err, errMsg)
+	}
+	return result, nil
+}
+
+// ListActiveByRoleIDs gets the unexpired assignments of many roles
+func (s *UserRoleStore) ListActiveByRoleIDs(ctx context.Context, roleIDs []int64) ([]dbmodels.UserRole, *faulterr.FaultErr) {
+	errMsg := "error when trying to get user roles by role ids"
+
+	queryStmt := `
+	SELECT * FROM user_roles
+	WHERE user_roles.role_id = ANY($1)
+	AND (user_roles.expires_at IS NULL OR user_roles.expires_at > NOW())
+	ORDER BY user_roles.id
+	`
+
+	rows, err := s.conn.Query(ctx, queryStmt, roleIDs)
+	if err != nil {
+		return nil, faulterr.NewPostgresError(err, errMsg)
+	}
+	defer rows.Close()
+
+	result, err := s.scanRows(rows)
+	if err != nil {
+		return nil, faulterr.NewPostgresError(err, errMsg)
+	}
+	return result, nil
+}
+
+// ListAsOfByUserIDs gets the role assignments of many users that were in place and unexpired
+// at a point in time from their versions
+func (s *UserRoleStore) ListAsOfByUserIDs(ctx context.Context, userIDs []int64, at time.Time) ([]dbmodels.UserRole, *faulterr.FaultErr) {
+	errMsg := "error when trying to get user roles as of a time"
+
+	queryStmt := `
+	SELECT
+		user_role_versions.user_id,
+		user_role_versions.role_id,
+		user_role_versions.expires_at
+	FROM user_role_versions
+	WHERE user_role_versions.user_id = ANY($1)
+	AND user_role_versions.valid_from <= $2
+	AND (user_role_versions.valid_to IS NULL OR user_role_versions.valid_to > $2)
+	AND (user_role_versions.expires_at IS NULL OR user_role_versions.expires_at > $2)
+	ORDER BY user_role_versions.id
+	`
+
+	rows, err := s.conn.Query(ctx, queryStmt, userIDs, at)
+	if err != nil {
+		return nil, faulterr.NewPostgresError(err, errMsg)
+	}
+	defer rows.Close()
+
+	result := []dbmodels.UserRole{}
+	for rows.Next() {
+		obj := dbmodels.UserRole{}
+		if err := rows.Scan(
+			&obj.UserID,
+			&obj.RoleID,
+			&obj.ExpiresAt,
+		); err != nil {
+			return nil, faulterr.NewPostgresError(err, errMsg)
+		}
+		result = append(result, obj)
+	}
+	return result, nil
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////
+//////////////////////////////////////////****Mutate****///////////////////////////////////////
+///////////////////////////////////////////////////////////////////////////////////////////////
+
+// Upsert assigns a role to a user, assigning it again replaces the expiry
+func (s *UserRoleStore) Upsert(ctx context.Context, tx pgx.Tx, arg *dbmodels.UserRole) (*dbmodels.UserRole, *faulterr.FaultErr) {
+	errMsg := "error when trying to upsert user role"
+
+	queryStmt := `
+	INSERT INTO
+	user_roles(
+		user_id,
+		role_id,
+		expires_at
+	)
+	VALUES ($1, $2, $3)
+	ON CONFLICT (user_id, role_id) DO UPDATE
+	SET
+		expires_at=EXCLUDED.expires_at
+	RETURNING *
+	`
+
+	row := tx.QueryRow(ctx, queryStmt,
+		arg.UserID,
+		arg.RoleID,
+		arg.ExpiresAt,
+	)
+
+	obj, err := s.scanRow(row)
+	if err != nil {
+		return nil, faulterr.NewPostgresError(err, errMsg)
+	}
+	if err := s.writeVersion(ctx, tx, obj.UserID, obj.RoleID); err != nil {
+		return nil, faulterr.NewPostgresError(err, errMsg)
+	}
+	return obj, nil
+}
+
+// Delete removes a role from a user and returns how many assignments were removed
+func (s *UserRoleStore) Delete(ctx context.Context, tx pgx.Tx, userID int64, roleID int64) (int64, *faulterr.FaultErr) {
+	errMsg := "error when trying to delete user role"
+
+	queryStmt := `DELETE FROM user_roles WHERE user_id=$1 AND role_id=$2`
+
+	tag, err := tx.Exec(ctx, queryStmt, userID, roleID)
+	if err != nil {
+		return 0, faulterr.NewPostgresError(err, errMsg)
+	}
+	if err := s.writeVersion(ctx, tx, userID, roleID); err != nil {
+		return 0, faulterr.NewPostgresError(err, errMsg)
+	}
+	return tag.RowsAffected(), nil
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////
+//////////////////////////////////////////****Helpers****//////////////////////////////////////
+///////////////////////////////////////////////////////////////////////////////////////////////
+
+func (s *UserRoleStore) scanRows(rows pgx.Rows) ([]dbmodels.UserRole, error) {
+	result := []dbmodels.UserRole{}
+
+	for rows.Next() {
+		obj, err := s.scanRow(rows)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, *obj)
+	}
+	return result, nil
+}
+
+func (s *UserRoleStore) scanRow(row pgx.Row) (*dbmodels.UserRole, error) {
+	obj := dbmodels.UserRole{}
+
+	if err := row.Scan(
+		&obj.ID,
+		&obj.UserID,
+		&obj.RoleID,
+		&obj.ExpiresAt,
+		&obj.CreatedAt,
+		&obj.UpdatedAt,
+	); err != nil {
+		return nil, err
+	}
+	return &obj, nil
+}
+
+// writeVersion closes the current version of an assignment when it no longer matches the assignment
+// and records the assignment as its new version, a removed assignment only gets its version closed
+func (s *UserRoleStore) writeVersion(ctx context.Context, tx pgx.Tx, userID int64, roleID int64) error {
+	closeStmt := `
+	UPDATE user_role_versions
+	SET valid_to=NOW()
+	WHERE user_role_versions.user_id = $1
+	AND user_role_versions.role_id = $2
+	AND user_role_versions.valid_to IS NULL
+	AND NOT EXISTS (
+		SELECT 1 FROM user_roles
+		WHERE user_roles.user_id = user_role_versions.user_id
+		AND user_roles.role_id = user_role_versions.role_id
+		AND user_roles.expires_at IS NOT DISTINCT FROM user_role_versions.expires_at
+	)
+	`
+	if _, err := tx.Exec(ctx, closeStmt, userID, roleID); err != nil {
+		return err
+	}
+
+	insertStmt := `
+	INSERT INTO
+	user_role_versions(
+		user_id,
+		role_id,
+		expires_at,
+		valid_from
+	)
+	SELECT user_id, role_id, expires_at, NOW()
+	FROM user_roles
+	WHERE user_roles.user_id = $1
+	AND user_roles.role_id = $2
+	AND NOT EXISTS (
+		SELECT 1 FROM user_role_versions
+		WHERE user_role_versions.user_id = user_roles.user_id
+		AND user_role_versions.role_id = user_roles.role_id
+		AND user_role_versions.valid_to IS NULL
+	)
+	`
+	_, err := tx.Exec(ctx, insertStmt, userID, roleID)
+	return err
+}
+
+// closeRemovedVersions closes the current versions of the assignments of a user or a role that no
+// longer exist, deleting a user or a role removes its assignments by cascade without writing versions
+func closeRemovedVersions(ctx context.Context, tx pgx.Tx, userID null.Int64, roleID null.Int64) error {
+	queryStmt := `
+	UPDATE user_role_versions
+	SET valid_to=NOW()
+	WHERE ($1::BIGINT IS NULL OR user_role_versions.user_id = $1)
+	AND ($2::BIGINT IS NULL OR user_role_versions.role_id = $2)
+	AND user_role_versions.valid_to IS NULL
+	AND NOT EXISTS (
+		SELECT 1 FROM user_roles
+		WHERE user_roles.user_id = user_role_versions.user_id
+		AND user_roles.role_id = user_role_versions.role_id
+	)
+	`
+	_, err := tx.Exec(ctx, queryStmt, userID, roleID)
+	return err
+}
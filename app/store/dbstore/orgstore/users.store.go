@@ -7,12 +7,15 @@ import (
 	"gogql/app/models/dbmodels"
 	"gogql/app/store/dbstore/dbhelpers"
 	"gogql/utils/faulterr"
+	"gogql/utils/phone"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/gofrs/uuid"
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/volatiletech/null"
 )
 
 type UserStore struct {
@@ -24,13 +27,15 @@ var _ UserStoreInterface = &UserStore{}
 type UserStoreInterface interface {
 	GetManyByIDs(ctx context.Context, ids []int64) ([]*dbmodels.User, *faulterr.FaultErr)
 
-	List(ctx context.Context, filter models.SearchFilter, orgUID *uuid.UUID, roleID *int64) ([]dbmodels.User, int, *faulterr.FaultErr)
+	List(ctx context.Context, filter models.SearchFilter, orgUID *uuid.UUID, roleID *int64, scope models.PermissionScope) ([]dbmodels.User, int, *faulterr.FaultErr)
 	GetByID(ctx context.Context, id int64) (*dbmodels.User, *faulterr.FaultErr)
 	GetByEmail(ctx context.Context, email string) (*dbmodels.User, *faulterr.FaultErr)
-	GetByPhone(ctx context.Context, phone string) (*dbmodels.User, *faulterr.FaultErr)
+	GetByPhone(ctx context.Context, number string, region string) (*dbmodels.User, *faulterr.FaultErr)
+	ListAsOf(ctx context.Context, ids []int64, orgUID *uuid.UUID, at time.Time) ([]dbmodels.User, *faulterr.FaultErr)
 
 	Insert(ctx context.Context, tx pgx.Tx, u dbmodels.User) (*dbmodels.User, *faulterr.FaultErr)
 	Update(ctx context.Context, tx pgx.Tx, u dbmodels.User) *faulterr.FaultErr
+	UpdateLoginAttempts(ctx context.Context, tx pgx.Tx, u dbmodels.User) *faulterr.FaultErr
 	Delete(ctx context.Context, tx pgx.Tx, id int64) *faulterr.FaultErr
 }
 
@@ -74,23 +79,36 @@ func (s *UserStore) GetManyByIDs(ctx context.Context, ids []int64) ([]*dbmodels.
 	return result, nil
 }
 
-// List gets all users
-func (s *UserStore) List(ctx context.Context, filter models.SearchFilter, orgUID *uuid.UUID, roleID *int64) ([]dbmodels.User, int, *faulterr.FaultErr) {
+// List gets all users, a role lists the users it is the primary role of or assigned to unexpired,
+// a scope only lists the users holding a role of its departments and its user
+func (s *UserStore) List(ctx context.Context, filter models.SearchFilter, orgUID *uuid.UUID, roleID *int64, scope models.PermissionScope) ([]dbmodels.User, int, *faulterr.FaultErr) {
 	errMsg := "error when trying to get users"
 
 	// define query
 	selectQuery := `SELECT * FROM users`
 	conditionsQuery := `
 	WHERE ($1::UUID IS NULL OR $1 = org_uid)
-	AND ($2::INTEGER IS NULL OR $2 = role_id)
+	AND ($2::INTEGER IS NULL OR $2 = role_id OR id IN (
+		SELECT user_roles.user_id FROM user_roles
+		WHERE user_roles.role_id = $2
+		AND (user_roles.expires_at IS NULL OR user_roles.expires_at > NOW())
+	))
 	AND ($3::BOOLEAN IS NULL OR $3 = is_final)
 	AND ($4::BOOLEAN IS NULL OR $4 = is_archived)
+	AND (($5::INTEGER[] IS NULL AND $6::INTEGER IS NULL)
+		OR id = $6
+		OR id IN (
+			SELECT user_roles.user_id FROM user_roles
+			INNER JOIN roles ON roles.id = user_roles.role_id
+			WHERE roles.department_id = ANY($5)
+			AND (user_roles.expires_at IS NULL OR user_roles.expires_at > NOW())
+		))
 	`
 	filterQuery := dbhelpers.ResolveFilterSort(dbhelpers.UsersTable, filter)
 	queryStmt := fmt.Sprintf("%s %s %s", selectQuery, conditionsQuery, filterQuery)
 
 	var queryArgs []interface{}
-	queryArgs = append(queryArgs, orgUID, roleID, filter.IsFinal, filter.IsArchived)
+	queryArgs = append(queryArgs, orgUID, roleID, filter.IsFinal, filter.IsArchived, scope.DepartmentIDs, scope.UserID)
 
 	// query rows
 	rows, err := s.conn.Query(ctx, queryStmt, queryArgs...)
@@ -140,13 +158,19 @@ func (s *UserStore) GetByEmail(ctx context.Context, email string) (*dbmodels.Use
 	return obj, nil
 }
 
-// GetByPhone User
-func (s *UserStore) GetByPhone(ctx context.Context, phone string) (*dbmodels.User, *faulterr.FaultErr) {
-	errMsg := fmt.Sprintf("error when trying to get user by phone - %s", phone)
+// GetByPhone User, phones are stored in E.164 so the number is normalized first with
+// national numbers read in the region, numbers that can not be normalized match no user
+func (s *UserStore) GetByPhone(ctx context.Context, number string, region string) (*dbmodels.User, *faulterr.FaultErr) {
+	errMsg := fmt.Sprintf("error when trying to get user by phone - %s", number)
+
+	normalized, nerr := phone.Normalize(number, region)
+	if nerr != nil {
+		return nil, faulterr.NewNotFoundError(errMsg)
+	}
 
 	queryStmt := `SELECT * FROM users WHERE phone=$1`
 
-	row := s.conn.QueryRow(ctx, queryStmt, phone)
+	row := s.conn.QueryRow(ctx, queryStmt, normalized)
 	obj, err := s.scanRow(row)
 	if err != nil {
 		return nil, faulterr.NewPostgresError(err, errMsg)
@@ -154,6 +178,52 @@ func (s *UserStore) GetByPhone(ctx context.Context, phone string) (*dbmodels.Use
 	return obj, nil
 }
 
+// ListAsOf gets users as they were at a point in time from their versions, the users of every id
+// or of an organization and the super admins when ids are left empty. Only the columns permissions
+// are decided on are versioned and users deleted by then are left out.
+func (s *UserStore) ListAsOf(ctx context.Context, ids []int64, orgUID *uuid.UUID, at time.Time) ([]dbmodels.User, *faulterr.FaultErr) {
+	errMsg := "error when trying to get users as of a time"
+
+	queryStmt := `
+	SELECT
+		user_versions.user_id,
+		user_versions.org_uid,
+		user_versions.role_id,
+		user_versions.is_admin,
+		user_versions.is_archived,
+		user_versions.status
+	FROM user_versions
+	WHERE ($1::BIGINT[] IS NULL OR user_versions.user_id = ANY($1))
+	AND ($2::UUID IS NULL OR user_versions.org_uid = $2 OR user_versions.is_admin)
+	AND user_versions.valid_from <= $3
+	AND (user_versions.valid_to IS NULL OR user_versions.valid_to > $3)
+	ORDER BY user_versions.user_id
+	`
+
+	rows, err := s.conn.Query(ctx, queryStmt, ids, orgUID, at)
+	if err != nil {
+		return nil, faulterr.NewPostgresError(err, errMsg)
+	}
+	defer rows.Close()
+
+	result := []dbmodels.User{}
+	for rows.Next() {
+		obj := dbmodels.User{}
+		if err := rows.Scan(
+			&obj.ID,
+			&obj.OrgUID,
+			&obj.RoleID,
+			&obj.IsAdmin,
+			&obj.IsArchived,
+			&obj.Status,
+		); err != nil {
+			return nil, faulterr.NewPostgresError(err, errMsg)
+		}
+		result = append(result, obj)
+	}
+	return result, nil
+}
+
 ///////////////////////////////////////////////////////////////////////////////////////////////
 //////////////////////////////////////////****Mutate****///////////////////////////////////////
 ///////////////////////////////////////////////////////////////////////////////////////////////
@@ -195,6 +265,9 @@ func (s *UserStore) Insert(ctx context.Context, tx pgx.Tx, arg dbmodels.User) (*
 	if err != nil {
 		return nil, faulterr.NewPostgresError(err, errMsg)
 	}
+	if err := s.writeVersion(ctx, tx, obj.ID); err != nil {
+		return nil, faulterr.NewPostgresError(err, errMsg)
+	}
 	return obj, nil
 }
 
@@ -211,8 +284,9 @@ func (s *UserStore) Update(ctx context.Context, tx pgx.Tx, arg dbmodels.User) *f
 		phone=$4,
 		role_id=$5,
 		status=$6,
-		is_archived=$7
-	WHERE id=$8
+		is_final=$7,
+		is_archived=$8
+	WHERE id=$9
 	`
 
 	_, err := tx.Exec(ctx, queryStmt,
@@ -222,12 +296,41 @@ func (s *UserStore) Update(ctx context.Context, tx pgx.Tx, arg dbmodels.User) *f
 		&arg.Phone,
 		&arg.RoleID,
 		&arg.Status,
+		&arg.IsFinal,
 		&arg.IsArchived,
 		&arg.ID,
 	)
 	if err != nil {
 		return faulterr.NewPostgresError(err, errMsg)
 	}
+	if err := s.writeVersion(ctx, tx, arg.ID); err != nil {
+		return faulterr.NewPostgresError(err, errMsg)
+	}
+	return nil
+}
+
+// UpdateLoginAttempts updates the failed login counters and lockout of a user
+func (s *UserStore) UpdateLoginAttempts(ctx context.Context, tx pgx.Tx, arg dbmodels.User) *faulterr.FaultErr {
+	errMsg := "error when trying to update user login attempts"
+
+	queryStmt := `
+	UPDATE users
+	SET
+		failed_login_count=$1,
+		last_failed_login_at=$2,
+		locked_until=$3
+	WHERE id=$4
+	`
+
+	_, err := tx.Exec(ctx, queryStmt,
+		&arg.FailedLoginCount,
+		&arg.LastFailedLoginAt,
+		&arg.LockedUntil,
+		&arg.ID,
+	)
+	if err != nil {
+		return faulterr.NewPostgresError(err, errMsg)
+	}
 	return nil
 }
 
@@ -239,6 +342,12 @@ func (s *UserStore) Delete(ctx context.Context, tx pgx.Tx, id int64) *faulterr.F
 	if err != nil {
 		return faulterr.NewPostgresError(err, "error when trying to delete user")
 	}
+	if err := s.writeVersion(ctx, tx, id); err != nil {
+		return faulterr.NewPostgresError(err, "error when trying to delete user")
+	}
+	if err := closeRemovedVersions(ctx, tx, null.Int64From(id), null.Int64{}); err != nil {
+		return faulterr.NewPostgresError(err, "error when trying to delete user")
+	}
 	return nil
 }
 
@@ -265,6 +374,9 @@ func (s *UserStore) scanRows(rows pgx.Rows) ([]dbmodels.User, error) {
 			&obj.IsArchived,
 			&obj.CreatedAt,
 			&obj.UpdatedAt,
+			&obj.FailedLoginCount,
+			&obj.LastFailedLoginAt,
+			&obj.LockedUntil,
 		); err != nil {
 			return nil, err
 		}
@@ -289,8 +401,55 @@ func (s *UserStore) scanRow(row pgx.Row) (*dbmodels.User, error) {
 		&obj.IsArchived,
 		&obj.CreatedAt,
 		&obj.UpdatedAt,
+		&obj.FailedLoginCount,
+		&obj.LastFailedLoginAt,
+		&obj.LockedUntil,
 	); err != nil {
 		return nil, err
 	}
 	return obj, nil
 }
+
+// writeVersion closes the current version of a user when it no longer matches the user and
+// records the user as its new version, a deleted user only gets its version closed
+func (s *UserStore) writeVersion(ctx context.Context, tx pgx.Tx, id int64) error {
+	closeStmt := `
+	UPDATE user_versions
+	SET valid_to=NOW()
+	WHERE user_versions.user_id = $1
+	AND user_versions.valid_to IS NULL
+	AND NOT EXISTS (
+		SELECT 1 FROM users
+		WHERE users.id = user_versions.user_id
+		AND (users.org_uid, users.role_id, users.is_admin, users.is_archived, users.status)
+		IS NOT DISTINCT FROM (user_versions.org_uid, user_versions.role_id, user_versions.is_admin,
+			user_versions.is_archived, user_versions.status)
+	)
+	`
+	if _, err := tx.Exec(ctx, closeStmt, id); err != nil {
+		return err
+	}
+
+	insertStmt := `
+	INSERT INTO
+	user_versions(
+		user_id,
+		org_uid,
+		role_id,
+		is_admin,
+		is_archived,
+		status,
+		valid_from
+	)
+	SELECT id, org_uid, role_id, is_admin, is_archived, status, NOW()
+	FROM users
+	WHERE users.id = $1
+	AND NOT EXISTS (
+		SELECT 1 FROM user_versions
+		WHERE user_versions.user_id = users.id
+		AND user_versions.valid_to IS NULL
+	)
+	`
+	_, err := tx.Exec(ctx, insertStmt, id)
+	return err
+}
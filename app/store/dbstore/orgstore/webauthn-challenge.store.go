@@ -0,0 +1,122 @@
+package orgstore
+
+import (
+	"context"
+	"gogql/app/models/dbmodels"
+	"gogql/utils/faulterr"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type WebAuthnChallengeStore struct {
+	conn *pgxpool.Pool
+}
+
+var _ WebAuthnChallengeStoreInterface = &WebAuthnChallengeStore{}
+
+type WebAuthnChallengeStoreInterface interface {
+	GetByChallengeHash(ctx context.Context, ceremony string, challengeHash string) (*dbmodels.WebAuthnChallenge, *faulterr.FaultErr)
+
+	Insert(ctx context.Context, tx pgx.Tx, arg *dbmodels.WebAuthnChallenge) (*dbmodels.WebAuthnChallenge, *faulterr.FaultErr)
+	MarkUsed(ctx context.Context, tx pgx.Tx, id int64) (bool, *faulterr.FaultErr)
+}
+
+func NewWebAuthnChallengeStore(conn *pgxpool.Pool) *WebAuthnChallengeStore {
+	return &WebAuthnChallengeStore{conn}
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////
+//////////////////////////////////////////****Read****/////////////////////////////////////////
+///////////////////////////////////////////////////////////////////////////////////////////////
+
+// GetByChallengeHash gets a challenge of a ceremony by its hash
+func (s *WebAuthnChallengeStore) GetByChallengeHash(ctx context.Context, ceremony string, challengeHash string) (*dbmodels.WebAuthnChallenge, *faulterr.FaultErr) {
+	errMsg := "error when trying to get webauthn challenge"
+
+	queryStmt := `SELECT * FROM webauthn_challenges WHERE ceremony=$1 AND challenge_hash=$2`
+
+	row := s.conn.QueryRow(ctx, queryStmt, ceremony, challengeHash)
+	obj, err := s.scanRow(row)
+	if err != nil {
+		return nil, faulterr.NewPostgresError(err, errMsg)
+	}
+	return obj, nil
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////
+//////////////////////////////////////////****Mutate****///////////////////////////////////////
+///////////////////////////////////////////////////////////////////////////////////////////////
+
+// Insert inserts a challenge
+func (s *WebAuthnChallengeStore) Insert(ctx context.Context, tx pgx.Tx, arg *dbmodels.WebAuthnChallenge) (*dbmodels.WebAuthnChallenge, *faulterr.FaultErr) {
+	errMsg := "error when trying to insert webauthn challenge"
+
+	queryStmt := `
+	INSERT INTO
+	webauthn_challenges(
+		user_id,
+		ceremony,
+		challenge_hash,
+		ip_address,
+		expires_at
+	)
+	VALUES ($1, $2, $3, $4, $5)
+	RETURNING *
+	`
+
+	row := tx.QueryRow(ctx, queryStmt,
+		arg.UserID,
+		arg.Ceremony,
+		arg.ChallengeHash,
+		arg.IPAddress,
+		arg.ExpiresAt,
+	)
+
+	obj, err := s.scanRow(row)
+	if err != nil {
+		return nil, faulterr.NewPostgresError(err, errMsg)
+	}
+	return obj, nil
+}
+
+// MarkUsed marks an unused challenge as used, it returns false if the challenge was already used
+func (s *WebAuthnChallengeStore) MarkUsed(ctx context.Context, tx pgx.Tx, id int64) (bool, *faulterr.FaultErr) {
+	errMsg := "error when trying to mark webauthn challenge as used"
+
+	queryStmt := `
+	UPDATE webauthn_challenges
+	SET
+		is_used=TRUE
+	WHERE id=$1
+	AND is_used=FALSE
+	`
+
+	tag, err := tx.Exec(ctx, queryStmt, id)
+	if err != nil {
+		return false, faulterr.NewPostgresError(err, errMsg)
+	}
+	return tag.RowsAffected() == 1, nil
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////
+//////////////////////////////////////////****Helpers****//////////////////////////////////////
+///////////////////////////////////////////////////////////////////////////////////////////////
+
+func (s *WebAuthnChallengeStore) scanRow(row pgx.Row) (*dbmodels.WebAuthnChallenge, error) {
+	obj := &dbmodels.WebAuthnChallenge{}
+	if err := row.Scan(
+		&obj.ID,
+		&obj.UserID,
+		&obj.Ceremony,
+		&obj.ChallengeHash,
+		&obj.IPAddress,
+		&obj.IsUsed,
+		&obj.ExpiresAt,
+		&obj.CreatedAt,
+		&obj.UpdatedAt,
+	); err != nil {
+		return nil, err
+	}
+	return obj, nil
+}
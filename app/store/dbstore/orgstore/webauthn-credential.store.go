@@ -0,0 +1,206 @@
+package orgstore
+
+import (
+	"context"
+	"gogql/app/models/dbmodels"
+	"gogql/utils/faulterr"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type WebAuthnCredentialStore struct {
+	conn *pgxpool.Pool
+}
+
+var _ WebAuthnCredentialStoreInterface = &WebAuthnCredentialStore{}
+
+type WebAuthnCredentialStoreInterface interface {
+	ListByUserID(ctx context.Context, userID int64) ([]dbmodels.WebAuthnCredential, *faulterr.FaultErr)
+	GetByID(ctx context.Context, id int64) (*dbmodels.WebAuthnCredential, *faulterr.FaultErr)
+	GetByCredentialID(ctx context.Context, credentialID string) (*dbmodels.WebAuthnCredential, *faulterr.FaultErr)
+
+	Insert(ctx context.Context, tx pgx.Tx, arg *dbmodels.WebAuthnCredential) (*dbmodels.WebAuthnCredential, *faulterr.FaultErr)
+	Update(ctx context.Context, tx pgx.Tx, arg *dbmodels.WebAuthnCredential) *faulterr.FaultErr
+	Delete(ctx context.Context, tx pgx.Tx, id int64) *faulterr.FaultErr
+}
+
+func NewWebAuthnCredentialStore(conn *pgxpool.Pool) *WebAuthnCredentialStore {
+	return &WebAuthnCredentialStore{conn}
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////
+//////////////////////////////////////////****Read****/////////////////////////////////////////
+///////////////////////////////////////////////////////////////////////////////////////////////
+
+// ListByUserID lists the passkeys of a user
+func (s *WebAuthnCredentialStore) ListByUserID(ctx context.Context, userID int64) ([]dbmodels.WebAuthnCredential, *faulterr.FaultErr) {
+	errMsg := "error when trying to list webauthn credentials by user id"
+
+	queryStmt := `SELECT * FROM webauthn_credentials WHERE user_id=$1 ORDER BY created_at`
+
+	rows, err := s.conn.Query(ctx, queryStmt, userID)
+	if err != nil {
+		return nil, faulterr.NewPostgresError(err, errMsg)
+	}
+	defer rows.Close()
+
+	result, err := s.scanRows(rows)
+	if err != nil {
+		return nil, faulterr.NewPostgresError(err, errMsg)
+	}
+	return result, nil
+}
+
+// GetByID gets a passkey by id
+func (s *WebAuthnCredentialStore) GetByID(ctx context.Context, id int64) (*dbmodels.WebAuthnCredential, *faulterr.FaultErr) {
+	errMsg := "error when trying to get webauthn credential by id"
+
+	queryStmt := `SELECT * FROM webauthn_credentials WHERE id=$1`
+
+	row := s.conn.QueryRow(ctx, queryStmt, id)
+	obj, err := s.scanRow(row)
+	if err != nil {
+		return nil, faulterr.NewPostgresError(err, errMsg)
+	}
+	return obj, nil
+}
+
+// GetByCredentialID gets a passkey by the base64url credential id chosen by the authenticator
+func (s *WebAuthnCredentialStore) GetByCredentialID(ctx context.Context, credentialID string) (*dbmodels.WebAuthnCredential, *faulterr.FaultErr) {
+	errMsg := "error when trying to get webauthn credential by credential id"
+
+	queryStmt := `SELECT * FROM webauthn_credentials WHERE credential_id=$1`
+
+	row := s.conn.QueryRow(ctx, queryStmt, credentialID)
+	obj, err := s.scanRow(row)
+	if err != nil {
+		return nil, faulterr.NewPostgresError(err, errMsg)
+	}
+	return obj, nil
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////
+//////////////////////////////////////////****Mutate****///////////////////////////////////////
+///////////////////////////////////////////////////////////////////////////////////////////////
+
+// Insert inserts a passkey
+func (s *WebAuthnCredentialStore) Insert(ctx context.Context, tx pgx.Tx, arg *dbmodels.WebAuthnCredential) (*dbmodels.WebAuthnCredential, *faulterr.FaultErr) {
+	errMsg := "error when trying to insert webauthn credential"
+
+	queryStmt := `
+	INSERT INTO
+	webauthn_credentials(
+		user_id,
+		credential_id,
+		public_key,
+		algorithm,
+		sign_count,
+		name
+	)
+	VALUES ($1, $2, $3, $4, $5, $6)
+	RETURNING *
+	`
+
+	row := tx.QueryRow(ctx, queryStmt,
+		arg.UserID,
+		arg.CredentialID,
+		arg.PublicKey,
+		arg.Algorithm,
+		arg.SignCount,
+		arg.Name,
+	)
+
+	obj, err := s.scanRow(row)
+	if err != nil {
+		return nil, faulterr.NewPostgresError(err, errMsg)
+	}
+	return obj, nil
+}
+
+// Update updates the sign count, clone flag and last use of a passkey
+func (s *WebAuthnCredentialStore) Update(ctx context.Context, tx pgx.Tx, arg *dbmodels.WebAuthnCredential) *faulterr.FaultErr {
+	errMsg := "error when trying to update webauthn credential"
+
+	queryStmt := `
+	UPDATE webauthn_credentials
+	SET
+		sign_count=$1,
+		name=$2,
+		is_cloned=$3,
+		last_used_at=$4
+	WHERE id=$5
+	`
+
+	_, err := tx.Exec(ctx, queryStmt,
+		arg.SignCount,
+		arg.Name,
+		arg.IsCloned,
+		arg.LastUsedAt,
+		arg.ID,
+	)
+	if err != nil {
+		return faulterr.NewPostgresError(err, errMsg)
+	}
+	return nil
+}
+
+// Delete deletes a passkey
+func (s *WebAuthnCredentialStore) Delete(ctx context.Context, tx pgx.Tx, id int64) *faulterr.FaultErr {
+	queryStmt := `DELETE FROM webauthn_credentials WHERE id=$1`
+
+	_, err := tx.Exec(ctx, queryStmt, id)
+	if err != nil {
+		return faulterr.NewPostgresError(err, "error when trying to delete webauthn credential")
+	}
+	return nil
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////
+//////////////////////////////////////////****Helpers****//////////////////////////////////////
+///////////////////////////////////////////////////////////////////////////////////////////////
+
+func (s *WebAuthnCredentialStore) scanRows(rows pgx.Rows) ([]dbmodels.WebAuthnCredential, error) {
+	result := []dbmodels.WebAuthnCredential{}
+	obj := dbmodels.WebAuthnCredential{}
+
+	for rows.Next() {
+		if err := rows.Scan(
+			&obj.ID,
+			&obj.UserID,
+			&obj.CredentialID,
+			&obj.PublicKey,
+			&obj.Algorithm,
+			&obj.SignCount,
+			&obj.Name,
+			&obj.IsCloned,
+			&obj.LastUsedAt,
+			&obj.CreatedAt,
+			&obj.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		result = append(result, obj)
+	}
+	return result, nil
+}
+
+func (s *WebAuthnCredentialStore) scanRow(row pgx.Row) (*dbmodels.WebAuthnCredential, error) {
+	obj := &dbmodels.WebAuthnCredential{}
+	if err := row.Scan(
+		&obj.ID,
+		&obj.UserID,
+		&obj.CredentialID,
+		&obj.PublicKey,
+		&obj.Algorithm,
+		&obj.SignCount,
+		&obj.Name,
+		&obj.IsCloned,
+		&obj.LastUsedAt,
+		&obj.CreatedAt,
+		&obj.UpdatedAt,
+	); err != nil {
+		return nil, err
+	}
+	return obj, nil
+}
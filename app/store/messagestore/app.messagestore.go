@@ -0,0 +1,55 @@
+package messagestore
+
+import (
+	"context"
+	"gogql/config"
+	"gogql/utils/faulterr"
+
+	"github.com/aws/aws-sdk-go/aws/session"
+)
+
+// Message is a single email or sms to be delivered
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Sender delivers a message through a single channel
+type Sender interface {
+	Send(ctx context.Context, msg Message) *faulterr.FaultErr
+}
+
+type MessageStore struct {
+	EmailSender Sender
+	SMSSender   Sender
+}
+
+// NewMessageStore picks the senders from messaging config, any channel without a provider
+// falls back to the message sink, config only allows it when the sink is enabled
+func NewMessageStore(sess *session.Session, conf *config.Messaging) *MessageStore {
+	sink := NewSinkSender(conf.SinkPath)
+	store := &MessageStore{
+		EmailSender: sink,
+		SMSSender:   sink,
+	}
+
+	if conf.SMTPHost != "" {
+		store.EmailSender = NewSMTPSender(conf.SMTPHost, conf.SMTPPort, conf.SMTPUsername, conf.SMTPPassword, conf.SMTPFrom)
+	}
+	if conf.SMSProvider == config.SMSProviderSNS {
+		store.SMSSender = NewSNSSender(sess, conf.SMSSenderID)
+	}
+
+	return store
+}
+
+// SendEmail delivers a message to an email address
+func (ms *MessageStore) SendEmail(ctx context.Context, msg Message) *faulterr.FaultErr {
+	return ms.EmailSender.Send(ctx, msg)
+}
+
+// SendSMS delivers a message to a phone number
+func (ms *MessageStore) SendSMS(ctx context.Context, msg Message) *faulterr.FaultErr {
+	return ms.SMSSender.Send(ctx, msg)
+}
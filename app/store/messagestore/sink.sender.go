@@ -0,0 +1,60 @@
+package messagestore
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"gogql/utils/faulterr"
+	"gogql/utils/logger"
+	"os"
+	"sync"
+	"time"
+)
+
+// SinkSender writes messages to a local file, or logs their recipient when no path is set.
+// It is meant for local development and tests, never for production.
+type SinkSender struct {
+	path string
+	mu   sync.Mutex
+}
+
+var _ Sender = &SinkSender{}
+
+func NewSinkSender(path string) *SinkSender {
+	return &SinkSender{path: path}
+}
+
+type sinkEntry struct {
+	To      string    `json:"to"`
+	Subject string    `json:"subject"`
+	Body    string    `json:"body"`
+	SentAt  time.Time `json:"sentAt"`
+}
+
+// Send appends the message as a json line to the sink file, the log never gets the body
+// as it holds otps and tokens
+func (s *SinkSender) Send(ctx context.Context, msg Message) *faulterr.FaultErr {
+	if s.path == "" {
+		logger.Info(fmt.Sprintf("message to %s: %s", msg.To, msg.Subject))
+		return nil
+	}
+
+	line, err := json.Marshal(sinkEntry{msg.To, msg.Subject, msg.Body, time.Now().UTC()})
+	if err != nil {
+		return faulterr.NewInternalServerError(err.Error())
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	file, err := os.OpenFile(s.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return faulterr.NewInternalServerError(fmt.Sprintf("error when trying to open message sink: %s", err.Error()))
+	}
+	defer file.Close()
+
+	if _, err := file.Write(append(line, '\n')); err != nil {
+		return faulterr.NewInternalServerError(fmt.Sprintf("error when trying to write message sink: %s", err.Error()))
+	}
+	return nil
+}
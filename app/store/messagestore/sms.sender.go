@@ -0,0 +1,48 @@
+package messagestore
+
+import (
+	"context"
+	"fmt"
+	"gogql/utils/faulterr"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/sns"
+)
+
+type SNSSender struct {
+	SNS      *sns.SNS
+	senderID string
+}
+
+var _ Sender = &SNSSender{}
+
+func NewSNSSender(sess *session.Session, senderID string) *SNSSender {
+	return &SNSSender{sns.New(sess), senderID}
+}
+
+// Send delivers a transactional sms through aws sns
+func (s *SNSSender) Send(ctx context.Context, msg Message) *faulterr.FaultErr {
+	attributes := map[string]*sns.MessageAttributeValue{
+		"AWS.SNS.SMS.SMSType": {
+			DataType:    aws.String("String"),
+			StringValue: aws.String("Transactional"),
+		},
+	}
+	if s.senderID != "" {
+		attributes["AWS.SNS.SMS.SenderID"] = &sns.MessageAttributeValue{
+			DataType:    aws.String("String"),
+			StringValue: aws.String(s.senderID),
+		}
+	}
+
+	_, err := s.SNS.PublishWithContext(ctx, &sns.PublishInput{
+		PhoneNumber:       aws.String(msg.To),
+		Message:           aws.String(msg.Body),
+		MessageAttributes: attributes,
+	})
+	if err != nil {
+		return faulterr.NewInternalServerError(fmt.Sprintf("error when trying to send sms: %s", err.Error()))
+	}
+	return nil
+}
@@ -0,0 +1,46 @@
+package messagestore
+
+import (
+	"context"
+	"fmt"
+	"gogql/utils/faulterr"
+	"net/smtp"
+	"strings"
+)
+
+type SMTPSender struct {
+	host     string
+	port     int64
+	username string
+	password string
+	from     string
+}
+
+var _ Sender = &SMTPSender{}
+
+func NewSMTPSender(host string, port int64, username, password, from string) *SMTPSender {
+	return &SMTPSender{host, port, username, password, from}
+}
+
+// Send delivers an email through the smtp server
+func (s *SMTPSender) Send(ctx context.Context, msg Message) *faulterr.FaultErr {
+	var auth smtp.Auth
+	if s.username != "" {
+		auth = smtp.PlainAuth("", s.username, s.password, s.host)
+	}
+
+	headers := []string{
+		fmt.Sprintf("From: %s", s.from),
+		fmt.Sprintf("To: %s", msg.To),
+		fmt.Sprintf("Subject: %s", msg.Subject),
+		"MIME-Version: 1.0",
+		"Content-Type: text/plain; charset=\"utf-8\"",
+	}
+	body := strings.Join(headers, "\r\n") + "\r\n\r\n" + msg.Body
+
+	addr := fmt.Sprintf("%s:%d", s.host, s.port)
+	if err := smtp.SendMail(addr, auth, s.from, []string{msg.To}, []byte(body)); err != nil {
+		return faulterr.NewInternalServerError(fmt.Sprintf("error when trying to send email: %s", err.Error()))
+	}
+	return nil
+}
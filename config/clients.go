@@ -10,4 +10,7 @@ type Clients struct {
 	AWSSession   *session.Session
 	AWSRegion    string
 	S3BucketName string
+	Messaging    *Messaging
+	Security     *Security
+	JWT          *JWT
 }
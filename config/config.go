@@ -4,9 +4,12 @@ import (
 	"fmt"
 	"gogql/settings/cloud"
 	"gogql/settings/database/postgres"
+	"gogql/utils/encrypt"
 	"log"
+	"net"
 	"os"
 	"strconv"
+	"time"
 
 	"encoding/base64"
 	"encoding/json"
@@ -19,13 +22,34 @@ import (
 
 const (
 	defaultServerAddress string = ":8080"
+	defaultSMTPPort      int64  = 587
+
+	defaultAccessTokenTTL          = 15 * time.Minute
+	defaultSessionIdleTimeout      = 7 * 24 * time.Hour
+	defaultSessionAbsoluteLifetime = 30 * 24 * time.Hour
+	defaultTOTPIssuer              = "gogql"
+	defaultWebAuthnRPID            = "localhost"
+	defaultWebAuthnOrigin          = "http://localhost:3000"
+	defaultOIDCCallbackBaseURL     = "http://localhost:8080"
+	defaultImpersonationLifetime   = 30 * time.Minute
+	defaultInvitationTTL           = 72 * time.Hour
+	defaultInvitationAcceptURL     = "http://localhost:3000/invitations/accept"
+	defaultContactChangeCancelURL  = "http://localhost:3000/contact-change/cancel"
+	defaultPhoneRegion             = "IN"
+	defaultAuthCacheTTL            = 30 * time.Second
 )
 
+// SMSProviderSNS delivers sms through aws sns, it is the only sms provider
+const SMSProviderSNS string = "sns"
+
 // Config stores all configurations of the application
 type Config struct {
 	Server         *Server
 	DBCreds        *DBCreds
 	AWSCredentails *AWSCredentails
+	Messaging      *Messaging
+	Security       *Security
+	JWT            *JWT
 }
 
 type Server struct {
@@ -47,6 +71,89 @@ type AWSCredentails struct {
 	S3BucketName    string
 }
 
+// Messaging holds the settings for delivering emails and sms, messages go to the sink
+// when a provider is not configured and the sink is enabled for development
+type Messaging struct {
+	SMTPHost     string
+	SMTPPort     int64
+	SMTPUsername string
+	SMTPPassword string
+	SMTPFrom     string
+	SMSProvider  string
+	SMSSenderID  string
+	SinkEnabled  bool
+	SinkPath     string
+}
+
+// Security holds the keys used to protect stored secrets and the session lifetimes
+type Security struct {
+	TokenHashKey string
+	// SecretEncryptionKey encrypts the stored totp secrets and oidc client secrets, it is kept
+	// apart from TokenHashKey so rotating the hash key does not lose them
+	SecretEncryptionKey string
+
+	// AccessTokenTTL is how long a session token is valid before it has to be refreshed
+	AccessTokenTTL time.Duration
+	// SessionIdleTimeout ends a session that was not used for this long
+	SessionIdleTimeout time.Duration
+	// SessionAbsoluteLifetime ends a session this long after login regardless of refreshes
+	SessionAbsoluteLifetime time.Duration
+
+	// TOTPIssuer is the account issuer shown in authenticator apps
+	TOTPIssuer string
+
+	// WebAuthnRPID is the domain passkeys are bound to, WebAuthnOrigins are the
+	// origins of the frontends allowed to use them
+	WebAuthnRPID    string
+	WebAuthnRPName  string
+	WebAuthnOrigins []string
+
+	// OIDCCallbackBaseURL is the public base url of the api, identity providers
+	// redirect back to its /api/auth/oidc/{orgCode}/callback route
+	OIDCCallbackBaseURL string
+
+	// ImpersonationLifetime ends a session started by a super admin impersonating a user
+	// this long after it started regardless of refreshes
+	ImpersonationLifetime time.Duration
+
+	// InvitationTTL is how long an invitation can be accepted, InvitationAcceptURL is the
+	// frontend page invitations link to with the token as query parameter
+	InvitationTTL       time.Duration
+	InvitationAcceptURL string
+
+	// ContactChangeCancelURL is the frontend page the notification of an email or phone
+	// change links to with the cancel token as query parameter
+	ContactChangeCancelURL string
+
+	// DefaultPhoneRegion is the region national phone numbers are read in when the
+	// organization of the user is unknown, organizations set their own region
+	DefaultPhoneRegion string
+
+	// AuthCacheTTL is how long sessions and role permissions are cached in process,
+	// zero disables the cache
+	AuthCacheTTL time.Duration
+
+	// TrustedProxies are the addresses of the proxies in front of the api, the client ip is only
+	// read from the forwarding headers of requests coming through one of them
+	TrustedProxies []*net.IPNet
+}
+
+// JWT holds the keys for signing and verifying stateless tokens, keys other than
+// the signing key are only used for verification while they are rotated out
+type JWT struct {
+	SigningKeyID string
+	Keys         []JWTKey
+	TTL          time.Duration
+}
+
+// JWTKey is a HS256 secret or a PEM encoded RS256 / EdDSA key, given inline or as a file path
+type JWTKey struct {
+	ID        string `json:"kid"`
+	Algorithm string `json:"alg"`
+	Key       string `json:"key"`
+	KeyFile   string `json:"keyFile"`
+}
+
 func LookupEnv(key string) (string, bool) {
 	value, valid := os.LookupEnv(key)
 	return strings.TrimSpace(value), valid
@@ -95,6 +202,19 @@ func LoadConfig() (*Config, error) {
 		S3BucketName:    awsS3BucketName,
 	}
 
+	messaging, err := loadMessaging()
+	if err != nil {
+		return nil, err
+	}
+	security, err := loadSecurity()
+	if err != nil {
+		return nil, err
+	}
+	jwtConf, err := loadJWT()
+	if err != nil {
+		return nil, err
+	}
+
 	dbCreds, err := fetchDbCreds(awsCreds)
 	if err != nil {
 		// No warning here, all configurations require dbCreds.
@@ -107,11 +227,273 @@ func LoadConfig() (*Config, error) {
 		DBCreds:        dbCreds,
 		Server:         server,
 		AWSCredentails: awsCreds,
+		Messaging:      messaging,
+		Security:       security,
+		JWT:            jwtConf,
 	}
 
 	return config, nil
 }
 
+func loadSecurity() (*Security, error) {
+	tokenHashKey, err := loadTokenHashKey()
+	if err != nil {
+		return nil, err
+	}
+	secretEncryptionKey, err := loadSecretEncryptionKey(tokenHashKey)
+	if err != nil {
+		return nil, err
+	}
+
+	totpIssuer := Getenv("TOTP_ISSUER")
+	if totpIssuer == "" {
+		totpIssuer = defaultTOTPIssuer
+	}
+
+	webAuthnRPID := Getenv("WEBAUTHN_RP_ID")
+	if webAuthnRPID == "" {
+		webAuthnRPID = defaultWebAuthnRPID
+		log.Println("WARNING: webauthn rp id is missing, passkeys are bound to", defaultWebAuthnRPID)
+	}
+	webAuthnRPName := Getenv("WEBAUTHN_RP_NAME")
+	if webAuthnRPName == "" {
+		webAuthnRPName = totpIssuer
+	}
+	webAuthnOrigins := []string{}
+	for _, origin := range strings.Split(Getenv("WEBAUTHN_ORIGINS"), ",") {
+		if origin = strings.TrimSpace(origin); origin != "" {
+			webAuthnOrigins = append(webAuthnOrigins, origin)
+		}
+	}
+	if len(webAuthnOrigins) == 0 {
+		webAuthnOrigins = append(webAuthnOrigins, defaultWebAuthnOrigin)
+	}
+
+	oidcCallbackBaseURL := strings.TrimSuffix(Getenv("OIDC_CALLBACK_BASE_URL"), "/")
+	if oidcCallbackBaseURL == "" {
+		oidcCallbackBaseURL = defaultOIDCCallbackBaseURL
+	}
+
+	invitationAcceptURL := Getenv("INVITATION_ACCEPT_URL")
+	if invitationAcceptURL == "" {
+		invitationAcceptURL = defaultInvitationAcceptURL
+	}
+
+	phoneRegion := strings.ToUpper(Getenv("DEFAULT_PHONE_REGION"))
+	if phoneRegion == "" {
+		phoneRegion = defaultPhoneRegion
+	}
+
+	contactChangeCancelURL := Getenv("CONTACT_CHANGE_CANCEL_URL")
+	if contactChangeCancelURL == "" {
+		contactChangeCancelURL = defaultContactChangeCancelURL
+	}
+
+	trustedProxies, err := parseTrustedProxies(Getenv("TRUSTED_PROXIES"))
+	if err != nil {
+		return nil, err
+	}
+
+	return &Security{
+		TokenHashKey:            tokenHashKey,
+		SecretEncryptionKey:     secretEncryptionKey,
+		AccessTokenTTL:          getDuration("ACCESS_TOKEN_TTL", defaultAccessTokenTTL),
+		SessionIdleTimeout:      getDuration("SESSION_IDLE_TIMEOUT", defaultSessionIdleTimeout),
+		SessionAbsoluteLifetime: getDuration("SESSION_ABSOLUTE_LIFETIME", defaultSessionAbsoluteLifetime),
+		TOTPIssuer:              totpIssuer,
+		WebAuthnRPID:            webAuthnRPID,
+		WebAuthnRPName:          webAuthnRPName,
+		WebAuthnOrigins:         webAuthnOrigins,
+		OIDCCallbackBaseURL:     oidcCallbackBaseURL,
+		ImpersonationLifetime:   getDuration("IMPERSONATION_LIFETIME", defaultImpersonationLifetime),
+		InvitationTTL:           getDuration("INVITATION_TTL", defaultInvitationTTL),
+		InvitationAcceptURL:     invitationAcceptURL,
+		ContactChangeCancelURL:  contactChangeCancelURL,
+		DefaultPhoneRegion:      phoneRegion,
+		AuthCacheTTL:            getDuration("AUTH_CACHE_TTL", defaultAuthCacheTTL),
+		TrustedProxies:          trustedProxies,
+	}, nil
+}
+
+// parseTrustedProxies reads a comma separated list of ip addresses and cidr ranges
+func parseTrustedProxies(value string) ([]*net.IPNet, error) {
+	result := []*net.IPNet{}
+	for _, entry := range strings.Split(value, ",") {
+		if entry = strings.TrimSpace(entry); entry == "" {
+			continue
+		}
+		if !strings.Contains(entry, "/") {
+			ip := net.ParseIP(entry)
+			if ip == nil {
+				return nil, fmt.Errorf("trusted proxy %q is not an ip address or cidr range", entry)
+			}
+			bits := 8 * net.IPv6len
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 8*net.IPv4len
+			}
+			result = append(result, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		_, ipNet, err := net.ParseCIDR(entry)
+		if err != nil {
+			return nil, fmt.Errorf("trusted proxy %q is not an ip address or cidr range", entry)
+		}
+		result = append(result, ipNet)
+	}
+	return result, nil
+}
+
+// loadTokenHashKey fails when TOKEN_HASH_KEY is unset, a random key is only used when
+// TOKEN_HASH_EPHEMERAL_KEY is set for development
+func loadTokenHashKey() (string, error) {
+	tokenHashKey := Getenv("TOKEN_HASH_KEY")
+	if tokenHashKey != "" {
+		return tokenHashKey, nil
+	}
+	if Getenv("TOKEN_HASH_EPHEMERAL_KEY") != "true" {
+		return "", fmt.Errorf("token hash key is required unless TOKEN_HASH_EPHEMERAL_KEY is set for development")
+	}
+	log.Println("WARNING: token hash key is missing, using a random key, sessions will not survive a restart")
+	return encrypt.GenerateRandomKey(32), nil
+}
+
+// loadSecretEncryptionKey fails when SECRET_ENCRYPTION_KEY is unset or reuses the token hash key,
+// a random key would make the stored secrets unreadable after a restart
+func loadSecretEncryptionKey(tokenHashKey string) (string, error) {
+	key := Getenv("SECRET_ENCRYPTION_KEY")
+	if key == "" {
+		return "", fmt.Errorf("secret encryption key is required")
+	}
+	if key == tokenHashKey {
+		return "", fmt.Errorf("secret encryption key has to differ from the token hash key")
+	}
+	return key, nil
+}
+
+// loadJWT fails on keys that can not be read and on a signing kid matching none of them, a random
+// key is only used when JWT_KEYS is unset and JWT_EPHEMERAL_KEY is set for development
+func loadJWT() (*JWT, error) {
+	conf := &JWT{
+		SigningKeyID: Getenv("JWT_SIGNING_KID"),
+		TTL:          getDuration("JWT_TTL", defaultAccessTokenTTL),
+	}
+
+	keys := Getenv("JWT_KEYS")
+	if keys == "" {
+		if Getenv("JWT_EPHEMERAL_KEY") != "true" {
+			return nil, fmt.Errorf("jwt keys are required unless JWT_EPHEMERAL_KEY is set for development")
+		}
+		if conf.SigningKeyID != "" {
+			return nil, fmt.Errorf("jwt signing kid %s is set without jwt keys", conf.SigningKeyID)
+		}
+		conf.Keys = []JWTKey{{ID: "ephemeral", Algorithm: "HS256", Key: encrypt.GenerateRandomKey(32)}}
+		conf.SigningKeyID = "ephemeral"
+		log.Println("WARNING: jwt keys are missing, using a random key, jwts will not survive a restart")
+		return conf, nil
+	}
+
+	if err := json.Unmarshal([]byte(keys), &conf.Keys); err != nil {
+		return nil, fmt.Errorf("jwt keys are not valid json: %w", err)
+	}
+	if err := validateJWTKeys(conf); err != nil {
+		return nil, err
+	}
+	return conf, nil
+}
+
+// validateJWTKeys checks every key names its kid, a supported algorithm and key material,
+// the signing kid defaults to the first key
+func validateJWTKeys(conf *JWT) error {
+	if len(conf.Keys) == 0 {
+		return fmt.Errorf("jwt keys are empty")
+	}
+
+	seen := map[string]bool{}
+	for i, k := range conf.Keys {
+		if k.ID == "" {
+			return fmt.Errorf("jwt key %d has no kid", i)
+		}
+		if seen[k.ID] {
+			return fmt.Errorf("jwt key %s: duplicate kid", k.ID)
+		}
+		seen[k.ID] = true
+
+		switch k.Algorithm {
+		case "HS256", "RS256", "EdDSA":
+		default:
+			return fmt.Errorf("jwt key %s: unsupported algorithm %q", k.ID, k.Algorithm)
+		}
+		if k.Key == "" && k.KeyFile == "" {
+			return fmt.Errorf("jwt key %s: key or keyFile is required", k.ID)
+		}
+	}
+
+	if conf.SigningKeyID == "" {
+		conf.SigningKeyID = conf.Keys[0].ID
+	}
+	if !seen[conf.SigningKeyID] {
+		return fmt.Errorf("jwt signing kid %s does not match any jwt key", conf.SigningKeyID)
+	}
+	return nil
+}
+
+// getDuration reads a duration like "15m" or "168h" from env and falls back to the default
+func getDuration(key string, fallback time.Duration) time.Duration {
+	value := Getenv(key)
+	if value == "" {
+		return fallback
+	}
+
+	duration, err := time.ParseDuration(value)
+	if err != nil || duration <= 0 {
+		log.Printf("WARNING: %s is not a valid duration, using %s", key, fallback)
+		return fallback
+	}
+	return duration
+}
+
+// loadMessaging fails on an unknown sms provider and on a channel without a provider unless
+// the message sink is enabled, a misconfigured server never writes otps to the sink
+func loadMessaging() (*Messaging, error) {
+	smtpHost := Getenv("SMTP_HOST")
+	smtpPortStr := Getenv("SMTP_PORT")
+	smsProvider := Getenv("SMS_PROVIDER")
+	sinkEnabled := Getenv("MESSAGE_SINK_ENABLED") == "true"
+
+	smtpPort, err := strconv.ParseInt(smtpPortStr, 10, 64)
+	if err != nil {
+		smtpPort = defaultSMTPPort
+	}
+
+	if smsProvider != "" && smsProvider != SMSProviderSNS {
+		return nil, fmt.Errorf("unknown sms provider %q", smsProvider)
+	}
+	if smtpHost == "" {
+		if !sinkEnabled {
+			return nil, fmt.Errorf("smtp host is required unless MESSAGE_SINK_ENABLED is set for development")
+		}
+		log.Println("WARNING: smtp host is missing, emails will be written to the message sink")
+	}
+	if smsProvider == "" {
+		if !sinkEnabled {
+			return nil, fmt.Errorf("sms provider is required unless MESSAGE_SINK_ENABLED is set for development")
+		}
+		log.Println("WARNING: sms provider is missing, sms will be written to the message sink")
+	}
+
+	return &Messaging{
+		SMTPHost:     smtpHost,
+		SMTPPort:     smtpPort,
+		SMTPUsername: Getenv("SMTP_USERNAME"),
+		SMTPPassword: Getenv("SMTP_PASSWORD"),
+		SMTPFrom:     Getenv("SMTP_FROM"),
+		SMSProvider:  smsProvider,
+		SMSSenderID:  Getenv("SMS_SENDER_ID"),
+		SinkEnabled:  sinkEnabled,
+		SinkPath:     Getenv("MESSAGE_SINK_PATH"),
+	}, nil
+}
+
 func fetchDbCreds(aws_credentials *AWSCredentails) (*DBCreds, error) {
 	AWS_DB_SECRET, AWS_DB_SECRET_EXISTS := LookupEnv("AWS_DB_SECRET")
 	if AWS_DB_SECRET == "" {
@@ -240,6 +622,9 @@ func SetupClients(conf Config) *Clients {
 		AWSSession:   awsSession,
 		AWSRegion:    conf.AWSCredentails.Region,
 		S3BucketName: conf.AWSCredentails.S3BucketName,
+		Messaging:    conf.Messaging,
+		Security:     conf.Security,
+		JWT:          conf.JWT,
 	}
 }
 
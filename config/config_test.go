@@ -0,0 +1,112 @@
+package config
+
+import (
+	"testing"
+)
+
+func TestLoadJWT(t *testing.T) {
+	valid := `[{"kid":"a","alg":"HS256","key":"secret"},{"kid":"b","alg":"EdDSA","keyFile":"/run/b.pem"}]`
+
+	tests := []struct {
+		name      string
+		keys      string
+		kid       string
+		ephemeral string
+		wantKID   string
+		wantErr   bool
+	}{
+		{"signing kid defaults to the first key", valid, "", "", "a", false},
+		{"signing kid picks a key", valid, "b", "", "b", false},
+		{"unknown signing kid", valid, "c", "", "", true},
+		{"invalid json", `[{"kid":"a",`, "", "true", "", true},
+		{"empty keys", `[]`, "", "true", "", true},
+		{"unsupported algorithm", `[{"kid":"a","alg":"none","key":"secret"}]`, "", "", "", true},
+		{"missing key material", `[{"kid":"a","alg":"HS256"}]`, "", "", "", true},
+		{"duplicate kid", `[{"kid":"a","alg":"HS256","key":"x"},{"kid":"a","alg":"HS256","key":"y"}]`, "", "", "", true},
+		{"unset keys outside development", "", "", "", "", true},
+		{"unset keys with a signing kid", "", "a", "true", "", true},
+		{"unset keys in development", "", "", "true", "ephemeral", false},
+	}
+	for _, tt := range tests {
+		t.Setenv("JWT_KEYS", tt.keys)
+		t.Setenv("JWT_SIGNING_KID", tt.kid)
+		t.Setenv("JWT_EPHEMERAL_KEY", tt.ephemeral)
+
+		conf, err := loadJWT()
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("%s: got no error", tt.name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if conf.SigningKeyID != tt.wantKID {
+			t.Errorf("%s: got signing kid %s, want %s", tt.name, conf.SigningKeyID, tt.wantKID)
+		}
+	}
+}
+
+func TestLoadTokenHashKey(t *testing.T) {
+	tests := []struct {
+		name      string
+		key       string
+		ephemeral string
+		wantErr   bool
+	}{
+		{"key set", "secret", "", false},
+		{"unset key outside development", "", "", true},
+		{"unset key in development", "", "true", false},
+	}
+	for _, tt := range tests {
+		t.Setenv("TOKEN_HASH_KEY", tt.key)
+		t.Setenv("TOKEN_HASH_EPHEMERAL_KEY", tt.ephemeral)
+
+		key, err := loadTokenHashKey()
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("%s: got no error", tt.name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if key == "" || (tt.key != "" && key != tt.key) {
+			t.Errorf("%s: got key %q", tt.name, key)
+		}
+	}
+}
+
+func TestLoadSecretEncryptionKey(t *testing.T) {
+	tests := []struct {
+		name    string
+		key     string
+		wantErr bool
+	}{
+		{"key set", "secret", false},
+		{"unset key", "", true},
+		{"token hash key reused", "hash", true},
+	}
+	for _, tt := range tests {
+		t.Setenv("SECRET_ENCRYPTION_KEY", tt.key)
+
+		key, err := loadSecretEncryptionKey("hash")
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("%s: got no error", tt.name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if key != tt.key {
+			t.Errorf("%s: got key %q, want %q", tt.name, key, tt.key)
+		}
+	}
+}
@@ -16,7 +16,7 @@ func SeedData(conf config.Config) {
 
 	ctx := context.Background()
 	d := dbstore.NewDBStore(c.PostgresConn)
-	m := master.NewMaster(d)
+	m := master.NewMaster(d, c.Security)
 
 	// initiate db transactions
 	tx, err := d.DBTX.BeginTx(ctx)
@@ -25,6 +25,11 @@ func SeedData(conf config.Config) {
 	}
 	defer d.DBTX.RollbackTx(ctx, tx)
 
+	// sync permission catalogue
+	if err := m.PermissionMaster.Sync(ctx, tx); err != nil {
+		log.Fatal(err.Error.Error())
+	}
+
 	// insert admin
 	_, err = orgseed.InsertAdmin(tx, m)
 	if err != nil {
@@ -17,7 +17,7 @@ func InsertAdmin(tx pgx.Tx, m *master.Master) (*dbmodels.User, *faulterr.FaultEr
 			FirstName: "Super",
 			LastName:  "Admin",
 			Email:     "superadmin@example.com",
-			Phone:     "9000090000",
+			Phone:     "+919000090000",
 			IsAdmin:   true,
 		},
 	}
@@ -1,9 +1,13 @@
 package server
 
 import (
+	"context"
 	"gogql/app/api/dataloaders"
 	"gogql/app/middlewares"
+	"gogql/app/services"
 	"gogql/config"
+	"gogql/utils/authtoken"
+	"gogql/utils/faulterr"
 	"time"
 
 	"log"
@@ -23,17 +27,22 @@ func NewRestServer(c *config.Clients) *RestServer {
 	r := chi.NewRouter()
 	restServer := &RestServer{r}
 
+	keys, err := authtoken.NewKeySet(c.JWT)
+	if err != nil {
+		log.Fatal(err)
+	}
+
 	// Add CORS
 	corsOrigin(r)
 
 	// read middlewares
 	r.Use(middleware.Logger)
 	r.Use(middleware.Timeout(10 * time.Second))
-	r.Use(middlewares.AuthTokenReader())
-	r.Use(middlewares.OrgUIDReader())
+	r.Use(middlewares.ClientIPReader(c.Security.TrustedProxies))
+	r.Use(middlewares.UserAgentReader())
 
 	r.Route("/", func(r chi.Router) {
-		urls(r, c)
+		urls(r, c, keys)
 	})
 
 	return restServer
@@ -44,14 +53,19 @@ func (restServer *RestServer) Start(address string) {
 	http.ListenAndServe(address, restServer.Router)
 }
 
-func urls(r chi.Router, c *config.Clients) {
-	dbStore, rt := Injection(c)
+func urls(r chi.Router, c *config.Clients, keys *authtoken.KeySet) {
+	dbStore, s, rt := Injection(c, keys)
+	syncPermissions(s)
+	go expirePermissionElevations(s, time.Minute)
 
+	r.Use(middlewares.AuthTokenReader(keys, s.AuthService))
+	r.Use(middlewares.OrgScopeReader(s.AuthService))
 	r.Use(dataloaders.DataloaderMiddleware(dbStore))
 
 	r.Get("/", func(w http.ResponseWriter, r *http.Request) {
 		w.Write([]byte("welcome"))
 	})
+	rt.WellKnownRoutes(r)
 
 	r.Route("/api", func(r chi.Router) {
 		rt.Ping(r)
@@ -60,6 +74,49 @@ func urls(r chi.Router, c *config.Clients) {
 	})
 }
 
+// syncPermissions writes the permission registry into the database before serving requests
+func syncPermissions(s *services.Services) {
+	ctx := context.Background()
+
+	tx, err := s.DBTX.BeginTx(ctx)
+	if err != nil {
+		log.Fatal(err.Error)
+	}
+	defer s.DBTX.RollbackTx(ctx, tx)
+
+	if err := s.RoleService.SyncPermissions(ctx, tx); err != nil {
+		log.Fatal(err.Error)
+	}
+	if err := s.DBTX.CommitTx(ctx, tx); err != nil {
+		log.Fatal(err.Error)
+	}
+}
+
+// expirePermissionElevations closes the permission elevations that ended on every tick,
+// failures are logged and retried on the next one
+func expirePermissionElevations(s *services.Services, interval time.Duration) {
+	for range time.Tick(interval) {
+		if err := expireEndedElevations(s); err != nil {
+			log.Printf("expiring permission elevations: %s", err.Message)
+		}
+	}
+}
+
+func expireEndedElevations(s *services.Services) *faulterr.FaultErr {
+	ctx := context.Background()
+
+	tx, err := s.DBTX.BeginTx(ctx)
+	if err != nil {
+		return err
+	}
+	defer s.DBTX.RollbackTx(ctx, tx)
+
+	if _, err := s.AuthService.ExpirePermissionElevations(ctx, tx); err != nil {
+		return err
+	}
+	return s.DBTX.CommitTx(ctx, tx)
+}
+
 // corsOrigin function
 func corsOrigin(r *chi.Mux) {
 	// Basic CORS
@@ -70,6 +127,7 @@ func corsOrigin(r *chi.Mux) {
 		AllowedHeaders: []string{
 			"Accept",
 			"Authorization",
+			"X-API-Key",
 			"Organization",
 			"Owner",
 			"Custodian",
@@ -5,19 +5,24 @@ import (
 	"gogql/app/api/routes"
 	"gogql/app/master"
 	"gogql/app/services"
+	"gogql/app/store/cachestore"
 	"gogql/app/store/dbstore"
 	"gogql/app/store/filestore"
+	"gogql/app/store/messagestore"
 	"gogql/config"
+	"gogql/utils/authtoken"
 )
 
 // All dependency injections will go here
-func Injection(c *config.Clients) (*dbstore.DBStore, *routes.Routes) {
+func Injection(c *config.Clients, keys *authtoken.KeySet) (*dbstore.DBStore, *services.Services, *routes.Routes) {
 	dbs := dbstore.NewDBStore(c.PostgresConn)
 	fs := filestore.NewFilestore(c.AWSSession, c.AWSRegion, c.S3BucketName)
-	m := master.NewMaster(dbs)
-	s := services.NewService(dbs, m)
-	h := handlers.NewHandlers(s, fs)
+	ms := messagestore.NewMessageStore(c.AWSSession, c.Messaging)
+	cs := cachestore.NewCacheStore(c.Security.AuthCacheTTL)
+	m := master.NewMaster(dbs, c.Security)
+	s := services.NewService(dbs, m, ms, cs)
+	h := handlers.NewHandlers(s, fs, keys)
 	rt := routes.NewRoutes(h)
 
-	return dbs, rt
+	return dbs, s, rt
 }
@@ -0,0 +1,14 @@
+BEGIN;
+
+DROP TABLE IF EXISTS auth_attempts;
+
+DROP INDEX IF EXISTS otp_sessions_ip_address_created_at_idx;
+DROP INDEX IF EXISTS otp_sessions_user_id_created_at_idx;
+ALTER TABLE "otp_sessions" DROP COLUMN IF EXISTS "failed_attempts";
+ALTER TABLE "otp_sessions" DROP COLUMN IF EXISTS "ip_address";
+
+ALTER TABLE "users" DROP COLUMN IF EXISTS "locked_until";
+ALTER TABLE "users" DROP COLUMN IF EXISTS "last_failed_login_at";
+ALTER TABLE "users" DROP COLUMN IF EXISTS "failed_login_count";
+
+COMMIT;
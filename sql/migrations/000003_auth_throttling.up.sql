@@ -0,0 +1,30 @@
+BEGIN;
+
+-- Login attempt tracking on users
+ALTER TABLE "users" ADD COLUMN "failed_login_count" integer NOT NULL DEFAULT 0;
+ALTER TABLE "users" ADD COLUMN "last_failed_login_at" timestamptz;
+ALTER TABLE "users" ADD COLUMN "locked_until" timestamptz;
+
+-- OTP issuance and attempt tracking
+ALTER TABLE "otp_sessions" ADD COLUMN "ip_address" varchar NOT NULL DEFAULT '';
+ALTER TABLE "otp_sessions" ADD COLUMN "failed_attempts" integer NOT NULL DEFAULT 0;
+CREATE INDEX otp_sessions_user_id_created_at_idx ON otp_sessions (user_id, created_at);
+CREATE INDEX otp_sessions_ip_address_created_at_idx ON otp_sessions (ip_address, created_at);
+
+-- Per ip login attempts, user_id is empty when the identifier did not match a user
+CREATE TABLE "auth_attempts" (
+    "id" bigserial PRIMARY KEY NOT NULL,
+    "user_id" bigint REFERENCES users (id),
+    "ip_address" varchar NOT NULL,
+    "action" varchar NOT NULL,
+    "is_success" boolean NOT NULL DEFAULT FALSE,
+    "created_at" timestamptz NOT NULL DEFAULT NOW(),
+    "updated_at" timestamptz NOT NULL DEFAULT NOW()
+);
+CREATE INDEX auth_attempts_ip_address_created_at_idx ON auth_attempts (ip_address, created_at);
+CREATE TRIGGER set_timestamp
+BEFORE UPDATE ON auth_attempts
+FOR EACH ROW
+EXECUTE FUNCTION trigger_set_timestamp();
+
+COMMIT;
@@ -0,0 +1,14 @@
+BEGIN;
+
+ALTER TABLE "user_activities" ALTER COLUMN "session_token" DROP DEFAULT;
+ALTER TABLE "user_activities" ALTER COLUMN "session_token" TYPE uuid USING gen_random_uuid();
+
+UPDATE "auth_sessions" SET "is_valid" = FALSE;
+ALTER TABLE "auth_sessions" RENAME COLUMN "token_hash" TO "token";
+ALTER TABLE "auth_sessions" ALTER COLUMN "token" TYPE uuid USING gen_random_uuid();
+
+DROP INDEX IF EXISTS otp_sessions_user_id_token_hash_idx;
+UPDATE "otp_sessions" SET "is_valid" = FALSE;
+ALTER TABLE "otp_sessions" RENAME COLUMN "token_hash" TO "token";
+
+COMMIT;
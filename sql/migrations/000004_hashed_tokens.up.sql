@@ -0,0 +1,18 @@
+BEGIN;
+
+-- OTPs are stored as keyed hashes and looked up together with the user they were issued to,
+-- outstanding plaintext otps can not be hashed without the key so they are invalidated
+UPDATE "otp_sessions" SET "is_valid" = FALSE, "token" = 'invalidated:' || "id";
+ALTER TABLE "otp_sessions" RENAME COLUMN "token" TO "token_hash";
+CREATE INDEX otp_sessions_user_id_token_hash_idx ON otp_sessions (user_id, token_hash);
+
+-- Session tokens are stored as keyed hashes, existing sessions are logged out
+UPDATE "auth_sessions" SET "is_valid" = FALSE;
+ALTER TABLE "auth_sessions" ALTER COLUMN "token" TYPE varchar USING 'invalidated:' || "id";
+ALTER TABLE "auth_sessions" RENAME COLUMN "token" TO "token_hash";
+
+-- Activities reference the session by its token hash
+ALTER TABLE "user_activities" ALTER COLUMN "session_token" TYPE varchar USING '';
+ALTER TABLE "user_activities" ALTER COLUMN "session_token" SET DEFAULT '';
+
+COMMIT;
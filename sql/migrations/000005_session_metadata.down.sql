@@ -0,0 +1,9 @@
+BEGIN;
+
+DROP INDEX IF EXISTS auth_sessions_user_id_idx;
+ALTER TABLE "auth_sessions" DROP COLUMN IF EXISTS "last_seen_at";
+ALTER TABLE "auth_sessions" DROP COLUMN IF EXISTS "device_label";
+ALTER TABLE "auth_sessions" DROP COLUMN IF EXISTS "user_agent";
+ALTER TABLE "auth_sessions" DROP COLUMN IF EXISTS "ip_address";
+
+COMMIT;
@@ -0,0 +1,10 @@
+BEGIN;
+
+-- Client details recorded at login and the last time the session was used
+ALTER TABLE "auth_sessions" ADD COLUMN "ip_address" varchar NOT NULL DEFAULT '';
+ALTER TABLE "auth_sessions" ADD COLUMN "user_agent" varchar NOT NULL DEFAULT '';
+ALTER TABLE "auth_sessions" ADD COLUMN "device_label" varchar NOT NULL DEFAULT '';
+ALTER TABLE "auth_sessions" ADD COLUMN "last_seen_at" timestamptz NOT NULL DEFAULT NOW();
+CREATE INDEX auth_sessions_user_id_idx ON auth_sessions (user_id);
+
+COMMIT;
@@ -0,0 +1,8 @@
+BEGIN;
+
+DROP TABLE IF EXISTS refresh_tokens;
+
+UPDATE "auth_sessions" SET "expires_at" = "absolute_expires_at";
+ALTER TABLE "auth_sessions" DROP COLUMN IF EXISTS "absolute_expires_at";
+
+COMMIT;
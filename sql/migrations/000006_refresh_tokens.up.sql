@@ -0,0 +1,25 @@
+BEGIN;
+
+-- expires_at is now the expiry of the short lived session token,
+-- absolute_expires_at ends the session regardless of refreshes
+ALTER TABLE "auth_sessions" ADD COLUMN "absolute_expires_at" timestamptz;
+UPDATE "auth_sessions" SET "absolute_expires_at" = "expires_at";
+ALTER TABLE "auth_sessions" ALTER COLUMN "absolute_expires_at" SET NOT NULL;
+
+-- Refresh tokens rotate on use, all tokens of a session form one family
+CREATE TABLE "refresh_tokens" (
+    "id" bigserial PRIMARY KEY NOT NULL,
+    "session_id" bigint NOT NULL REFERENCES auth_sessions (id),
+    "token_hash" varchar UNIQUE NOT NULL,
+    "is_used" boolean NOT NULL DEFAULT FALSE,
+    "expires_at" timestamptz NOT NULL,
+    "created_at" timestamptz NOT NULL DEFAULT NOW(),
+    "updated_at" timestamptz NOT NULL DEFAULT NOW()
+);
+CREATE INDEX refresh_tokens_session_id_idx ON refresh_tokens (session_id);
+CREATE TRIGGER set_timestamp
+BEFORE UPDATE ON refresh_tokens
+FOR EACH ROW
+EXECUTE FUNCTION trigger_set_timestamp();
+
+COMMIT;
@@ -0,0 +1,11 @@
+BEGIN;
+
+UPDATE "auth_sessions" SET "is_valid" = FALSE WHERE "assurance_level" = 'PARTIAL';
+ALTER TABLE "auth_sessions" DROP COLUMN IF EXISTS "assurance_level";
+
+ALTER TABLE "organizations" DROP COLUMN IF EXISTS "require_management_2fa";
+
+DROP TABLE IF EXISTS recovery_codes;
+DROP TABLE IF EXISTS totp_credentials;
+
+COMMIT;
@@ -0,0 +1,40 @@
+BEGIN;
+
+-- TOTP authenticators, the secret is encrypted and the credential is enabled once a first code is confirmed
+CREATE TABLE "totp_credentials" (
+    "id" bigserial PRIMARY KEY NOT NULL,
+    "user_id" bigint UNIQUE NOT NULL REFERENCES users (id),
+    "secret" varchar NOT NULL,
+    "is_enabled" boolean NOT NULL DEFAULT FALSE,
+    "last_used_step" bigint NOT NULL DEFAULT 0,
+    "confirmed_at" timestamptz,
+    "created_at" timestamptz NOT NULL DEFAULT NOW(),
+    "updated_at" timestamptz NOT NULL DEFAULT NOW()
+);
+CREATE TRIGGER set_timestamp
+BEFORE UPDATE ON totp_credentials
+FOR EACH ROW
+EXECUTE FUNCTION trigger_set_timestamp();
+
+-- One time recovery codes, stored as keyed hashes
+CREATE TABLE "recovery_codes" (
+    "id" bigserial PRIMARY KEY NOT NULL,
+    "user_id" bigint NOT NULL REFERENCES users (id),
+    "code_hash" varchar NOT NULL,
+    "used_at" timestamptz,
+    "created_at" timestamptz NOT NULL DEFAULT NOW(),
+    "updated_at" timestamptz NOT NULL DEFAULT NOW()
+);
+CREATE INDEX recovery_codes_user_id_code_hash_idx ON recovery_codes (user_id, code_hash);
+CREATE TRIGGER set_timestamp
+BEFORE UPDATE ON recovery_codes
+FOR EACH ROW
+EXECUTE FUNCTION trigger_set_timestamp();
+
+-- Organizations can require a second factor for management roles
+ALTER TABLE "organizations" ADD COLUMN "require_management_2fa" boolean NOT NULL DEFAULT FALSE;
+
+-- Sessions record how the user authenticated, PARTIAL sessions still need a second factor
+ALTER TABLE "auth_sessions" ADD COLUMN "assurance_level" varchar NOT NULL DEFAULT 'OTP';
+
+COMMIT;
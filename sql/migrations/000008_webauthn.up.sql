@@ -0,0 +1,40 @@
+BEGIN;
+
+-- Passkeys, the credential id is base64url encoded and the public key is stored as its cose encoding
+CREATE TABLE "webauthn_credentials" (
+    "id" bigserial PRIMARY KEY NOT NULL,
+    "user_id" bigint NOT NULL REFERENCES users (id),
+    "credential_id" varchar UNIQUE NOT NULL,
+    "public_key" bytea NOT NULL,
+    "algorithm" bigint NOT NULL,
+    "sign_count" bigint NOT NULL DEFAULT 0,
+    "name" varchar NOT NULL DEFAULT '',
+    "is_cloned" boolean NOT NULL DEFAULT FALSE,
+    "last_used_at" timestamptz,
+    "created_at" timestamptz NOT NULL DEFAULT NOW(),
+    "updated_at" timestamptz NOT NULL DEFAULT NOW()
+);
+CREATE INDEX webauthn_credentials_user_id_idx ON webauthn_credentials (user_id);
+CREATE TRIGGER set_timestamp
+BEFORE UPDATE ON webauthn_credentials
+FOR EACH ROW
+EXECUTE FUNCTION trigger_set_timestamp();
+
+-- Challenges issued for registration and login ceremonies, stored as keyed hashes and used once
+CREATE TABLE "webauthn_challenges" (
+    "id" bigserial PRIMARY KEY NOT NULL,
+    "user_id" bigint REFERENCES users (id),
+    "ceremony" varchar NOT NULL,
+    "challenge_hash" varchar UNIQUE NOT NULL,
+    "ip_address" varchar NOT NULL DEFAULT '',
+    "is_used" boolean NOT NULL DEFAULT FALSE,
+    "expires_at" timestamptz NOT NULL,
+    "created_at" timestamptz NOT NULL DEFAULT NOW(),
+    "updated_at" timestamptz NOT NULL DEFAULT NOW()
+);
+CREATE TRIGGER set_timestamp
+BEFORE UPDATE ON webauthn_challenges
+FOR EACH ROW
+EXECUTE FUNCTION trigger_set_timestamp();
+
+COMMIT;
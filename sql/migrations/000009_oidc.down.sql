@@ -0,0 +1,9 @@
+BEGIN;
+
+DROP INDEX IF EXISTS users_phone_key;
+ALTER TABLE users ADD CONSTRAINT users_phone_key UNIQUE (phone);
+
+DROP TABLE IF EXISTS oidc_login_states;
+DROP TABLE IF EXISTS organization_oidc_configs;
+
+COMMIT;
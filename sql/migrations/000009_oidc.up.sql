@@ -0,0 +1,44 @@
+BEGIN;
+
+-- Single sign-on settings of an organization, the client secret is sealed with the token hash key
+CREATE TABLE "organization_oidc_configs" (
+    "id" bigserial PRIMARY KEY NOT NULL,
+    "org_uid" uuid UNIQUE NOT NULL REFERENCES organizations (uid),
+    "issuer" varchar NOT NULL,
+    "client_id" varchar NOT NULL,
+    "client_secret" varchar NOT NULL DEFAULT '',
+    "allowed_domains" varchar[] NOT NULL DEFAULT '{}',
+    "is_enabled" boolean NOT NULL DEFAULT FALSE,
+    "jit_provisioning" boolean NOT NULL DEFAULT FALSE,
+    "default_role_id" bigint REFERENCES roles (id),
+    "created_at" timestamptz NOT NULL DEFAULT NOW(),
+    "updated_at" timestamptz NOT NULL DEFAULT NOW()
+);
+CREATE TRIGGER set_timestamp
+BEFORE UPDATE ON organization_oidc_configs
+FOR EACH ROW
+EXECUTE FUNCTION trigger_set_timestamp();
+
+-- Pending authorization requests, the state is stored as a keyed hash and the pkce verifier sealed
+CREATE TABLE "oidc_login_states" (
+    "id" bigserial PRIMARY KEY NOT NULL,
+    "org_uid" uuid NOT NULL REFERENCES organizations (uid),
+    "state_hash" varchar UNIQUE NOT NULL,
+    "nonce" varchar NOT NULL,
+    "code_verifier" varchar NOT NULL,
+    "ip_address" varchar NOT NULL DEFAULT '',
+    "is_used" boolean NOT NULL DEFAULT FALSE,
+    "expires_at" timestamptz NOT NULL,
+    "created_at" timestamptz NOT NULL DEFAULT NOW(),
+    "updated_at" timestamptz NOT NULL DEFAULT NOW()
+);
+CREATE TRIGGER set_timestamp
+BEFORE UPDATE ON oidc_login_states
+FOR EACH ROW
+EXECUTE FUNCTION trigger_set_timestamp();
+
+-- Users provisioned through single sign-on have no phone, only non empty phones have to be unique
+ALTER TABLE users DROP CONSTRAINT users_phone_key;
+CREATE UNIQUE INDEX users_phone_key ON users (phone) WHERE phone <> '';
+
+COMMIT;
@@ -0,0 +1,29 @@
+BEGIN;
+
+-- Api keys of machine clients, personal access tokens have a user and act as that user,
+-- organization keys act on behalf of the organization. Only the keyed hash of a key is stored.
+CREATE TABLE "api_keys" (
+    "id" bigserial PRIMARY KEY NOT NULL,
+    "org_uid" uuid REFERENCES organizations (uid),
+    "user_id" bigint REFERENCES users (id),
+    "created_by" bigint NOT NULL REFERENCES users (id),
+    "name" varchar NOT NULL,
+    "prefix" varchar UNIQUE NOT NULL,
+    "key_hash" varchar UNIQUE NOT NULL,
+    "permissions" varchar[] NOT NULL DEFAULT '{}',
+    "allowed_ips" varchar[] NOT NULL DEFAULT '{}',
+    "expires_at" timestamptz,
+    "last_used_at" timestamptz,
+    "last_used_ip" varchar NOT NULL DEFAULT '',
+    "is_revoked" boolean NOT NULL DEFAULT FALSE,
+    "created_at" timestamptz NOT NULL DEFAULT NOW(),
+    "updated_at" timestamptz NOT NULL DEFAULT NOW()
+);
+CREATE INDEX api_keys_org_uid_idx ON api_keys (org_uid);
+CREATE INDEX api_keys_user_id_idx ON api_keys (user_id);
+CREATE TRIGGER set_timestamp
+BEFORE UPDATE ON api_keys
+FOR EACH ROW
+EXECUTE FUNCTION trigger_set_timestamp();
+
+COMMIT;
@@ -0,0 +1,7 @@
+BEGIN;
+
+DROP INDEX IF EXISTS user_activities_actor_id_idx;
+ALTER TABLE "user_activities" DROP COLUMN IF EXISTS "actor_id";
+ALTER TABLE "auth_sessions" DROP COLUMN IF EXISTS "impersonator_id";
+
+COMMIT;
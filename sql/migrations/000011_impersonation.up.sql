@@ -0,0 +1,12 @@
+BEGIN;
+
+-- Impersonation sessions belong to the impersonated user and keep the super admin who started them
+ALTER TABLE "auth_sessions" ADD COLUMN "impersonator_id" bigint REFERENCES users (id);
+
+-- The user who really performed an activity, it differs from user_id while impersonating
+ALTER TABLE "user_activities" ADD COLUMN "actor_id" bigint REFERENCES users (id);
+UPDATE "user_activities" SET "actor_id" = "user_id";
+ALTER TABLE "user_activities" ALTER COLUMN "actor_id" SET NOT NULL;
+CREATE INDEX user_activities_actor_id_idx ON user_activities (actor_id);
+
+COMMIT;
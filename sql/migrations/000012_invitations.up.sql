@@ -0,0 +1,27 @@
+BEGIN;
+
+-- Invitations of pending users, the user accepts with a single-use token sent by email.
+-- Only the keyed hash of the token is stored, resending replaces the token.
+CREATE TABLE "invitations" (
+    "id" bigserial PRIMARY KEY NOT NULL,
+    "org_uid" uuid NOT NULL REFERENCES organizations (uid),
+    "user_id" bigint NOT NULL REFERENCES users (id),
+    "email" varchar NOT NULL,
+    "token_hash" varchar UNIQUE NOT NULL,
+    "status" varchar NOT NULL,
+    "invited_by" bigint NOT NULL REFERENCES users (id),
+    "expires_at" timestamptz NOT NULL,
+    "accepted_at" timestamptz,
+    "send_count" integer NOT NULL DEFAULT 1,
+    "last_sent_at" timestamptz NOT NULL DEFAULT NOW(),
+    "created_at" timestamptz NOT NULL DEFAULT NOW(),
+    "updated_at" timestamptz NOT NULL DEFAULT NOW()
+);
+CREATE INDEX invitations_org_uid_idx ON invitations (org_uid);
+CREATE INDEX invitations_user_id_idx ON invitations (user_id);
+CREATE TRIGGER set_timestamp
+BEFORE UPDATE ON invitations
+FOR EACH ROW
+EXECUTE FUNCTION trigger_set_timestamp();
+
+COMMIT;
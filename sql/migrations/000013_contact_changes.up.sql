@@ -0,0 +1,27 @@
+BEGIN;
+
+-- Pending changes of the email or phone of a user, applied once confirmed with the otp sent
+-- to the new value. The old value is notified with a link to cancel the change.
+CREATE TABLE "contact_changes" (
+    "id" bigserial PRIMARY KEY NOT NULL,
+    "user_id" bigint NOT NULL REFERENCES users (id),
+    "channel" varchar NOT NULL,
+    "old_value" varchar NOT NULL,
+    "new_value" varchar NOT NULL,
+    "code_hash" varchar NOT NULL,
+    "cancel_token_hash" varchar UNIQUE NOT NULL,
+    "status" varchar NOT NULL,
+    "failed_attempts" integer NOT NULL DEFAULT 0,
+    "requested_by" bigint NOT NULL REFERENCES users (id),
+    "expires_at" timestamptz NOT NULL,
+    "confirmed_at" timestamptz,
+    "created_at" timestamptz NOT NULL DEFAULT NOW(),
+    "updated_at" timestamptz NOT NULL DEFAULT NOW()
+);
+CREATE INDEX contact_changes_user_id_idx ON contact_changes (user_id);
+CREATE TRIGGER set_timestamp
+BEFORE UPDATE ON contact_changes
+FOR EACH ROW
+EXECUTE FUNCTION trigger_set_timestamp();
+
+COMMIT;
@@ -0,0 +1,7 @@
+BEGIN;
+
+-- normalized phone numbers are kept, they stay valid without the region
+DROP TABLE IF EXISTS "phone_normalization_conflicts";
+ALTER TABLE "organizations" DROP COLUMN IF EXISTS "phone_region";
+
+COMMIT;
@@ -0,0 +1,93 @@
+BEGIN;
+
+-- Region national phone numbers of an organization are read in, users without an
+-- organization use the DEFAULT_PHONE_REGION of the api which defaults to IN as well
+ALTER TABLE "organizations" ADD COLUMN "phone_region" varchar NOT NULL DEFAULT 'IN';
+
+-- Phone numbers the normalization below could not rewrite, kept as they were until resolved.
+-- conflicting_user_id is the user who got the normalized number, invalid numbers have none.
+CREATE TABLE "phone_normalization_conflicts" (
+    "id" bigserial PRIMARY KEY NOT NULL,
+    "user_id" bigint NOT NULL REFERENCES users (id),
+    "phone" varchar NOT NULL,
+    "normalized" varchar,
+    "conflicting_user_id" bigint REFERENCES users (id),
+    "created_at" timestamptz NOT NULL DEFAULT NOW()
+);
+
+-- Normalize the phone numbers of users to E.164. Numbers with a + or 00 prefix are
+-- international, others are national numbers of the region of the organization of the
+-- user. The calling codes and trunk prefixes match the plans of utils/phone.
+CREATE TEMP TABLE phone_plans ("region", "calling_code", "trunk_prefix", "national_length") ON COMMIT DROP AS
+VALUES
+    ('US', '1', '1', 10), ('CA', '1', '1', 10), ('GB', '44', '0', 10), ('IE', '353', '0', 9),
+    ('DE', '49', '0', 13), ('FR', '33', '0', 9), ('ES', '34', '', 9), ('NL', '31', '0', 9),
+    ('AU', '61', '0', 9), ('NZ', '64', '0', 9), ('IN', '91', '0', 10), ('PK', '92', '0', 10),
+    ('BD', '880', '0', 10), ('LK', '94', '0', 9), ('NP', '977', '0', 10), ('SG', '65', '', 8),
+    ('JP', '81', '0', 10), ('CN', '86', '0', 11), ('AE', '971', '0', 9), ('SA', '966', '0', 9),
+    ('NG', '234', '0', 10), ('KE', '254', '0', 9), ('ZA', '27', '0', 9), ('BR', '55', '0', 11),
+    ('MX', '52', '', 10);
+
+CREATE TEMP TABLE phone_normalization ON COMMIT DROP AS
+WITH cleaned AS (
+    SELECT
+        u.id,
+        u.phone,
+        regexp_replace(u.phone, '[\s\-\.\(\)/]', '', 'g') AS digits,
+        COALESCE(o.phone_region, 'IN') AS region
+    FROM users u
+    LEFT JOIN organizations o ON o.uid = u.org_uid
+    WHERE u.phone <> ''
+), normalized AS (
+    SELECT
+        c.id,
+        c.phone,
+        CASE
+            WHEN c.digits LIKE '+%' THEN c.digits
+            WHEN c.digits LIKE '00%' THEN '+' || substr(c.digits, 3)
+            WHEN p.trunk_prefix <> '' AND c.digits LIKE p.trunk_prefix || '%' AND length(c.digits) > p.national_length
+                THEN '+' || p.calling_code || substr(c.digits, length(p.trunk_prefix) + 1)
+            ELSE '+' || p.calling_code || c.digits
+        END AS normalized
+    FROM cleaned c
+    LEFT JOIN phone_plans p ON p.region = c.region
+)
+SELECT
+    n.id,
+    n.phone,
+    CASE WHEN n.normalized ~ '^\+[1-9][0-9]{6,14}$' THEN n.normalized END AS normalized,
+    -- the user already holding the normalized number keeps it, otherwise the oldest user
+    first_value(n.id) OVER (
+        PARTITION BY n.normalized
+        ORDER BY (n.phone = n.normalized) DESC, n.id
+    ) AS owner_id
+FROM normalized n;
+
+INSERT INTO phone_normalization_conflicts (user_id, phone, normalized, conflicting_user_id)
+SELECT
+    id,
+    phone,
+    normalized,
+    CASE WHEN normalized IS NOT NULL THEN owner_id END
+FROM phone_normalization
+WHERE normalized IS NULL OR id <> owner_id;
+
+UPDATE users
+SET phone = n.normalized
+FROM phone_normalization n
+WHERE users.id = n.id
+AND n.normalized IS NOT NULL
+AND n.id = n.owner_id
+AND users.phone <> n.normalized;
+
+DO $$
+DECLARE
+    total integer;
+BEGIN
+    SELECT COUNT(*) INTO total FROM phone_normalization_conflicts;
+    IF total > 0 THEN
+        RAISE NOTICE '% phone numbers could not be normalized, see phone_normalization_conflicts', total;
+    END IF;
+END $$;
+
+COMMIT;
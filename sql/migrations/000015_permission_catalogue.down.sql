@@ -0,0 +1,9 @@
+BEGIN;
+
+DROP INDEX IF EXISTS permissions_name_idx;
+ALTER TABLE "permissions"
+    DROP COLUMN IF EXISTS "group_name",
+    DROP COLUMN IF EXISTS "description",
+    DROP COLUMN IF EXISTS "is_removed";
+
+COMMIT;
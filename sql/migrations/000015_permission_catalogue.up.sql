@@ -0,0 +1,11 @@
+BEGIN;
+
+-- The permissions table mirrors the permission registry of the application, it is synced at
+-- startup. Permissions dropped from the registry are kept and marked removed.
+ALTER TABLE "permissions"
+    ADD COLUMN "group_name" varchar NOT NULL DEFAULT '',
+    ADD COLUMN "description" varchar NOT NULL DEFAULT '',
+    ADD COLUMN "is_removed" boolean NOT NULL DEFAULT false;
+CREATE UNIQUE INDEX permissions_name_idx ON permissions (name);
+
+COMMIT;
@@ -0,0 +1,6 @@
+BEGIN;
+
+ALTER TABLE "roles"
+    DROP COLUMN IF EXISTS "permission_scopes";
+
+COMMIT;
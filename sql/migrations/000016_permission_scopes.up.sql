@@ -0,0 +1,8 @@
+BEGIN;
+
+-- A role grants its permissions organization wide unless the permission is mapped to a
+-- narrower scope here, e.g. {"READ_USER": "DEPARTMENT"}
+ALTER TABLE "roles"
+    ADD COLUMN "permission_scopes" JSONB NOT NULL DEFAULT '{}';
+
+COMMIT;
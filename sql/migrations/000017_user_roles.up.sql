@@ -0,0 +1,24 @@
+BEGIN;
+
+-- Roles assigned to a user, the permissions of a user are the union of its unexpired roles.
+-- users.role_id stays the primary role of the user and is always assigned here too.
+CREATE TABLE "user_roles" (
+    "id" bigserial PRIMARY KEY NOT NULL,
+    "user_id" bigint NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    "role_id" bigint NOT NULL REFERENCES roles (id) ON DELETE CASCADE,
+    "expires_at" timestamptz,
+    "created_at" timestamptz NOT NULL DEFAULT NOW(),
+    "updated_at" timestamptz NOT NULL DEFAULT NOW()
+);
+CREATE UNIQUE INDEX user_roles_user_id_role_id_idx ON user_roles (user_id, role_id);
+CREATE INDEX user_roles_role_id_idx ON user_roles (role_id);
+CREATE TRIGGER set_timestamp
+BEFORE UPDATE ON user_roles
+FOR EACH ROW
+EXECUTE FUNCTION trigger_set_timestamp();
+
+INSERT INTO user_roles (user_id, role_id)
+SELECT users.id, users.role_id FROM users
+WHERE users.role_id IS NOT NULL;
+
+COMMIT;
@@ -0,0 +1,28 @@
+BEGIN;
+
+-- Temporary permissions of a user on top of its roles, granted between starts_at and ends_at.
+-- Elevations requested by members are PENDING until a management role approves them.
+CREATE TABLE "permission_elevations" (
+    "id" bigserial PRIMARY KEY NOT NULL,
+    "org_uid" uuid NOT NULL REFERENCES organizations (uid),
+    "user_id" bigint NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    "permission" varchar NOT NULL,
+    "justification" varchar NOT NULL,
+    "status" varchar NOT NULL,
+    "starts_at" timestamptz NOT NULL,
+    "ends_at" timestamptz NOT NULL,
+    "requested_by" bigint NOT NULL REFERENCES users (id),
+    "decided_by" bigint REFERENCES users (id),
+    "decided_at" timestamptz,
+    "created_at" timestamptz NOT NULL DEFAULT NOW(),
+    "updated_at" timestamptz NOT NULL DEFAULT NOW()
+);
+CREATE INDEX permission_elevations_org_uid_idx ON permission_elevations (org_uid);
+CREATE INDEX permission_elevations_user_id_idx ON permission_elevations (user_id);
+CREATE INDEX permission_elevations_status_ends_at_idx ON permission_elevations (status, ends_at);
+CREATE TRIGGER set_timestamp
+BEFORE UPDATE ON permission_elevations
+FOR EACH ROW
+EXECUTE FUNCTION trigger_set_timestamp();
+
+COMMIT;
@@ -0,0 +1,21 @@
+BEGIN;
+
+-- Permissions of an organization that no single role or user may hold together, holding
+-- two or more permissions of an active rule is a separation of duties violation.
+CREATE TABLE "separation_rules" (
+    "id" bigserial PRIMARY KEY NOT NULL,
+    "org_uid" uuid NOT NULL REFERENCES organizations (uid),
+    "name" varchar NOT NULL,
+    "permissions" varchar[] NOT NULL,
+    "is_active" boolean NOT NULL DEFAULT true,
+    "created_by" bigint NOT NULL REFERENCES users (id),
+    "created_at" timestamptz NOT NULL DEFAULT NOW(),
+    "updated_at" timestamptz NOT NULL DEFAULT NOW()
+);
+CREATE INDEX separation_rules_org_uid_idx ON separation_rules (org_uid);
+CREATE TRIGGER set_timestamp
+BEFORE UPDATE ON separation_rules
+FOR EACH ROW
+EXECUTE FUNCTION trigger_set_timestamp();
+
+COMMIT;
@@ -0,0 +1,6 @@
+BEGIN;
+
+DROP TABLE IF EXISTS access_review_items;
+DROP TABLE IF EXISTS access_review_campaigns;
+
+COMMIT;
@@ -0,0 +1,47 @@
+BEGIN;
+
+-- Access review campaigns confirm the role assignments of an organization or of one of its
+-- departments are still appropriate, revocations are applied when the campaign closes.
+CREATE TABLE "access_review_campaigns" (
+    "id" bigserial PRIMARY KEY NOT NULL,
+    "org_uid" uuid NOT NULL REFERENCES organizations (uid),
+    "department_id" bigint REFERENCES departments (id),
+    "name" varchar NOT NULL,
+    "status" varchar NOT NULL,
+    "reviewer_ids" bigint[] NOT NULL,
+    "started_by" bigint NOT NULL REFERENCES users (id),
+    "closed_by" bigint REFERENCES users (id),
+    "closed_at" timestamptz,
+    "created_at" timestamptz NOT NULL DEFAULT NOW(),
+    "updated_at" timestamptz NOT NULL DEFAULT NOW()
+);
+CREATE INDEX access_review_campaigns_org_uid_idx ON access_review_campaigns (org_uid);
+CREATE TRIGGER set_timestamp
+BEFORE UPDATE ON access_review_campaigns
+FOR EACH ROW
+EXECUTE FUNCTION trigger_set_timestamp();
+
+-- One role assignment of a user to certify or revoke, decisions stay PENDING until reviewed.
+CREATE TABLE "access_review_items" (
+    "id" bigserial PRIMARY KEY NOT NULL,
+    "campaign_id" bigint NOT NULL REFERENCES access_review_campaigns (id) ON DELETE CASCADE,
+    "user_id" bigint NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    "role_id" bigint NOT NULL REFERENCES roles (id) ON DELETE CASCADE,
+    "is_primary" boolean NOT NULL DEFAULT false,
+    "reviewer_id" bigint NOT NULL REFERENCES users (id),
+    "decision" varchar NOT NULL,
+    "revoke_action" varchar NOT NULL DEFAULT '',
+    "reassign_role_id" bigint REFERENCES roles (id),
+    "note" varchar NOT NULL DEFAULT '',
+    "decided_at" timestamptz,
+    "applied_at" timestamptz,
+    "created_at" timestamptz NOT NULL DEFAULT NOW(),
+    "updated_at" timestamptz NOT NULL DEFAULT NOW()
+);
+CREATE INDEX access_review_items_campaign_id_reviewer_id_idx ON access_review_items (campaign_id, reviewer_id);
+CREATE TRIGGER set_timestamp
+BEFORE UPDATE ON access_review_items
+FOR EACH ROW
+EXECUTE FUNCTION trigger_set_timestamp();
+
+COMMIT;
@@ -0,0 +1,7 @@
+BEGIN;
+
+DROP TABLE IF EXISTS user_role_versions;
+DROP TABLE IF EXISTS user_versions;
+DROP TABLE IF EXISTS role_versions;
+
+COMMIT;
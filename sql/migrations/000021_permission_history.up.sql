@@ -0,0 +1,55 @@
+BEGIN;
+
+-- Versions of the rows permissions are evaluated from, a version is valid from valid_from until
+-- valid_to and the current version has no valid_to. The stores close the current version and
+-- record the new one in the transaction writing the row, deleted rows only close their version.
+-- History starts with this migration, the rows are recorded as they are now.
+CREATE TABLE "role_versions" (
+    "id" bigserial PRIMARY KEY NOT NULL,
+    "role_id" bigint NOT NULL,
+    "org_uid" uuid NOT NULL,
+    "department_id" bigint NOT NULL,
+    "name" varchar NOT NULL,
+    "permissions" text[] NOT NULL DEFAULT '{}',
+    "permission_scopes" jsonb NOT NULL DEFAULT '{}',
+    "is_management" boolean NOT NULL,
+    "is_archived" boolean NOT NULL,
+    "valid_from" timestamptz NOT NULL,
+    "valid_to" timestamptz
+);
+CREATE INDEX role_versions_role_id_valid_from_idx ON role_versions (role_id, valid_from);
+
+CREATE TABLE "user_versions" (
+    "id" bigserial PRIMARY KEY NOT NULL,
+    "user_id" bigint NOT NULL,
+    "org_uid" uuid,
+    "role_id" bigint,
+    "is_admin" boolean NOT NULL,
+    "is_archived" boolean NOT NULL,
+    "status" varchar NOT NULL,
+    "valid_from" timestamptz NOT NULL,
+    "valid_to" timestamptz
+);
+CREATE INDEX user_versions_user_id_valid_from_idx ON user_versions (user_id, valid_from);
+CREATE INDEX user_versions_org_uid_idx ON user_versions (org_uid);
+
+CREATE TABLE "user_role_versions" (
+    "id" bigserial PRIMARY KEY NOT NULL,
+    "user_id" bigint NOT NULL,
+    "role_id" bigint NOT NULL,
+    "expires_at" timestamptz,
+    "valid_from" timestamptz NOT NULL,
+    "valid_to" timestamptz
+);
+CREATE INDEX user_role_versions_user_id_valid_from_idx ON user_role_versions (user_id, valid_from);
+
+INSERT INTO role_versions (role_id, org_uid, department_id, name, permissions, permission_scopes, is_management, is_archived, valid_from)
+SELECT id, org_uid, department_id, name, COALESCE(permissions, '{}'), permission_scopes, is_management, is_archived, NOW() FROM roles;
+
+INSERT INTO user_versions (user_id, org_uid, role_id, is_admin, is_archived, status, valid_from)
+SELECT id, org_uid, role_id, is_admin, is_archived, status, NOW() FROM users;
+
+INSERT INTO user_role_versions (user_id, role_id, expires_at, valid_from)
+SELECT user_id, role_id, expires_at, NOW() FROM user_roles;
+
+COMMIT;
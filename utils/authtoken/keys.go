@@ -0,0 +1,127 @@
+package authtoken
+
+import (
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"gogql/config"
+	"os"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+// KeySet signs tokens with one key and verifies them against every configured key,
+// so tokens signed with a key that is being rotated out stay valid until they expire
+type KeySet struct {
+	signing *key
+	keys    map[string]*key
+	ttl     time.Duration
+}
+
+type key struct {
+	id        string
+	method    jwt.SigningMethod
+	signKey   interface{}
+	verifyKey interface{}
+}
+
+// NewKeySet parses the configured keys, the signing key must contain private key material
+func NewKeySet(conf *config.JWT) (*KeySet, error) {
+	ks := &KeySet{keys: map[string]*key{}, ttl: conf.TTL}
+
+	for _, k := range conf.Keys {
+		parsed, err := parseKey(k)
+		if err != nil {
+			return nil, fmt.Errorf("jwt key %s: %w", k.ID, err)
+		}
+		if _, ok := ks.keys[parsed.id]; ok {
+			return nil, fmt.Errorf("jwt key %s: duplicate kid", k.ID)
+		}
+		ks.keys[parsed.id] = parsed
+	}
+
+	signing, ok := ks.keys[conf.SigningKeyID]
+	if !ok {
+		return nil, fmt.Errorf("jwt signing key %s is not configured", conf.SigningKeyID)
+	}
+	if signing.signKey == nil {
+		return nil, fmt.Errorf("jwt signing key %s has no private key", conf.SigningKeyID)
+	}
+	ks.signing = signing
+
+	return ks, nil
+}
+
+func parseKey(k config.JWTKey) (*key, error) {
+	if k.ID == "" {
+		return nil, errors.New("kid is required")
+	}
+
+	material := []byte(k.Key)
+	if k.KeyFile != "" {
+		b, err := os.ReadFile(k.KeyFile)
+		if err != nil {
+			return nil, err
+		}
+		material = b
+	}
+	if len(material) == 0 {
+		return nil, errors.New("key is empty")
+	}
+
+	switch k.Algorithm {
+	case jwt.SigningMethodHS256.Alg():
+		return &key{k.ID, jwt.SigningMethodHS256, material, material}, nil
+
+	case jwt.SigningMethodRS256.Alg():
+		if privateKey, err := jwt.ParseRSAPrivateKeyFromPEM(material); err == nil {
+			return &key{k.ID, jwt.SigningMethodRS256, privateKey, &privateKey.PublicKey}, nil
+		}
+		publicKey, err := jwt.ParseRSAPublicKeyFromPEM(material)
+		if err != nil {
+			return nil, errors.New("key is not a pem encoded rsa key")
+		}
+		return &key{k.ID, jwt.SigningMethodRS256, nil, publicKey}, nil
+
+	case SigningMethodEdDSA.Alg():
+		block, _ := pem.Decode(material)
+		if block == nil {
+			return nil, errors.New("key is not pem encoded")
+		}
+		if parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes); err == nil {
+			privateKey, ok := parsed.(ed25519.PrivateKey)
+			if !ok {
+				return nil, errors.New("key is not an ed25519 key")
+			}
+			return &key{k.ID, SigningMethodEdDSA, privateKey, privateKey.Public()}, nil
+		}
+		parsed, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, errors.New("key is not a pkcs8 or pkix ed25519 key")
+		}
+		publicKey, ok := parsed.(ed25519.PublicKey)
+		if !ok {
+			return nil, errors.New("key is not an ed25519 key")
+		}
+		return &key{k.ID, SigningMethodEdDSA, nil, publicKey}, nil
+
+	default:
+		return nil, fmt.Errorf("unsupported algorithm %q", k.Algorithm)
+	}
+}
+
+// publicKeys returns the verify keys of asymmetric keys, hmac secrets are never published
+func (ks *KeySet) publicKeys() []*key {
+	result := []*key{}
+	for _, k := range ks.keys {
+		switch k.verifyKey.(type) {
+		case *rsa.PublicKey, ed25519.PublicKey:
+			result = append(result, k)
+		}
+	}
+	return result
+}
@@ -3,34 +3,55 @@ package authtoken
 import (
 	"gogql/app/models"
 	"gogql/utils/faulterr"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/dgrijalva/jwt-go"
+	"github.com/gofrs/uuid"
+	"github.com/volatiletech/null"
 )
 
-var jwtKey = []byte("secret_key")
-
 type Payload struct {
 	TokenString string
 	ExpiresAt   time.Time
 }
 
+// Claims carries the auther, the session token itself is never put into a jwt. Impersonator is
+// the super admin acting as the user, tokens of impersonation sessions stay marked as such.
 type Claims struct {
-	models.Auther
+	Name         string        `json:"name"`
+	IsAdmin      bool          `json:"adm"`
+	OrgUID       uuid.NullUUID `json:"org"`
+	RoleID       null.Int64    `json:"role"`
+	SessionID    int64         `json:"sid"`
+	Assurance    string        `json:"acr"`
+	Impersonator null.Int64    `json:"imp"`
 	jwt.StandardClaims
 }
 
-func Generate(auther *models.Auther) (*Payload, *faulterr.FaultErr) {
-	expirationTime := time.Now().Add(time.Hour * time.Duration(24) * time.Duration(30))
+// Generate signs a jwt for the auther with the signing key
+func (ks *KeySet) Generate(auther *models.Auther) (*Payload, *faulterr.FaultErr) {
+	now := time.Now()
+	expirationTime := now.Add(ks.ttl)
 	claims := &Claims{
-		Auther: *auther,
+		Name:         auther.Name,
+		IsAdmin:      auther.IsAdmin,
+		OrgUID:       auther.OrgUID,
+		RoleID:       auther.RoleID,
+		SessionID:    auther.SessionID,
+		Assurance:    auther.AssuranceLevel,
+		Impersonator: auther.ImpersonatorID,
 		StandardClaims: jwt.StandardClaims{
+			Subject:   strconv.FormatInt(auther.ID, 10),
+			IssuedAt:  now.Unix(),
 			ExpiresAt: expirationTime.Unix(),
 		},
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	tokenString, err := token.SignedString(jwtKey)
+	token := jwt.NewWithClaims(ks.signing.method, claims)
+	token.Header["kid"] = ks.signing.id
+	tokenString, err := token.SignedString(ks.signing.signKey)
 	if err != nil {
 		return nil, faulterr.NewBadRequestError("bad token request")
 	}
@@ -43,30 +64,62 @@ func Generate(auther *models.Auther) (*Payload, *faulterr.FaultErr) {
 	return payload, nil
 }
 
-func Decode(tokenString string) (*models.Auther, *faulterr.FaultErr) {
+// Decode verifies a jwt against the key named by its kid header and returns the auther
+func (ks *KeySet) Decode(tokenString string) (*models.Auther, *faulterr.FaultErr) {
 	claims := &Claims{}
-	token, err := jwt.ParseWithClaims(tokenString, claims, keyFunc)
+	token, err := jwt.ParseWithClaims(tokenString, claims, ks.keyFunc)
 	if err != nil {
-		if err == jwt.ErrSignatureInvalid {
-			return nil, faulterr.NewUnauthorizedError("unauthorized request: invalid signature")
+		if vErr, ok := err.(*jwt.ValidationError); ok && vErr.Errors&jwt.ValidationErrorExpired != 0 {
+			return nil, faulterr.NewUnauthorizedError("unauthorized request: token is expired")
 		}
-		return nil, faulterr.NewBadRequestError(err.Error())
+		return nil, faulterr.NewUnauthorizedError("unauthorized request: invalid token")
 	}
 	if !token.Valid {
 		return nil, faulterr.NewUnauthorizedError("unauthorized request: token is not valid")
 	}
 
+	id, err := strconv.ParseInt(claims.Subject, 10, 64)
+	if err != nil {
+		return nil, faulterr.NewUnauthorizedError("unauthorized request: invalid subject")
+	}
+
 	auther := &models.Auther{
-		ID:      claims.ID,
-		Name:    claims.Name,
-		IsAdmin: claims.IsAdmin,
-		OrgUID:  claims.OrgUID,
-		RoleID:  claims.RoleID,
+		ID:             id,
+		Name:           claims.Name,
+		IsAdmin:        claims.IsAdmin,
+		OrgUID:         claims.OrgUID,
+		RoleID:         claims.RoleID,
+		SessionID:      claims.SessionID,
+		ExpiresAt:      time.Unix(claims.ExpiresAt, 0),
+		AssuranceLevel: claims.Assurance,
+		ImpersonatorID: claims.Impersonator,
 	}
 
 	return auther, nil
 }
 
-func keyFunc(t *jwt.Token) (interface{}, error) {
-	return jwtKey, nil
+// keyFunc picks the key by kid and rejects tokens whose alg does not match the key
+func (ks *KeySet) keyFunc(t *jwt.Token) (interface{}, error) {
+	kid, _ := t.Header["kid"].(string)
+	k, ok := ks.keys[kid]
+	if !ok {
+		return nil, jwt.NewValidationError("unknown kid", jwt.ValidationErrorUnverifiable)
+	}
+	if t.Method.Alg() != k.method.Alg() {
+		return nil, jwt.NewValidationError("unexpected signing method", jwt.ValidationErrorSignatureInvalid)
+	}
+	return k.verifyKey, nil
+}
+
+// APIKeyPrefix starts every api key so keys can be told apart from session tokens and jwts
+const APIKeyPrefix = "gqk_"
+
+// IsAPIKey reports whether the token looks like an api key
+func IsAPIKey(tokenString string) bool {
+	return strings.HasPrefix(tokenString, APIKeyPrefix)
+}
+
+// IsJWT reports whether the token looks like a compact serialized jwt
+func IsJWT(tokenString string) bool {
+	return strings.Count(tokenString, ".") == 2
 }
@@ -1,18 +1,20 @@
 package encrypt
 
 import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
 	"crypto/md5"
+	"crypto/rand"
+	"crypto/sha256"
 	"encoding/hex"
-	"math/rand"
+	"errors"
+	"math/big"
 	"time"
-	"unsafe"
 )
 
 const (
-	letterBytes   = "0123456789"
-	letterIdxBits = 6                    // 6 bits to represent a letter index
-	letterIdxMask = 1<<letterIdxBits - 1 // All 1-bits, as many as letterIdxBits
-	letterIdxMax  = 63 / letterIdxBits   // # of letter indices fitting in 63 bits
+	letterBytes = "0123456789"
 )
 
 // GetMd5 function
@@ -23,27 +25,95 @@ func GetMd5(input string) string {
 	return hex.EncodeToString(hash.Sum(nil))
 }
 
-// GenerateRandomString function
+// GenerateRandomString generates a numeric string of length n using crypto/rand
 func GenerateRandomString(n int) string {
-	var src = rand.NewSource(time.Now().UnixNano())
+	max := big.NewInt(int64(len(letterBytes)))
 
 	b := make([]byte, n)
-	// A src.Int63() generates 63 random bits, enough for letterIdxMax characters!
-	for i, cache, remain := n-1, src.Int63(), letterIdxMax; i >= 0; {
-		if remain == 0 {
-			cache, remain = src.Int63(), letterIdxMax
+	for i := range b {
+		idx, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			// crypto/rand only fails when the os entropy source is broken
+			panic(err)
 		}
-		if idx := int(cache & letterIdxMask); idx < len(letterBytes) {
-			b[i] = letterBytes[idx]
-			i--
-		}
-		cache >>= letterIdxBits
-		remain--
+		b[i] = letterBytes[idx.Int64()]
 	}
 
-	return *(*string)(unsafe.Pointer(&b))
+	return string(b)
+}
+
+// GenerateRandomKey generates n random bytes encoded as hex using crypto/rand
+func GenerateRandomKey(n int) string {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(b)
 }
 
 func GenerateRandomInt64() int64 {
 	return time.Now().Add(time.Minute * 1440).UTC().Unix()
 }
+
+// TokenHasher computes keyed hashes (HMAC-SHA256) of secrets like otps and session tokens,
+// so only the hash is stored and a leaked table cannot be replayed without the key
+type TokenHasher struct {
+	key []byte
+}
+
+func NewTokenHasher(key string) *TokenHasher {
+	return &TokenHasher{[]byte(key)}
+}
+
+// Hash returns the hex encoded keyed hash of a token
+func (h *TokenHasher) Hash(token string) string {
+	mac := hmac.New(sha256.New, h.key)
+	mac.Write([]byte(token))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// SecretBox encrypts secrets that have to be read back, like totp secrets, with AES-GCM
+type SecretBox struct {
+	aead cipher.AEAD
+}
+
+// NewSecretBox derives a 256 bit key from the given key
+func NewSecretBox(key string) *SecretBox {
+	sum := sha256.Sum256([]byte("secretbox:" + key))
+	block, err := aes.NewCipher(sum[:])
+	if err != nil {
+		panic(err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		panic(err)
+	}
+	return &SecretBox{aead}
+}
+
+// Seal encrypts plaintext and returns the hex encoded nonce and ciphertext
+func (b *SecretBox) Seal(plaintext string) string {
+	nonce := make([]byte, b.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(b.aead.Seal(nonce, nonce, []byte(plaintext), nil))
+}
+
+// Open decrypts a value returned by Seal
+func (b *SecretBox) Open(sealed string) (string, error) {
+	data, err := hex.DecodeString(sealed)
+	if err != nil {
+		return "", err
+	}
+	if len(data) < b.aead.NonceSize() {
+		return "", errors.New("sealed value is too short")
+	}
+
+	nonce, ciphertext := data[:b.aead.NonceSize()], data[b.aead.NonceSize():]
+	plaintext, err := b.aead.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return "", err
+	}
+	return string(plaintext), nil
+}
@@ -0,0 +1,40 @@
+package faulterr
+
+import "errors"
+
+// CodedError carries a machine readable code next to the error message,
+// api layers expose the code so clients can react without parsing messages
+type CodedError struct {
+	Code    string
+	Details map[string]string
+	Err     error
+}
+
+func (e *CodedError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *CodedError) Unwrap() error {
+	return e.Err
+}
+
+// WithCode attaches a machine readable code to the fault error
+func (e *FaultErr) WithCode(code string) *FaultErr {
+	e.Error = &CodedError{Code: code, Err: e.Error}
+	return e
+}
+
+// WithDetail attaches a machine readable detail to the code of the fault error,
+// like the name of the permission a denied request is missing
+func (e *FaultErr) WithDetail(key string, value string) *FaultErr {
+	var codedErr *CodedError
+	if !errors.As(e.Error, &codedErr) {
+		codedErr = &CodedError{Err: e.Error}
+		e.Error = codedErr
+	}
+	if codedErr.Details == nil {
+		codedErr.Details = map[string]string{}
+	}
+	codedErr.Details[key] = value
+	return e
+}
@@ -66,6 +66,16 @@ func unprocessableEntityErr(msg string, err error) *FaultErr {
 	}
 }
 
+// tooManyRequestsErr structure
+func tooManyRequestsErr(msg string, err error) *FaultErr {
+	logger.Error(err, msg)
+	return &FaultErr{
+		Status:  http.StatusTooManyRequests,
+		Error:   fmt.Errorf(msg),
+		Message: msg,
+	}
+}
+
 // internalServerErr structure
 func internalServerErr(msg string, err error) *FaultErr {
 	logger.Error(err, msg)
@@ -36,6 +36,12 @@ func NewUnprocessableEntityError(msg string) *FaultErr {
 	return unprocessableEntityErr(msg, err)
 }
 
+// NewTooManyRequestsError structure
+func NewTooManyRequestsError(msg string) *FaultErr {
+	var err error
+	return tooManyRequestsErr(msg, err)
+}
+
 // NewInternalServerError structure
 func NewInternalServerError(msg string) *FaultErr {
 	var err error
@@ -0,0 +1,181 @@
+package oidc
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"math/big"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+// clock skew tolerated when checking the times of an id token
+const leeway = time.Minute
+
+// Claims are the id token claims used to match or provision a user
+type Claims struct {
+	Subject       string
+	Email         string
+	EmailVerified bool
+	GivenName     string
+	FamilyName    string
+	Name          string
+}
+
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+type cachedKeys struct {
+	keys      map[string]interface{}
+	fetchedAt time.Time
+}
+
+// VerifyIDToken verifies the signature of an id token with the provider keys and checks
+// its issuer, audience, expiry and nonce. Only RS256 and ES256 are accepted.
+func (c *Client) VerifyIDToken(ctx context.Context, p *Provider, clientID, rawIDToken, nonce string) (*Claims, error) {
+	parser := &jwt.Parser{ValidMethods: []string{jwt.SigningMethodRS256.Alg(), jwt.SigningMethodES256.Alg()}, SkipClaimsValidation: true}
+
+	claims := jwt.MapClaims{}
+	token, err := parser.ParseWithClaims(rawIDToken, claims, func(t *jwt.Token) (interface{}, error) {
+		kid, _ := t.Header["kid"].(string)
+		return c.signingKey(ctx, p, kid)
+	})
+	if err != nil || !token.Valid {
+		return nil, ErrInvalidToken
+	}
+
+	now := time.Now()
+	iss, _ := claims["iss"].(string)
+	exp, _ := claims["exp"].(float64)
+	tokenNonce, _ := claims["nonce"].(string)
+	if iss != p.Issuer || now.After(time.Unix(int64(exp), 0).Add(leeway)) || tokenNonce != nonce || nonce == "" {
+		return nil, ErrInvalidToken
+	}
+	if iat, ok := claims["iat"].(float64); ok && time.Unix(int64(iat), 0).After(now.Add(leeway)) {
+		return nil, ErrInvalidToken
+	}
+	if !hasAudience(claims, clientID) {
+		return nil, ErrInvalidToken
+	}
+
+	result := &Claims{}
+	result.Subject, _ = claims["sub"].(string)
+	result.Email, _ = claims["email"].(string)
+	result.GivenName, _ = claims["given_name"].(string)
+	result.FamilyName, _ = claims["family_name"].(string)
+	result.Name, _ = claims["name"].(string)
+	switch verified := claims["email_verified"].(type) {
+	case bool:
+		result.EmailVerified = verified
+	case string:
+		result.EmailVerified = verified == "true"
+	}
+	if result.Subject == "" {
+		return nil, ErrInvalidToken
+	}
+	return result, nil
+}
+
+// hasAudience checks that the token was issued to the client, with several audiences
+// the authorized party must be the client
+func hasAudience(claims jwt.MapClaims, clientID string) bool {
+	switch aud := claims["aud"].(type) {
+	case string:
+		return aud == clientID
+	case []interface{}:
+		found := false
+		for _, a := range aud {
+			if a == clientID {
+				found = true
+			}
+		}
+		if len(aud) > 1 {
+			azp, _ := claims["azp"].(string)
+			return found && azp == clientID
+		}
+		return found
+	}
+	return false
+}
+
+// signingKey returns the provider key with the kid, the keys are fetched again once
+// when the kid is unknown as the provider may have rotated them
+func (c *Client) signingKey(ctx context.Context, p *Provider, kid string) (interface{}, error) {
+	c.mu.Lock()
+	cached, ok := c.keys[p.JWKSURI]
+	c.mu.Unlock()
+
+	if ok && time.Since(cached.fetchedAt) < cacheTTL {
+		if key, found := cached.keys[kid]; found {
+			return key, nil
+		}
+	}
+
+	keys, err := c.fetchKeys(ctx, p.JWKSURI)
+	if err != nil {
+		return nil, err
+	}
+	key, found := keys[kid]
+	if !found {
+		return nil, ErrInvalidToken
+	}
+	return key, nil
+}
+
+func (c *Client) fetchKeys(ctx context.Context, jwksURI string) (map[string]interface{}, error) {
+	set := struct {
+		Keys []jwk `json:"keys"`
+	}{}
+	if err := c.getJSON(ctx, jwksURI, &set); err != nil {
+		return nil, ErrDiscovery
+	}
+
+	keys := map[string]interface{}{}
+	for _, k := range set.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		if key := parseJWK(k); key != nil {
+			keys[k.Kid] = key
+		}
+	}
+
+	c.mu.Lock()
+	c.keys[jwksURI] = cachedKeys{keys, time.Now()}
+	c.mu.Unlock()
+	return keys, nil
+}
+
+func parseJWK(k jwk) interface{} {
+	switch k.Kty {
+	case "RSA":
+		n, errN := encoding.DecodeString(k.N)
+		e, errE := encoding.DecodeString(k.E)
+		if errN != nil || errE != nil || len(e) == 0 || len(e) > 4 {
+			return nil
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}
+	case "EC":
+		x, errX := encoding.DecodeString(k.X)
+		y, errY := encoding.DecodeString(k.Y)
+		if k.Crv != "P-256" || errX != nil || errY != nil {
+			return nil
+		}
+		key := &ecdsa.PublicKey{Curve: elliptic.P256(), X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+		if !key.Curve.IsOnCurve(key.X, key.Y) {
+			return nil
+		}
+		return key
+	}
+	return nil
+}
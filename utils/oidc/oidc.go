@@ -0,0 +1,197 @@
+package oidc
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// discovery documents and signing keys of identity providers are cached for this long
+const cacheTTL = time.Hour
+
+var (
+	ErrDiscovery     = errors.New("oidc: provider discovery failed")
+	ErrTokenExchange = errors.New("oidc: token exchange failed")
+	ErrInvalidToken  = errors.New("oidc: id token is invalid")
+)
+
+var encoding = base64.RawURLEncoding
+
+// Provider is the part of the discovery document used for the authorization code flow
+type Provider struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// TokenResponse is the response of the token endpoint
+type TokenResponse struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	IDToken     string `json:"id_token"`
+	ExpiresIn   int64  `json:"expires_in"`
+}
+
+// Client talks to identity providers and caches their discovery documents and keys
+type Client struct {
+	http *http.Client
+
+	mu        sync.Mutex
+	providers map[string]cachedProvider
+	keys      map[string]cachedKeys
+}
+
+type cachedProvider struct {
+	provider  *Provider
+	fetchedAt time.Time
+}
+
+func NewClient(timeout time.Duration) *Client {
+	return &Client{
+		http:      &http.Client{Timeout: timeout},
+		providers: map[string]cachedProvider{},
+		keys:      map[string]cachedKeys{},
+	}
+}
+
+// Discover fetches the discovery document of an issuer, the issuer in the document must match
+func (c *Client) Discover(ctx context.Context, issuer string) (*Provider, error) {
+	issuer = strings.TrimSuffix(issuer, "/")
+
+	c.mu.Lock()
+	cached, ok := c.providers[issuer]
+	c.mu.Unlock()
+	if ok && time.Since(cached.fetchedAt) < cacheTTL {
+		return cached.provider, nil
+	}
+
+	provider := &Provider{}
+	if err := c.getJSON(ctx, issuer+"/.well-known/openid-configuration", provider); err != nil {
+		return nil, ErrDiscovery
+	}
+	if strings.TrimSuffix(provider.Issuer, "/") != issuer || provider.AuthorizationEndpoint == "" || provider.TokenEndpoint == "" || provider.JWKSURI == "" {
+		return nil, ErrDiscovery
+	}
+
+	c.mu.Lock()
+	c.providers[issuer] = cachedProvider{provider, time.Now()}
+	c.mu.Unlock()
+	return provider, nil
+}
+
+// AuthCodeURL builds the authorization request of the code flow with a S256 pkce challenge
+func AuthCodeURL(p *Provider, clientID, redirectURI, state, nonce, codeChallenge string) string {
+	params := url.Values{}
+	params.Set("response_type", "code")
+	params.Set("client_id", clientID)
+	params.Set("redirect_uri", redirectURI)
+	params.Set("scope", "openid email profile")
+	params.Set("state", state)
+	params.Set("nonce", nonce)
+	params.Set("code_challenge", codeChallenge)
+	params.Set("code_challenge_method", "S256")
+
+	separator := "?"
+	if strings.Contains(p.AuthorizationEndpoint, "?") {
+		separator = "&"
+	}
+	return p.AuthorizationEndpoint + separator + params.Encode()
+}
+
+// Exchange redeems an authorization code at the token endpoint, the client authenticates with
+// client_secret_basic when it has a secret and as a public client otherwise
+func (c *Client) Exchange(ctx context.Context, p *Provider, clientID, clientSecret, redirectURI, code, codeVerifier string) (*TokenResponse, error) {
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", code)
+	form.Set("redirect_uri", redirectURI)
+	form.Set("code_verifier", codeVerifier)
+	if clientSecret == "" {
+		form.Set("client_id", clientID)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, ErrTokenExchange
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if clientSecret != "" {
+		req.SetBasicAuth(url.QueryEscape(clientID), url.QueryEscape(clientSecret))
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, ErrTokenExchange
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, ErrTokenExchange
+	}
+	result := &TokenResponse{}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(result); err != nil || result.IDToken == "" {
+		return nil, ErrTokenExchange
+	}
+	return result, nil
+}
+
+// NewState generates a random value for the state and nonce parameters
+func NewState() (string, error) {
+	return randomString(32)
+}
+
+// NewPKCE generates a code verifier and its S256 challenge
+func NewPKCE() (verifier string, challenge string, err error) {
+	verifier, err = randomString(32)
+	if err != nil {
+		return "", "", err
+	}
+	return verifier, S256Challenge(verifier), nil
+}
+
+// S256Challenge derives the pkce challenge of a code verifier
+func S256Challenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return encoding.EncodeToString(sum[:])
+}
+
+// Helpers
+
+func (c *Client) getJSON(ctx context.Context, url string, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(v)
+}
+
+func randomString(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return encoding.EncodeToString(b), nil
+}
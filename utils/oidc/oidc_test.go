@@ -0,0 +1,185 @@
+package oidc
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+// mockProvider is a local identity provider issuing id tokens for a single authorization code
+type mockProvider struct {
+	server   *httptest.Server
+	key      *rsa.PrivateKey
+	clientID string
+	secret   string
+
+	code      string
+	challenge string
+	nonce     string
+	claims    jwt.MapClaims
+}
+
+func newMockProvider(t *testing.T) *mockProvider {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := &mockProvider{key: key, clientID: "client-1", secret: "s3cret"}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(Provider{
+			Issuer:                p.server.URL,
+			AuthorizationEndpoint: p.server.URL + "/authorize",
+			TokenEndpoint:         p.server.URL + "/token",
+			JWKSURI:               p.server.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{"keys": []jwk{{
+			Kty: "RSA",
+			Kid: "k1",
+			Use: "sig",
+			N:   encoding.EncodeToString(key.N.Bytes()),
+			E:   encoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}}})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		id, secret, ok := r.BasicAuth()
+		if !ok || id != p.clientID || secret != p.secret {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if r.FormValue("code") != p.code || S256Challenge(r.FormValue("code_verifier")) != p.challenge {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		claims := jwt.MapClaims{
+			"iss":            p.server.URL,
+			"sub":            "user-1",
+			"aud":            p.clientID,
+			"exp":            time.Now().Add(time.Minute).Unix(),
+			"iat":            time.Now().Unix(),
+			"nonce":          p.nonce,
+			"email":          "jane@example.com",
+			"email_verified": true,
+			"given_name":     "Jane",
+			"family_name":    "Doe",
+		}
+		for k, v := range p.claims {
+			claims[k] = v
+		}
+		token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+		token.Header["kid"] = "k1"
+		signed, err := token.SignedString(key)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		json.NewEncoder(w).Encode(TokenResponse{AccessToken: "at", TokenType: "Bearer", IDToken: signed})
+	})
+	p.server = httptest.NewServer(mux)
+	t.Cleanup(p.server.Close)
+	return p
+}
+
+// authorize plays the user signing in at the provider and returns the code sent to the redirect uri
+func (p *mockProvider) authorize(t *testing.T, authURL string) string {
+	u, err := url.Parse(authURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	query := u.Query()
+	if query.Get("code_challenge_method") != "S256" || query.Get("client_id") != p.clientID {
+		t.Fatalf("unexpected authorization request %s", authURL)
+	}
+	p.code = "code-1"
+	p.challenge = query.Get("code_challenge")
+	p.nonce = query.Get("nonce")
+	return p.code
+}
+
+func TestAuthorizationCodeFlow(t *testing.T) {
+	p := newMockProvider(t)
+	client := NewClient(5 * time.Second)
+	ctx := context.Background()
+
+	provider, err := client.Discover(ctx, p.server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	verifier, challenge, err := NewPKCE()
+	if err != nil {
+		t.Fatal(err)
+	}
+	code := p.authorize(t, AuthCodeURL(provider, p.clientID, "http://localhost/callback", "state-1", "nonce-1", challenge))
+
+	tokens, err := client.Exchange(ctx, provider, p.clientID, p.secret, "http://localhost/callback", code, verifier)
+	if err != nil {
+		t.Fatal(err)
+	}
+	claims, err := client.VerifyIDToken(ctx, provider, p.clientID, tokens.IDToken, "nonce-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if claims.Subject != "user-1" || claims.Email != "jane@example.com" || !claims.EmailVerified || claims.GivenName != "Jane" {
+		t.Fatalf("unexpected claims %+v", claims)
+	}
+
+	// a wrong verifier means the code was intercepted
+	if _, err := client.Exchange(ctx, provider, p.clientID, p.secret, "http://localhost/callback", code, "other"); err == nil {
+		t.Fatal("expected exchange with a wrong code verifier to fail")
+	}
+}
+
+func TestVerifyIDTokenRejects(t *testing.T) {
+	tests := []struct {
+		name   string
+		claims jwt.MapClaims
+		nonce  string
+	}{
+		{"wrong nonce", nil, "other"},
+		{"wrong audience", jwt.MapClaims{"aud": "client-2"}, "nonce-1"},
+		{"several audiences without azp", jwt.MapClaims{"aud": []string{"client-1", "client-2"}}, "nonce-1"},
+		{"wrong issuer", jwt.MapClaims{"iss": "https://evil.example.com"}, "nonce-1"},
+		{"expired", jwt.MapClaims{"exp": time.Now().Add(-time.Hour).Unix()}, "nonce-1"},
+	}
+
+	p := newMockProvider(t)
+	client := NewClient(5 * time.Second)
+	ctx := context.Background()
+
+	provider, err := client.Discover(ctx, p.server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, test := range tests {
+		p.claims = test.claims
+
+		verifier, challenge, err := NewPKCE()
+		if err != nil {
+			t.Fatal(err)
+		}
+		code := p.authorize(t, AuthCodeURL(provider, p.clientID, "http://localhost/callback", "state-1", "nonce-1", challenge))
+		tokens, err := client.Exchange(ctx, provider, p.clientID, p.secret, "http://localhost/callback", code, verifier)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := client.VerifyIDToken(ctx, provider, p.clientID, tokens.IDToken, test.nonce); err != ErrInvalidToken {
+			t.Fatalf("%s: expected invalid token, got %v", test.name, err)
+		}
+	}
+}
@@ -0,0 +1,123 @@
+package phone
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+)
+
+var (
+	ErrInvalidNumber     = errors.New("phone: number is invalid")
+	ErrUnsupportedRegion = errors.New("phone: region is not supported")
+)
+
+// maxDigits is the longest number E.164 allows, including the country calling code
+const maxDigits = 15
+
+// plan is the numbering plan of a region, national numbers are the digits after the
+// calling code without the trunk prefix dialled inside the country
+type plan struct {
+	callingCode string
+	trunkPrefix string
+	national    *regexp.Regexp
+}
+
+// nanp is shared by the regions of the north american numbering plan
+var nanp = plan{"1", "1", regexp.MustCompile(`^[2-9]\d{2}[2-9]\d{6}$`)}
+
+// plans by ISO 3166-1 alpha-2 region code
+var plans = map[string]plan{
+	"US": nanp,
+	"CA": nanp,
+	"GB": {"44", "0", regexp.MustCompile(`^[1-9]\d{8,9}$`)},
+	"IE": {"353", "0", regexp.MustCompile(`^[1-9]\d{6,9}$`)},
+	"DE": {"49", "0", regexp.MustCompile(`^[1-9]\d{5,13}$`)},
+	"FR": {"33", "0", regexp.MustCompile(`^[1-9]\d{8}$`)},
+	"ES": {"34", "", regexp.MustCompile(`^[5-9]\d{8}$`)},
+	"NL": {"31", "0", regexp.MustCompile(`^[1-9]\d{8}$`)},
+	"AU": {"61", "0", regexp.MustCompile(`^[2-478]\d{8}$`)},
+	"NZ": {"64", "0", regexp.MustCompile(`^[2-9]\d{7,9}$`)},
+	"IN": {"91", "0", regexp.MustCompile(`^[1-9]\d{9}$`)},
+	"PK": {"92", "0", regexp.MustCompile(`^[1-9]\d{8,9}$`)},
+	"BD": {"880", "0", regexp.MustCompile(`^[1-9]\d{7,9}$`)},
+	"LK": {"94", "0", regexp.MustCompile(`^[1-9]\d{8}$`)},
+	"NP": {"977", "0", regexp.MustCompile(`^[1-9]\d{7,9}$`)},
+	"SG": {"65", "", regexp.MustCompile(`^[3689]\d{7}$`)},
+	"JP": {"81", "0", regexp.MustCompile(`^[1-9]\d{8,9}$`)},
+	"CN": {"86", "0", regexp.MustCompile(`^[1-9]\d{7,10}$`)},
+	"AE": {"971", "0", regexp.MustCompile(`^[2-9]\d{7,8}$`)},
+	"SA": {"966", "0", regexp.MustCompile(`^[1-9]\d{7,8}$`)},
+	"NG": {"234", "0", regexp.MustCompile(`^[1-9]\d{7,9}$`)},
+	"KE": {"254", "0", regexp.MustCompile(`^[17]\d{8}$`)},
+	"ZA": {"27", "0", regexp.MustCompile(`^[1-9]\d{8}$`)},
+	"BR": {"55", "0", regexp.MustCompile(`^[1-9]{2}\d{8,9}$`)},
+	"MX": {"52", "", regexp.MustCompile(`^[1-9]\d{9}$`)},
+}
+
+// separators are the characters people format numbers with
+var separators = strings.NewReplacer(" ", "", "-", "", ".", "", "(", "", ")", "", "/", "", " ", "")
+
+// ValidRegion reports whether numbers of a region can be normalized
+func ValidRegion(region string) bool {
+	_, ok := plans[strings.ToUpper(region)]
+	return ok
+}
+
+// Normalize parses a number as it was typed and returns it in E.164. Numbers with a +
+// or 00 prefix are international, others are national numbers of the given region.
+func Normalize(number, region string) (string, error) {
+	digits := separators.Replace(strings.TrimSpace(number))
+
+	switch {
+	case strings.HasPrefix(digits, "+"):
+		return international(digits[1:])
+	case strings.HasPrefix(digits, "00"):
+		return international(digits[2:])
+	}
+
+	p, ok := plans[strings.ToUpper(region)]
+	if !ok {
+		return "", ErrUnsupportedRegion
+	}
+	return p.format(digits)
+}
+
+// international matches the calling code of the number, calling codes are prefix free
+// so at most one of the known codes matches
+func international(digits string) (string, error) {
+	for i := 1; i <= 3 && i <= len(digits); i++ {
+		for _, p := range plans {
+			if p.callingCode == digits[:i] {
+				return p.format(digits[i:])
+			}
+		}
+	}
+	return "", ErrInvalidNumber
+}
+
+// format validates a national number against the plan, the trunk prefix is dropped
+// when the number was written with it, like +44 (0)20 or 020 in the UK
+func (p plan) format(national string) (string, error) {
+	if !isDigits(national) {
+		return "", ErrInvalidNumber
+	}
+	if p.trunkPrefix != "" && strings.HasPrefix(national, p.trunkPrefix) && !p.national.MatchString(national) {
+		national = national[len(p.trunkPrefix):]
+	}
+	if !p.national.MatchString(national) || len(p.callingCode)+len(national) > maxDigits {
+		return "", ErrInvalidNumber
+	}
+	return "+" + p.callingCode + national, nil
+}
+
+func isDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
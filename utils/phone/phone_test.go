@@ -0,0 +1,65 @@
+package phone
+
+import "testing"
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		number string
+		region string
+		want   string
+	}{
+		{"9000090000", "IN", "+919000090000"},
+		{"09000090000", "IN", "+919000090000"},
+		{"+91 90000 90000", "US", "+919000090000"},
+		{"0091-9000090000", "", "+919000090000"},
+		{"(415) 555-2671", "US", "+14155552671"},
+		{"1 415 555 2671", "CA", "+14155552671"},
+		{"+1.415.555.2671", "", "+14155552671"},
+		{"020 7946 0958", "gb", "+442079460958"},
+		{"+44 (0)20 7946 0958", "IN", "+442079460958"},
+		{"07700 900123", "GB", "+447700900123"},
+		{"9123 4567", "SG", "+6591234567"},
+	}
+	for _, tt := range tests {
+		got, err := Normalize(tt.number, tt.region)
+		if err != nil {
+			t.Errorf("Normalize(%q, %q) error: %v", tt.number, tt.region, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("Normalize(%q, %q) = %q, want %q", tt.number, tt.region, got, tt.want)
+		}
+	}
+}
+
+func TestNormalizeRejects(t *testing.T) {
+	tests := []struct {
+		number string
+		region string
+		err    error
+	}{
+		{"", "IN", ErrInvalidNumber},
+		{"90000", "IN", ErrInvalidNumber},
+		{"90000900001", "IN", ErrInvalidNumber},
+		{"0415 555 2671", "US", ErrInvalidNumber},
+		{"415 155 2671", "US", ErrInvalidNumber},
+		{"+999 1234567", "", ErrInvalidNumber},
+		{"9000O90000", "IN", ErrInvalidNumber},
+		{"9000090000", "", ErrUnsupportedRegion},
+		{"9000090000", "XX", ErrUnsupportedRegion},
+	}
+	for _, tt := range tests {
+		if _, err := Normalize(tt.number, tt.region); err != tt.err {
+			t.Errorf("Normalize(%q, %q) error = %v, want %v", tt.number, tt.region, err, tt.err)
+		}
+	}
+}
+
+func TestValidRegion(t *testing.T) {
+	if !ValidRegion("in") || !ValidRegion("US") {
+		t.Error("expected supported regions to be valid")
+	}
+	if ValidRegion("XX") || ValidRegion("") {
+		t.Error("expected unknown regions to be invalid")
+	}
+}
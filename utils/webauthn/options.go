@@ -0,0 +1,96 @@
+package webauthn
+
+// The options below are serialized as json and passed by the client to
+// navigator.credentials.create / get, binary fields are base64url encoded
+
+type RelyingPartyEntity struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+type UserEntity struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	DisplayName string `json:"displayName"`
+}
+
+type CredentialParameter struct {
+	Type      string `json:"type"`
+	Algorithm int64  `json:"alg"`
+}
+
+type CredentialDescriptor struct {
+	Type string `json:"type"`
+	ID   string `json:"id"`
+}
+
+type AuthenticatorSelection struct {
+	AuthenticatorAttachment string `json:"authenticatorAttachment,omitempty"`
+	ResidentKey             string `json:"residentKey"`
+	UserVerification        string `json:"userVerification"`
+}
+
+// CreationOptions are the PublicKeyCredentialCreationOptions of a registration
+type CreationOptions struct {
+	Challenge              string                 `json:"challenge"`
+	RP                     RelyingPartyEntity     `json:"rp"`
+	User                   UserEntity             `json:"user"`
+	PubKeyCredParams       []CredentialParameter  `json:"pubKeyCredParams"`
+	Timeout                int64                  `json:"timeout"`
+	ExcludeCredentials     []CredentialDescriptor `json:"excludeCredentials"`
+	AuthenticatorSelection AuthenticatorSelection `json:"authenticatorSelection"`
+	Attestation            string                 `json:"attestation"`
+}
+
+// RequestOptions are the PublicKeyCredentialRequestOptions of a login
+type RequestOptions struct {
+	Challenge        string                 `json:"challenge"`
+	RPID             string                 `json:"rpId"`
+	Timeout          int64                  `json:"timeout"`
+	AllowCredentials []CredentialDescriptor `json:"allowCredentials"`
+	UserVerification string                 `json:"userVerification"`
+}
+
+// CreationOptions builds the options for registering a platform authenticator of a user,
+// existing credentials are excluded so an authenticator is not registered twice
+func (rp *RelyingParty) CreationOptions(challenge string, user UserEntity, existing [][]byte) *CreationOptions {
+	params := []CredentialParameter{}
+	for _, alg := range SupportedAlgorithms {
+		params = append(params, CredentialParameter{Type: "public-key", Algorithm: alg})
+	}
+
+	return &CreationOptions{
+		Challenge:          challenge,
+		RP:                 rp.rpEntity,
+		User:               user,
+		PubKeyCredParams:   params,
+		Timeout:            Timeout.Milliseconds(),
+		ExcludeCredentials: descriptors(existing),
+		AuthenticatorSelection: AuthenticatorSelection{
+			AuthenticatorAttachment: "platform",
+			ResidentKey:             "preferred",
+			UserVerification:        "preferred",
+		},
+		Attestation: "none",
+	}
+}
+
+// RequestOptions builds the options for a login, without allowed credentials the
+// authenticator offers its discoverable credentials
+func (rp *RelyingParty) RequestOptions(challenge string, allowed [][]byte) *RequestOptions {
+	return &RequestOptions{
+		Challenge:        challenge,
+		RPID:             rp.id,
+		Timeout:          Timeout.Milliseconds(),
+		AllowCredentials: descriptors(allowed),
+		UserVerification: "preferred",
+	}
+}
+
+func descriptors(ids [][]byte) []CredentialDescriptor {
+	result := []CredentialDescriptor{}
+	for _, id := range ids {
+		result = append(result, CredentialDescriptor{Type: "public-key", ID: Encoding.EncodeToString(id)})
+	}
+	return result
+}
@@ -0,0 +1,260 @@
+package webauthn
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+const (
+	challengeSize = 32
+
+	// Timeout is how long the browser waits for the authenticator, challenges expire with it
+	Timeout = 5 * time.Minute
+
+	ceremonyCreate = "webauthn.create"
+	ceremonyGet    = "webauthn.get"
+)
+
+// authenticator data flags, WebAuthn level 2 section 6.1
+const (
+	flagUserPresent   byte = 0x01
+	flagUserVerified  byte = 0x04
+	flagAttestedData  byte = 0x40
+	flagExtensionData byte = 0x80
+)
+
+var (
+	ErrInvalidClientData = errors.New("webauthn: client data does not match the ceremony")
+	ErrInvalidAuthData   = errors.New("webauthn: authenticator data is invalid")
+	ErrInvalidSignature  = errors.New("webauthn: signature is invalid")
+)
+
+// Encoding is used for challenges, credential ids and user handles exchanged with the browser
+var Encoding = base64.RawURLEncoding
+
+// RelyingParty verifies registration and assertion ceremonies for one rp id
+type RelyingParty struct {
+	id       string
+	name     string
+	origins  []string
+	idHash   [32]byte
+	rpEntity RelyingPartyEntity
+}
+
+func NewRelyingParty(id, name string, origins []string) *RelyingParty {
+	return &RelyingParty{
+		id:       id,
+		name:     name,
+		origins:  origins,
+		idHash:   sha256.Sum256([]byte(id)),
+		rpEntity: RelyingPartyEntity{ID: id, Name: name},
+	}
+}
+
+// NewChallenge generates a random challenge
+func NewChallenge() (string, error) {
+	b := make([]byte, challengeSize)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return Encoding.EncodeToString(b), nil
+}
+
+// ClientData is the part of clientDataJSON the relying party checks
+type ClientData struct {
+	Type        string `json:"type"`
+	Challenge   string `json:"challenge"`
+	Origin      string `json:"origin"`
+	CrossOrigin bool   `json:"crossOrigin"`
+}
+
+// ParseClientData decodes clientDataJSON, it is used to find the challenge before verifying a ceremony
+func ParseClientData(clientDataJSON []byte) (*ClientData, error) {
+	obj := &ClientData{}
+	if err := json.Unmarshal(clientDataJSON, obj); err != nil {
+		return nil, ErrInvalidClientData
+	}
+	return obj, nil
+}
+
+// AuthenticatorData is the decoded authenticator data of a ceremony
+type AuthenticatorData struct {
+	RPIDHash     []byte
+	Flags        byte
+	SignCount    uint32
+	AAGUID       []byte
+	CredentialID []byte
+	PublicKey    []byte
+}
+
+func (a *AuthenticatorData) UserPresent() bool  { return a.Flags&flagUserPresent != 0 }
+func (a *AuthenticatorData) UserVerified() bool { return a.Flags&flagUserVerified != 0 }
+
+// ParseAuthenticatorData decodes authenticator data including the attested credential if present
+func ParseAuthenticatorData(raw []byte) (*AuthenticatorData, error) {
+	if len(raw) < 37 {
+		return nil, ErrInvalidAuthData
+	}
+
+	obj := &AuthenticatorData{
+		RPIDHash:  raw[:32],
+		Flags:     raw[32],
+		SignCount: binary.BigEndian.Uint32(raw[33:37]),
+	}
+	rest := raw[37:]
+
+	if obj.Flags&flagAttestedData != 0 {
+		if len(rest) < 18 {
+			return nil, ErrInvalidAuthData
+		}
+		obj.AAGUID = rest[:16]
+		idLen := int(binary.BigEndian.Uint16(rest[16:18]))
+		rest = rest[18:]
+		if idLen == 0 || idLen > 1023 || len(rest) < idLen {
+			return nil, ErrInvalidAuthData
+		}
+		obj.CredentialID = rest[:idLen]
+		rest = rest[idLen:]
+
+		// the cose key has no length prefix, its end is found by decoding it
+		_, after, err := decodeCBOR(rest)
+		if err != nil {
+			return nil, ErrInvalidAuthData
+		}
+		obj.PublicKey = rest[:len(rest)-len(after)]
+		rest = after
+	}
+	if obj.Flags&flagExtensionData != 0 {
+		_, after, err := decodeCBOR(rest)
+		if err != nil {
+			return nil, ErrInvalidAuthData
+		}
+		rest = after
+	}
+	if len(rest) != 0 {
+		return nil, ErrInvalidAuthData
+	}
+	return obj, nil
+}
+
+// Credential is a newly registered credential
+type Credential struct {
+	ID           []byte
+	PublicKey    []byte
+	Algorithm    int64
+	SignCount    uint32
+	AAGUID       []byte
+	UserVerified bool
+}
+
+// VerifyRegistration checks a registration response against the issued challenge and returns the
+// new credential. Attestation is requested as "none", so attestation statements are not used for trust.
+func (rp *RelyingParty) VerifyRegistration(clientDataJSON, attestationObject []byte, challenge string) (*Credential, error) {
+	if err := rp.checkClientData(clientDataJSON, ceremonyCreate, challenge); err != nil {
+		return nil, err
+	}
+
+	item, _, err := decodeCBOR(attestationObject)
+	if err != nil {
+		return nil, err
+	}
+	attestation, ok := item.(map[interface{}]interface{})
+	if !ok {
+		return nil, ErrInvalidAuthData
+	}
+	rawAuthData, ok := attestation["authData"].([]byte)
+	if !ok {
+		return nil, ErrInvalidAuthData
+	}
+
+	authData, err := rp.checkAuthData(rawAuthData)
+	if err != nil {
+		return nil, err
+	}
+	if authData.CredentialID == nil {
+		return nil, ErrInvalidAuthData
+	}
+
+	publicKey, err := ParsePublicKey(authData.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Credential{
+		ID:           authData.CredentialID,
+		PublicKey:    authData.PublicKey,
+		Algorithm:    publicKey.Algorithm,
+		SignCount:    authData.SignCount,
+		AAGUID:       authData.AAGUID,
+		UserVerified: authData.UserVerified(),
+	}, nil
+}
+
+// VerifyAssertion checks an assertion response against the issued challenge and the stored
+// cose public key of the credential, and returns the authenticator data for the sign count check
+func (rp *RelyingParty) VerifyAssertion(clientDataJSON, rawAuthData, signature []byte, challenge string, publicKey []byte) (*AuthenticatorData, error) {
+	if err := rp.checkClientData(clientDataJSON, ceremonyGet, challenge); err != nil {
+		return nil, err
+	}
+
+	authData, err := rp.checkAuthData(rawAuthData)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := ParsePublicKey(publicKey)
+	if err != nil {
+		return nil, err
+	}
+
+	clientDataHash := sha256.Sum256(clientDataJSON)
+	signed := append(append([]byte{}, rawAuthData...), clientDataHash[:]...)
+	if !key.Verify(signed, signature) {
+		return nil, ErrInvalidSignature
+	}
+	return authData, nil
+}
+
+// SignCountValid reports whether the sign count of an assertion moved forward. Authenticators
+// that do not count always report zero, anything else not above the stored count suggests a cloned authenticator.
+func SignCountValid(stored, received uint32) bool {
+	if stored == 0 && received == 0 {
+		return true
+	}
+	return received > stored
+}
+
+// Helpers
+
+func (rp *RelyingParty) checkClientData(clientDataJSON []byte, ceremony, challenge string) error {
+	clientData, err := ParseClientData(clientDataJSON)
+	if err != nil {
+		return err
+	}
+	if clientData.Type != ceremony || clientData.Challenge != challenge || clientData.CrossOrigin {
+		return ErrInvalidClientData
+	}
+	for _, origin := range rp.origins {
+		if clientData.Origin == origin {
+			return nil
+		}
+	}
+	return ErrInvalidClientData
+}
+
+func (rp *RelyingParty) checkAuthData(raw []byte) (*AuthenticatorData, error) {
+	authData, err := ParseAuthenticatorData(raw)
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.Equal(authData.RPIDHash, rp.idHash[:]) || !authData.UserPresent() {
+		return nil, ErrInvalidAuthData
+	}
+	return authData, nil
+}
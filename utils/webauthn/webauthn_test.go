@@ -0,0 +1,147 @@
+package webauthn
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"testing"
+)
+
+const (
+	testRPID   = "example.com"
+	testOrigin = "https://example.com"
+)
+
+// softAuthenticator is a software platform authenticator with a P-256 key
+type softAuthenticator struct {
+	key          *ecdsa.PrivateKey
+	credentialID []byte
+	signCount    uint32
+}
+
+func newSoftAuthenticator(t *testing.T) *softAuthenticator {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &softAuthenticator{key: key, credentialID: []byte("soft-credential")}
+}
+
+func (a *softAuthenticator) clientData(typ, challenge string) []byte {
+	b, _ := json.Marshal(ClientData{Type: typ, Challenge: challenge, Origin: testOrigin})
+	return b
+}
+
+func (a *softAuthenticator) authData(flags byte) []byte {
+	rpIDHash := sha256.Sum256([]byte(testRPID))
+	b := append([]byte{}, rpIDHash[:]...)
+	b = append(b, flags, 0, 0, 0, 0)
+	binary.BigEndian.PutUint32(b[33:], a.signCount)
+	return b
+}
+
+func (a *softAuthenticator) create(challenge string) ([]byte, []byte) {
+	cose := []byte{0xa5, 0x01, 0x02, 0x03, 0x26, 0x20, 0x01}
+	cose = append(cose, 0x21, 0x58, 0x20)
+	cose = append(cose, a.key.X.FillBytes(make([]byte, 32))...)
+	cose = append(cose, 0x22, 0x58, 0x20)
+	cose = append(cose, a.key.Y.FillBytes(make([]byte, 32))...)
+
+	authData := a.authData(flagUserPresent | flagUserVerified | flagAttestedData)
+	authData = append(authData, make([]byte, 16)...)
+	authData = append(authData, 0, byte(len(a.credentialID)))
+	authData = append(authData, a.credentialID...)
+	authData = append(authData, cose...)
+
+	attestation := []byte{0xa3, 0x63, 'f', 'm', 't', 0x64, 'n', 'o', 'n', 'e'}
+	attestation = append(attestation, 0x67, 'a', 't', 't', 'S', 't', 'm', 't', 0xa0)
+	attestation = append(attestation, 0x68, 'a', 'u', 't', 'h', 'D', 'a', 't', 'a', 0x59, 0, 0)
+	binary.BigEndian.PutUint16(attestation[len(attestation)-2:], uint16(len(authData)))
+	attestation = append(attestation, authData...)
+
+	return a.clientData(ceremonyCreate, challenge), attestation
+}
+
+func (a *softAuthenticator) get(t *testing.T, challenge string) ([]byte, []byte, []byte) {
+	a.signCount++
+	clientData := a.clientData(ceremonyGet, challenge)
+	authData := a.authData(flagUserPresent | flagUserVerified)
+
+	clientDataHash := sha256.Sum256(clientData)
+	digest := sha256.Sum256(append(append([]byte{}, authData...), clientDataHash[:]...))
+	signature, err := ecdsa.SignASN1(rand.Reader, a.key, digest[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return clientData, authData, signature
+}
+
+func TestRegistrationAndAssertion(t *testing.T) {
+	rp := NewRelyingParty(testRPID, "Example", []string{testOrigin})
+	authenticator := newSoftAuthenticator(t)
+
+	challenge, _ := NewChallenge()
+	clientData, attestation := authenticator.create(challenge)
+	credential, err := rp.VerifyRegistration(clientData, attestation, challenge)
+	if err != nil {
+		t.Fatal("VerifyRegistration:", err)
+	}
+	if string(credential.ID) != string(authenticator.credentialID) || credential.Algorithm != AlgES256 {
+		t.Fatal("VerifyRegistration: credential is not the registered one")
+	}
+
+	challenge, _ = NewChallenge()
+	clientData, authData, signature := authenticator.get(t, challenge)
+	result, err := rp.VerifyAssertion(clientData, authData, signature, challenge, credential.PublicKey)
+	if err != nil {
+		t.Fatal("VerifyAssertion:", err)
+	}
+	if result.SignCount != 1 || !result.UserVerified() {
+		t.Fatal("VerifyAssertion: authenticator data is not expected")
+	}
+
+	// a different challenge or a tampered signature must fail
+	other, _ := NewChallenge()
+	if _, err := rp.VerifyAssertion(clientData, authData, signature, other, credential.PublicKey); err != ErrInvalidClientData {
+		t.Fatal("VerifyAssertion: accepted a foreign challenge")
+	}
+	signature[len(signature)-1] ^= 0xff
+	if _, err := rp.VerifyAssertion(clientData, authData, signature, challenge, credential.PublicKey); err != ErrInvalidSignature {
+		t.Fatal("VerifyAssertion: accepted a tampered signature")
+	}
+}
+
+func TestRegistrationRejectsOtherOrigin(t *testing.T) {
+	rp := NewRelyingParty(testRPID, "Example", []string{"https://other.example.com"})
+	authenticator := newSoftAuthenticator(t)
+
+	challenge, _ := NewChallenge()
+	clientData, attestation := authenticator.create(challenge)
+	if _, err := rp.VerifyRegistration(clientData, attestation, challenge); err != ErrInvalidClientData {
+		t.Fatal("VerifyRegistration: accepted an unknown origin")
+	}
+}
+
+var signCountResults = []struct {
+	stored   uint32
+	received uint32
+	expected bool
+}{
+	{0, 0, true},
+	{0, 1, true},
+	{5, 6, true},
+	{5, 5, false},
+	{5, 2, false},
+	{5, 0, false},
+}
+
+func TestSignCountValid(t *testing.T) {
+	for _, test := range signCountResults {
+		if SignCountValid(test.stored, test.received) != test.expected {
+			t.Fatalf("SignCountValid(%d, %d): output is not expected result", test.stored, test.received)
+		}
+	}
+}